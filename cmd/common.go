@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
+	"unicode"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -13,16 +17,60 @@ import (
 
 	// Plugin does not export any functions therefore it is ignored IE. "_"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 var envGetter = os.LookupEnv
 
-func setFromKubeConfig(cfg *config.Config) error {
+// inClusterConfig and serviceAccountNamespaceFile are variables so that they can be mocked.
+var inClusterConfig = rest.InClusterConfig
+
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// isInCluster returns whether kube-compose runs in a pod, based on the environment variable that Kubernetes sets in every container.
+func isInCluster() bool {
+	host, _ := envGetter("KUBERNETES_SERVICE_HOST")
+	return host != ""
+}
+
+// setFromInClusterConfig configures access to the cluster with the service account of the pod that kube-compose runs in. The namespace
+// defaults to the namespace of the pod.
+func setFromInClusterConfig(cfg *config.Config) error {
+	kubeConfig, err := inClusterConfig()
+	if err != nil {
+		return errors.Wrap(err, "could not load in-cluster config")
+	}
+	namespace := "default"
+	if data, err := ioutil.ReadFile(serviceAccountNamespaceFile); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		namespace = strings.TrimSpace(string(data))
+	}
+	cfg.KubeConfig = kubeConfig
+	cfg.Namespace = namespace
+	return nil
+}
+
+// setFromKubeConfig loads the kube config the same way kubectl does. If kubeConfigFile is not empty then only that file is loaded, instead
+// of the files of the environment variable KUBECONFIG or ~/.kube/config. If kubeContext is not empty then it selects the context to use
+// instead of the current context. The namespace is resolved (in order of precedence) from the selected context, the namespace of the
+// service account when running in-cluster and finally "default". Callers can override the namespace afterwards (see getNamespaceFlag).
+// If inCluster is true, or if there is no kube config and kube-compose runs in a pod, the service account of the pod is used instead
+// (see setFromInClusterConfig).
+func setFromKubeConfig(cfg *config.Config, kubeConfigFile, kubeContext string, inCluster bool) error {
+	if inCluster {
+		return setFromInClusterConfig(cfg)
+	}
 	loader := clientcmd.NewDefaultClientConfigLoadingRules()
-	overrides := clientcmd.ConfigOverrides{}
+	loader.ExplicitPath = kubeConfigFile
+	overrides := clientcmd.ConfigOverrides{
+		CurrentContext: kubeContext,
+	}
 	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &overrides)
 	kubeConfig, err := clientConfig.ClientConfig()
+	if clientcmd.IsEmptyConfig(err) && kubeConfigFile == "" && kubeContext == "" && isInCluster() {
+		log.Debug("no kube config found, using the in-cluster config")
+		return setFromInClusterConfig(cfg)
+	}
 	if err != nil {
 		return errors.Wrap(err, "could not load kube config")
 	}
@@ -55,18 +103,62 @@ func getEnvIDFlag(flags *pflag.FlagSet) (string, error) {
 		if !exists {
 			return "", fmt.Errorf("either the flag --%s or the environment variable %s must be set", envIDFlagName, envIDEnvVarName)
 		}
-		if e := validation.IsValidLabelValue(envID); len(e) > 0 {
-			return "", fmt.Errorf("the environment variable %s must be a valid label value: %s", envIDEnvVarName, e[0])
+		normalized, err := normalizeEnvID(envID)
+		if err != nil {
+			return "", fmt.Errorf("the environment variable %s is invalid: %v", envIDEnvVarName, err)
 		}
+		envID = normalized
 	} else {
 		envID, _ = flags.GetString(envIDFlagName)
-		if e := validation.IsValidLabelValue(envID); len(e) > 0 {
-			return "", fmt.Errorf("the --%s flag must be a valid label value: %s", envIDFlagName, e[0])
+		normalized, err := normalizeEnvID(envID)
+		if err != nil {
+			return "", fmt.Errorf("the --%s flag is invalid: %v", envIDFlagName, err)
 		}
+		envID = normalized
 	}
 	return envID, nil
 }
 
+// normalizeEnvID maps an environment id to a DNS label, because the environment id is used as a suffix of resource names and as a label
+// value. Upper case letters are converted to lower case, and whitespace, '_' and '.' are replaced by '-'. Other characters that may not
+// appear in a DNS label result in an error.
+func normalizeEnvID(envID string) (string, error) {
+	normalized := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r) || r == '_' || r == '.':
+			return '-'
+		case r >= 'A' && r <= 'Z':
+			return unicode.ToLower(r)
+		}
+		return r
+	}, strings.TrimSpace(envID))
+	if e := validation.IsDNS1123Label(normalized); len(e) > 0 {
+		return "", fmt.Errorf("%#v cannot be normalized to a valid DNS label (lower case alphanumeric characters and '-', starting and "+
+			"ending with an alphanumeric character, at most %d characters): %s", envID, validation.DNS1123LabelMaxLength, e[0])
+	}
+	if normalized != envID {
+		log.Infof("normalized environment id %#v to %#v", envID, normalized)
+	}
+	return normalized, nil
+}
+
+// validateK8sNames checks that the names of the resources of all services are valid DNS labels, because the environment id can make
+// names too long. A warning is logged for each service whose names are truncated (see --truncate-names).
+func validateK8sNames(cfg *config.Config) error {
+	for _, service := range cfg.Services {
+		name := k8smeta.GetK8sName(service, cfg)
+		if e := validation.IsDNS1123Label(name); len(e) > 0 {
+			return fmt.Errorf("the resources of service %s would be named %#v, which is not a valid name: %s (use a shorter "+
+				"environment id, see --%s, or set --%s)", service.Name(), name, e[0], envIDFlagName, truncateNamesFlagName)
+		}
+		if untruncatedName := k8smeta.GetUntruncatedK8sName(service, cfg); name != untruncatedName {
+			log.Warnf("the resources of service %s are named %#v, because %#v exceeds %d characters", service.Name(), name,
+				untruncatedName, validation.DNS1123LabelMaxLength)
+		}
+	}
+	return nil
+}
+
 func getNamespaceFlag(flags *pflag.FlagSet) (string, bool) {
 	var namespace string
 	var exists bool
@@ -81,7 +173,61 @@ func getNamespaceFlag(flags *pflag.FlagSet) (string, bool) {
 	return namespace, true
 }
 
+// getProjectNameFlag returns the value of the --project-name flag, or of the environment variable COMPOSE_PROJECT_NAME if the flag is not
+// set. The second return value is false if neither is set.
+func getProjectNameFlag(flags *pflag.FlagSet) (string, bool, error) {
+	var projectName string
+	if flags.Changed(projectNameFlagName) {
+		projectName, _ = flags.GetString(projectNameFlagName)
+	} else {
+		var exists bool
+		projectName, exists = envGetter(projectNameEnvVarName)
+		if !exists {
+			return "", false, nil
+		}
+	}
+	if e := validation.IsValidLabelValue(projectName); projectName == "" || len(e) > 0 {
+		return "", false, fmt.Errorf("the project name %#v is not a valid label value (alphanumeric characters, '-', '_' and '.', "+
+			"starting and ending with an alphanumeric character, at most %d characters)", projectName, validation.LabelValueMaxLength)
+	}
+	return projectName, true, nil
+}
+
+// getProfilesFlag returns the active profiles of the --profile flag, or of the environment variable COMPOSE_PROFILES if the flag is not
+// set.
+func getProfilesFlag(flags *pflag.FlagSet) []string {
+	if !flags.Changed(profileFlagName) {
+		var profiles []string
+		if value, exists := envGetter(profilesEnvVarName); exists {
+			for _, profile := range strings.Split(value, ",") {
+				if profile = strings.TrimSpace(profile); profile != "" {
+					profiles = append(profiles, profile)
+				}
+			}
+		}
+		return profiles
+	}
+	profiles, _ := flags.GetStringSlice(profileFlagName)
+	return profiles
+}
+
+// addEnabledServicesToFilter adds the services that are enabled by the active profiles to the filter, which also adds their
+// dependencies regardless of their profiles (see config.Config.AddToFilter).
+func addEnabledServicesToFilter(cfg *config.Config, profiles []string) {
+	for _, service := range cfg.Services {
+		if service.IsEnabledByProfiles(profiles) {
+			cfg.AddToFilter(service)
+		}
+	}
+}
+
 func getCommandConfig(cmd *cobra.Command, args []string) (*config.Config, error) {
+	return getCommandConfigCore(cmd, args, true)
+}
+
+// getCommandConfigCore loads the docker compose configuration and applies the common flags. The kube config is only loaded if
+// loadKubeConfig is true, so that commands that do not talk to the cluster work without a kube config.
+func getCommandConfigCore(cmd *cobra.Command, args []string, loadKubeConfig bool) (*config.Config, error) {
 	envID, err := getEnvIDFlag(cmd.Flags())
 	if err != nil {
 		return nil, err
@@ -90,25 +236,50 @@ func getCommandConfig(cmd *cobra.Command, args []string) (*config.Config, error)
 	if err != nil {
 		return nil, err
 	}
-	cfg, err := config.New(files)
+	envFile, _ := cmd.Flags().GetString(envFileFlagName)
+	cfg, err := config.NewWithEnvFile(files, envFile)
 	if err != nil {
 		log.Error(err)
 		os.Exit(1)
 	}
-	if err := setFromKubeConfig(cfg); err != nil {
-		log.Error(err)
-		os.Exit(1)
+	if loadKubeConfig {
+		kubeConfigFile, _ := cmd.Flags().GetString(kubeConfigFlagName)
+		kubeContext, _ := cmd.Flags().GetString(contextFlagName)
+		inCluster, _ := cmd.Flags().GetBool(inClusterFlagName)
+		if inCluster && kubeContext != "" {
+			return nil, fmt.Errorf("the flags --%s and --%s cannot both be set", inClusterFlagName, contextFlagName)
+		}
+		if inCluster && kubeConfigFile != "" {
+			return nil, fmt.Errorf("the flags --%s and --%s cannot both be set", inClusterFlagName, kubeConfigFlagName)
+		}
+		if err := setFromKubeConfig(cfg, kubeConfigFile, kubeContext, inCluster); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
 	}
 	cfg.EnvironmentID = envID
 	if namespace, exists := getNamespaceFlag(cmd.Flags()); exists {
 		cfg.Namespace = namespace
 	}
+	if projectName, exists, err := getProjectNameFlag(cmd.Flags()); err != nil {
+		return nil, err
+	} else if exists {
+		cfg.ProjectName = projectName
+		cfg.FilterByProject = true
+	}
 	cfg.EnvironmentIDNoAppend, _ = cmd.Flags().GetBool(envIdNoAppendFlagName)
+	cfg.TruncateNames, _ = cmd.Flags().GetBool(truncateNamesFlagName)
+	if err := validateK8sNames(cfg); err != nil {
+		return nil, err
+	}
+	strict, _ := cmd.Flags().GetBool(strictFlagName)
+	if err := cfg.CheckUnsupportedKeys(strict); err != nil {
+		return nil, err
+	}
 
+	// Services that are passed as arguments are included regardless of their profiles, like docker compose does.
 	if len(args) == 0 {
-		for _, service := range cfg.Services {
-			cfg.AddToFilter(service)
-		}
+		addEnabledServicesToFilter(cfg, getProfilesFlag(cmd.Flags()))
 	} else {
 		for _, arg := range args {
 			service := cfg.Services[arg]
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
 )
 
 func withMockedEnv(mockEnv map[string]string, callback func()) {
@@ -80,6 +87,157 @@ func Test_GetEnvIDFlag_InvalidFlagError(t *testing.T) {
 	})
 }
 
+func Test_GetEnvIDFlag_NormalizedFlagSuccess(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		cmd := &cobra.Command{}
+		setRootCommandFlags(cmd)
+		_ = cmd.ParseFlags([]string{"--" + envIDFlagName, " My Feature_Env "})
+		key, err := getEnvIDFlag(cmd.Flags())
+		if err != nil {
+			t.Error(err)
+		} else if key != "my-feature-env" {
+			t.Error(key)
+		}
+	})
+}
+
+func Test_NormalizeEnvID_TooLongError(t *testing.T) {
+	_, err := normalizeEnvID(strings.Repeat("a", 64))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_NormalizeEnvID_InvalidCharacterError(t *testing.T) {
+	_, err := normalizeEnvID("feature/123")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_GetProjectNameFlag_EnvLookupSuccess(t *testing.T) {
+	withMockedEnv(map[string]string{
+		"COMPOSE_PROJECT_NAME": "myproject",
+	}, func() {
+		cmd := &cobra.Command{}
+		cmd.Flags().String(projectNameFlagName, "", "")
+		projectName, exists, err := getProjectNameFlag(cmd.Flags())
+		if err != nil {
+			t.Error(err)
+		} else if !exists || projectName != "myproject" {
+			t.Fail()
+		}
+	})
+}
+
+func Test_GetProjectNameFlag_NotSet(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		cmd := &cobra.Command{}
+		cmd.Flags().String(projectNameFlagName, "", "")
+		_, exists, err := getProjectNameFlag(cmd.Flags())
+		if err != nil || exists {
+			t.Fail()
+		}
+	})
+}
+
+func Test_GetProjectNameFlag_InvalidError(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		for _, projectName := range []string{"", "my/project", "-project"} {
+			cmd := &cobra.Command{}
+			cmd.Flags().String(projectNameFlagName, "", "")
+			_ = cmd.ParseFlags([]string{"--" + projectNameFlagName + "=" + projectName})
+			_, _, err := getProjectNameFlag(cmd.Flags())
+			if err == nil {
+				t.Error(projectName)
+			}
+		}
+	})
+}
+
+func Test_GetProfilesFlag_EnvLookupSuccess(t *testing.T) {
+	withMockedEnv(map[string]string{
+		"COMPOSE_PROFILES": "debug, seed,,",
+	}, func() {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice(profileFlagName, []string{}, "")
+		profiles := getProfilesFlag(cmd.Flags())
+		if !reflect.DeepEqual(profiles, []string{"debug", "seed"}) {
+			t.Error(profiles)
+		}
+	})
+}
+
+func Test_GetProfilesFlag_FlagOverridesEnv(t *testing.T) {
+	withMockedEnv(map[string]string{
+		"COMPOSE_PROFILES": "debug",
+	}, func() {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice(profileFlagName, []string{}, "")
+		_ = cmd.ParseFlags([]string{"--" + profileFlagName, "seed", "--" + profileFlagName, "tools"})
+		profiles := getProfilesFlag(cmd.Flags())
+		if !reflect.DeepEqual(profiles, []string{"seed", "tools"}) {
+			t.Error(profiles)
+		}
+	})
+}
+
+func Test_AddEnabledServicesToFilter_Profiles(t *testing.T) {
+	cfg := &config.Config{}
+	web := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	})
+	seeder := cfg.AddService(&dockerComposeConfig.Service{
+		Name:     "seeder",
+		Profiles: []string{"seed"},
+	})
+	db := cfg.AddService(&dockerComposeConfig.Service{
+		Name:     "db",
+		Profiles: []string{"db"},
+	})
+	debug := cfg.AddService(&dockerComposeConfig.Service{
+		Name:     "debug",
+		Profiles: []string{"debug"},
+	})
+	seeder.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"db": dockerComposeConfig.ServiceStarted,
+	}
+	addEnabledServicesToFilter(cfg, nil)
+	if !cfg.MatchesFilter(web) || cfg.MatchesFilter(seeder) || cfg.MatchesFilter(db) || cfg.MatchesFilter(debug) {
+		t.Fail()
+	}
+	cfg.ClearFilter()
+	addEnabledServicesToFilter(cfg, []string{"seed"})
+	// db is enabled because seeder depends on it.
+	if !cfg.MatchesFilter(web) || !cfg.MatchesFilter(seeder) || !cfg.MatchesFilter(db) || cfg.MatchesFilter(debug) {
+		t.Fail()
+	}
+}
+
+func Test_ValidateK8sNames_TooLongError(t *testing.T) {
+	cfg := newTestUpConfig()
+	cfg.EnvironmentID = strings.Repeat("a", 62)
+	err := validateK8sNames(cfg)
+	if err == nil {
+		t.Fail()
+	}
+	cfg.EnvironmentID = strings.Repeat("a", 61)
+	err = validateK8sNames(cfg)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_ValidateK8sNames_TruncatedSuccess(t *testing.T) {
+	cfg := newTestUpConfig()
+	cfg.EnvironmentID = strings.Repeat("a", 62)
+	cfg.TruncateNames = true
+	err := validateK8sNames(cfg)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func Test_GetNamespaceFlag_EnvLookupSuccess(t *testing.T) {
 	withMockedEnv(map[string]string{
 		"KUBECOMPOSE_NAMESPACE": "1234",
@@ -113,3 +271,166 @@ func Test_GetNamespaceFlag_FlagSuccess(t *testing.T) {
 		}
 	})
 }
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: https://localhost:8443
+users:
+- name: user
+  user:
+    token: token
+contexts:
+- name: current
+  context:
+    cluster: cluster
+    user: user
+    namespace: current-namespace
+- name: selected
+  context:
+    cluster: cluster
+    user: user
+    namespace: selected-namespace
+- name: no-namespace
+  context:
+    cluster: cluster
+    user: user
+current-context: current
+`
+
+func withTestKubeConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(file, []byte(testKubeConfig), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", file)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+}
+
+func Test_SetFromKubeConfig_CurrentContextNamespace(t *testing.T) {
+	withTestKubeConfig(t)
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Namespace != "current-namespace" {
+		t.Error(cfg.Namespace)
+	}
+}
+
+func Test_SetFromKubeConfig_SelectedContextNamespace(t *testing.T) {
+	withTestKubeConfig(t)
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, "", "selected", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Namespace != "selected-namespace" {
+		t.Error(cfg.Namespace)
+	}
+}
+
+func Test_SetFromKubeConfig_SelectedContextDefaultNamespace(t *testing.T) {
+	withTestKubeConfig(t)
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, "", "no-namespace", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Namespace != "default" {
+		t.Error(cfg.Namespace)
+	}
+}
+
+func Test_SetFromKubeConfig_ExplicitFile(t *testing.T) {
+	withTestKubeConfig(t)
+	file := os.Getenv("KUBECONFIG")
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "does-not-exist"))
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, file, "selected", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.KubeConfig.Host != "https://localhost:8443" || cfg.Namespace != "selected-namespace" {
+		t.Error(cfg.KubeConfig.Host, cfg.Namespace)
+	}
+}
+
+func Test_SetFromKubeConfig_ExplicitFileNotFoundError(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	withMockedInClusterConfig(t, "pod-namespace")
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, filepath.Join(t.TempDir(), "does-not-exist"), "", false)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_SetFromKubeConfig_UnknownContextError(t *testing.T) {
+	withTestKubeConfig(t)
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, "", "unknown", false)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func withMockedInClusterConfig(t *testing.T, namespace string) {
+	origConfig, origFile := inClusterConfig, serviceAccountNamespaceFile
+	t.Cleanup(func() {
+		inClusterConfig, serviceAccountNamespaceFile = origConfig, origFile
+	})
+	inClusterConfig = func() (*rest.Config, error) {
+		return &rest.Config{Host: "https://10.0.0.1:443"}, nil
+	}
+	serviceAccountNamespaceFile = filepath.Join(t.TempDir(), "namespace")
+	err := os.WriteFile(serviceAccountNamespaceFile, []byte(namespace), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_SetFromKubeConfig_InCluster(t *testing.T) {
+	withTestKubeConfig(t)
+	withMockedInClusterConfig(t, "pod-namespace")
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, "", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.KubeConfig.Host != "https://10.0.0.1:443" || cfg.Namespace != "pod-namespace" {
+		t.Error(cfg.KubeConfig.Host, cfg.Namespace)
+	}
+}
+
+func Test_SetFromKubeConfig_InClusterFallback(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "does-not-exist"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	withMockedInClusterConfig(t, "pod-namespace")
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Namespace != "pod-namespace" {
+		t.Error(cfg.Namespace)
+	}
+}
+
+func Test_SetFromKubeConfig_KubeConfigPreferred(t *testing.T) {
+	withTestKubeConfig(t)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	withMockedInClusterConfig(t, "pod-namespace")
+	cfg := &config.Config{}
+	err := setFromKubeConfig(cfg, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Namespace != "current-namespace" {
+		t.Error(cfg.Namespace)
+	}
+}
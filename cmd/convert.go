@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+const (
+	formatFlagName              = "format"
+	formatJSON                  = "json"
+	formatYAML                  = "yaml"
+	includeSecretValuesFlagName = "include-secret-values"
+)
+
+func newConvertCli() *cobra.Command {
+	var convertCmd = &cobra.Command{
+		Use:   "convert",
+		Short: "Write the Kubernetes resources that up would create, without deploying them",
+		Long: "Translate the docker compose services to the Kubernetes resources that up would create, and write them to stdout or to a " +
+			"directory (one file per resource, prefixed by the order in which they can be applied). Images are used as is, so images are " +
+			"not pushed and bind mounted volumes are not supported.",
+		RunE: convertCommand,
+	}
+	convertCmd.PersistentFlags().Bool("as-deployment", false, "Convert services to Deployments instead of bare pods (see up)")
+	convertCmd.PersistentFlags().Bool("default-deny-ingress", false, "Include the NetworkPolicies of --default-deny-ingress of up")
+	convertCmd.PersistentFlags().StringToString(externalFlagName, nil, "Resolve the name of a skipped service or the target of an "+
+		"external link to an external address <name>=<host>[:<port>] (see up)")
+	convertCmd.PersistentFlags().String(formatFlagName, formatYAML, fmt.Sprintf("The format of the resources: %#v or %#v", formatYAML,
+		formatJSON))
+	convertCmd.PersistentFlags().Bool("headless-services", false, "Convert the Service of each service to a headless Service (see up)")
+	convertCmd.PersistentFlags().Bool(includeSecretValuesFlagName, false, "Write the values of secrets and the credentials of pull secrets "+
+		"(in plain text). By default Secrets are written with empty values, which must be filled in before applying them")
+	convertCmd.PersistentFlags().StringP("output", "o", "", "The directory to write the resources to. The resources are written to "+
+		"stdout if unset")
+	convertCmd.PersistentFlags().BoolP("skip-host-aliases", "a", false, "Do not add host aliases of external services to pods")
+	convertCmd.PersistentFlags().StringSlice(skipServicesFlagName, nil, "Comma separated names of services that are not converted (see up)")
+	convertCmd.PersistentFlags().StringSlice(statefulServicesFlagName, nil, "Comma separated names of services that are converted to a "+
+		"StatefulSet (see up)")
+	convertCmd.PersistentFlags().String("storage-class", "", "The storage class of the volume claims of stateful services")
+	return convertCmd
+}
+
+func convertCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfigCore(cmd, args, false)
+	if err != nil {
+		return err
+	}
+	format, _ := cmd.Flags().GetString(formatFlagName)
+	if format != formatYAML && format != formatJSON {
+		return fmt.Errorf("the flag --%s must be one of %#v and %#v", formatFlagName, formatYAML, formatJSON)
+	}
+	opts := &up.Options{}
+	opts.AsDeployment, _ = cmd.Flags().GetBool("as-deployment")
+	opts.DefaultDenyIngress, _ = cmd.Flags().GetBool("default-deny-ingress")
+	err = skipServices(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	opts.External, err = getExternalFlag(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	opts.HeadlessServices, err = getHeadlessServicesFlag(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	err = statefulServices(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	opts.IncludeSecretValues, _ = cmd.Flags().GetBool(includeSecretValuesFlagName)
+	opts.SkipHostAliases, _ = cmd.Flags().GetBool("skip-host-aliases")
+	opts.StorageClass, _ = cmd.Flags().GetString("storage-class")
+	objects, err := up.Convert(cfg, opts)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	warnSecretValues(objects, opts.IncludeSecretValues)
+	dir, _ := cmd.Flags().GetString("output")
+	if dir != "" {
+		err = writeObjectsToDir(dir, objects, format)
+	} else {
+		err = writeObjects(os.Stdout, objects, format)
+	}
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
+
+// warnSecretValues warns if objects contain Secrets, which either contain values in plain text (base64 is not encryption) or must be filled
+// in before the objects are applied.
+func warnSecretValues(objects []runtime.Object, includeSecretValues bool) {
+	secretCount := 0
+	for _, obj := range objects {
+		if _, ok := obj.(*v1.Secret); ok {
+			secretCount++
+		}
+	}
+	switch {
+	case secretCount == 0:
+	case includeSecretValues:
+		log.Warnf("the output contains the values of %d secret(s) in plain text, do not commit or share it", secretCount)
+	default:
+		log.Warnf("the values of %d secret(s) are left empty, fill them in before applying the resources or use --%s", secretCount,
+			includeSecretValuesFlagName)
+	}
+}
+
+func newObjectSerializer(format string) runtime.Encoder {
+	return json.NewSerializerWithOptions(json.DefaultMetaFactory, nil, nil, json.SerializerOptions{
+		Yaml:   format == formatYAML,
+		Pretty: true,
+	})
+}
+
+// writeObjects writes the objects to w as a multi-document YAML stream, or as a JSON List.
+func writeObjects(w io.Writer, objects []runtime.Object, format string) error {
+	encoder := newObjectSerializer(format)
+	if format == formatYAML {
+		for _, obj := range objects {
+			fmt.Fprintln(w, "---")
+			if err := encoder.Encode(obj, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	list := &metav1.List{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "List",
+		},
+	}
+	for _, obj := range objects {
+		var buffer bytes.Buffer
+		if err := encoder.Encode(obj, &buffer); err != nil {
+			return err
+		}
+		list.Items = append(list.Items, runtime.RawExtension{
+			Raw: buffer.Bytes(),
+		})
+	}
+	return encoder.Encode(list, w)
+}
+
+// writeObjectsToDir writes each object to a file in dir, named after the kind and name of the object. File names are prefixed by the
+// index of the object, so that applying the files in lexical order applies the objects in order.
+func writeObjectsToDir(dir string, objects []runtime.Object, format string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	encoder := newObjectSerializer(format)
+	for i, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		kind := strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind)
+		file := filepath.Join(dir, fmt.Sprintf("%02d-%s-%s.%s", i+1, kind, accessor.GetName(), format))
+		var buffer bytes.Buffer
+		if err := encoder.Encode(obj, &buffer); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, buffer.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTestConvertObjects() []runtime.Object {
+	return []runtime.Object{
+		&v1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-123",
+			},
+		},
+		&v1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-123",
+			},
+		},
+	}
+}
+
+func TestWriteObjects_YAML(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeObjects(&buffer, newTestConvertObjects(), formatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buffer.String()
+	if strings.Count(s, "---\n") != 2 || !strings.Contains(s, "kind: Service\n") || !strings.Contains(s, "kind: Pod\n") {
+		t.Error(s)
+	}
+}
+
+func TestWriteObjects_JSON(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeObjects(&buffer, newTestConvertObjects(), formatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buffer.String()
+	if !strings.Contains(s, `"kind": "List"`) || !strings.Contains(s, `"kind": "Pod"`) {
+		t.Error(s)
+	}
+}
+
+func TestWriteObjectsToDir_Success(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kube-compose-convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = writeObjectsToDir(dir, newTestConvertObjects(), formatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name() != "01-service-a-123.yaml" || files[1].Name() != "02-pod-a-123.yaml" {
+		t.Error(files)
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newDescribeCli() *cobra.Command {
+	var describeCmd = &cobra.Command{
+		Use:   "describe",
+		Short: "Show how a service is translated to Kubernetes resources, and its status",
+		Long: "Print the fully resolved docker compose service (after merging, interpolation and extends), the Kubernetes resources " +
+			"generated for it and the status of its pods if it is deployed.",
+		RunE: describeCommand,
+	}
+	return describeCmd
+}
+
+func describeCommand(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("exactly one positional argument is required")
+	}
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	service := cfg.Services[args[0]]
+	if service == nil {
+		return fmt.Errorf("no service named %#v exists", args[0])
+	}
+	d, err := up.Describe(cfg, service)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	err = printServiceDescription(os.Stdout, d)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
+
+func printServiceDescription(w io.Writer, d *up.ServiceDescription) error {
+	fmt.Fprintf(w, "Name:            %s\n", d.ComposeService.Name())
+	fmt.Fprintf(w, "Resources:       %s %s\n", strings.Join(d.Kinds, ", "), d.K8sName)
+	fmt.Fprintf(w, "Image:           %s\n", d.Image)
+	fmt.Fprintf(w, "Environment:     %d variable(s)\n", d.EnvCount)
+	fmt.Fprintf(w, "Limits:          %s\n", formatResourceList(d.Resources.Limits))
+	fmt.Fprintf(w, "Requests:        %s\n", formatResourceList(d.Resources.Requests))
+	fmt.Fprintf(w, "Restart policy:  %s\n", d.RestartPolicy)
+	if d.ReadinessProbe != nil {
+		fmt.Fprintf(w, "Readiness probe: %s (period %ds, timeout %ds, failure threshold %d)\n",
+			formatProbeHandler(&d.ReadinessProbe.ProbeHandler), d.ReadinessProbe.PeriodSeconds, d.ReadinessProbe.TimeoutSeconds, d.ReadinessProbe.FailureThreshold)
+	} else {
+		fmt.Fprintf(w, "Readiness probe: <none> (the healthcheck of the image is used, if any)\n")
+	}
+	fmt.Fprintf(w, "Mounts:\n")
+	for _, mount := range d.Mounts {
+		fmt.Fprintf(w, "  %s\n", mount)
+	}
+	fmt.Fprintf(w, "Pods:\n")
+	if len(d.Pods) == 0 {
+		fmt.Fprintf(w, "  <not deployed>\n")
+	}
+	for _, pod := range d.Pods {
+		if pod.Err != nil {
+			fmt.Fprintf(w, "  %s: %s (%v)\n", pod.Name, pod.Status, pod.Err)
+		} else {
+			fmt.Fprintf(w, "  %s: %s\n", pod.Name, pod.Status)
+		}
+	}
+	data, err := yaml.Marshal(d.ComposeService.DockerComposeService)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Resolved docker compose service:\n%s", data)
+	return nil
+}
+
+// formatResourceList formats the quantities of a resource list sorted by resource name, e.g. "cpu=500m, memory=512Mi".
+func formatResourceList(list v1.ResourceList) string {
+	if len(list) == 0 {
+		return "<none>"
+	}
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		quantity := list[v1.ResourceName(name)]
+		names[i] = fmt.Sprintf("%s=%s", name, quantity.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func formatProbeHandler(handler *v1.ProbeHandler) string {
+	switch {
+	case handler.Exec != nil:
+		return strings.Join(handler.Exec.Command, " ")
+	case handler.HTTPGet != nil:
+		return fmt.Sprintf("http-get :%s%s", handler.HTTPGet.Port.String(), handler.HTTPGet.Path)
+	case handler.TCPSocket != nil:
+		return fmt.Sprintf("tcp-socket :%s", handler.TCPSocket.Port.String())
+	case handler.GRPC != nil:
+		return fmt.Sprintf("grpc :%d", handler.GRPC.Port)
+	}
+	return "<unknown>"
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestDescribeCommand_NoArgsError(t *testing.T) {
+	cmd := &cobra.Command{}
+	err := describeCommand(cmd, []string{})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPrintServiceDescription_NotDeployed(t *testing.T) {
+	cfg := newTestUpConfig()
+	d := &up.ServiceDescription{
+		ComposeService: cfg.Services["a"],
+		K8sName:        "a-123",
+		Kinds:          []string{"Pod"},
+		Mounts:         []string{"/data (bind mount of /home/henk/data)"},
+	}
+	var buffer bytes.Buffer
+	err := printServiceDescription(&buffer, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+	for _, s := range []string{"Resources:       Pod a-123\n", "Limits:          <none>\n", "  /data (bind mount of /home/henk/data)\n", "  <not deployed>\n",
+		"Resolved docker compose service:\n"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("output %#v does not contain %#v", output, s)
+		}
+	}
+}
+
+func TestPrintServiceDescription_ResourcesAndHTTPProbe(t *testing.T) {
+	cfg := newTestUpConfig()
+	d := &up.ServiceDescription{
+		ComposeService: cfg.Services["a"],
+		ReadinessProbe: &v1.Probe{
+			ProbeHandler: v1.ProbeHandler{
+				HTTPGet: &v1.HTTPGetAction{
+					Path: "/health",
+					Port: intstr.FromInt(8080),
+				},
+			},
+			PeriodSeconds: 10,
+		},
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{
+				v1.ResourceMemory: resource.MustParse("512Mi"),
+				v1.ResourceCPU:    resource.MustParse("500m"),
+			},
+		},
+	}
+	var buffer bytes.Buffer
+	err := printServiceDescription(&buffer, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+	for _, s := range []string{"Limits:          cpu=500m, memory=512Mi\n", "Requests:        <none>\n",
+		"Readiness probe: http-get :8080/health (period 10s"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("output %#v does not contain %#v", output, s)
+		}
+	}
+}
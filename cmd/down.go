@@ -16,6 +16,13 @@ func newDownCli() *cobra.Command {
 		Long: "destroy all pods and services",
 		RunE: downCommand,
 	}
+	downCmd.PersistentFlags().Bool("delete-namespace", false, "Delete the namespace of the environment if it was created by up "+
+		"--create-namespace for the same environment, all docker compose services are deleted and no resources of other environments "+
+		"remain in the namespace")
+	downCmd.PersistentFlags().Duration("timeout", 0, "Maximum duration to wait for resources to be deleted (see --wait). Set to 0 to wait "+
+		"indefinitely")
+	downCmd.PersistentFlags().Bool("wait", false, "Wait until the deleted resources are gone (e.g. pods have terminated), instead of "+
+		"returning as soon as their deletion has been requested")
 	return downCmd
 }
 
@@ -24,7 +31,15 @@ func downCommand(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	err = down.Run(cfg)
+	if len(args) == 0 {
+		// Delete the resources of services of all profiles, so that nothing is left behind when the active profiles have changed since up.
+		addEnabledServicesToFilter(cfg, []string{"*"})
+	}
+	opts := &down.Options{}
+	opts.DeleteNamespace, _ = cmd.Flags().GetBool("delete-namespace")
+	opts.Wait, _ = cmd.Flags().GetBool("wait")
+	opts.WaitTimeout, _ = cmd.Flags().GetDuration("timeout")
+	err = down.Run(cfg, opts)
 	if err != nil {
 		log.Error(err)
 		os.Exit(1)
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kube-compose/kube-compose/internal/app/exec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	k8sExec "k8s.io/client-go/util/exec"
+)
+
+const (
+	indexFlagName       = "index"
+	interactiveFlagName = "interactive"
+	ttyFlagName         = "tty"
+)
+
+func newExecCli() *cobra.Command {
+	var execCmd = &cobra.Command{
+		Use:   "exec SERVICE [--] COMMAND [ARG...]",
+		Short: "Run a command in a running pod of a service",
+		Long: "Run a command in the container of the first ready pod of a service, like docker compose exec. With multiple replicas, " +
+			"--index selects a pod in order of pod names.",
+		Args: cobra.MinimumNArgs(2),
+		RunE: execCommand,
+	}
+	// Flags after the service name belong to the command.
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.PersistentFlags().Int(indexFlagName, -1, "The index of the pod of the service to run the command in, in order of pod "+
+		"names. Defaults to the first ready pod")
+	execCmd.PersistentFlags().BoolP(interactiveFlagName, "i", false, "Pass stdin to the command")
+	execCmd.PersistentFlags().BoolP(ttyFlagName, "t", false, "Allocate a TTY")
+	return execCmd
+}
+
+func execCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args[:1])
+	if err != nil {
+		return err
+	}
+	opts := &exec.Options{
+		Command: args[1:],
+		In:      os.Stdin,
+		Out:     os.Stdout,
+		Err:     os.Stderr,
+	}
+	opts.Index, _ = cmd.Flags().GetInt(indexFlagName)
+	opts.Stdin, _ = cmd.Flags().GetBool(interactiveFlagName)
+	opts.TTY, _ = cmd.Flags().GetBool(ttyFlagName)
+	err = exec.Run(cfg, cfg.Services[args[0]], opts)
+	var exitError k8sExec.ExitError
+	if errors.As(err, &exitError) {
+		os.Exit(exitError.ExitStatus())
+	}
+	if err != nil {
+		log.Error(fmt.Errorf("could not run the command in service %s: %v", args[0], err))
+		os.Exit(1)
+	}
+	return nil
+}
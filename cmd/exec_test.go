@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_NewExecCli_Flags(t *testing.T) {
+	cmd := newExecCli()
+	err := cmd.ParseFlags([]string{"-it", "--" + indexFlagName, "1", "web", "ls", "-la"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interactive, _ := cmd.Flags().GetBool(interactiveFlagName)
+	tty, _ := cmd.Flags().GetBool(ttyFlagName)
+	index, _ := cmd.Flags().GetInt(indexFlagName)
+	if !interactive || !tty || index != 1 {
+		t.Fail()
+	}
+	// Flags of the command are not parsed.
+	if !reflect.DeepEqual(cmd.Flags().Args(), []string{"web", "ls", "-la"}) {
+		t.Error(cmd.Flags().Args())
+	}
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"text/template"
@@ -10,6 +12,13 @@ import (
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	getOutputTable = "table"
+	getOutputJSON  = "json"
+	getOutputYAML  = "yaml"
 )
 
 func newGetCli() *cobra.Command {
@@ -19,7 +28,8 @@ func newGetCli() *cobra.Command {
 		Long:  "Print a detailed description of the selected resources, including related resources such as hostname or host IP.",
 		RunE:  getCommand,
 	}
-	getCmd.PersistentFlags().StringP("output", "o", "", "Go template string")
+	getCmd.PersistentFlags().StringP("output", "o", getOutputTable, fmt.Sprintf("The output format: %#v, %#v, %#v or a Go template "+
+		"string", getOutputTable, getOutputJSON, getOutputYAML))
 	return getCmd
 }
 
@@ -33,10 +43,12 @@ func getCommand(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = getOutputTable
+	}
 	var tmpl *template.Template
-	if cmd.Flags().Changed("output") {
-		var output string
-		output, _ = cmd.Flags().GetString("output")
+	if output != getOutputTable && output != getOutputJSON && output != getOutputYAML {
 		tmpl, err = template.New("test").Parse(output)
 		if err != nil {
 			log.Error(err)
@@ -51,16 +63,35 @@ func getCommand(cmd *cobra.Command, args []string) error {
 	}
 	if tmpl != nil {
 		err = tmpl.Execute(os.Stdout, d)
+	} else {
+		err = writeServiceDetails(os.Stdout, d, output)
+	}
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
+
+// writeServiceDetails writes d to w in the output format output, which is one of getOutputTable, getOutputJSON and getOutputYAML.
+func writeServiceDetails(w io.Writer, d *details.ServiceDetails, output string) error {
+	switch output {
+	case getOutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(d)
+	case getOutputYAML:
+		data, err := yaml.Marshal(d)
 		if err != nil {
-			log.Error(err)
-			os.Exit(1)
+			return err
 		}
-	} else {
-		output := util.FormatTable([][]string{
+		_, err = w.Write(data)
+		return err
+	default:
+		_, err := fmt.Fprintln(w, util.FormatTable([][]string{
 			{"NAME", "HOSTNAME", "CLUSTER-IP"},
 			{d.Name, d.Hostname, d.ClusterIP},
-		})
-		fmt.Println(output)
+		}))
+		return err
 	}
-	return nil
 }
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 
+	details "github.com/kube-compose/kube-compose/internal/app/get"
 	"github.com/spf13/cobra"
 )
 
@@ -23,3 +28,63 @@ func TestGetCommand_ConfigError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func newTestServiceDetails() *details.ServiceDetails {
+	return &details.ServiceDetails{
+		Name:         "web",
+		ResourceName: "web-123",
+		Namespace:    "default",
+		ClusterIP:    "10.0.0.1",
+		Hostname:     "web-123.default.svc.cluster.local",
+	}
+}
+
+func TestWriteServiceDetails_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeServiceDetails(&buf, newTestServiceDetails(), getOutputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual map[string]string
+	err = json.Unmarshal(buf.Bytes(), &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, map[string]string{
+		"name":         "web",
+		"resourceName": "web-123",
+		"namespace":    "default",
+		"clusterIP":    "10.0.0.1",
+		"hostname":     "web-123.default.svc.cluster.local",
+	}) {
+		t.Error(actual)
+	}
+}
+
+func TestWriteServiceDetails_YAML(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeServiceDetails(&buf, newTestServiceDetails(), getOutputYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `name: web
+resourceName: web-123
+namespace: default
+clusterIP: 10.0.0.1
+hostname: web-123.default.svc.cluster.local
+`
+	if buf.String() != expected {
+		t.Error(buf.String())
+	}
+}
+
+func TestWriteServiceDetails_Table(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeServiceDetails(&buf, newTestServiceDetails(), getOutputTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "NAME") || !strings.Contains(buf.String(), "10.0.0.1") {
+		t.Error(buf.String())
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const serviceFlagName = "service"
+
+func newHealthCli() *cobra.Command {
+	var healthCmd = &cobra.Command{
+		Use:   "health",
+		Short: "Check whether all services with a readiness gate are ready",
+		Long: "Print the number of ready and not ready pods of each service that has a healthcheck, and exit with a non-zero code unless " +
+			"all pods of those services are ready. A pod is ready if up would consider the depends_on condition service_healthy satisfied.",
+		RunE: healthCommand,
+	}
+	healthCmd.PersistentFlags().StringSlice(serviceFlagName, nil, "Comma separated names of services to check. Defaults to all services")
+	return healthCmd
+}
+
+func healthCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	composeServices, err := getHealthServices(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	healths, err := up.Health(cfg, composeServices)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	if !printServiceHealths(os.Stdout, healths) {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// getHealthServices returns the services of the flag --service, or all services if the flag is not set.
+func getHealthServices(flags *pflag.FlagSet, cfg *config.Config) ([]*config.Service, error) {
+	names, _ := flags.GetStringSlice(serviceFlagName)
+	var composeServices []*config.Service
+	if len(names) == 0 {
+		for _, composeService := range cfg.Services {
+			composeServices = append(composeServices, composeService)
+		}
+		return composeServices, nil
+	}
+	for _, name := range names {
+		composeService := cfg.Services[name]
+		if composeService == nil {
+			return nil, fmt.Errorf("the flag --%s refers to service %#v, but no service with that name exists", serviceFlagName, name)
+		}
+		composeServices = append(composeServices, composeService)
+	}
+	return composeServices, nil
+}
+
+// printServiceHealths writes a table of the healths to w, and returns true if and only if all services are healthy.
+func printServiceHealths(w io.Writer, healths []*up.ServiceHealth) bool {
+	healthy := true
+	rows := [][]string{
+		{"SERVICE", "READY", "NOT-READY"},
+	}
+	for _, h := range healths {
+		rows = append(rows, []string{h.Name, strconv.Itoa(h.Ready), strconv.Itoa(h.NotReady)})
+		if !h.IsHealthy() {
+			healthy = false
+		}
+	}
+	fmt.Fprint(w, util.FormatTable(rows))
+	return healthy
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+)
+
+func TestGetHealthServices_Filter(t *testing.T) {
+	cmd := newHealthCli()
+	_ = cmd.ParseFlags([]string{"--" + serviceFlagName, "b"})
+	cfg := newTestUpConfig()
+	composeServices, err := getHealthServices(cmd.Flags(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(composeServices) != 1 || composeServices[0] != cfg.Services["b"] {
+		t.Error(composeServices)
+	}
+}
+
+func TestGetHealthServices_UnknownServiceError(t *testing.T) {
+	cmd := newHealthCli()
+	_ = cmd.ParseFlags([]string{"--" + serviceFlagName, "c"})
+	_, err := getHealthServices(cmd.Flags(), newTestUpConfig())
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPrintServiceHealths_NotHealthy(t *testing.T) {
+	var buffer bytes.Buffer
+	healthy := printServiceHealths(&buffer, []*up.ServiceHealth{
+		{Name: "a", Ready: 1},
+		{Name: "b", Ready: 1, NotReady: 1},
+	})
+	if healthy {
+		t.Fail()
+	}
+	expected := "SERVICE  READY  NOT-READY\na        1      0\nb        1      1\n"
+	if buffer.String() != expected {
+		t.Errorf("%#v\n", buffer.String())
+	}
+}
+
+func TestPrintServiceHealths_Healthy(t *testing.T) {
+	var buffer bytes.Buffer
+	if !printServiceHealths(&buffer, []*up.ServiceHealth{{Name: "a", Ready: 1}}) {
+		t.Fail()
+	}
+}
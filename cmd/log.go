@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -59,7 +60,7 @@ func setupLogging(cmd *cobra.Command, _ []string) error {
 	}
 	log.SetLevel(logLevel)
 	log.SetOutput(os.Stdout)
-	if reporter.IsTerminal(os.Stdout) {
+	if reporter.IsTerminal(os.Stdout) && util.ColorsEnabled() {
 		log.SetFormatter(createTerminalLogFormatter())
 	} else {
 		log.SetFormatter(&log.TextFormatter{
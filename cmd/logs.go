@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/logs"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	followFlagName    = "follow"
+	tailLinesFlagName = "tail-lines"
+)
+
+func newLogsCli() *cobra.Command {
+	var logsCmd = &cobra.Command{
+		Use:   "logs [service...]",
+		Short: "Print the logs of services",
+		Long: "Print the logs of the pods of the specified services (or all services), prefixing each line with the name of its " +
+			"service. The logs of all pods and containers of a service (e.g. replicas) are multiplexed.",
+		RunE: logsCommand,
+	}
+	// The shorthand -f is taken by the global flag --file.
+	logsCmd.PersistentFlags().Bool(followFlagName, false, "Keep streaming the logs until interrupted, including the logs of "+
+		"pods that are created or recreated later")
+	logsCmd.PersistentFlags().Int64P(tailLinesFlagName, "t", -1, "The number of lines at the end of each log to show. Defaults to all "+
+		"lines")
+	return logsCmd
+}
+
+func logsCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	opts := &logs.Options{
+		Out: os.Stdout,
+	}
+	opts.Follow, _ = cmd.Flags().GetBool(followFlagName)
+	opts.TailLines, _ = cmd.Flags().GetInt64(tailLinesFlagName)
+	err = logs.Run(cfg, getLogsServices(cfg, args), opts)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
+
+// getLogsServices returns the services passed as arguments, or the services that match the filter if there are no arguments (see
+// addEnabledServicesToFilter). Unlike other commands, the dependencies of the services passed as arguments are not included.
+func getLogsServices(cfg *config.Config, args []string) []*config.Service {
+	var composeServices []*config.Service
+	for _, composeService := range cfg.Services {
+		if (len(args) == 0 && cfg.MatchesFilter(composeService)) || cfg.MatchesFilterDirectly(composeService) {
+			composeServices = append(composeServices, composeService)
+		}
+	}
+	return composeServices
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func Test_GetLogsServices_Args(t *testing.T) {
+	cfg := newTestUpConfig()
+	cfg.ClearFilter()
+	// a depends on b, but only the logs of a are requested.
+	cfg.AddToFilter(cfg.Services["a"])
+	composeServices := getLogsServices(cfg, []string{"a"})
+	if len(composeServices) != 1 || composeServices[0] != cfg.Services["a"] {
+		t.Error(composeServices)
+	}
+}
+
+func Test_GetLogsServices_NoArgs(t *testing.T) {
+	cfg := newTestUpConfig()
+	composeServices := getLogsServices(cfg, nil)
+	if len(composeServices) != 2 {
+		t.Error(composeServices)
+	}
+}
+
+func Test_NewLogsCli_Flags(t *testing.T) {
+	cmd := newLogsCli()
+	err := cmd.ParseFlags([]string{"--" + followFlagName, "-t", "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	follow, _ := cmd.Flags().GetBool(followFlagName)
+	tailLines, _ := cmd.Flags().GetInt64(tailLinesFlagName)
+	if !follow || tailLines != 5 {
+		t.Fail()
+	}
+}
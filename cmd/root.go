@@ -5,21 +5,56 @@ import (
 	cc "github.com/ivanpirog/coloredcobra"
 	"os"
 
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 const (
+	contextFlagName       = "context"
 	envVarPrefix          = "KUBECOMPOSE_"
+	envFileFlagName       = "env-file"
 	fileFlagName          = "file"
+	inClusterFlagName     = "in-cluster"
+	kubeConfigFlagName    = "kubeconfig"
+	noColorEnvVarName     = "NO_COLOR"
+	noColorFlagName       = "no-color"
 	namespaceEnvVarName   = envVarPrefix + "NAMESPACE"
 	namespaceFlagName     = "namespace"
+	profileFlagName       = "profile"
+	profilesEnvVarName    = "COMPOSE_PROFILES"
+	projectNameEnvVarName = "COMPOSE_PROJECT_NAME"
+	projectNameFlagName   = "project-name"
+	strictFlagName        = "strict"
 	envIDEnvVarName       = envVarPrefix + "ENVID"
 	envIDFlagName         = "env-id"
 	envIdNoAppendFlagName = "env-id-no-append"
+	truncateNamesFlagName = "truncate-names"
 )
 
+// isColorEnabled returns whether output may contain ANSI escape codes. Colors are disabled if the flag --no-color is in args, if the
+// environment variable NO_COLOR is set (see https://no-color.org) or if stdout is not a terminal. The flag is looked up in args instead of
+// being parsed, because the help text of flags is colored before the command line is parsed.
+func isColorEnabled(args []string, isTerminal bool) bool {
+	if value, exists := envGetter(noColorEnvVarName); exists && value != "" {
+		return false
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+noColorFlagName || arg == "--"+noColorFlagName+"=true" {
+			return false
+		}
+	}
+	return isTerminal
+}
+
 func Execute() error {
+	colorEnabled := isColorEnabled(os.Args[1:], reporter.IsTerminal(os.Stdout))
+	util.SetColorsEnabled(colorEnabled)
 	log.SetOutput(os.Stdout)
 	rootCmd := &cobra.Command{
 		Use:               "kube-compose",
@@ -28,27 +63,50 @@ func Execute() error {
 		Version:           "0.6.3",
 		PersistentPreRunE: setupLogging,
 	}
-	rootCmd.AddCommand(newDownCli(), newUpCli(), newGetCli())
+	rootCmd.AddCommand(newDownCli(), newUpCli(), newGetCli(), newDescribeCli(), newHealthCli(), newLogsCli(), newExecCli(), newConvertCli())
 	setRootCommandFlags(rootCmd)
-	cc.Init(&cc.Config{
-		RootCmd:  rootCmd,
-		Headings: cc.HiCyan + cc.Bold + cc.Underline,
-		Commands: cc.HiYellow + cc.Bold,
-		Example:  cc.Italic,
-		ExecName: cc.Bold,
-		Flags:    cc.Bold,
-	})
+	if colorEnabled {
+		cc.Init(&cc.Config{
+			RootCmd:  rootCmd,
+			Headings: cc.HiCyan + cc.Bold + cc.Underline,
+			Commands: cc.HiYellow + cc.Bold,
+			Example:  cc.Italic,
+			ExecName: cc.Bold,
+			Flags:    cc.Bold,
+		})
+	}
 	return rootCmd.Execute()
 }
 
 func setRootCommandFlags(rootCmd *cobra.Command) {
-	rootCmd.PersistentFlags().StringSliceP(fileFlagName, "f", []string{}, "Specify an alternate compose file")
+	rootCmd.PersistentFlags().String(envFileFlagName, "", "A file of variables (KEY=VALUE lines) that are substituted in the "+
+		"docker compose files, unless they are set in the environment. Defaults to the .env file in the directory of the (first) "+
+		"docker compose file, if it exists")
+	rootCmd.PersistentFlags().StringSliceP(fileFlagName, "f", []string{}, "Specify an alternate compose file. Can be repeated, in which case later files override earlier files")
+	rootCmd.PersistentFlags().String(contextFlagName, "", "The name of the kube config context to use. "+
+		"Defaults to the current context of the kube config")
+	rootCmd.PersistentFlags().String(kubeConfigFlagName, "", "The path of the kube config file to use. "+
+		"Defaults to the files of the environment variable KUBECONFIG, or ~/.kube/config")
+	rootCmd.PersistentFlags().Bool(inClusterFlagName, false, "Access the cluster with the service account of the pod that kube-compose "+
+		"runs in, instead of the kube config. This is also done if there is no kube config and kube-compose runs in a pod")
+	rootCmd.PersistentFlags().Bool(noColorFlagName, false, fmt.Sprintf("Do not color output. Colors are also disabled if the "+
+		"environment variable %s is set or if stdout is not a terminal", noColorEnvVarName))
 	rootCmd.PersistentFlags().StringP(namespaceFlagName, "n", "", fmt.Sprintf("namespace for environment. "+
-		"Defaults to the namespace of the current kube config context. (env %s)", namespaceEnvVarName))
+		"Defaults to the namespace of the selected kube config context. (env %s)", namespaceEnvVarName))
+	rootCmd.PersistentFlags().StringSlice(profileFlagName, []string{}, fmt.Sprintf("Enable the docker compose services of a profile. "+
+		"Can be repeated. Services without profiles are always enabled (env %s, a comma separated list)", profilesEnvVarName))
+	rootCmd.PersistentFlags().String(projectNameFlagName, "", fmt.Sprintf("The project name, which is added as the label %s to all "+
+		"resources. If set, get and down only select resources of the project. Defaults to the name of the directory of the first "+
+		"docker compose file (env %s)", k8smeta.ProjectLabel, projectNameEnvVarName))
 	rootCmd.PersistentFlags().StringP(envIDFlagName, "e", "", "used to isolate environments deployed to a shared namespace, "+
 		"by (1) using this value as a suffix of pod and service names and (2) using this value to isolate selectors. "+
+		"The value is normalized to a DNS label (e.g. \"My_Env\" becomes \"my-env\"). "+
 		fmt.Sprintf("(env %s)", envIDEnvVarName))
 	rootCmd.PersistentFlags().BoolP(envIdNoAppendFlagName, "E", false, "Do not append the '-{env-id}' to the k8s service/pod names (So DNS lookups can be done on the exact service names as listed in the docker-compose yaml)")
+	rootCmd.PersistentFlags().Bool(strictFlagName, false, "Fail if the docker compose configuration has keys that are not supported, "+
+		"instead of ignoring them with a warning")
+	rootCmd.PersistentFlags().Bool(truncateNamesFlagName, false, "Truncate names of k8s resources that would exceed 63 characters, "+
+		"replacing the overflow with a hash of the name")
 	rootCmd.PersistentFlags().StringP(logLevelFlagName, "l", "", fmt.Sprintf("Set to one of %s. "+
 		"(env %s, default %s)", formattedLogLevelList, logLevelEnvVarName, logLevelDefault.String()))
 }
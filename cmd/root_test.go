@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func Test_IsColorEnabled_Terminal(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		if !isColorEnabled([]string{"up"}, true) {
+			t.Fail()
+		}
+	})
+}
+
+func Test_IsColorEnabled_NotTerminal(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		if isColorEnabled([]string{"up"}, false) {
+			t.Fail()
+		}
+	})
+}
+
+func Test_IsColorEnabled_NoColorEnv(t *testing.T) {
+	withMockedEnv(map[string]string{
+		"NO_COLOR": "1",
+	}, func() {
+		if isColorEnabled([]string{"up"}, true) {
+			t.Fail()
+		}
+	})
+}
+
+func Test_IsColorEnabled_NoColorEnvEmpty(t *testing.T) {
+	withMockedEnv(map[string]string{
+		"NO_COLOR": "",
+	}, func() {
+		if !isColorEnabled([]string{"up"}, true) {
+			t.Fail()
+		}
+	})
+}
+
+func Test_IsColorEnabled_NoColorFlag(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		if isColorEnabled([]string{"up", "--no-color"}, true) {
+			t.Fail()
+		}
+		if isColorEnabled([]string{"--no-color=true", "up"}, true) {
+			t.Fail()
+		}
+	})
+}
+
+func Test_IsColorEnabled_NoColorFlagAfterDoubleDash(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		if !isColorEnabled([]string{"up", "--", "--no-color"}, true) {
+			t.Fail()
+		}
+	})
+}
@@ -3,17 +3,38 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/up"
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+// A path component of a docker repository, see https://github.com/distribution/reference/blob/main/regexp.go.
+var repositoryPathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*$`)
+
 const (
+	externalFlagName         = "external"
+	progressLogFileFlagName  = "progress-log-file"
+	registryPrefixFlagName   = "registry-prefix"
+	skipServicesFlagName     = "skip-services"
+	statefulServicesFlagName = "stateful-services"
+	volumeInitImageFlagName  = "volume-init-image"
+
+	volumeInitImageEnvVarName = envVarPrefix + "VOLUME_INIT_IMAGE"
+
 	registryUserEnvVarName = envVarPrefix + "REGISTRY_USER"
 
 	registryPassEnvVarName = envVarPrefix + "REGISTRY_PASS"
@@ -30,17 +51,76 @@ func newUpCli() *cobra.Command {
 		Long:  "creates pods and services in an order that respects depends_on in the docker compose file",
 		RunE:  upCommand,
 	}
+	upCmd.PersistentFlags().Bool("as-deployment", false, "Deploy each service as a Deployment with deploy.replicas replicas (1 if "+
+		"unset) instead of as a bare pod. Stateful services and services with restart policy on-failure are not affected")
+	upCmd.PersistentFlags().String("apply-order", up.ApplyOrderDeps, fmt.Sprintf("The order in which resources are applied: %#v "+
+		"respects depends_on, %#v applies resources grouped by kind (Services before workloads) and %#v applies the resources of each "+
+		"service in the order in which services are declared", up.ApplyOrderDeps, up.ApplyOrderKind, up.ApplyOrderManifest))
+	upCmd.PersistentFlags().Duration("apply-timeout", up.DefaultApplyTimeout, "Maximum duration of each request that creates or updates a "+
+		"resource (e.g. when an admission webhook is slow). Set to 0 to disable")
+	upCmd.PersistentFlags().Bool("create-namespace", false, "Create the namespace of the environment (labeled with the environment) if "+
+		"it does not exist")
+	upCmd.PersistentFlags().Bool("default-deny-ingress", false, "Create a NetworkPolicy that denies all ingress traffic to the pods of the "+
+		"environment, and NetworkPolicies that allow ingress traffic between pods of the environment")
 	upCmd.PersistentFlags().BoolP("detach", "d", false, "Run in "+util.AnsiColorWrap("d", "4", "0")+"etached mode: runs containers in the background")
+	upCmd.PersistentFlags().String("dry-run", up.DryRunNone, fmt.Sprintf("Set to %#v to print the resources that would be created "+
+		"without talking to the cluster, or %#v to submit all resources to the API server with dry run enabled, so that they are "+
+		"validated (including by admission webhooks) without being persisted. Images are not pulled, built or pushed in a dry run",
+		up.DryRunClient, up.DryRunServer))
 	upCmd.PersistentFlags().BoolP("event-diffs", "v", false, "Show e"+util.AnsiColorWrap("v", "4", "0")+"ent diffs as they come in from k8s. Very useful for debugging k8s internals.")
+	upCmd.PersistentFlags().Bool("explain", false, "Explain progress by summarizing k8s events of pods (e.g. pulling images and failing "+
+		"readiness probes). Unlike --event-diffs, this is intended for diagnosing services that do not become ready")
+	upCmd.PersistentFlags().StringToString(externalFlagName, nil, "Resolve the name of a skipped service or the target of an "+
+		"external link to an external address <name>=<host>[:<port>]. IP addresses are added to the host aliases of pods, other hosts are published as a Service of type "+
+		"ExternalName and require --"+envIdNoAppendFlagName+" (see --"+skipServicesFlagName+")")
+	upCmd.PersistentFlags().Bool("headless-services", false, "Create the Service of each service without a cluster IP, so that "+
+		"its name resolves to the IPs of its pods through the cluster DNS (like docker compose) instead of through host aliases. Requires "+
+		"--"+envIdNoAppendFlagName)
+	upCmd.PersistentFlags().Int("max-parallel", runtime.NumCPU(), "The maximum number of images that are pulled, built or pushed "+
+		"concurrently. Images are prepared regardless of depends_on, which only orders the creation of pods")
+	upCmd.PersistentFlags().String(progressLogFileFlagName, "", "Write the progress of services as plain text lines to this file (e.g. "+
+		"/dev/fd/3), instead of rendering a table on stdout. Logs are then written to stdout, separately from the progress")
+	upCmd.PersistentFlags().String("pull-policy", "", "Force the image pull policy of all containers (Always, IfNotPresent or Never). "+
+		"By default, images that are used as is are always pulled if they are untagged or tagged latest")
+	upCmd.PersistentFlags().Int("push-retries", up.DefaultPushRetries, "The number of times a failed push of an image to the "+
+		"docker registry is retried. Errors that a retry cannot fix (e.g. the registry rejects the credentials) are not retried")
+	upCmd.PersistentFlags().Duration("push-retry-delay", up.DefaultPushRetryDelay, "The delay before the first retry of a failed "+
+		"push, which doubles with each retry")
+	upCmd.PersistentFlags().String("recreate", up.RecreateChanged, fmt.Sprintf("Set to %#v to leave existing resources untouched, "+
+		"%#v to recreate pods whose docker compose configuration or image has changed, or %#v to recreate all pods", up.RecreateNever,
+		up.RecreateChanged, up.RecreateAlways))
+	upCmd.PersistentFlags().String(registryPrefixFlagName, "", "A repository path (e.g. team-a) that is prepended to the names of "+
+		"pushed images, so that environments that share a docker registry do not overwrite each other's images")
 	upCmd.PersistentFlags().StringP("registry-user", "", registryUserFromEnv,
 		fmt.Sprintf("The docker registry user to authenticate as. The default is common for Openshift clusters. (env %s)", registryUserEnvVarName))
 	upCmd.PersistentFlags().StringP("registry-pass", "", registryPassFromEnv,
 		fmt.Sprintf("The docker registry password to authenticate with. When unset, will use the Bearer Token from Kube config as is common for Openshift clusters. (env %s)", registryPassEnvVarName))
 	upCmd.PersistentFlags().BoolP("run-as-user", "", false, "When set, the runAsUser/runAsGroup will be set for each pod based on the "+
 		"user of the pod's image and the \"user\" key of the pod's docker-compose service")
+	upCmd.PersistentFlags().Bool("skip-escaping-symlinks", false, "Skip (with a warning) symlinks in bind mounted volumes whose "+
+		"target is outside the volume, instead of failing")
 	upCmd.PersistentFlags().BoolP("skip-host-aliases", "a", false, "Skip adding all services ClusterIP in Pod host "+util.AnsiColorWrap("a", "4", "0")+"liases (useful when in-cluster name resolving is sufficient)")
+	upCmd.PersistentFlags().StringSlice(skipServicesFlagName, nil, "Comma separated names of services that are not deployed (e.g. "+
+		"because they run outside the cluster). Dependencies on these services are ignored")
 	upCmd.PersistentFlags().BoolP("skip-push", "p", false, "Skip "+util.AnsiColorWrap("p", "4", "0")+"ushing images to registry: assumes they were previously pushed (helps get around connection problems to registry)")
+	upCmd.PersistentFlags().StringSlice(statefulServicesFlagName, nil, "Comma separated names of services that are deployed as a "+
+		"StatefulSet with a volume claim template per named volume, so that data survives pod restarts")
+	upCmd.PersistentFlags().String("storage-class", "", "The storage class of the volume claims of stateful services. The default "+
+		"storage class of the cluster is used if unset")
 	upCmd.PersistentFlags().Int64P("tail-lines", "t", 10, "Pod history log lines to show when starting to "+util.AnsiColorWrap("t", "4", "0")+"ail logs.")
+	upCmd.PersistentFlags().Duration("timeout", 0, "Maximum duration to wait for services to become ready. Set to 0 to wait indefinitely")
+	upCmd.PersistentFlags().String("transfer", up.TransferPush, fmt.Sprintf("How images reach the cluster: %#v uses the "+
+		"cluster_image_storage of x-kube-compose, %#v saves each image with docker and loads it into the container runtime (containerd) "+
+		"of each node via a privileged loader pod, so that no registry is needed", up.TransferPush, up.TransferSaveLoad))
+	upCmd.PersistentFlags().String("transfer-loader-image", up.DefaultTransferLoaderImage, "The image of the loader pods of "+
+		"--transfer="+up.TransferSaveLoad+", which must provide sh, sleep and nsenter")
+	upCmd.PersistentFlags().Bool("use-owner-refs", false, "Create a ConfigMap that owns all resources of the environment, so that "+
+		"deleting the ConfigMap deletes the environment through the garbage collector of Kubernetes")
+	upCmd.PersistentFlags().String(volumeInitImageFlagName, "", fmt.Sprintf("The base image of the helper images of bind mounted "+
+		"volumes (e.g. a mirror of ubuntu:latest in an internal registry). Overrides volume_init_base_image of x-kube-compose (env %s)",
+		volumeInitImageEnvVarName))
+	upCmd.PersistentFlags().Duration("wait-before-logs", 0, "Delay before each (re)connection to the logs of a container when not "+
+		"detached, e.g. to give slow starting containers time to open their log streams")
 	return upCmd
 }
 
@@ -50,34 +130,267 @@ func upCommand(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	opts := &up.Options{}
+	opts.ApplyOrder, _ = cmd.Flags().GetString("apply-order")
+	if opts.ApplyOrder != up.ApplyOrderDeps && opts.ApplyOrder != up.ApplyOrderKind && opts.ApplyOrder != up.ApplyOrderManifest {
+		return fmt.Errorf("the flag --apply-order must be one of %#v, %#v and %#v", up.ApplyOrderDeps, up.ApplyOrderKind,
+			up.ApplyOrderManifest)
+	}
+	opts.ApplyTimeout, _ = cmd.Flags().GetDuration("apply-timeout")
+	opts.AsDeployment, _ = cmd.Flags().GetBool("as-deployment")
 	opts.Context = context.Background()
+	opts.DefaultDenyIngress, _ = cmd.Flags().GetBool("default-deny-ingress")
 	opts.Detach, _ = cmd.Flags().GetBool("detach")
+	opts.DryRun, _ = cmd.Flags().GetString("dry-run")
+	if opts.DryRun != up.DryRunNone && opts.DryRun != up.DryRunClient && opts.DryRun != up.DryRunServer {
+		return fmt.Errorf("the flag --dry-run must be one of %#v, %#v and %#v", up.DryRunNone, up.DryRunClient, up.DryRunServer)
+	}
 	opts.EventDiffs, _ = cmd.Flags().GetBool("event-diffs")
+	opts.Explain, _ = cmd.Flags().GetBool("explain")
+	err = skipServices(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	opts.External, err = getExternalFlag(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	opts.HeadlessServices, err = getHeadlessServicesFlag(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	err = statefulServices(cmd.Flags(), cfg)
+	if err != nil {
+		return err
+	}
+	opts.MaxParallel, _ = cmd.Flags().GetInt("max-parallel")
+	if opts.MaxParallel < 1 {
+		return fmt.Errorf("the flag --max-parallel must be at least 1")
+	}
+	opts.PullPolicy, _ = cmd.Flags().GetString("pull-policy")
+	if opts.PullPolicy != "" && opts.PullPolicy != string(v1.PullAlways) && opts.PullPolicy != string(v1.PullIfNotPresent) &&
+		opts.PullPolicy != string(v1.PullNever) {
+		return fmt.Errorf("the flag --pull-policy must be one of %#v, %#v and %#v", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever)
+	}
+	opts.PushRetries, _ = cmd.Flags().GetInt("push-retries")
+	if opts.PushRetries < 0 {
+		return fmt.Errorf("the flag --push-retries must not be negative")
+	}
+	opts.PushRetryDelay, _ = cmd.Flags().GetDuration("push-retry-delay")
+	if opts.PushRetryDelay < 0 {
+		return fmt.Errorf("the flag --push-retry-delay must not be negative")
+	}
+	opts.Recreate, _ = cmd.Flags().GetString("recreate")
+	if opts.Recreate != up.RecreateNever && opts.Recreate != up.RecreateChanged && opts.Recreate != up.RecreateAlways {
+		return fmt.Errorf("the flag --recreate must be one of %#v, %#v and %#v", up.RecreateNever, up.RecreateChanged, up.RecreateAlways)
+	}
 	opts.RunAsUser, _ = cmd.Flags().GetBool("run-as-user")
+	opts.SkipEscapingSymlinks, _ = cmd.Flags().GetBool("skip-escaping-symlinks")
 	opts.SkipPush, _ = cmd.Flags().GetBool("skip-push")
 	opts.SkipHostAliases, _ = cmd.Flags().GetBool("skip-host-aliases")
+	opts.StorageClass, _ = cmd.Flags().GetString("storage-class")
 	opts.TailLines, _ = cmd.Flags().GetInt64("tail-lines")
+	opts.UseOwnerRefs, _ = cmd.Flags().GetBool("use-owner-refs")
+	opts.CreateNamespace, _ = cmd.Flags().GetBool("create-namespace")
+	opts.WaitBeforeLogs, _ = cmd.Flags().GetDuration("wait-before-logs")
+	opts.Transfer, _ = cmd.Flags().GetString("transfer")
+	if opts.Transfer != up.TransferPush && opts.Transfer != up.TransferSaveLoad {
+		return fmt.Errorf("the flag --transfer must be one of %#v and %#v", up.TransferPush, up.TransferSaveLoad)
+	}
+	opts.TransferLoaderImage, _ = cmd.Flags().GetString("transfer-loader-image")
+	opts.VolumeInitBaseImage = getVolumeInitImageFlag(cmd.Flags())
+	opts.WaitTimeout, _ = cmd.Flags().GetDuration("timeout")
 
-	opts.Reporter = reporter.New(os.Stdout)
-	if opts.Reporter.IsTerminal() {
-		log.StandardLogger().SetOutput(opts.Reporter.LogSink())
-		go func() {
-			for {
-				opts.Reporter.Refresh()
-				time.Sleep(reporter.RefreshInterval)
-			}
-		}()
+	progressLogFile, _ := cmd.Flags().GetString(progressLogFileFlagName)
+	var stopReporter func()
+	opts.Reporter, stopReporter, err = newReporter(progressLogFile)
+	if err != nil {
+		return err
 	}
 
 	opts.RegistryUser, _ = cmd.Flags().GetString("registry-user")
 	opts.RegistryPass, _ = cmd.Flags().GetString("registry-pass")
+	registryPrefix, _ := cmd.Flags().GetString(registryPrefixFlagName)
+	opts.RegistryPrefix, err = parseRegistryPrefix(registryPrefix)
+	if err != nil {
+		return fmt.Errorf("the flag --%s is invalid: %v", registryPrefixFlagName, err)
+	}
 
 	err = up.Run(cfg, opts)
 	if err != nil {
 		log.Error(err)
-		opts.Reporter.Refresh()
+		stopReporter()
 		os.Exit(1)
 	}
-	opts.Reporter.Refresh()
+	stopReporter()
+	return nil
+}
+
+// newReporter creates the reporter of the up command. By default the progress is rendered as a table on stdout (if stdout is a terminal),
+// in which case logs are written to the log sink of the reporter so that they do not corrupt the table. If progressLogFile is set the
+// progress is written to that file instead, and logs go to stdout. The returned function stops refreshing the reporter, renders the
+// progress a final time and closes progressLogFile.
+func newReporter(progressLogFile string) (*reporter.Reporter, func(), error) {
+	var r *reporter.Reporter
+	var file *os.File
+	if progressLogFile == "" {
+		r = reporter.New(os.Stdout)
+		if !r.IsTerminal() {
+			return r, r.Refresh, nil
+		}
+		log.StandardLogger().SetOutput(r.LogSink())
+	} else {
+		var err error
+		file, err = os.OpenFile(progressLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = reporter.NewPlain(file)
+	}
+	stopChannel := make(chan struct{})
+	doneChannel := make(chan struct{})
+	go func() {
+		defer close(doneChannel)
+		ticker := time.NewTicker(reporter.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			r.Refresh()
+			select {
+			case <-stopChannel:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	stop := func() {
+		close(stopChannel)
+		<-doneChannel
+		r.Refresh()
+		if file != nil {
+			util.CloseAndLogError(file)
+		}
+	}
+	return r, stop, nil
+}
+
+func skipServices(flags *pflag.FlagSet, cfg *config.Config) error {
+	names, _ := flags.GetStringSlice(skipServicesFlagName)
+	for _, name := range names {
+		service := cfg.Services[name]
+		if service == nil {
+			return fmt.Errorf("the flag --%s refers to service %#v, but no service with that name exists", skipServicesFlagName, name)
+		}
+		cfg.Skip(service)
+	}
+	return nil
+}
+
+func getExternalFlag(flags *pflag.FlagSet, cfg *config.Config) (map[string]up.ExternalService, error) {
+	values, _ := flags.GetStringToString(externalFlagName)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	external := map[string]up.ExternalService{}
+	for name, address := range values {
+		service := cfg.Services[name]
+		if (service == nil || !cfg.IsSkipped(service)) && !isExternalLinkTarget(cfg, name) {
+			return nil, fmt.Errorf("the flag --%s can only be set for services skipped with --%s and targets of external_links, but got "+
+				"%#v", externalFlagName, skipServicesFlagName, name)
+		}
+		externalService, err := parseExternalAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("the flag --%s has an invalid address for service %s: %v", externalFlagName, name, err)
+		}
+		// A DNS name is published as a Service of type ExternalName, which is named after the service (or the alias of the external
+		// link). The names of Services are suffixed with the environment ID, so pods would not resolve the name itself.
+		if net.ParseIP(externalService.Host) == nil && !cfg.EnvironmentIDNoAppend {
+			return nil, fmt.Errorf("the flag --%s sets the DNS name %#v for service %s, which requires the flag --%s so that the name %s "+
+				"resolves (or use an IP address instead)", externalFlagName, externalService.Host, name, envIdNoAppendFlagName, name)
+		}
+		external[name] = externalService
+	}
+	return external, nil
+}
+
+func isExternalLinkTarget(cfg *config.Config, name string) bool {
+	for _, service := range cfg.Services {
+		for _, externalLink := range service.DockerComposeService.ExternalLinks {
+			if externalLink.Target == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getHeadlessServicesFlag returns the value of the flag --headless-services. Pods resolve the names of headless Services through the
+// cluster DNS instead of through host aliases, so the names of the Services must be the names of the docker compose services.
+func getHeadlessServicesFlag(flags *pflag.FlagSet, cfg *config.Config) (bool, error) {
+	headlessServices, _ := flags.GetBool("headless-services")
+	if headlessServices && !cfg.EnvironmentIDNoAppend {
+		return false, fmt.Errorf("the flag --headless-services requires the flag --%s, so that the names of the docker compose services "+
+			"resolve", envIdNoAppendFlagName)
+	}
+	return headlessServices, nil
+}
+
+// statefulServices marks the services of the flag --stateful-services as stateful, so that they are deployed as a StatefulSet.
+func statefulServices(flags *pflag.FlagSet, cfg *config.Config) error {
+	names, _ := flags.GetStringSlice(statefulServicesFlagName)
+	for _, name := range names {
+		service := cfg.Services[name]
+		if service == nil {
+			return fmt.Errorf("the flag --%s refers to service %#v, but no service with that name exists", statefulServicesFlagName, name)
+		}
+		service.Stateful = true
+	}
 	return nil
 }
+
+func parseExternalAddress(address string) (up.ExternalService, error) {
+	var externalService up.ExternalService
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// The address does not have a port.
+		host = address
+	} else {
+		port64, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || port64 == 0 {
+			return externalService, fmt.Errorf("%#v is not a valid port", port)
+		}
+		externalService.Port = int32(port64)
+	}
+	if net.ParseIP(host) == nil {
+		if e := validation.IsDNS1123Subdomain(host); len(e) > 0 {
+			return externalService, fmt.Errorf("%#v is neither an IP address nor a valid DNS name: %s", host, e[0])
+		}
+	}
+	externalService.Host = host
+	return externalService, nil
+}
+
+// parseRegistryPrefix validates that prefix is a legal repository path (one or more path components separated by '/'). Leading and
+// trailing slashes are removed.
+func parseRegistryPrefix(prefix string) (string, error) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return "", nil
+	}
+	for _, component := range strings.Split(prefix, "/") {
+		if !repositoryPathComponentRegexp.MatchString(component) {
+			return "", fmt.Errorf("%#v is not a valid path component of a repository (lower case alphanumeric characters, separated by "+
+				"'.', '_', '__' or '-')", component)
+		}
+	}
+	return prefix, nil
+}
+
+// getVolumeInitImageFlag returns the value of the flag --volume-init-image, or of the environment variable KUBECOMPOSE_VOLUME_INIT_IMAGE
+// if the flag is not set.
+func getVolumeInitImageFlag(flags *pflag.FlagSet) string {
+	if !flags.Changed(volumeInitImageFlagName) {
+		volumeInitImage, _ := envGetter(volumeInitImageEnvVarName)
+		return volumeInitImage
+	}
+	volumeInitImage, _ := flags.GetString(volumeInitImageFlagName)
+	return volumeInitImage
+}
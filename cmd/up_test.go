@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/spf13/cobra"
+)
+
+func newTestUpConfig() *config.Config {
+	cfg := &config.Config{}
+	serviceA := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	serviceA.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"b": dockerComposeConfig.ServiceHealthy,
+	}
+	for _, service := range cfg.Services {
+		cfg.AddToFilter(service)
+	}
+	return cfg
+}
+
+func Test_SkipServices_Success(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--" + skipServicesFlagName, "b", "--" + externalFlagName, "b=10.0.0.1:5432"})
+	cfg := newTestUpConfig()
+	err := skipServices(cmd.Flags(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.IsSkipped(cfg.Services["b"]) || len(cfg.Services["a"].DockerComposeService.DependsOn) != 0 {
+		t.Fail()
+	}
+	external, err := getExternalFlag(cmd.Flags(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if external["b"].Host != "10.0.0.1" || external["b"].Port != 5432 {
+		t.Error(external)
+	}
+}
+
+func Test_SkipServices_UnknownServiceError(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--" + skipServicesFlagName, "c"})
+	err := skipServices(cmd.Flags(), newTestUpConfig())
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_GetExternalFlag_NotSkippedError(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringToString(externalFlagName, nil, "")
+	_ = cmd.ParseFlags([]string{"--" + externalFlagName, "b=10.0.0.1"})
+	_, err := getExternalFlag(cmd.Flags(), newTestUpConfig())
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_GetExternalFlag_DNSNameEnvIDAppendedError(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--" + externalFlagName, "b=db.example.com"})
+	cfg := newTestUpConfig()
+	cfg.Skip(cfg.Services["b"])
+	_, err := getExternalFlag(cmd.Flags(), cfg)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_GetExternalFlag_DNSNameEnvIDNoAppend(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--" + externalFlagName, "b=db.example.com"})
+	cfg := newTestUpConfig()
+	cfg.Skip(cfg.Services["b"])
+	cfg.EnvironmentIDNoAppend = true
+	external, err := getExternalFlag(cmd.Flags(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if external["b"].Host != "db.example.com" {
+		t.Error(external)
+	}
+}
+
+func Test_ParseExternalAddress_NoPort(t *testing.T) {
+	externalService, err := parseExternalAddress("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if externalService.Host != "10.0.0.1" || externalService.Port != 0 {
+		t.Error(externalService)
+	}
+}
+
+func Test_ParseExternalAddress_InvalidPort(t *testing.T) {
+	_, err := parseExternalAddress("10.0.0.1:0")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_ParseExternalAddress_DNSName(t *testing.T) {
+	externalService, err := parseExternalAddress("db.example.com:5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if externalService.Host != "db.example.com" || externalService.Port != 5432 {
+		t.Error(externalService)
+	}
+}
+
+func Test_ParseExternalAddress_InvalidHostError(t *testing.T) {
+	_, err := parseExternalAddress("db_example!:80")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_GetExternalFlag_ExternalLinkTarget(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--" + externalFlagName, "realmysql=10.0.0.2:3306"})
+	cfg := newTestUpConfig()
+	cfg.Services["a"].DockerComposeService.ExternalLinks = []dockerComposeConfig.ExternalLink{
+		{Target: "realmysql", Alias: "mysql"},
+	}
+	external, err := getExternalFlag(cmd.Flags(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if external["realmysql"].Host != "10.0.0.2" {
+		t.Error(external)
+	}
+}
+
+func Test_NewReporter_ProgressLogFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "progress.log")
+	r, stop, err := newReporter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AddRow("a").SetPhase(reporter.PhaseResolving)
+	stop()
+	if !r.IsPlain() {
+		t.Fail()
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "a: resolving") {
+		t.Errorf("%q", content)
+	}
+}
+
+func Test_NewReporter_ProgressLogFileError(t *testing.T) {
+	_, _, err := newReporter("/this/directory/does/not/exist/progress.log")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_StatefulServices_Success(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--" + statefulServicesFlagName, "b"})
+	cfg := newTestUpConfig()
+	err := statefulServices(cmd.Flags(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Services["b"].Stateful || cfg.Services["a"].Stateful {
+		t.Fail()
+	}
+}
+
+func Test_StatefulServices_UnknownServiceError(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--" + statefulServicesFlagName, "c"})
+	err := statefulServices(cmd.Flags(), newTestUpConfig())
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_GetHeadlessServicesFlag_Success(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--headless-services"})
+	cfg := newTestUpConfig()
+	cfg.EnvironmentIDNoAppend = true
+	headlessServices, err := getHeadlessServicesFlag(cmd.Flags(), cfg)
+	if err != nil || !headlessServices {
+		t.Error(headlessServices, err)
+	}
+}
+
+func Test_GetHeadlessServicesFlag_EnvIDAppendedError(t *testing.T) {
+	cmd := newUpCli()
+	_ = cmd.ParseFlags([]string{"--headless-services"})
+	_, err := getHeadlessServicesFlag(cmd.Flags(), newTestUpConfig())
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_ParseRegistryPrefix_Success(t *testing.T) {
+	prefix, err := parseRegistryPrefix("/team-a/sub.group/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix != "team-a/sub.group" {
+		t.Error(prefix)
+	}
+}
+
+func Test_ParseRegistryPrefix_InvalidError(t *testing.T) {
+	for _, prefix := range []string{"Team-A", "team-a//b", "-team", "team a"} {
+		_, err := parseRegistryPrefix(prefix)
+		if err == nil {
+			t.Error(prefix)
+		}
+	}
+}
+
+func Test_GetVolumeInitImageFlag_Env(t *testing.T) {
+	withMockedEnv(map[string]string{
+		volumeInitImageEnvVarName: "mirror.example.com/ubuntu:latest",
+	}, func() {
+		cmd := newUpCli()
+		if volumeInitImage := getVolumeInitImageFlag(cmd.Flags()); volumeInitImage != "mirror.example.com/ubuntu:latest" {
+			t.Error(volumeInitImage)
+		}
+	})
+}
+
+func Test_GetVolumeInitImageFlag_FlagOverridesEnv(t *testing.T) {
+	withMockedEnv(map[string]string{
+		volumeInitImageEnvVarName: "mirror.example.com/ubuntu:latest",
+	}, func() {
+		cmd := newUpCli()
+		_ = cmd.ParseFlags([]string{"--" + volumeInitImageFlagName, "ubuntu:20.04"})
+		if volumeInitImage := getVolumeInitImageFlag(cmd.Flags()); volumeInitImage != "ubuntu:20.04" {
+			t.Error(volumeInitImage)
+		}
+	})
+}
+
+func Test_GetVolumeInitImageFlag_NotSet(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		cmd := newUpCli()
+		if volumeInitImage := getVolumeInitImageFlag(cmd.Flags()); volumeInitImage != "" {
+			t.Error(volumeInitImage)
+		}
+	})
+}
@@ -2,12 +2,16 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"github.com/uber-go/mapdecode"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/rest"
 )
@@ -23,12 +27,42 @@ type Service struct {
 	matchesFilterDirectly bool
 	NameEscaped           string
 	Ports                 []Port
+	skipped               bool
+	// Whether the service should not get a Kubernetes Service even if it has ports, see "x-kube-compose"."no_service".
+	NoService bool
+	// The probes set by "x-kube-compose"."probes", which override the probes derived from healthchecks. Nil if not set.
+	Probes *Probes
+	// The node port of the only port of the Kubernetes Service, see "x-kube-compose"."service"."node_port". Zero if not set.
+	NodePort int32
+	// The type of the Kubernetes Service, see "x-kube-compose"."service"."type". Empty means ClusterIP.
+	ServiceType v1.ServiceType
+	// Whether the service should be deployed as a StatefulSet, see "x-kube-compose"."stateful" and the flag --stateful-services.
+	Stateful bool
 }
 
 func (s *Service) Name() string {
 	return s.DockerComposeService.Name
 }
 
+// IsEnabledByProfiles returns whether the service is enabled when the specified profiles are active. Like docker compose, a service is
+// enabled if it has no profiles or if any of its profiles is active. The profile "*" activates all profiles.
+func (s *Service) IsEnabledByProfiles(profiles []string) bool {
+	if len(s.DockerComposeService.Profiles) == 0 {
+		return true
+	}
+	for _, profile := range profiles {
+		if profile == "*" {
+			return true
+		}
+		for _, serviceProfile := range s.DockerComposeService.Profiles {
+			if profile == serviceProfile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type ClusterImageStorage struct {
 	Docker         *struct{}
 	DockerRegistry *DockerRegistryClusterImageStorage
@@ -43,25 +77,58 @@ type Config struct {
 	KubeConfig            *rest.Config
 	Namespace             string
 	ClusterImageStorage   ClusterImageStorage
-	VolumeInitBaseImage   *string
+	// The top-level configs of the docker compose configuration.
+	Configs map[string]*dockerComposeConfig.ComposeConfig
+	// If true then commands only select resources whose project label is ProjectName (see --project-name).
+	FilterByProject bool
+	// The owner of all resources of the environment, so that deleting the owner deletes the environment through garbage collection (see
+	// --use-owner-refs and k8smeta.InitOwnerReferences). Nil if resources have no owner.
+	OwnerReference *metav1.OwnerReference
+	// The name of the project, which is added as a label to all resources (see k8smeta.ProjectLabel). Defaults to the normalized name of
+	// the directory of the first docker compose file, like docker compose (see NormalizeProjectName).
+	ProjectName string
+	// The top-level secrets of the docker compose configuration.
+	Secrets map[string]*dockerComposeConfig.Secret
+	// The names of the services in the order in which they are declared in the docker compose files.
+	ServiceOrder []string
+	// If true then names of Kubernetes resources that exceed the maximum length are truncated (see k8smeta.TruncateName).
+	TruncateNames       bool
+	VolumeInitBaseImage *string
+	// The shell that runs the entrypoint of helper images of bind mounted volumes. Nil means the default (/bin/sh).
+	VolumeInitShell *string
 
 	Services map[string]*Service
 }
 
 type Port struct {
-	Port int32
+	// The port of the node that is mapped to Port, set by ports with mode host. Zero if not set.
+	HostPort int32
+	Port     int32
 	// one of "udp", "tcp" and "sctp"
 	Protocol string
 }
 
 func New(files []string) (*Config, error) {
+	return NewWithEnvFile(files, "")
+}
+
+// NewWithEnvFile is New, but substitutes variables of the docker compose files that are not set in the environment with the variables of
+// envFile (see dockerComposeConfig.NewWithEnvFile).
+func NewWithEnvFile(files []string, envFile string) (*Config, error) {
 	cfg := &Config{
 		EnvironmentLabel: "env",
 	}
-	dcCfg, err := dockerComposeConfig.New(files)
+	dcCfg, err := dockerComposeConfig.NewWithEnvFile(files, envFile)
 	if err != nil {
 		return nil, err
 	}
+	for _, name := range dcCfg.UnsetVariables {
+		log.Warnf("the %#v variable is not set, defaulting to a blank string", name)
+	}
+	cfg.ProjectName = NormalizeProjectName(filepath.Base(dcCfg.ProjectDir))
+	cfg.Configs = dcCfg.Configs
+	cfg.Secrets = dcCfg.Secrets
+	cfg.ServiceOrder = dcCfg.ServiceOrder
 	cfg.Services = map[string]*Service{}
 	for name, dcService := range dcCfg.Services {
 		if e := validation.IsDNS1123Subdomain(name); len(e) > 0 {
@@ -72,10 +139,18 @@ func New(files []string) (*Config, error) {
 			NameEscaped:          util.EscapeName(name),
 		}
 		for _, portBinding := range dcService.Ports {
-			service.Ports = append(service.Ports, Port{
+			port := Port{
 				Protocol: portBinding.Protocol,
 				Port:     portBinding.Internal,
-			})
+			}
+			if portBinding.Mode == "host" && portBinding.ExternalMin >= 0 && portBinding.ExternalMin == portBinding.ExternalMax {
+				port.HostPort = portBinding.ExternalMin
+			}
+			service.Ports = append(service.Ports, port)
+		}
+		err = loadServiceXKubeCompose(service, dcService.XProperties)
+		if err != nil {
+			return nil, err
 		}
 		cfg.Services[name] = service
 	}
@@ -86,48 +161,135 @@ func New(files []string) (*Config, error) {
 	return cfg, nil
 }
 
+// NormalizeProjectName maps a directory name to a valid label value, like docker compose derives project names from directory names.
+// Upper case letters are converted to lower case, characters other than [a-z0-9_-] are removed and the result is at most 63 characters
+// long, starting and ending with an alphanumeric character. The result is empty if the name has no alphanumeric characters.
+func NormalizeProjectName(name string) string {
+	normalized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-':
+			return r
+		}
+		return -1
+	}, name)
+	normalized = strings.Trim(normalized, "_-")
+	if len(normalized) > validation.LabelValueMaxLength {
+		normalized = strings.TrimRight(normalized[:validation.LabelValueMaxLength], "_-")
+	}
+	return normalized
+}
+
+// formatUnsupportedKeys lists the keys of all services that are not translated to Kubernetes, grouped by service. Returns the empty
+// string if there are no such keys.
+func formatUnsupportedKeys(cfg *Config) string {
+	var names []string
+	for name, service := range cfg.Services {
+		if len(service.DockerComposeService.UnsupportedKeys) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "\n  service %s:", name)
+		for _, key := range cfg.Services[name].DockerComposeService.UnsupportedKeys {
+			fmt.Fprintf(&sb, "\n    %s: %s", key.Key, key.Reason)
+		}
+	}
+	return sb.String()
+}
+
+// CheckUnsupportedKeys reports the keys of all services that are not translated to Kubernetes in a single message, so that users
+// migrating docker compose files get a complete list of what is ignored. If strict is true then an error is returned if there are such
+// keys, otherwise a warning is logged.
+func (cfg *Config) CheckUnsupportedKeys(strict bool) error {
+	s := formatUnsupportedKeys(cfg)
+	if s == "" {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("the docker compose configuration has keys that are not supported:%s", s)
+	}
+	log.Warnf("ignoring keys of the docker compose configuration that are not supported:%s", s)
+	return nil
+}
+
 type clusterImageStorage struct {
 	Type          string  `mapdecode:"type"`
 	Host          *string `mapdecode:"host"`
 	HostInCluster *string `mapdecode:"host_in_cluster"`
 }
 
+// xKubeComposeKey is the extension field of docker compose files and services that holds the options of kube-compose.
+const xKubeComposeKey = "x-kube-compose"
+
 type xKubeCompose struct {
-	XKubeCompose struct {
-		ClusterImageStorage *clusterImageStorage `mapdecode:"cluster_image_storage"`
-		PushImages          *struct {
-			DockerRegistry string `mapdecode:"docker_registry"`
-		} `mapdecode:"push_images"`
-		VolumeInitBaseImage *string `mapdecode:"volume_init_base_image"`
-	} `mapdecode:"x-kube-compose"`
+	ClusterImageStorage *clusterImageStorage `mapdecode:"cluster_image_storage"`
+	PushImages          *struct {
+		DockerRegistry string `mapdecode:"docker_registry"`
+	} `mapdecode:"push_images"`
+	VolumeInitBaseImage *string `mapdecode:"volume_init_base_image"`
+	VolumeInitShell     *string `mapdecode:"volume_init_shell"`
 }
 
 func loadXKubeCompose(cfg *Config, xPropertiesSlice []dockerComposeConfig.XProperties) error {
 	for i := len(xPropertiesSlice) - 1; i >= 0; i-- {
 		var x xKubeCompose
-		err := mapdecode.Decode(&x, xPropertiesSlice[i], mapdecode.IgnoreUnused(true))
+		_, err := xPropertiesSlice[i].Decode(xKubeComposeKey, &x)
 		if err != nil {
 			return errors.Wrap(err, "error while parsing \"x-kube-compose\" of a docker compose file")
 		}
-		if x.XKubeCompose.ClusterImageStorage != nil {
-			if x.XKubeCompose.PushImages != nil {
+		if x.ClusterImageStorage != nil {
+			if x.PushImages != nil {
 				return fmt.Errorf("a docker compose file cannot set both \"x-kube-compose\".\"push_images\" and \"x-kube-compose\"." +
 					"\"cluster_image_storage\"")
 			}
-			err = loadClusterImageStorage(cfg, x.XKubeCompose.ClusterImageStorage)
+			err = loadClusterImageStorage(cfg, x.ClusterImageStorage)
 			if err != nil {
 				return err
 			}
-		} else if x.XKubeCompose.PushImages != nil {
+		} else if x.PushImages != nil {
 			log.Warn("a docker compose file has set \"x-kube-compose\".\"push_images\", but this functionality is deprecated. " +
 				"See https://github.com/kube-compose/kube-compose.")
 			cfg.ClusterImageStorage.Docker = nil
 			cfg.ClusterImageStorage.DockerRegistry = &DockerRegistryClusterImageStorage{
-				Host: x.XKubeCompose.PushImages.DockerRegistry,
+				Host: x.PushImages.DockerRegistry,
 			}
 		}
-		cfg.VolumeInitBaseImage = x.XKubeCompose.VolumeInitBaseImage
+		cfg.VolumeInitBaseImage = x.VolumeInitBaseImage
+		cfg.VolumeInitShell = x.VolumeInitShell
+	}
+	return nil
+}
+
+type serviceXKubeCompose struct {
+	NoService bool         `mapdecode:"no_service"`
+	Probes    *probes      `mapdecode:"probes"`
+	Service   *serviceSpec `mapdecode:"service"`
+	Stateful  bool         `mapdecode:"stateful"`
+}
+
+func loadServiceXKubeCompose(service *Service, xProperties dockerComposeConfig.XProperties) error {
+	var x serviceXKubeCompose
+	_, err := xProperties.Decode(xKubeComposeKey, &x)
+	if err != nil {
+		return errors.Wrapf(err, "error while parsing \"x-kube-compose\" of docker compose service %s", service.Name())
+	}
+	service.NoService = x.NoService
+	service.Probes, err = parseProbes(x.Probes, service.DockerComposeService)
+	if err != nil {
+		return errors.Wrapf(err, "error while parsing \"x-kube-compose\".\"probes\" of docker compose service %s", service.Name())
 	}
+	err = loadServiceSpec(service, x.Service)
+	if err != nil {
+		return errors.Wrapf(err, "error while parsing \"x-kube-compose\".\"service\" of docker compose service %s", service.Name())
+	}
+	service.Stateful = x.Stateful
 	return nil
 }
 
@@ -170,6 +332,7 @@ func (cfg *Config) AddService(dockerComposeService *dockerComposeConfig.Service)
 			cfg.Services = map[string]*Service{}
 		}
 		cfg.Services[dockerComposeService.Name] = service
+		cfg.ServiceOrder = append(cfg.ServiceOrder, dockerComposeService.Name)
 	}
 	return service
 }
@@ -184,6 +347,28 @@ func (cfg *Config) MatchesFilterDirectly(service *Service) bool {
 	return service.matchesFilterDirectly
 }
 
+// IsSkipped determines whether a service was excluded with Skip.
+func (cfg *Config) IsSkipped(service *Service) bool {
+	return service.skipped
+}
+
+// Skip excludes a service from the current filter, even if other services that match the filter depend on it. Any depends_on conditions
+// on the service are dropped, so that its dependents do not wait for a service that will never start.
+func (cfg *Config) Skip(service *Service) {
+	service.skipped = true
+	service.matchesFilter = false
+	service.matchesFilterDirectly = false
+	for _, service2 := range cfg.Services {
+		if _, ok := service2.DockerComposeService.DependsOn[service.Name()]; !ok {
+			continue
+		}
+		if service2.matchesFilter {
+			log.Warnf("service %s depends on skipped service %s, ignoring this dependency", service2.Name(), service.Name())
+		}
+		delete(service2.DockerComposeService.DependsOn, service.Name())
+	}
+}
+
 // ClearFilter sets the current filter to match no service.
 func (cfg *Config) ClearFilter() {
 	for _, service := range cfg.Services {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
@@ -99,6 +100,7 @@ var dockerComposeYmlInvalid = "/docker-compose.invalid.yml"
 var dockerComposeYmlInvalidServiceName = "/docker-compose.invalid-service-name.yml"
 var dockerComposeYmlInvalidXKubeCompose = "/docker-compose.invalid-x-kube-compose.yml"
 var dockerComposeYmlValidPushImages = "/docker-compose.valid-push-images.yml"
+var dockerComposeYmlPortRanges = "/docker-compose.port-ranges.yml"
 var vfs fs.VirtualFileSystem = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
 	dockerComposeYmlInvalid: {
 		Content: []byte(`version: 'asdf'`),
@@ -118,6 +120,19 @@ services:
     ports: [8080]
 x-kube-compose:
   push_images: ""
+`),
+	},
+	dockerComposeYmlPortRanges: {
+		Content: []byte(`version: '2'
+services:
+  dns:
+    image: ubuntu:latest
+    ports:
+    - "53:53/udp"
+    - "3000-3002:4000-4002"
+    - target: 80
+      published: 8080
+      mode: host
 `),
 	},
 	dockerComposeYmlValidPushImages: {
@@ -367,3 +382,206 @@ x-kube-compose:
 		}
 	})
 }
+
+func Test_New_ServiceStateful(t *testing.T) {
+	file := "/servicestateful"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  db:
+    image: postgres
+    x-kube-compose:
+      stateful: true
+  web:
+    image: nginx
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.Services["db"].Stateful || c.Services["web"].Stateful {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ServiceNoService(t *testing.T) {
+	file := "/servicenoservice"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  worker:
+    image: worker
+    ports:
+    - 8080
+    x-kube-compose:
+      no_service: true
+  web:
+    image: nginx
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.Services["worker"].NoService || c.Services["web"].NoService {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_UnrelatedXProperties(t *testing.T) {
+	file := "/unrelatedxproperties"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.4'
+x-foo:
+  bar: [1, 2]
+x-kube-compose:
+  volume_init_shell: /bin/bash
+  unknown_option: true
+services:
+  db:
+    image: postgres
+    x-foo: baz
+    x-kube-compose:
+      stateful: true
+      unknown_option: true
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.Services["db"].Stateful || c.VolumeInitShell == nil || *c.VolumeInitShell != "/bin/bash" {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ServiceStatefulInvalid(t *testing.T) {
+	file := "/servicestatefulinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  db:
+    image: postgres
+    x-kube-compose:
+      stateful: [true]
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestSkip_DropsDependsOn(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.AddToFilter(cfg.Services["a"])
+	cfg.Skip(cfg.Services["c"])
+	if cfg.MatchesFilter(cfg.Services["c"]) || !cfg.IsSkipped(cfg.Services["c"]) {
+		t.Fail()
+	}
+	if _, ok := cfg.Services["b"].DockerComposeService.DependsOn["c"]; ok {
+		t.Fail()
+	}
+	if _, ok := cfg.Services["b"].DockerComposeService.DependsOn["d"]; !ok {
+		t.Fail()
+	}
+	if !cfg.MatchesFilter(cfg.Services["a"]) || !cfg.MatchesFilter(cfg.Services["d"]) {
+		t.Fail()
+	}
+}
+
+func TestNormalizeProjectName(t *testing.T) {
+	testCases := map[string]string{
+		"MyProject":             "myproject",
+		"my project.v2":         "myprojectv2",
+		"_my-project_":          "my-project",
+		"...":                   "",
+		strings.Repeat("a", 70): strings.Repeat("a", 63),
+	}
+	for name, expected := range testCases {
+		if actual := NormalizeProjectName(name); actual != expected {
+			t.Errorf("%#v normalized to %#v, expected %#v", name, actual, expected)
+		}
+	}
+}
+
+func TestIsEnabledByProfiles(t *testing.T) {
+	service := &Service{
+		DockerComposeService: &dockerComposeConfig.Service{},
+	}
+	if !service.IsEnabledByProfiles(nil) {
+		t.Error("a service without profiles is always enabled")
+	}
+	service.DockerComposeService.Profiles = []string{"debug", "tools"}
+	if service.IsEnabledByProfiles(nil) || service.IsEnabledByProfiles([]string{"seed"}) {
+		t.Fail()
+	}
+	if !service.IsEnabledByProfiles([]string{"seed", "tools"}) || !service.IsEnabledByProfiles([]string{"*"}) {
+		t.Fail()
+	}
+}
+
+func newTestConfigUnsupportedKeys() *Config {
+	cfg := newTestConfig()
+	cfg.Services["b"].DockerComposeService.UnsupportedKeys = []dockerComposeConfig.UnsupportedKey{
+		{Key: "blkio_config", Reason: "no equivalent"},
+		{Key: "build", Reason: "images are not built"},
+	}
+	cfg.Services["a"].DockerComposeService.UnsupportedKeys = []dockerComposeConfig.UnsupportedKey{
+		{Key: "tmpfs", Reason: "not supported yet"},
+	}
+	return cfg
+}
+
+func TestFormatUnsupportedKeys_GroupedByService(t *testing.T) {
+	s := formatUnsupportedKeys(newTestConfigUnsupportedKeys())
+	expected := "\n  service a:\n    tmpfs: not supported yet\n  service b:\n    blkio_config: no equivalent\n    build: images are not built"
+	if s != expected {
+		t.Error(s)
+	}
+}
+
+func TestCheckUnsupportedKeys_Strict(t *testing.T) {
+	cfg := newTestConfigUnsupportedKeys()
+	if err := cfg.CheckUnsupportedKeys(false); err != nil {
+		t.Error(err)
+	}
+	if err := cfg.CheckUnsupportedKeys(true); err == nil {
+		t.Fail()
+	}
+	if err := newTestConfig().CheckUnsupportedKeys(true); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_New_PortRanges(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{dockerComposeYmlPortRanges})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []Port{
+			{Port: 53, Protocol: "udp"},
+			{Port: 4000, Protocol: "tcp"},
+			{Port: 4001, Protocol: "tcp"},
+			{Port: 4002, Protocol: "tcp"},
+			{HostPort: 8080, Port: 80, Protocol: "tcp"},
+		}
+		if !reflect.DeepEqual(c.Services["dns"].Ports, expected) {
+			t.Error(c.Services["dns"].Ports)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"math"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Probes are the probes of a service that are set by "x-kube-compose"."probes". A nil probe is derived from the healthcheck of the docker
+// compose service or image instead (if applicable).
+type Probes struct {
+	Liveness  *v1.Probe
+	Readiness *v1.Probe
+	Startup   *v1.Probe
+}
+
+type probeHTTPHeader struct {
+	Name  string `mapdecode:"name"`
+	Value string `mapdecode:"value"`
+}
+
+type probe struct {
+	Exec *struct {
+		Command []string `mapdecode:"command"`
+	} `mapdecode:"exec"`
+	GRPC *struct {
+		Port    *int    `mapdecode:"port"`
+		Service *string `mapdecode:"service"`
+	} `mapdecode:"grpc"`
+	HTTPGet *struct {
+		Host        string            `mapdecode:"host"`
+		HTTPHeaders []probeHTTPHeader `mapdecode:"http_headers"`
+		Path        string            `mapdecode:"path"`
+		Port        *int              `mapdecode:"port"`
+		Scheme      string            `mapdecode:"scheme"`
+	} `mapdecode:"http_get"`
+	TCPSocket *struct {
+		Host string `mapdecode:"host"`
+		Port *int   `mapdecode:"port"`
+	} `mapdecode:"tcp_socket"`
+	FailureThreshold              *int   `mapdecode:"failure_threshold"`
+	InitialDelaySeconds           *int   `mapdecode:"initial_delay_seconds"`
+	PeriodSeconds                 *int   `mapdecode:"period_seconds"`
+	SuccessThreshold              *int   `mapdecode:"success_threshold"`
+	TerminationGracePeriodSeconds *int64 `mapdecode:"termination_grace_period_seconds"`
+	TimeoutSeconds                *int   `mapdecode:"timeout_seconds"`
+}
+
+type probes struct {
+	Liveness  *probe `mapdecode:"liveness"`
+	Readiness *probe `mapdecode:"readiness"`
+	Startup   *probe `mapdecode:"startup"`
+}
+
+// getDeclaredProbePorts returns the TCP ports of the ports and expose of a docker compose service, on which probes can connect to the
+// service's container. Returns nil if the service does not declare any ports, because the image may expose ports that are not known here.
+func getDeclaredProbePorts(dcService *dockerComposeConfig.Service) map[int32]bool {
+	var declaredPorts map[int32]bool
+	for _, portBinding := range append(append([]dockerComposeConfig.PortBinding{}, dcService.Ports...), dcService.Expose...) {
+		if declaredPorts == nil {
+			declaredPorts = map[int32]bool{}
+		}
+		if portBinding.Protocol == "tcp" {
+			declaredPorts[portBinding.Internal] = true
+		}
+	}
+	return declaredPorts
+}
+
+// parseProbePort validates the port of a probe. If declaredPorts is not nil, the port must be one of declaredPorts (see
+// getDeclaredProbePorts).
+func parseProbePort(port *int, declaredPorts map[int32]bool) (int32, error) {
+	if port == nil {
+		return 0, fmt.Errorf("\"port\" is required")
+	}
+	if *port < 1 || *port > math.MaxUint16 {
+		return 0, fmt.Errorf("\"port\" must be between 1 and %d, but got %d", math.MaxUint16, *port)
+	}
+	if declaredPorts != nil && !declaredPorts[int32(*port)] {
+		return 0, fmt.Errorf("\"port\" %d is not a TCP port of the ports or expose of the docker compose service", *port)
+	}
+	return int32(*port), nil
+}
+
+// parseProbeHandler validates that exactly one action is set and that the action has its required fields. The ports of actions that probe
+// the pod's IP are validated against declaredPorts (see parseProbePort); actions with a host probe another host, so their ports are not.
+func parseProbeHandler(p *probe, declaredPorts map[int32]bool) (v1.ProbeHandler, error) {
+	var handler v1.ProbeHandler
+	n := 0
+	if p.Exec != nil {
+		n++
+		if len(p.Exec.Command) == 0 {
+			return handler, fmt.Errorf("\"exec\".\"command\" is required")
+		}
+		handler.Exec = &v1.ExecAction{
+			Command: p.Exec.Command,
+		}
+	}
+	if p.GRPC != nil {
+		n++
+		port, err := parseProbePort(p.GRPC.Port, declaredPorts)
+		if err != nil {
+			return handler, errors.Wrap(err, "\"grpc\"")
+		}
+		handler.GRPC = &v1.GRPCAction{
+			Port:    port,
+			Service: p.GRPC.Service,
+		}
+	}
+	if p.HTTPGet != nil {
+		n++
+		if p.HTTPGet.Host != "" {
+			declaredPorts = nil
+		}
+		port, err := parseProbePort(p.HTTPGet.Port, declaredPorts)
+		if err != nil {
+			return handler, errors.Wrap(err, "\"http_get\"")
+		}
+		scheme := v1.URIScheme(p.HTTPGet.Scheme)
+		if scheme != "" && scheme != v1.URISchemeHTTP && scheme != v1.URISchemeHTTPS {
+			return handler, fmt.Errorf("\"http_get\".\"scheme\" must be one of %#v and %#v", v1.URISchemeHTTP, v1.URISchemeHTTPS)
+		}
+		handler.HTTPGet = &v1.HTTPGetAction{
+			Host:   p.HTTPGet.Host,
+			Path:   p.HTTPGet.Path,
+			Port:   intstr.FromInt(int(port)),
+			Scheme: scheme,
+		}
+		for _, header := range p.HTTPGet.HTTPHeaders {
+			if header.Name == "" {
+				return handler, fmt.Errorf("\"http_get\".\"http_headers\" has a header without a name")
+			}
+			handler.HTTPGet.HTTPHeaders = append(handler.HTTPGet.HTTPHeaders, v1.HTTPHeader{
+				Name:  header.Name,
+				Value: header.Value,
+			})
+		}
+	}
+	if p.TCPSocket != nil {
+		n++
+		if p.TCPSocket.Host != "" {
+			declaredPorts = nil
+		}
+		port, err := parseProbePort(p.TCPSocket.Port, declaredPorts)
+		if err != nil {
+			return handler, errors.Wrap(err, "\"tcp_socket\"")
+		}
+		handler.TCPSocket = &v1.TCPSocketAction{
+			Host: p.TCPSocket.Host,
+			Port: intstr.FromInt(int(port)),
+		}
+	}
+	if n != 1 {
+		return handler, fmt.Errorf("exactly one of \"exec\", \"grpc\", \"http_get\" and \"tcp_socket\" must be set")
+	}
+	return handler, nil
+}
+
+// parseProbeInt32 validates that an optional timing field is at least min.
+func parseProbeInt32(name string, v *int, min int) (int32, error) {
+	if v == nil {
+		return 0, nil
+	}
+	if *v < min || *v > math.MaxInt32 {
+		return 0, fmt.Errorf("\"%s\" must be between %d and %d, but got %d", name, min, math.MaxInt32, *v)
+	}
+	return int32(*v), nil
+}
+
+func parseProbe(p *probe, declaredPorts map[int32]bool) (*v1.Probe, error) {
+	if p == nil {
+		return nil, nil
+	}
+	handler, err := parseProbeHandler(p, declaredPorts)
+	if err != nil {
+		return nil, err
+	}
+	probeParsed := &v1.Probe{
+		ProbeHandler:                  handler,
+		TerminationGracePeriodSeconds: p.TerminationGracePeriodSeconds,
+	}
+	fields := []struct {
+		name  string
+		value *int
+		min   int
+		into  *int32
+	}{
+		{"failure_threshold", p.FailureThreshold, 1, &probeParsed.FailureThreshold},
+		{"initial_delay_seconds", p.InitialDelaySeconds, 0, &probeParsed.InitialDelaySeconds},
+		{"period_seconds", p.PeriodSeconds, 1, &probeParsed.PeriodSeconds},
+		{"success_threshold", p.SuccessThreshold, 1, &probeParsed.SuccessThreshold},
+		{"timeout_seconds", p.TimeoutSeconds, 1, &probeParsed.TimeoutSeconds},
+	}
+	for _, field := range fields {
+		*field.into, err = parseProbeInt32(field.name, field.value, field.min)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.TerminationGracePeriodSeconds != nil && *p.TerminationGracePeriodSeconds < 1 {
+		return nil, fmt.Errorf("\"termination_grace_period_seconds\" must be positive, but got %d", *p.TerminationGracePeriodSeconds)
+	}
+	return probeParsed, nil
+}
+
+// parseProbes maps "x-kube-compose"."probes" of a docker compose service to Kubernetes probes.
+func parseProbes(p *probes, dcService *dockerComposeConfig.Service) (*Probes, error) {
+	if p == nil {
+		return nil, nil
+	}
+	probesParsed := &Probes{}
+	declaredPorts := getDeclaredProbePorts(dcService)
+	var err error
+	probesParsed.Liveness, err = parseProbe(p.Liveness, declaredPorts)
+	if err != nil {
+		return nil, errors.Wrap(err, "\"liveness\"")
+	}
+	probesParsed.Readiness, err = parseProbe(p.Readiness, declaredPorts)
+	if err != nil {
+		return nil, errors.Wrap(err, "\"readiness\"")
+	}
+	probesParsed.Startup, err = parseProbe(p.Startup, declaredPorts)
+	if err != nil {
+		return nil, errors.Wrap(err, "\"startup\"")
+	}
+	// Kubernetes rejects liveness and startup probes with a success threshold other than 1.
+	if probesParsed.Liveness != nil && probesParsed.Liveness.SuccessThreshold > 1 {
+		return nil, fmt.Errorf("\"liveness\".\"success_threshold\" must be 1")
+	}
+	if probesParsed.Startup != nil && probesParsed.Startup.SuccessThreshold > 1 {
+		return nil, fmt.Errorf("\"startup\".\"success_threshold\" must be 1")
+	}
+	return probesParsed, nil
+}
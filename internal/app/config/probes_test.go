@@ -0,0 +1,213 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_New_ServiceProbes(t *testing.T) {
+	file := "/serviceprobes"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  web:
+    image: web
+    ports:
+    - "8080"
+    expose:
+    - "9090"
+    x-kube-compose:
+      probes:
+        readiness:
+          http_get:
+            path: /ready
+            port: 8080
+            http_headers:
+            - name: X-Probe
+              value: readiness
+          period_seconds: 5
+        liveness:
+          tcp_socket:
+            port: 8080
+          initial_delay_seconds: 10
+          failure_threshold: 6
+        startup:
+          grpc:
+            port: 9090
+            service: health
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		probes := c.Services["web"].Probes
+		if probes == nil {
+			t.Fatal("expected probes")
+		}
+		readiness := probes.Readiness
+		if readiness.HTTPGet == nil || readiness.HTTPGet.Path != "/ready" || readiness.HTTPGet.Port != intstr.FromInt(8080) ||
+			len(readiness.HTTPGet.HTTPHeaders) != 1 || readiness.PeriodSeconds != 5 {
+			t.Error(readiness)
+		}
+		liveness := probes.Liveness
+		if liveness.TCPSocket == nil || liveness.InitialDelaySeconds != 10 || liveness.FailureThreshold != 6 {
+			t.Error(liveness)
+		}
+		startup := probes.Startup
+		if startup.GRPC == nil || startup.GRPC.Port != 9090 || *startup.GRPC.Service != "health" {
+			t.Error(startup)
+		}
+	})
+}
+
+func Test_ParseProbe_Exec(t *testing.T) {
+	p := &probe{}
+	p.Exec = &struct {
+		Command []string `mapdecode:"command"`
+	}{
+		Command: []string{"true"},
+	}
+	probeParsed, err := parseProbe(p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probeParsed.Exec == nil || probeParsed.Exec.Command[0] != "true" {
+		t.Error(probeParsed)
+	}
+}
+
+func Test_ParseProbe_NoActionError(t *testing.T) {
+	_, err := parseProbe(&probe{}, nil)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_ParseProbeHandler_MultipleActionsError(t *testing.T) {
+	port := 80
+	p := &probe{}
+	p.Exec = &struct {
+		Command []string `mapdecode:"command"`
+	}{
+		Command: []string{"true"},
+	}
+	p.TCPSocket = &struct {
+		Host string `mapdecode:"host"`
+		Port *int   `mapdecode:"port"`
+	}{
+		Port: &port,
+	}
+	_, err := parseProbeHandler(p, nil)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_ParseProbePort_Errors(t *testing.T) {
+	if _, err := parseProbePort(nil, nil); err == nil {
+		t.Error("missing port")
+	}
+	port := 70000
+	if _, err := parseProbePort(&port, nil); err == nil {
+		t.Error(port)
+	}
+	port = 8080
+	if _, err := parseProbePort(&port, map[int32]bool{9090: true}); err == nil {
+		t.Error(port)
+	}
+}
+
+func Test_ParseProbeHandler_HostSkipsDeclaredPorts(t *testing.T) {
+	port := 5432
+	p := &probe{}
+	p.TCPSocket = &struct {
+		Host string `mapdecode:"host"`
+		Port *int   `mapdecode:"port"`
+	}{
+		Host: "db.example.com",
+		Port: &port,
+	}
+	handler, err := parseProbeHandler(p, map[int32]bool{8080: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handler.TCPSocket == nil || handler.TCPSocket.Port != intstr.FromInt(5432) {
+		t.Error(handler)
+	}
+}
+
+func Test_GetDeclaredProbePorts(t *testing.T) {
+	dcService := &dockerComposeConfig.Service{
+		Expose: []dockerComposeConfig.PortBinding{
+			{Internal: 53, Protocol: "udp"},
+		},
+		Ports: []dockerComposeConfig.PortBinding{
+			{Internal: 8080, Protocol: "tcp"},
+		},
+	}
+	declaredPorts := getDeclaredProbePorts(dcService)
+	if len(declaredPorts) != 1 || !declaredPorts[8080] {
+		t.Error(declaredPorts)
+	}
+	if declaredPorts = getDeclaredProbePorts(&dockerComposeConfig.Service{}); declaredPorts != nil {
+		t.Error(declaredPorts)
+	}
+}
+
+func Test_New_ServiceProbesUndeclaredPortError(t *testing.T) {
+	file := "/serviceprobesundeclaredport"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  web:
+    image: web
+    ports:
+    - "8080"
+    x-kube-compose:
+      probes:
+        readiness:
+          tcp_socket:
+            port: 8081
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil || !strings.Contains(err.Error(), "8081 is not a TCP port") {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_New_ServiceProbesInvalid(t *testing.T) {
+	file := "/serviceprobesinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  web:
+    image: web
+    x-kube-compose:
+      probes:
+        liveness:
+          exec:
+            command: ['true']
+          success_threshold: 2
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		} else {
+			t.Log(err)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+type serviceSpec struct {
+	NodePort *int    `mapdecode:"node_port"`
+	Type     *string `mapdecode:"type"`
+}
+
+// loadServiceSpec sets the type and node port of the Kubernetes Service of a docker compose service from "x-kube-compose"."service".
+// The type is left empty if not set, which means ClusterIP. A node port can only be set if the type allocates node ports (NodePort and
+// LoadBalancer) and the Service has exactly one port, because there would be no way to tell which port it applies to otherwise.
+func loadServiceSpec(service *Service, spec *serviceSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.Type != nil {
+		switch serviceType := v1.ServiceType(*spec.Type); serviceType {
+		case v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+			service.ServiceType = serviceType
+		default:
+			return fmt.Errorf("\"type\" must be one of %s, %s and %s, but got %#v", v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort,
+				v1.ServiceTypeLoadBalancer, *spec.Type)
+		}
+	}
+	if spec.NodePort != nil {
+		if service.ServiceType != v1.ServiceTypeNodePort && service.ServiceType != v1.ServiceTypeLoadBalancer {
+			return fmt.Errorf("\"node_port\" requires a \"type\" of %s or %s", v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer)
+		}
+		if *spec.NodePort <= 0 || *spec.NodePort > math.MaxUint16 {
+			return fmt.Errorf("\"node_port\" must be between 1 and %d, but got %d", math.MaxUint16, *spec.NodePort)
+		}
+		if len(service.Ports) != 1 {
+			return fmt.Errorf("\"node_port\" requires a service with exactly one port, but it has %d port(s)", len(service.Ports))
+		}
+		service.NodePort = int32(*spec.NodePort)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	v1 "k8s.io/api/core/v1"
+)
+
+func Test_New_ServiceType(t *testing.T) {
+	file := "/servicetype"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  web:
+    image: nginx
+    ports:
+    - 80
+    x-kube-compose:
+      service:
+        type: NodePort
+        node_port: 30080
+  lb:
+    image: nginx
+    ports:
+    - 80
+    x-kube-compose:
+      service:
+        type: LoadBalancer
+  db:
+    image: postgres
+    ports:
+    - 5432
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if web := c.Services["web"]; web.ServiceType != v1.ServiceTypeNodePort || web.NodePort != 30080 {
+			t.Error(web.ServiceType, web.NodePort)
+		}
+		if lb := c.Services["lb"]; lb.ServiceType != v1.ServiceTypeLoadBalancer || lb.NodePort != 0 {
+			t.Error(lb.ServiceType, lb.NodePort)
+		}
+		if db := c.Services["db"]; db.ServiceType != "" || db.NodePort != 0 {
+			t.Error(db.ServiceType, db.NodePort)
+		}
+	})
+}
+
+func TestLoadServiceSpec_Errors(t *testing.T) {
+	invalidType := "ExternalName"
+	clusterIP := string(v1.ServiceTypeClusterIP)
+	nodePort := string(v1.ServiceTypeNodePort)
+	validPort := 30080
+	invalidPort := 65536
+	onePort := []Port{{Port: 80, Protocol: "tcp"}}
+	testCases := []struct {
+		spec  *serviceSpec
+		ports []Port
+	}{
+		{spec: &serviceSpec{Type: &invalidType}, ports: onePort},
+		{spec: &serviceSpec{NodePort: &validPort}, ports: onePort},
+		{spec: &serviceSpec{Type: &clusterIP, NodePort: &validPort}, ports: onePort},
+		{spec: &serviceSpec{Type: &nodePort, NodePort: &invalidPort}, ports: onePort},
+		{spec: &serviceSpec{Type: &nodePort, NodePort: &validPort}, ports: append(onePort, Port{Port: 443, Protocol: "tcp"})},
+	}
+	for i, testCase := range testCases {
+		service := &Service{
+			Ports: testCase.ports,
+		}
+		if err := loadServiceSpec(service, testCase.spec); err == nil {
+			t.Errorf("test case %d: expected an error", i)
+		}
+	}
+}
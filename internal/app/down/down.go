@@ -2,23 +2,56 @@ package down
 
 import (
 	"context"
+	"fmt"
+	"time"
+
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
 	log "github.com/sirupsen/logrus"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	clientAppsV1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	clientBatchV1 "k8s.io/client-go/kubernetes/typed/batch/v1"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	clientNetworkingV1 "k8s.io/client-go/kubernetes/typed/networking/v1"
 )
 
 type deleter func(ctx context.Context, name string, options metav1.DeleteOptions) error
 
 type lister func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error)
 
+type watcher func(ctx context.Context, listOptions metav1.ListOptions) (watch.Interface, error)
+
+// Options are the options of down.
+type Options struct {
+	// True to delete the namespace of the environment if it was created by up for the environment (see up.Options.CreateNamespace) and
+	// all docker compose services are deleted.
+	DeleteNamespace bool
+	// True to wait until the deleted resources are gone (e.g. pods have terminated), instead of returning as soon as their deletion has
+	// been requested.
+	Wait bool
+	// Bounds the time spent waiting for resources to be gone. Zero means no timeout.
+	WaitTimeout time.Duration
+}
+
 type downRunner struct {
-	cfg              *config.Config
-	k8sClientset     *kubernetes.Clientset
-	k8sServiceClient clientV1.ServiceInterface
-	k8sPodClient     clientV1.PodInterface
+	cfg                    *config.Config
+	opts                   *Options
+	pendingDeletions       []*pendingDeletion
+	k8sClientset           *kubernetes.Clientset
+	k8sConfigMapClient     clientV1.ConfigMapInterface
+	k8sNamespaceClient     clientV1.NamespaceInterface
+	k8sServiceClient       clientV1.ServiceInterface
+	k8sPodClient           clientV1.PodInterface
+	k8sSecretClient        clientV1.SecretInterface
+	k8sStatefulSetClient   clientAppsV1.StatefulSetInterface
+	k8sDeploymentClient    clientAppsV1.DeploymentInterface
+	k8sJobClient           clientBatchV1.JobInterface
+	k8sNetworkPolicyClient clientNetworkingV1.NetworkPolicyInterface
 }
 
 func (d *downRunner) initKubernetesClientset() error {
@@ -27,14 +60,21 @@ func (d *downRunner) initKubernetesClientset() error {
 		return err
 	}
 	d.k8sClientset = k8sClientset
+	d.k8sConfigMapClient = d.k8sClientset.CoreV1().ConfigMaps(d.cfg.Namespace)
+	d.k8sNamespaceClient = d.k8sClientset.CoreV1().Namespaces()
 	d.k8sServiceClient = d.k8sClientset.CoreV1().Services(d.cfg.Namespace)
 	d.k8sPodClient = d.k8sClientset.CoreV1().Pods(d.cfg.Namespace)
+	d.k8sSecretClient = d.k8sClientset.CoreV1().Secrets(d.cfg.Namespace)
+	d.k8sStatefulSetClient = d.k8sClientset.AppsV1().StatefulSets(d.cfg.Namespace)
+	d.k8sDeploymentClient = d.k8sClientset.AppsV1().Deployments(d.cfg.Namespace)
+	d.k8sJobClient = d.k8sClientset.BatchV1().Jobs(d.cfg.Namespace)
+	d.k8sNetworkPolicyClient = d.k8sClientset.NetworkingV1().NetworkPolicies(d.cfg.Namespace)
 	return nil
 }
 
-func (d *downRunner) deleteCommon(ctx context.Context, kind string, lister lister, deleter deleter) (bool, error) {
+func (d *downRunner) deleteCommon(ctx context.Context, kind string, lister lister, watcher watcher, deleter deleter) (bool, error) {
 	listOptions := metav1.ListOptions{
-		LabelSelector: d.cfg.EnvironmentLabel + "=" + d.cfg.EnvironmentID,
+		LabelSelector: k8smeta.LabelSelector(d.cfg),
 	}
 	list, err := lister(listOptions)
 	if err != nil {
@@ -42,6 +82,12 @@ func (d *downRunner) deleteCommon(ctx context.Context, kind string, lister liste
 	}
 	deleteOptions := &metav1.DeleteOptions{}
 	deletedAll := true
+	pending := &pendingDeletion{
+		kind:    kind,
+		lister:  lister,
+		watcher: watcher,
+		names:   map[string]bool{},
+	}
 	for _, item := range list {
 		composeService := k8smeta.FindFromObjectMeta(d.cfg, item)
 		if composeService == nil || d.cfg.MatchesFilter(composeService) {
@@ -50,47 +96,165 @@ func (d *downRunner) deleteCommon(ctx context.Context, kind string, lister liste
 				return false, err
 			}
 			log.Infof("deleted %s %s\n", kind, item.Name)
+			pending.names[item.Name] = true
 		} else {
 			deletedAll = false
 		}
 	}
+	if len(pending.names) > 0 {
+		d.pendingDeletions = append(d.pendingDeletions, pending)
+	}
 	return deletedAll, nil
 }
 
-// Linter reports code duplication amongst deleteServices and deletePods. Although this is true, deduplicating would require the use of
-// generics, so we choose to nolint.
-// nolint
-func (d *downRunner) deleteServices() (bool, error) {
-	lister := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
-		serviceList, err := d.k8sServiceClient.List(context.Background(), listOptions)
+// newLister adapts the List method of a typed client to a lister.
+func newLister[T runtime.Object](list func(ctx context.Context, listOptions metav1.ListOptions) (T, error)) lister {
+	return func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+		obj, err := list(context.Background(), listOptions)
 		if err != nil {
 			return nil, err
 		}
-		list := make([]*metav1.ObjectMeta, len(serviceList.Items))
-		for i := 0; i < len(serviceList.Items); i++ {
-			list[i] = &serviceList.Items[i].ObjectMeta
+		items, err := meta.ExtractList(obj)
+		if err != nil {
+			return nil, err
 		}
-		return list, nil
+		result := make([]*metav1.ObjectMeta, len(items))
+		for i, item := range items {
+			accessor, ok := item.(metav1.ObjectMetaAccessor)
+			if !ok {
+				return nil, fmt.Errorf("%T does not have object metadata", item)
+			}
+			if result[i], ok = accessor.GetObjectMeta().(*metav1.ObjectMeta); !ok {
+				return nil, fmt.Errorf("%T does not have object metadata", item)
+			}
+		}
+		return result, nil
+	}
+}
+
+// propagatingDeleter propagates the deletion of a resource to its dependents explicitly, in the background.
+func propagatingDeleter(del deleter) deleter {
+	return func(ctx context.Context, name string, options metav1.DeleteOptions) error {
+		propagationPolicy := metav1.DeletePropagationBackground
+		options.PropagationPolicy = &propagationPolicy
+		return del(ctx, name, options)
 	}
-	return d.deleteCommon(context.Background(), "Service", lister, d.k8sServiceClient.Delete)
 }
 
-// Linter reports code duplication amongst deleteServices and deletePods. Although this is true, deduplicating would require the use of
-// generics, so we choose to nolint.
-// nolint
+func (d *downRunner) deleteServices() (bool, error) {
+	return d.deleteCommon(context.Background(), "Service", newLister(d.k8sServiceClient.List), d.k8sServiceClient.Watch,
+		d.k8sServiceClient.Delete)
+}
+
 func (d *downRunner) deletePods() (bool, error) {
-	lister := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
-		podList, err := d.k8sPodClient.List(context.Background(), listOptions)
-		if err != nil {
-			return nil, err
-		}
-		list := make([]*metav1.ObjectMeta, len(podList.Items))
-		for i := 0; i < len(podList.Items); i++ {
-			list[i] = &podList.Items[i].ObjectMeta
+	return d.deleteCommon(context.Background(), "Pod", newLister(d.k8sPodClient.List), d.k8sPodClient.Watch, d.k8sPodClient.Delete)
+}
+
+func (d *downRunner) deleteStatefulSets() (bool, error) {
+	return d.deleteCommon(context.Background(), "StatefulSet", newLister(d.k8sStatefulSetClient.List), d.k8sStatefulSetClient.Watch,
+		d.k8sStatefulSetClient.Delete)
+}
+
+// deleteDeployments propagates the deletion explicitly, so that the ReplicaSets and pods of the Deployments are deleted as well.
+func (d *downRunner) deleteDeployments() (bool, error) {
+	return d.deleteCommon(context.Background(), "Deployment", newLister(d.k8sDeploymentClient.List), d.k8sDeploymentClient.Watch,
+		propagatingDeleter(d.k8sDeploymentClient.Delete))
+}
+
+// deleteJobs propagates the deletion explicitly, because Jobs orphan their pods by default.
+func (d *downRunner) deleteJobs() (bool, error) {
+	return d.deleteCommon(context.Background(), "Job", newLister(d.k8sJobClient.List), d.k8sJobClient.Watch,
+		propagatingDeleter(d.k8sJobClient.Delete))
+}
+
+func (d *downRunner) deleteNetworkPolicies() (bool, error) {
+	return d.deleteCommon(context.Background(), "NetworkPolicy", newLister(d.k8sNetworkPolicyClient.List), d.k8sNetworkPolicyClient.Watch,
+		d.k8sNetworkPolicyClient.Delete)
+}
+
+func (d *downRunner) deleteSecrets() (bool, error) {
+	return d.deleteCommon(context.Background(), "Secret", newLister(d.k8sSecretClient.List), d.k8sSecretClient.Watch,
+		d.k8sSecretClient.Delete)
+}
+
+// deleteConfigMaps propagates the deletion explicitly, because the ConfigMap of up --use-owner-refs owns all resources of the environment,
+// so that any resource that was not deleted by label (e.g. resources of services that have been removed from the docker compose file) is
+// deleted as well.
+func (d *downRunner) deleteConfigMaps() (bool, error) {
+	return d.deleteCommon(context.Background(), "ConfigMap", newLister(d.k8sConfigMapClient.List), d.k8sConfigMapClient.Watch,
+		propagatingDeleter(d.k8sConfigMapClient.Delete))
+}
+
+// findForeignResource returns the kind and name of a kube-compose resource in the namespace that is not deleted by down, because it belongs
+// to another environment or (if filtering by project) to another project of the environment. Returns empty strings if there is no such
+// resource.
+func (d *downRunner) findForeignResource() (kind, name string, err error) {
+	// A selector "l!=v" also matches resources without the label l, so the first selector requires the environment label to be set.
+	selectors := []string{d.cfg.EnvironmentLabel + "," + d.cfg.EnvironmentLabel + "!=" + d.cfg.EnvironmentID}
+	if d.cfg.FilterByProject {
+		selectors = append(selectors, d.cfg.EnvironmentLabel+"="+d.cfg.EnvironmentID+","+k8smeta.ProjectLabel+"!="+d.cfg.ProjectName)
+	}
+	listers := []struct {
+		kind string
+		list lister
+	}{
+		{"Pod", newLister(d.k8sPodClient.List)},
+		{"StatefulSet", newLister(d.k8sStatefulSetClient.List)},
+		{"Deployment", newLister(d.k8sDeploymentClient.List)},
+		{"Job", newLister(d.k8sJobClient.List)},
+		{"Service", newLister(d.k8sServiceClient.List)},
+		{"Secret", newLister(d.k8sSecretClient.List)},
+		{"ConfigMap", newLister(d.k8sConfigMapClient.List)},
+		{"NetworkPolicy", newLister(d.k8sNetworkPolicyClient.List)},
+	}
+	for _, selector := range selectors {
+		for _, lister := range listers {
+			items, err := lister.list(metav1.ListOptions{
+				LabelSelector: selector,
+				Limit:         1,
+			})
+			if err != nil {
+				return "", "", err
+			}
+			if len(items) > 0 {
+				return lister.kind, items[0].Name, nil
+			}
 		}
-		return list, nil
 	}
-	return d.deleteCommon(context.Background(), "Pod", lister, d.k8sPodClient.Delete)
+	return "", "", nil
+}
+
+// deleteNamespace deletes the namespace of the environment if it is labeled with the environment, i.e. if it was created by up
+// --create-namespace for this environment. Other namespaces are left untouched, because these may contain resources that do not belong to
+// the environment. Because namespaces are commonly shared by environments, the namespace is not deleted either if it still contains
+// resources of kube-compose that down does not delete (see findForeignResource).
+func (d *downRunner) deleteNamespace() error {
+	namespace, err := d.k8sNamespaceClient.Get(context.Background(), d.cfg.Namespace, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if namespace.ObjectMeta.Labels[d.cfg.EnvironmentLabel] != d.cfg.EnvironmentID {
+		log.Warnf("not deleting namespace %s, because it was not created by kube-compose for environment %s", d.cfg.Namespace,
+			d.cfg.EnvironmentID)
+		return nil
+	}
+	kind, name, err := d.findForeignResource()
+	if err != nil {
+		return err
+	}
+	if kind != "" {
+		log.Warnf("not deleting namespace %s, because it is shared with other environments or projects (e.g. %s %s)", d.cfg.Namespace,
+			kind, name)
+		return nil
+	}
+	err = d.k8sNamespaceClient.Delete(context.Background(), d.cfg.Namespace, metav1.DeleteOptions{})
+	if err != nil && !k8sError.IsNotFound(err) {
+		return err
+	}
+	log.Infof("deleted namespace %s", d.cfg.Namespace)
+	return nil
 }
 
 func (d *downRunner) run() error {
@@ -99,6 +263,22 @@ func (d *downRunner) run() error {
 		return err
 	}
 
+	// StatefulSets are deleted before pods, otherwise the StatefulSet controller would recreate the deleted pods. The persistent volume
+	// claims of StatefulSets are retained, so that data survives a down.
+	_, err = d.deleteStatefulSets()
+	if err != nil {
+		return err
+	}
+	// Similarly, Jobs and Deployments are deleted before pods.
+	_, err = d.deleteJobs()
+	if err != nil {
+		return err
+	}
+	_, err = d.deleteDeployments()
+	if err != nil {
+		return err
+	}
+
 	deletedAllPods, err := d.deletePods()
 	if err != nil {
 		return err
@@ -111,14 +291,40 @@ func (d *downRunner) run() error {
 		if err != nil {
 			return err
 		}
+		// Network policies are deleted with the services, so that the default deny ingress policy keeps protecting remaining pods.
+		_, err = d.deleteNetworkPolicies()
+		if err != nil {
+			return err
+		}
+		// Secrets are shared by pods, so these are also only deleted if all pods are deleted.
+		_, err = d.deleteSecrets()
+		if err != nil {
+			return err
+		}
+		// ConfigMaps of docker compose configs are shared like Secrets, and the owner of the environment (see up --use-owner-refs) is
+		// deleted last.
+		_, err = d.deleteConfigMaps()
+		if err != nil {
+			return err
+		}
+		if d.opts.DeleteNamespace {
+			err = d.deleteNamespace()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if d.opts.Wait {
+		return d.waitForDeletions()
 	}
 	return nil
 }
 
 // Run runs a docker-compose down command...
-func Run(cfg *config.Config) error {
+func Run(cfg *config.Config, opts *Options) error {
 	d := &downRunner{
-		cfg: cfg,
+		cfg:  cfg,
+		opts: opts,
 	}
 	return d.run()
 }
@@ -0,0 +1,232 @@
+package down
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func TestDeleteConfigMaps_Owner(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for _, envID := range []string{"123", "456"} {
+		_ = clientset.Tracker().Add(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kube-compose-" + envID,
+				Labels: map[string]string{
+					"env": envID,
+				},
+			},
+		})
+	}
+	d := &downRunner{
+		cfg: &config.Config{
+			EnvironmentID:    "123",
+			EnvironmentLabel: "env",
+		},
+		k8sConfigMapClient: clientset.CoreV1().ConfigMaps(""),
+		opts:               &Options{},
+	}
+	deletedAll, err := d.deleteConfigMaps()
+	if err != nil || !deletedAll {
+		t.Fatal(deletedAll, err)
+	}
+	list, err := clientset.CoreV1().ConfigMaps("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "kube-compose-456" {
+		t.Error(list.Items)
+	}
+}
+
+func TestPropagatingDeleter(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+	})
+	err := propagatingDeleter(clientset.CoreV1().ConfigMaps("").Delete)(context.Background(), "a", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteAction := clientset.Actions()[0].(k8sTesting.DeleteAction)
+	propagationPolicy := deleteAction.GetDeleteOptions().PropagationPolicy
+	if propagationPolicy == nil || *propagationPolicy != metav1.DeletePropagationBackground {
+		t.Error(propagationPolicy)
+	}
+}
+
+func TestDeleteSecrets_FilterByProject(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for _, project := range []string{"web", "other"} {
+		_ = clientset.Tracker().Add(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "db9cxpassword-secret-" + project,
+				Labels: map[string]string{
+					"env":                  "123",
+					"kube-compose/project": project,
+				},
+			},
+		})
+	}
+	d := &downRunner{
+		cfg: &config.Config{
+			EnvironmentID:    "123",
+			EnvironmentLabel: "env",
+			FilterByProject:  true,
+			ProjectName:      "web",
+		},
+		k8sSecretClient: clientset.CoreV1().Secrets(""),
+		opts:            &Options{},
+	}
+	_, err := d.deleteSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := clientset.CoreV1().Secrets("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "db9cxpassword-secret-other" {
+		t.Error(list.Items)
+	}
+}
+
+func newTestNamespaceDownRunner(labels map[string]string, objects ...runtime.Object) *downRunner {
+	objects = append(objects, &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "ns",
+			Labels: labels,
+		},
+	})
+	clientset := fake.NewSimpleClientset(objects...)
+	return &downRunner{
+		cfg: &config.Config{
+			EnvironmentID:    "123",
+			EnvironmentLabel: "env",
+			Namespace:        "ns",
+		},
+		k8sConfigMapClient:     clientset.CoreV1().ConfigMaps("ns"),
+		k8sDeploymentClient:    clientset.AppsV1().Deployments("ns"),
+		k8sJobClient:           clientset.BatchV1().Jobs("ns"),
+		k8sNamespaceClient:     clientset.CoreV1().Namespaces(),
+		k8sNetworkPolicyClient: clientset.NetworkingV1().NetworkPolicies("ns"),
+		k8sPodClient:           clientset.CoreV1().Pods("ns"),
+		k8sSecretClient:        clientset.CoreV1().Secrets("ns"),
+		k8sServiceClient:       clientset.CoreV1().Services("ns"),
+		k8sStatefulSetClient:   clientset.AppsV1().StatefulSets("ns"),
+		opts: &Options{
+			DeleteNamespace: true,
+		},
+	}
+}
+
+func TestDeleteNamespace_CreatedForEnvironment(t *testing.T) {
+	d := newTestNamespaceDownRunner(map[string]string{
+		"env": "123",
+	})
+	err := d.deleteNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.k8sNamespaceClient.Get(context.Background(), "ns", metav1.GetOptions{})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestDeleteNamespace_NotCreatedForEnvironment(t *testing.T) {
+	d := newTestNamespaceDownRunner(map[string]string{
+		"env": "456",
+	})
+	err := d.deleteNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.k8sNamespaceClient.Get(context.Background(), "ns", metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteNamespace_SharedWithOtherEnvironment(t *testing.T) {
+	d := newTestNamespaceDownRunner(map[string]string{
+		"env": "123",
+	}, &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "a-456",
+			Namespace: "ns",
+			Labels: map[string]string{
+				"env": "456",
+			},
+		},
+	}, &v1.Secret{
+		// Resources that do not belong to kube-compose do not prevent the deletion.
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unrelated",
+			Namespace: "ns",
+		},
+	})
+	err := d.deleteNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.k8sNamespaceClient.Get(context.Background(), "ns", metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteNamespace_NetworkPolicyOfOtherEnvironment(t *testing.T) {
+	d := newTestNamespaceDownRunner(map[string]string{
+		"env": "123",
+	}, &networkingV1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default-deny-ingress-456",
+			Namespace: "ns",
+			Labels: map[string]string{
+				"env": "456",
+			},
+		},
+	})
+	err := d.deleteNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.k8sNamespaceClient.Get(context.Background(), "ns", metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteNamespace_SharedWithOtherProject(t *testing.T) {
+	d := newTestNamespaceDownRunner(map[string]string{
+		"env": "123",
+	}, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-config",
+			Namespace: "ns",
+			Labels: map[string]string{
+				"env":                  "123",
+				"kube-compose/project": "other",
+			},
+		},
+	})
+	d.cfg.ProjectName = "web"
+	d.cfg.FilterByProject = true
+	err := d.deleteNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.k8sNamespaceClient.Get(context.Background(), "ns", metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+}
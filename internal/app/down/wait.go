@@ -0,0 +1,140 @@
+package down
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	log "github.com/sirupsen/logrus"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// pendingDeletion is a set of resources of a single kind of which down has requested the deletion.
+type pendingDeletion struct {
+	kind    string
+	lister  lister
+	watcher watcher
+	// The names of the resources that have not been observed to be gone.
+	names map[string]bool
+}
+
+func (p *pendingDeletion) remainingNames() []string {
+	var names []string
+	for name := range p.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *pendingDeletion) timeoutError() error {
+	return fmt.Errorf("timed out waiting for the deletion of %s(s) %s", p.kind, strings.Join(p.remainingNames(), ", "))
+}
+
+// watchPendingDeletion starts a watch of the resources of p from resourceVersion. If resourceVersion is empty then the watch starts at the
+// current state, so the resources are listed after the watch has started and resources that are not listed are gone. This guarantees that
+// a resource that is gone after the list produces a Deleted event.
+func (d *downRunner) watchPendingDeletion(ctx context.Context, p *pendingDeletion, resourceVersion string) (watch.Interface, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: k8smeta.LabelSelector(d.cfg),
+	}
+	watchOptions := listOptions
+	watchOptions.ResourceVersion = resourceVersion
+	w, err := p.watcher(ctx, watchOptions)
+	if err != nil || resourceVersion != "" {
+		return w, err
+	}
+	list, err := p.lister(listOptions)
+	if err != nil {
+		w.Stop()
+		return nil, err
+	}
+	existing := map[string]bool{}
+	for _, item := range list {
+		existing[item.Name] = true
+	}
+	for name := range p.names {
+		if !existing[name] {
+			delete(p.names, name)
+		}
+	}
+	return w, nil
+}
+
+// waitForPendingDeletion blocks until all resources of p are gone. A watch that is closed by the server is resumed from the resource
+// version of the last event, and restarted if that resource version has expired.
+func (d *downRunner) waitForPendingDeletion(ctx context.Context, p *pendingDeletion) error {
+	resourceVersion := ""
+	var w watch.Interface
+	defer func() {
+		if w != nil {
+			w.Stop()
+		}
+	}()
+	for len(p.names) > 0 {
+		// The watch is closed and requests fail once the context is done, which must be reported as a timeout.
+		if ctx.Err() != nil {
+			return p.timeoutError()
+		}
+		if w == nil {
+			var err error
+			w, err = d.watchPendingDeletion(ctx, p, resourceVersion)
+			if err != nil {
+				if ctx.Err() != nil {
+					return p.timeoutError()
+				}
+				return err
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return p.timeoutError()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				log.Debugf("the watch of %ss was closed, resuming from resource version %#v\n", p.kind, resourceVersion)
+				w = nil
+				continue
+			}
+			if event.Type == watch.Error {
+				// Typically the resource version has expired (410 Gone).
+				log.Debugf("the watch of %ss failed, restarting: %v\n", p.kind, k8sError.FromObject(event.Object))
+				w.Stop()
+				w = nil
+				resourceVersion = ""
+				continue
+			}
+			objectMeta, ok := event.Object.(metav1.Object)
+			if !ok {
+				continue
+			}
+			resourceVersion = objectMeta.GetResourceVersion()
+			if event.Type == watch.Deleted && p.names[objectMeta.GetName()] {
+				delete(p.names, objectMeta.GetName())
+				log.Infof("%s %s is gone\n", p.kind, objectMeta.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// waitForDeletions blocks until all resources of which down has requested the deletion are gone, or the wait timeout has elapsed.
+func (d *downRunner) waitForDeletions() error {
+	ctx := context.Background()
+	if d.opts.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.opts.WaitTimeout)
+		defer cancel()
+	}
+	for _, p := range d.pendingDeletions {
+		err := d.waitForPendingDeletion(ctx, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
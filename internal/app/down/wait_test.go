@@ -0,0 +1,158 @@
+package down
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestDownRunner(opts *Options, objects ...*v1.Pod) *downRunner {
+	clientset := fake.NewSimpleClientset()
+	for _, obj := range objects {
+		_ = clientset.Tracker().Add(obj)
+	}
+	return &downRunner{
+		cfg: &config.Config{
+			EnvironmentID:    "123",
+			EnvironmentLabel: "env",
+		},
+		k8sPodClient: clientset.CoreV1().Pods(""),
+		opts:         opts,
+	}
+}
+
+func newTestPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"env": "123",
+			},
+		},
+	}
+}
+
+func TestDeletePods_WaitSuccess(t *testing.T) {
+	d := newTestDownRunner(&Options{
+		Wait:        true,
+		WaitTimeout: time.Minute,
+	}, newTestPod("a-123"), newTestPod("b-123"))
+	deletedAll, err := d.deletePods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deletedAll || len(d.pendingDeletions) != 1 || len(d.pendingDeletions[0].names) != 2 {
+		t.Fatal(deletedAll, d.pendingDeletions)
+	}
+	err = d.waitForDeletions()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForDeletions_Timeout(t *testing.T) {
+	d := newTestDownRunner(&Options{
+		Wait:        true,
+		WaitTimeout: 10 * time.Millisecond,
+	}, newTestPod("a-123"))
+	d.pendingDeletions = append(d.pendingDeletions, &pendingDeletion{
+		kind: "Pod",
+		lister: func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			return []*metav1.ObjectMeta{{Name: "a-123"}}, nil
+		},
+		watcher: d.k8sPodClient.Watch,
+		names: map[string]bool{
+			"a-123": true,
+		},
+	})
+	err := d.waitForDeletions()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestWaitForPendingDeletion_DeletedEvent(t *testing.T) {
+	d := newTestDownRunner(&Options{}, newTestPod("a-123"))
+	p := &pendingDeletion{
+		kind: "Pod",
+		lister: func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			// The pod is deleted after the watch has started, but is still listed.
+			err := d.k8sPodClient.Delete(context.Background(), "a-123", metav1.DeleteOptions{})
+			return []*metav1.ObjectMeta{{Name: "a-123"}}, err
+		},
+		watcher: d.k8sPodClient.Watch,
+		names: map[string]bool{
+			"a-123": true,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := d.waitForPendingDeletion(ctx, p)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForPendingDeletion_ResumeClosedWatch(t *testing.T) {
+	d := newTestDownRunner(&Options{})
+	var resourceVersions []string
+	p := &pendingDeletion{
+		kind: "Pod",
+		lister: func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			return []*metav1.ObjectMeta{{Name: "a-123"}}, nil
+		},
+		watcher: func(ctx context.Context, listOptions metav1.ListOptions) (watch.Interface, error) {
+			resourceVersions = append(resourceVersions, listOptions.ResourceVersion)
+			w := watch.NewFakeWithChanSize(1, false)
+			if len(resourceVersions) == 1 {
+				// The server closes the watch after an event.
+				pod := newTestPod("a-123")
+				pod.ResourceVersion = "5"
+				w.Modify(pod)
+				w.Stop()
+			} else {
+				w.Delete(newTestPod("a-123"))
+			}
+			return w, nil
+		},
+		names: map[string]bool{
+			"a-123": true,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := d.waitForPendingDeletion(ctx, p)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(resourceVersions) != 2 || resourceVersions[0] != "" || resourceVersions[1] != "5" {
+		t.Error(resourceVersions)
+	}
+}
+
+func TestWaitForPendingDeletion_TimeoutReported(t *testing.T) {
+	d := newTestDownRunner(&Options{})
+	p := &pendingDeletion{
+		kind: "Pod",
+		watcher: func(ctx context.Context, listOptions metav1.ListOptions) (watch.Interface, error) {
+			return nil, ctx.Err()
+		},
+		names: map[string]bool{
+			"a-123": true,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	err := d.waitForPendingDeletion(ctx, p)
+	if err == nil || !strings.Contains(err.Error(), "timed out waiting for the deletion of Pod(s) a-123") {
+		t.Error(err)
+	}
+}
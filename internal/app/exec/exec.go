@@ -0,0 +1,120 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"golang.org/x/crypto/ssh/terminal"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Options are the options of Run.
+type Options struct {
+	// The command and its arguments.
+	Command []string
+	// The index of the pod to run the command in, in order of pod names. Negative means the first ready pod.
+	Index int
+	// True to pass In to the command.
+	Stdin bool
+	// True to allocate a TTY, in which case the output of the command is written to Out only.
+	TTY bool
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// findExecPod returns the pod of the docker compose service at index in order of pod names, or the first ready pod if index is negative.
+func findExecPod(cfg *config.Config, composeService *config.Service, pods []v1.Pod, index int) (*v1.Pod, error) {
+	var servicePods []*v1.Pod
+	for i := 0; i < len(pods); i++ {
+		if k8smeta.FindFromObjectMeta(cfg, &pods[i].ObjectMeta) == composeService {
+			servicePods = append(servicePods, &pods[i])
+		}
+	}
+	sort.Slice(servicePods, func(i, j int) bool {
+		return servicePods[i].Name < servicePods[j].Name
+	})
+	if index >= 0 {
+		if index >= len(servicePods) {
+			return nil, fmt.Errorf("service %s has %d pod(s), so there is no pod with index %d", composeService.Name(), len(servicePods),
+				index)
+		}
+		pod := servicePods[index]
+		if pod.Status.Phase != v1.PodRunning {
+			return nil, fmt.Errorf("pod %s of service %s is not running", pod.Name, composeService.Name())
+		}
+		return pod, nil
+	}
+	for _, pod := range servicePods {
+		if k8smeta.IsPodReady(pod) {
+			return pod, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s has no ready pod", composeService.Name())
+}
+
+// Run runs a command in the container of a pod of a docker compose service (see findExecPod), like docker compose exec. If the command
+// exits with a non-zero code then the error implements k8s.io/client-go/util/exec.ExitError.
+func Run(cfg *config.Config, composeService *config.Service, opts *Options) error {
+	k8sClientset, err := kubernetes.NewForConfig(cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	podList, err := k8sClientset.CoreV1().Pods(cfg.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: k8smeta.LabelSelector(cfg),
+	})
+	if err != nil {
+		return err
+	}
+	pod, err := findExecPod(cfg, composeService, podList.Items, opts.Index)
+	if err != nil {
+		return err
+	}
+	req := k8sClientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(cfg.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: composeService.NameEscaped,
+			Command:   opts.Command,
+			Stdin:     opts.Stdin,
+			Stdout:    true,
+			Stderr:    !opts.TTY,
+			TTY:       opts.TTY,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(cfg.KubeConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	streamOptions := remotecommand.StreamOptions{
+		Stdout: opts.Out,
+		Tty:    opts.TTY,
+	}
+	if opts.Stdin {
+		streamOptions.Stdin = opts.In
+	}
+	if !opts.TTY {
+		streamOptions.Stderr = opts.Err
+	}
+	if file, ok := opts.In.(*os.File); ok && opts.TTY && terminal.IsTerminal(int(file.Fd())) {
+		// Put the local terminal in raw mode, so that keys such as Ctrl+C are sent to the command.
+		state, err := terminal.MakeRaw(int(file.Fd()))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = terminal.Restore(int(file.Fd()), state)
+		}()
+	}
+	return executor.StreamWithContext(context.Background(), streamOptions)
+}
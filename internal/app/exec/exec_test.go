@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	for _, name := range []string{"a", "b"} {
+		cfg.AddService(&dockerComposeConfig.Service{
+			Name: name,
+		})
+	}
+	return cfg
+}
+
+func newTestExecPod(name string, phase v1.PodPhase, ready bool) v1.Pod {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				k8smeta.AnnotationName: "a",
+			},
+		},
+	}
+	pod.Status.Phase = phase
+	if ready {
+		pod.Status.Conditions = []v1.PodCondition{
+			{Type: v1.PodReady, Status: v1.ConditionTrue},
+		}
+	}
+	return pod
+}
+
+func TestFindExecPod_FirstReady(t *testing.T) {
+	cfg := newTestConfig()
+	pods := []v1.Pod{
+		newTestExecPod("a-123-2", v1.PodRunning, true),
+		newTestExecPod("a-123-0", v1.PodPending, false),
+		newTestExecPod("a-123-1", v1.PodRunning, true),
+	}
+	pod, err := findExecPod(cfg, cfg.Services["a"], pods, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Name != "a-123-1" {
+		t.Error(pod.Name)
+	}
+}
+
+func TestFindExecPod_NoReadyPodError(t *testing.T) {
+	cfg := newTestConfig()
+	pods := []v1.Pod{
+		newTestExecPod("a-123", v1.PodPending, false),
+	}
+	_, err := findExecPod(cfg, cfg.Services["a"], pods, -1)
+	if err == nil {
+		t.Fail()
+	}
+	_, err = findExecPod(cfg, cfg.Services["b"], pods, -1)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestFindExecPod_Index(t *testing.T) {
+	cfg := newTestConfig()
+	pods := []v1.Pod{
+		newTestExecPod("a-123-1", v1.PodRunning, false),
+		newTestExecPod("a-123-0", v1.PodPending, false),
+	}
+	pod, err := findExecPod(cfg, cfg.Services["a"], pods, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Name != "a-123-1" {
+		t.Error(pod.Name)
+	}
+	if _, err = findExecPod(cfg, cfg.Services["a"], pods, 0); err == nil {
+		t.Error("expected an error because the pod is not running")
+	}
+	if _, err = findExecPod(cfg, cfg.Services["a"], pods, 2); err == nil {
+		t.Error("expected an error because the index is out of range")
+	}
+}
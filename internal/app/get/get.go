@@ -2,8 +2,11 @@ package details
 
 import (
 	"context"
+	"fmt"
+
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -16,10 +19,16 @@ type getRunner struct {
 	service          *config.Service
 }
 
+// ServiceDetails describes the Kubernetes Service of a docker compose service. The field tags determine the keys of the json and yaml
+// output of the get command.
 type ServiceDetails struct {
-	Name      string
-	ClusterIP string
-	Hostname  string
+	// The name of the docker compose service that the Kubernetes Service belongs to.
+	Name string `json:"name" yaml:"name"`
+	// The name and namespace of the Kubernetes Service.
+	ResourceName string `json:"resourceName" yaml:"resourceName"`
+	Namespace    string `json:"namespace" yaml:"namespace"`
+	ClusterIP    string `json:"clusterIP" yaml:"clusterIP"`
+	Hostname     string `json:"hostname" yaml:"hostname"`
 }
 
 func GetServiceDetails(cfg *config.Config, service *config.Service) (*ServiceDetails, error) {
@@ -50,10 +59,23 @@ func (g *getRunner) run() (*ServiceDetails, error) {
 	if err != nil {
 		return nil, err
 	}
-	details := &ServiceDetails{
-		Name:      g.service.Name(),
-		Hostname:  result.Name + "." + result.Namespace + ".svc.cluster.local",
-		ClusterIP: result.Spec.ClusterIP,
+	if !k8smeta.MatchesProject(g.cfg, &result.ObjectMeta) {
+		return nil, fmt.Errorf("the Service %s does not belong to project %s", k8sName, g.cfg.ProjectName)
+	}
+	return newServiceDetails(g.cfg, g.service, result), nil
+}
+
+// newServiceDetails returns the details of the Kubernetes Service k8sService of the docker compose service. The name of the docker
+// compose service is taken from the annotations of k8sService if possible.
+func newServiceDetails(cfg *config.Config, service *config.Service, k8sService *v1.Service) *ServiceDetails {
+	if composeService := k8smeta.FindFromObjectMeta(cfg, &k8sService.ObjectMeta); composeService != nil {
+		service = composeService
+	}
+	return &ServiceDetails{
+		Name:         service.Name(),
+		ResourceName: k8sService.Name,
+		Namespace:    k8sService.Namespace,
+		Hostname:     k8sService.Name + "." + k8sService.Namespace + ".svc.cluster.local",
+		ClusterIP:    k8sService.Spec.ClusterIP,
 	}
-	return details, nil
 }
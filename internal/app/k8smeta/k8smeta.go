@@ -1,17 +1,30 @@
 package k8smeta
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/pkg/errors"
+	"strings"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // AnnotationName is the name of an annotation added by kube compose to resources, so that resources can be mapped back to their docker
 // compose service.
 const AnnotationName = "kube-compose/service"
 
+// ConfigHashAnnotationName is the name of an annotation added by kube compose to pods, whose value is a hash of the docker compose
+// configuration of the pod's service. This is used to detect pods whose configuration has changed.
+const ConfigHashAnnotationName = "kube-compose/config-hash"
+
+// ProjectLabel is the name of a label added by kube compose to resources, whose value is the project name (see config.Config.ProjectName),
+// so that resources can be grouped by project.
+const ProjectLabel = "kube-compose/project"
+
 // ErrorResourcesModifiedExternally returns an error indicating that resources managed by kube-compose have been modified externally.
 func ErrorResourcesModifiedExternally() error {
 	return fmt.Errorf("one or more resources appear to have been modified by an external process, aborting")
@@ -20,7 +33,9 @@ func ErrorWrapResourcesModifiedExternally(fmt string, args ...any) error {
 	return errors.Wrapf(ErrorResourcesModifiedExternally(), fmt, args...)
 }
 
-// InitCommonLabels adds the labels for the specified docker compose service to the string map.
+// InitCommonLabels adds the labels for the specified docker compose service to the string map. These labels are also used as the selectors
+// of Services, Deployments and StatefulSets, so they do not include the project label: the selectors of Deployments and StatefulSets
+// cannot be changed, and pods created before the project label existed would not be selected.
 func InitCommonLabels(cfg *config.Config, composeService *config.Service, labels map[string]string) map[string]string {
 	if labels == nil {
 		labels = map[string]string{}
@@ -30,14 +45,112 @@ func InitCommonLabels(cfg *config.Config, composeService *config.Service, labels
 	return labels
 }
 
-// InitObjectMeta sets the name, labels and annotations of a resource for the specified docker compose service.
+// EnvironmentLabels returns the labels of a resource that belongs to the environment as a whole instead of to a docker compose service (e.g.
+// the Secret of a docker compose secret), so that the resource is selected by LabelSelector.
+func EnvironmentLabels(cfg *config.Config) map[string]string {
+	labels := map[string]string{
+		cfg.EnvironmentLabel: cfg.EnvironmentID,
+	}
+	if cfg.ProjectName != "" {
+		labels[ProjectLabel] = cfg.ProjectName
+	}
+	return labels
+}
+
+// LabelSelector returns the label selector of the resources of the environment, restricted to the resources of the project if
+// cfg.FilterByProject is set.
+func LabelSelector(cfg *config.Config) string {
+	selector := cfg.EnvironmentLabel + "=" + cfg.EnvironmentID
+	if cfg.FilterByProject {
+		selector += "," + ProjectLabel + "=" + cfg.ProjectName
+	}
+	return selector
+}
+
+// MatchesProject returns whether a resource is selected by the project filter (see LabelSelector).
+func MatchesProject(cfg *config.Config, objectMeta *metav1.ObjectMeta) bool {
+	return !cfg.FilterByProject || objectMeta.Labels[ProjectLabel] == cfg.ProjectName
+}
+
+// IsReservedLabel returns whether a label is set by kube-compose (see InitCommonLabels), so that the labels of a docker compose service
+// cannot overwrite it.
+func IsReservedLabel(cfg *config.Config, name string) bool {
+	return name == "app" || name == cfg.EnvironmentLabel || name == ProjectLabel
+}
+
+// IsValidLabel returns whether the name and value of a label of a docker compose service are valid for a Kubernetes label. Labels that
+// are not valid Kubernetes labels are added as annotations, if their names are valid annotation names.
+func IsValidLabel(name, value string) bool {
+	return len(validation.IsQualifiedName(name)) == 0 && len(validation.IsValidLabelValue(value)) == 0
+}
+
+// initServiceLabels adds the labels of the docker compose service (see IsValidLabel), except reserved labels (see IsReservedLabel).
+func initServiceLabels(cfg *config.Config, objectMeta *metav1.ObjectMeta, composeService *config.Service) {
+	for name, value := range composeService.DockerComposeService.Labels {
+		switch {
+		case IsReservedLabel(cfg, name):
+		case IsValidLabel(name, value):
+			if objectMeta.Labels == nil {
+				objectMeta.Labels = map[string]string{}
+			}
+			objectMeta.Labels[name] = value
+		case len(validation.IsQualifiedName(name)) == 0:
+			if objectMeta.Annotations == nil {
+				objectMeta.Annotations = map[string]string{}
+			}
+			objectMeta.Annotations[name] = value
+		}
+	}
+}
+
+// InitObjectMeta sets the name, labels, annotations and owner references of a resource for the specified docker compose service. The labels
+// of the docker compose service are added as well (see initServiceLabels).
 func InitObjectMeta(cfg *config.Config, objectMeta *metav1.ObjectMeta, composeService *config.Service) {
 	objectMeta.Name = GetK8sName(composeService, cfg)
+	initServiceLabels(cfg, objectMeta, composeService)
 	objectMeta.Labels = InitCommonLabels(cfg, composeService, objectMeta.Labels)
+	if cfg.ProjectName != "" {
+		objectMeta.Labels[ProjectLabel] = cfg.ProjectName
+	}
 	if objectMeta.Annotations == nil {
 		objectMeta.Annotations = map[string]string{}
 	}
 	objectMeta.Annotations[AnnotationName] = composeService.Name()
+	InitOwnerReferences(cfg, objectMeta)
+}
+
+// GetOwnerName returns the name of the ConfigMap that owns all resources of the environment if owner references are used (see
+// config.Config.OwnerReference).
+func GetOwnerName(cfg *config.Config) string {
+	return GetK8sNameFromEscapedName(cfg, "kube-compose")
+}
+
+// InitOwnerReferences adds cfg.OwnerReference (if set) to the owner references of a resource, so that the resource is deleted by the
+// garbage collector of Kubernetes when its owner is deleted.
+func InitOwnerReferences(cfg *config.Config, objectMeta *metav1.ObjectMeta) {
+	if cfg.OwnerReference == nil {
+		return
+	}
+	for _, ownerReference := range objectMeta.OwnerReferences {
+		if ownerReference.UID == cfg.OwnerReference.UID {
+			return
+		}
+	}
+	objectMeta.OwnerReferences = append(objectMeta.OwnerReferences, *cfg.OwnerReference)
+}
+
+// IsOwned returns whether an existing resource was created by kube-compose for the environment of cfg, so that it may be updated to the
+// desired resource. All resources of an environment have the environment label (see InitCommonLabels), and if the desired resource
+// belongs to a docker compose service then the existing resource must also have the annotation AnnotationName.
+func IsOwned(cfg *config.Config, existing, desired metav1.Object) bool {
+	if existing.GetLabels()[cfg.EnvironmentLabel] != cfg.EnvironmentID {
+		return false
+	}
+	if _, ok := desired.GetAnnotations()[AnnotationName]; ok {
+		_, ok = existing.GetAnnotations()[AnnotationName]
+		return ok
+	}
+	return true
 }
 
 // FindFromObjectMeta finds a docker compose service from resource metadata.
@@ -51,10 +164,49 @@ func FindFromObjectMeta(cfg *config.Config, objectMeta *metav1.ObjectMeta) *conf
 	return nil
 }
 
+// IsPodReady returns whether the Ready condition of a pod is true.
+func IsPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 func GetK8sName(service *config.Service, cfg *config.Config) string {
+	return GetK8sNameFromEscapedName(cfg, service.NameEscaped)
+}
+
+// GetK8sNameFromEscapedName names a resource the same way as GetK8sName, for resources that do not belong to a single docker compose
+// service. The name must have been escaped with util.EscapeName.
+func GetK8sNameFromEscapedName(cfg *config.Config, nameEscaped string) string {
+	return TruncateName(cfg, getUntruncatedK8sName(cfg, nameEscaped))
+}
+
+// GetUntruncatedK8sName returns the name that GetK8sName would return if names were not truncated (see TruncateName).
+func GetUntruncatedK8sName(service *config.Service, cfg *config.Config) string {
+	return getUntruncatedK8sName(cfg, service.NameEscaped)
+}
+
+func getUntruncatedK8sName(cfg *config.Config, nameEscaped string) string {
 	if cfg.EnvironmentIDNoAppend {
-		return service.NameEscaped
-	} else {
-		return service.NameEscaped + "-" + cfg.EnvironmentID
+		return nameEscaped
+	}
+	return nameEscaped + "-" + cfg.EnvironmentID
+}
+
+// The number of hexadecimal characters of the hash that replaces the overflow of a truncated name.
+const truncatedNameHashLength = 8
+
+// TruncateName truncates a name that exceeds the maximum length of a DNS label if cfg.TruncateNames is set. The overflow is replaced by a
+// hash of the name, so that truncated names are stable across runs and (almost certainly) unique. Resources can be mapped back to their
+// docker compose service using the annotation AnnotationName.
+func TruncateName(cfg *config.Config, name string) string {
+	if !cfg.TruncateNames || len(name) <= validation.DNS1123LabelMaxLength {
+		return name
 	}
+	hash := sha256.Sum256([]byte(name))
+	prefix := strings.TrimRight(name[:validation.DNS1123LabelMaxLength-truncatedNameHashLength-1], "-")
+	return prefix + "-" + hex.EncodeToString(hash[:])[:truncatedNameHashLength]
 }
@@ -1,10 +1,13 @@
 package k8smeta
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -59,9 +62,263 @@ func TestInitObjectMeta_Success(t *testing.T) {
 	InitObjectMeta(cfg, &objectMeta, serviceA)
 }
 
+func TestInitObjectMeta_Project(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID:    "myenv",
+		EnvironmentLabel: "env",
+		ProjectName:      "myproject",
+	}
+	serviceA := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	objectMeta := metav1.ObjectMeta{}
+	InitObjectMeta(cfg, &objectMeta, serviceA)
+	if objectMeta.Labels[ProjectLabel] != "myproject" || !IsReservedLabel(cfg, ProjectLabel) {
+		t.Error(objectMeta.Labels)
+	}
+	// The project label is not part of selectors.
+	if _, ok := InitCommonLabels(cfg, serviceA, nil)[ProjectLabel]; ok {
+		t.Fail()
+	}
+	cfg.ProjectName = ""
+	objectMeta = metav1.ObjectMeta{}
+	InitObjectMeta(cfg, &objectMeta, serviceA)
+	if _, ok := objectMeta.Labels[ProjectLabel]; ok {
+		t.Error(objectMeta.Labels)
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID:    "myenv",
+		EnvironmentLabel: "env",
+		ProjectName:      "myproject",
+	}
+	if selector := LabelSelector(cfg); selector != "env=myenv" {
+		t.Error(selector)
+	}
+	cfg.FilterByProject = true
+	if selector := LabelSelector(cfg); selector != "env=myenv,kube-compose/project=myproject" {
+		t.Error(selector)
+	}
+}
+
+func TestMatchesProject(t *testing.T) {
+	cfg := &config.Config{
+		ProjectName: "myproject",
+	}
+	objectMeta := &metav1.ObjectMeta{
+		Labels: map[string]string{
+			ProjectLabel: "otherproject",
+		},
+	}
+	if !MatchesProject(cfg, objectMeta) {
+		t.Fail()
+	}
+	cfg.FilterByProject = true
+	if MatchesProject(cfg, objectMeta) {
+		t.Fail()
+	}
+	objectMeta.Labels[ProjectLabel] = "myproject"
+	if !MatchesProject(cfg, objectMeta) {
+		t.Fail()
+	}
+}
+
 func Test_ErrorResourcesModifiedExternally(t *testing.T) {
 	err := ErrorResourcesModifiedExternally()
 	if err == nil {
 		t.Fail()
 	}
 }
+
+func TestIsOwned(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentLabel: "env",
+		EnvironmentID:    "123",
+	}
+	ofService := &metav1.ObjectMeta{
+		Labels:      map[string]string{"env": "123"},
+		Annotations: map[string]string{AnnotationName: "a"},
+	}
+	ofEnvironment := &metav1.ObjectMeta{
+		Labels: map[string]string{"env": "123"},
+	}
+	ofOtherEnvironment := &metav1.ObjectMeta{
+		Labels:      map[string]string{"env": "456"},
+		Annotations: map[string]string{AnnotationName: "a"},
+	}
+	testCases := []struct {
+		existing, desired *metav1.ObjectMeta
+		expected          bool
+	}{
+		{existing: ofService, desired: ofService, expected: true},
+		{existing: ofEnvironment, desired: ofEnvironment, expected: true},
+		{existing: ofService, desired: ofEnvironment, expected: true},
+		{existing: ofEnvironment, desired: ofService, expected: false},
+		{existing: ofOtherEnvironment, desired: ofService, expected: false},
+		{existing: &metav1.ObjectMeta{}, desired: ofEnvironment, expected: false},
+	}
+	for i, testCase := range testCases {
+		if IsOwned(cfg, testCase.existing, testCase.desired) != testCase.expected {
+			t.Errorf("test case %d: expected %v", i, testCase.expected)
+		}
+	}
+}
+
+func TestInitOwnerReferences(t *testing.T) {
+	cfg := &config.Config{}
+	objectMeta := &metav1.ObjectMeta{}
+	InitOwnerReferences(cfg, objectMeta)
+	if len(objectMeta.OwnerReferences) != 0 {
+		t.Error(objectMeta.OwnerReferences)
+	}
+	cfg.OwnerReference = &metav1.OwnerReference{
+		Kind: "ConfigMap",
+		Name: "kube-compose-123",
+		UID:  "uid",
+	}
+	InitOwnerReferences(cfg, objectMeta)
+	InitOwnerReferences(cfg, objectMeta)
+	if len(objectMeta.OwnerReferences) != 1 || objectMeta.OwnerReferences[0].UID != "uid" {
+		t.Error(objectMeta.OwnerReferences)
+	}
+}
+
+func TestGetOwnerName(t *testing.T) {
+	cfg := &config.Config{EnvironmentID: "123"}
+	if name := GetOwnerName(cfg); name != "kube-compose-123" {
+		t.Error(name)
+	}
+}
+
+func TestGetK8sName_Truncated(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID: "feature-123",
+		TruncateNames: true,
+	}
+	service := cfg.AddService(&dockerComposeConfig.Service{
+		Name: strings.Repeat("a", 60),
+	})
+	name := GetK8sName(service, cfg)
+	if len(name) != 63 || !strings.HasPrefix(name, strings.Repeat("a", 54)+"-") {
+		t.Error(name)
+	}
+	if name != GetK8sName(service, cfg) {
+		t.Error("truncated name is not stable")
+	}
+	other := cfg.AddService(&dockerComposeConfig.Service{
+		Name: strings.Repeat("a", 61),
+	})
+	if name == GetK8sName(other, cfg) {
+		t.Error("truncated names are not unique")
+	}
+	objectMeta := metav1.ObjectMeta{}
+	InitObjectMeta(cfg, &objectMeta, service)
+	if FindFromObjectMeta(cfg, &objectMeta) != service {
+		t.Error(objectMeta)
+	}
+}
+
+func TestGetK8sName_TruncatedBoundary(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID: "env",
+		TruncateNames: true,
+	}
+	// 59 + len("-env") = 63 characters, which is the maximum length.
+	service := &config.Service{NameEscaped: strings.Repeat("a", 59)}
+	if name := GetK8sName(service, cfg); name != strings.Repeat("a", 59)+"-env" {
+		t.Error(name)
+	}
+	service = &config.Service{NameEscaped: strings.Repeat("a", 60)}
+	name := GetK8sName(service, cfg)
+	if len(name) != 63 || name == GetUntruncatedK8sName(service, cfg) {
+		t.Error(name)
+	}
+}
+
+func TestGetK8sName_TruncatedTrimsDash(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentIDNoAppend: true,
+		TruncateNames:         true,
+	}
+	// The character before the hash would be '-', which is trimmed so that the separator is not doubled.
+	service := &config.Service{NameEscaped: strings.Repeat("a", 53) + "-" + strings.Repeat("b", 20)}
+	name := GetK8sName(service, cfg)
+	if len(name) != 62 || !strings.HasPrefix(name, strings.Repeat("a", 53)+"-") || strings.Contains(name, "--") {
+		t.Error(name)
+	}
+}
+
+func TestGetUntruncatedK8sName(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID: "env",
+		TruncateNames: true,
+	}
+	service := &config.Service{NameEscaped: strings.Repeat("a", 70)}
+	if GetUntruncatedK8sName(service, cfg) != strings.Repeat("a", 70)+"-env" {
+		t.Fail()
+	}
+	cfg.EnvironmentIDNoAppend = true
+	if GetUntruncatedK8sName(service, cfg) != strings.Repeat("a", 70) {
+		t.Fail()
+	}
+}
+
+func TestGetK8sName_NotTruncated(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID: "feature-123",
+	}
+	service := &config.Service{NameEscaped: strings.Repeat("a", 60)}
+	if GetK8sName(service, cfg) != strings.Repeat("a", 60)+"-feature-123" {
+		t.Fail()
+	}
+}
+
+func TestInitObjectMeta_ServiceLabels(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID:    "myenv",
+		EnvironmentLabel: "env",
+	}
+	serviceA := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		Labels: map[string]string{
+			"app":                     "other",
+			"env":                     "other",
+			"com.example.team":        "payments",
+			"com.example.description": "not a label value",
+			"invalid name":            "x",
+		},
+	})
+	objectMeta := metav1.ObjectMeta{}
+	InitObjectMeta(cfg, &objectMeta, serviceA)
+	expectedLabels := map[string]string{
+		"app":              "a",
+		"env":              "myenv",
+		"com.example.team": "payments",
+	}
+	if !reflect.DeepEqual(objectMeta.Labels, expectedLabels) {
+		t.Error(objectMeta.Labels)
+	}
+	expectedAnnotations := map[string]string{
+		AnnotationName:            "a",
+		"com.example.description": "not a label value",
+	}
+	if !reflect.DeepEqual(objectMeta.Annotations, expectedAnnotations) {
+		t.Error(objectMeta.Annotations)
+	}
+}
+
+func TestIsPodReady(t *testing.T) {
+	pod := &v1.Pod{}
+	if IsPodReady(pod) {
+		t.Fail()
+	}
+	pod.Status.Conditions = []v1.PodCondition{
+		{Type: v1.PodScheduled, Status: v1.ConditionTrue},
+		{Type: v1.PodReady, Status: v1.ConditionTrue},
+	}
+	if !IsPodReady(pod) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,304 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Options are the options of Run.
+type Options struct {
+	// True to keep streaming the logs, including those of pods that are created (or recreated) later, until the context is done.
+	Follow bool
+	// The number of lines at the end of each log to show. Negative means all lines.
+	TailLines int64
+	// The writer to which log lines are written.
+	Out io.Writer
+}
+
+// logStream is the log of a single container, whose lines are prefixed with prefix.
+type logStream struct {
+	podName   string
+	podUID    types.UID
+	container string
+	prefix    string
+}
+
+// key identifies the container of a stream, so that the log of a container is streamed once. A recreated pod with the same name (e.g. of
+// a StatefulSet) has a different UID.
+func (s *logStream) key() string {
+	return fmt.Sprintf("%s/%s/%s", s.podName, s.podUID, s.container)
+}
+
+// serviceColors returns a color of util.AnsiColorPalette for each of the docker compose services, in order of name.
+func serviceColors(composeServices []*config.Service) map[*config.Service]string {
+	composeServices = append([]*config.Service{}, composeServices...)
+	sort.Slice(composeServices, func(i, j int) bool {
+		return composeServices[i].Name() < composeServices[j].Name()
+	})
+	colors := map[*config.Service]string{}
+	for i, composeService := range composeServices {
+		colors[composeService] = util.AnsiColorPalette[i%len(util.AnsiColorPalette)]
+	}
+	return colors
+}
+
+// formatPrefix pads prefix to prefixLength and colors it.
+func formatPrefix(prefix string, prefixLength int, color string) string {
+	return util.AnsiColorWrap(fmt.Sprintf("%-*s|", prefixLength+1, prefix), color, "0")
+}
+
+// newLogStreams returns a log stream for each container of the pods of the specified docker compose services, sorted by pod name. Lines
+// are prefixed with the name of the service, or with the pod name if the service has multiple pods (e.g. replicas), so that the logs of
+// multiple pods can be told apart. Each service gets a color (see serviceColors). Also returns the length of the longest prefix.
+func newLogStreams(cfg *config.Config, composeServices []*config.Service, pods []v1.Pod) ([]*logStream, int) {
+	podsByService := map[*config.Service][]*v1.Pod{}
+	for i := 0; i < len(pods); i++ {
+		if composeService := k8smeta.FindFromObjectMeta(cfg, &pods[i].ObjectMeta); composeService != nil {
+			podsByService[composeService] = append(podsByService[composeService], &pods[i])
+		}
+	}
+	colors := serviceColors(composeServices)
+	var streams []*logStream
+	var streamColors []string
+	maxPrefixLength := 0
+	for _, composeService := range composeServices {
+		servicePods := podsByService[composeService]
+		for _, pod := range servicePods {
+			prefix := composeService.Name()
+			if len(servicePods) > 1 {
+				prefix = pod.Name
+			}
+			if len(prefix) > maxPrefixLength {
+				maxPrefixLength = len(prefix)
+			}
+			for _, container := range pod.Spec.Containers {
+				streams = append(streams, &logStream{
+					podName:   pod.Name,
+					podUID:    pod.UID,
+					container: container.Name,
+					prefix:    prefix,
+				})
+				streamColors = append(streamColors, colors[composeService])
+			}
+		}
+	}
+	for i, stream := range streams {
+		stream.prefix = formatPrefix(stream.prefix, maxPrefixLength, streamColors[i])
+	}
+	sort.SliceStable(streams, func(i, j int) bool {
+		return streams[i].podName < streams[j].podName
+	})
+	return streams, maxPrefixLength
+}
+
+// streamLogs writes the lines of the log of stream to opts.Out, prefixed with stream.prefix. mutex serializes writes of concurrent
+// streams, so that lines are not interleaved.
+func streamLogs(ctx context.Context, podClient clientV1.PodInterface, stream *logStream, opts *Options, mutex *sync.Mutex) error {
+	podLogOptions := &v1.PodLogOptions{
+		Container: stream.container,
+		Follow:    opts.Follow,
+	}
+	if opts.TailLines >= 0 {
+		podLogOptions.TailLines = &opts.TailLines
+	}
+	bodyReader, err := podClient.GetLogs(stream.podName, podLogOptions).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(bodyReader)
+	scanner := bufio.NewScanner(bodyReader)
+	for scanner.Scan() {
+		mutex.Lock()
+		_, err = fmt.Fprintf(opts.Out, "%s %s\n", stream.prefix, scanner.Text())
+		mutex.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// logsRunner streams the logs of containers concurrently.
+type logsRunner struct {
+	cfg       *config.Config
+	podClient clientV1.PodInterface
+	opts      *Options
+	// mutex serializes writes of streams, and guards the fields below.
+	mutex sync.Mutex
+	wg    sync.WaitGroup
+	err   error
+	// The keys of the streams that have been started (see logStream.key).
+	started map[string]bool
+}
+
+// start streams the logs of stream in a goroutine, unless it was started before. The first error of any stream is kept in r.err. The
+// caller must hold r.mutex.
+func (r *logsRunner) start(ctx context.Context, stream *logStream) {
+	if r.started[stream.key()] {
+		return
+	}
+	r.started[stream.key()] = true
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		err := streamLogs(ctx, r.podClient, stream, r.opts, &r.mutex)
+		if err != nil {
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+			if r.err == nil {
+				r.err = errors.Wrapf(err, "could not stream the logs of container %s of pod %s", stream.container, stream.podName)
+			}
+		}
+	}()
+}
+
+// followPods watches the pods of the environment from resourceVersion on, and streams the logs of the containers of pods of the specified
+// docker compose services that are created (or recreated) after the initial list, once the containers have started. The watch is
+// re-established when the API server closes it. Returns when ctx is done. New pods are prefixed with the name of their service, or with
+// their pod name if the service has other pods.
+func (r *logsRunner) followPods(ctx context.Context, composeServices []*config.Service, pods []v1.Pod, resourceVersion string,
+	prefixLength int) error {
+	colors := serviceColors(composeServices)
+	podNames := map[*config.Service]map[string]bool{}
+	for _, composeService := range composeServices {
+		podNames[composeService] = map[string]bool{}
+	}
+	for i := 0; i < len(pods); i++ {
+		if composeService := k8smeta.FindFromObjectMeta(r.cfg, &pods[i].ObjectMeta); podNames[composeService] != nil {
+			podNames[composeService][pods[i].Name] = true
+		}
+	}
+	for {
+		w, err := r.podClient.Watch(ctx, metav1.ListOptions{
+			LabelSelector:   k8smeta.LabelSelector(r.cfg),
+			ResourceVersion: resourceVersion,
+		})
+		if err != nil {
+			return err
+		}
+		resourceVersion, prefixLength, err = r.followPodEvents(ctx, w, resourceVersion, podNames, colors, prefixLength)
+		w.Stop()
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// followPodEvents handles the events of w for followPods until w is closed or ctx is done, and returns the resource version of the last
+// event (resourceVersion if there were none) and the length of the longest prefix. podNames are the names of the pods of each docker
+// compose service whose logs are streamed.
+func (r *logsRunner) followPodEvents(ctx context.Context, w watch.Interface, resourceVersion string,
+	podNames map[*config.Service]map[string]bool, colors map[*config.Service]string, prefixLength int) (string, int, error) {
+	for {
+		var event watch.Event
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return resourceVersion, prefixLength, nil
+		case event, ok = <-w.ResultChan():
+		}
+		if !ok {
+			return resourceVersion, prefixLength, nil
+		}
+		if event.Type == watch.Error {
+			return resourceVersion, prefixLength, fmt.Errorf("could not watch pods: %v", event.Object)
+		}
+		pod, ok := event.Object.(*v1.Pod)
+		if !ok {
+			continue
+		}
+		resourceVersion = pod.ResourceVersion
+		composeService := k8smeta.FindFromObjectMeta(r.cfg, &pod.ObjectMeta)
+		if podNames[composeService] == nil {
+			continue
+		}
+		if event.Type == watch.Deleted {
+			delete(podNames[composeService], pod.Name)
+			continue
+		}
+		podNames[composeService][pod.Name] = true
+		prefix := composeService.Name()
+		if len(podNames[composeService]) > 1 {
+			prefix = pod.Name
+		}
+		if len(prefix) > prefixLength {
+			prefixLength = len(prefix)
+		}
+		r.mutex.Lock()
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.State.Running == nil && containerStatus.State.Terminated == nil {
+				continue
+			}
+			r.start(ctx, &logStream{
+				podName:   pod.Name,
+				podUID:    pod.UID,
+				container: containerStatus.Name,
+				prefix:    formatPrefix(prefix, prefixLength, colors[composeService]),
+			})
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// run streams the logs of the containers of the pods of the specified docker compose services concurrently. The first error of any
+// stream is returned after all streams have ended.
+func run(ctx context.Context, cfg *config.Config, podClient clientV1.PodInterface, composeServices []*config.Service, opts *Options) error {
+	podList, err := podClient.List(ctx, metav1.ListOptions{
+		LabelSelector: k8smeta.LabelSelector(cfg),
+	})
+	if err != nil {
+		return err
+	}
+	streams, prefixLength := newLogStreams(cfg, composeServices, podList.Items)
+	if len(streams) == 0 {
+		log.Warn("no pods found for the selected services")
+		if !opts.Follow {
+			return nil
+		}
+	}
+	r := &logsRunner{
+		cfg:       cfg,
+		podClient: podClient,
+		opts:      opts,
+		started:   map[string]bool{},
+	}
+	r.mutex.Lock()
+	for _, stream := range streams {
+		r.start(ctx, stream)
+	}
+	r.mutex.Unlock()
+	if opts.Follow {
+		err = r.followPods(ctx, composeServices, podList.Items, podList.ResourceVersion, prefixLength)
+	}
+	r.wg.Wait()
+	if err != nil {
+		return err
+	}
+	return r.err
+}
+
+// Run writes the logs of the pods of the specified docker compose services to opts.Out, multiplexing the logs of all pods and containers
+// (e.g. of replicas). Each line is prefixed with the name of its service.
+func Run(cfg *config.Config, composeServices []*config.Service, opts *Options) error {
+	k8sClientset, err := kubernetes.NewForConfig(cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	return run(context.Background(), cfg, k8sClientset.CoreV1().Pods(cfg.Namespace), composeServices, opts)
+}
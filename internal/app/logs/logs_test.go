@@ -0,0 +1,175 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	for _, name := range []string{"a", "b", "c"} {
+		cfg.AddService(&dockerComposeConfig.Service{
+			Name: name,
+		})
+	}
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	return cfg
+}
+
+func newTestLogsPod(name string, composeService *config.Service, containers ...string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"env": "123",
+			},
+			Annotations: map[string]string{
+				k8smeta.AnnotationName: composeService.Name(),
+			},
+		},
+	}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: container})
+	}
+	return pod
+}
+
+func withColorsDisabled(cb func()) {
+	util.SetColorsEnabled(false)
+	defer util.SetColorsEnabled(true)
+	cb()
+}
+
+func TestNewLogStreams_Prefixes(t *testing.T) {
+	withColorsDisabled(func() {
+		cfg := newTestConfig()
+		pods := []v1.Pod{
+			*newTestLogsPod("b-123-1", cfg.Services["b"], "b"),
+			*newTestLogsPod("a-123", cfg.Services["a"], "a", "sidecar"),
+			*newTestLogsPod("b-123-0", cfg.Services["b"], "b"),
+			*newTestLogsPod("c-123", cfg.Services["c"], "c"),
+		}
+		streams, prefixLength := newLogStreams(cfg, []*config.Service{cfg.Services["b"], cfg.Services["a"]}, pods)
+		expected := []logStream{
+			{podName: "a-123", container: "a", prefix: "a       |"},
+			{podName: "a-123", container: "sidecar", prefix: "a       |"},
+			{podName: "b-123-0", container: "b", prefix: "b-123-0 |"},
+			{podName: "b-123-1", container: "b", prefix: "b-123-1 |"},
+		}
+		if len(streams) != len(expected) || prefixLength != 7 {
+			t.Fatal(streams, prefixLength)
+		}
+		for i, stream := range streams {
+			if *stream != expected[i] {
+				t.Errorf("stream %d: got %+v, expected %+v", i, *stream, expected[i])
+			}
+		}
+	})
+}
+
+func TestRun_Success(t *testing.T) {
+	withColorsDisabled(func() {
+		cfg := newTestConfig()
+		clientset := fake.NewSimpleClientset(newTestLogsPod("a-123", cfg.Services["a"], "a"))
+		var out bytes.Buffer
+		opts := &Options{
+			TailLines: 10,
+			Out:       &out,
+		}
+		err := run(context.Background(), cfg, clientset.CoreV1().Pods(""), []*config.Service{cfg.Services["a"]}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The fake clientset serves the log "fake logs" for every container.
+		if out.String() != "a | fake logs\n" {
+			t.Errorf("%q", out.String())
+		}
+	})
+}
+
+func TestRun_NoPods(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset()
+	var out bytes.Buffer
+	err := run(context.Background(), cfg, clientset.CoreV1().Pods(""), []*config.Service{cfg.Services["a"]}, &Options{
+		TailLines: -1,
+		Out:       &out,
+	})
+	if err != nil || out.Len() > 0 {
+		t.Fail()
+	}
+}
+
+// countActions returns the number of actions of clientset with the verb and subresource.
+func countActions(clientset *fake.Clientset, verb, subresource string) int {
+	count := 0
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == verb && action.GetSubresource() == subresource {
+			count++
+		}
+	}
+	return count
+}
+
+func waitForActions(t *testing.T, clientset *fake.Clientset, verb, subresource string, count int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for countActions(clientset, verb, subresource) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d %s actions", count, verb)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRun_FollowRecreatedPod(t *testing.T) {
+	withColorsDisabled(func() {
+		cfg := newTestConfig()
+		pod := newTestLogsPod("a-123", cfg.Services["a"], "a")
+		pod.UID = "1"
+		clientset := fake.NewSimpleClientset(pod)
+		var out bytes.Buffer
+		opts := &Options{
+			Follow:    true,
+			TailLines: -1,
+			Out:       &out,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		errChannel := make(chan error, 1)
+		go func() {
+			errChannel <- run(ctx, cfg, clientset.CoreV1().Pods(""), []*config.Service{cfg.Services["a"]}, opts)
+		}()
+		waitForActions(t, clientset, "watch", "", 1)
+		podsResource := v1.SchemeGroupVersion.WithResource("pods")
+		if err := clientset.Tracker().Delete(podsResource, "", "a-123"); err != nil {
+			t.Fatal(err)
+		}
+		recreatedPod := newTestLogsPod("a-123", cfg.Services["a"], "a")
+		recreatedPod.UID = "2"
+		recreatedPod.Status.ContainerStatuses = []v1.ContainerStatus{
+			{Name: "a", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+		}
+		if err := clientset.Tracker().Add(recreatedPod); err != nil {
+			t.Fatal(err)
+		}
+		waitForActions(t, clientset, "get", "log", 2)
+		cancel()
+		if err := <-errChannel; err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "a | fake logs\na | fake logs\n" {
+			t.Errorf("%q", out.String())
+		}
+	})
+}
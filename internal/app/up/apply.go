@@ -0,0 +1,172 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultApplyTimeout is the default of Options.ApplyTimeout. It is generous, because admission webhooks can be slow.
+const DefaultApplyTimeout = 5 * time.Minute
+
+// applyContext returns the context of a single request of the apply phase (creating or updating a resource). The request is bounded by
+// Options.ApplyTimeout, if set.
+func (u *upRunner) applyContext() (context.Context, context.CancelFunc) {
+	if u.opts.ApplyTimeout <= 0 {
+		return context.WithCancel(u.opts.Context)
+	}
+	return context.WithTimeout(u.opts.Context, u.opts.ApplyTimeout)
+}
+
+// applyError distinguishes requests that timed out from other errors, so that a slow API server or admission webhook is not mistaken for a
+// service that does not become ready.
+func (u *upRunner) applyError(ctx context.Context, kind, name string, err error) error {
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("applying %s %s did not complete within the apply timeout of %s (a slow admission webhook can cause this, see "+
+			"--apply-timeout): %v", kind, name, u.opts.ApplyTimeout, err)
+	}
+	return err
+}
+
+// resourceClient is the subset of the typed clients of client-go that is needed to create or update a resource.
+type resourceClient[T metav1.Object] interface {
+	Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error)
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+}
+
+// createOrUpdate creates the resource obj if it does not exist. Otherwise, the existing resource must have been created by kube-compose
+// for this environment (see k8smeta.IsOwned), and it is updated (preserving its resourceVersion) or left untouched depending on
+// Options.Recreate. If prepareUpdate is not nil then it is called before updating, so that fields of the existing resource that are
+// allocated by the cluster can be copied to obj. Returns the resource as returned by the API server and the operation that was performed.
+func createOrUpdate[T metav1.Object](u *upRunner, client resourceClient[T], kind string, obj T,
+	prepareUpdate func(existing, obj T)) (T, string, error) {
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	name := obj.GetName()
+	existing, err := client.Get(ctx, name, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		var result T
+		result, err = client.Create(ctx, obj, u.createOptions())
+		return result, "created", u.applyError(ctx, kind, name, err)
+	}
+	if err != nil {
+		return existing, "", u.applyError(ctx, kind, name, err)
+	}
+	if !k8smeta.IsOwned(u.cfg, existing, obj) {
+		return existing, "", k8smeta.ErrorWrapResourcesModifiedExternally("%s %s already exists, but was not created by kube-compose for "+
+			"environment %s", kind, name, u.cfg.EnvironmentID)
+	}
+	if !u.shouldUpdateExisting() {
+		return existing, "left untouched", nil
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	if prepareUpdate != nil {
+		prepareUpdate(existing, obj)
+	}
+	result, err := client.Update(ctx, obj, u.updateOptions())
+	return result, "updated", u.applyError(ctx, kind, name, err)
+}
+
+// checkExistingIsOwned is called when obj cannot be created because a resource with its name already exists. Returns an error if the
+// existing resource was not created by kube-compose for this environment (see k8smeta.IsOwned), so that it is not mistaken for the
+// resource of the environment.
+func checkExistingIsOwned[T metav1.Object](u *upRunner, client resourceClient[T], kind string, obj T) error {
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	name := obj.GetName()
+	existing, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return u.applyError(ctx, kind, name, err)
+	}
+	if !k8smeta.IsOwned(u.cfg, existing, obj) {
+		return k8smeta.ErrorWrapResourcesModifiedExternally("%s %s already exists, but was not created by kube-compose for environment %s",
+			kind, name, u.cfg.EnvironmentID)
+	}
+	return nil
+}
+
+// prepareServiceUpdate copies the cluster IPs and node ports that the cluster has allocated for the existing service, because the cluster
+// IPs of a service cannot be changed and node ports would otherwise be reallocated.
+func prepareServiceUpdate(existing, service *v1.Service) {
+	if service.Spec.ClusterIP == "" {
+		service.Spec.ClusterIP = existing.Spec.ClusterIP
+		service.Spec.ClusterIPs = existing.Spec.ClusterIPs
+	}
+	for i := range service.Spec.Ports {
+		port := &service.Spec.Ports[i]
+		for _, existingPort := range existing.Spec.Ports {
+			if port.NodePort == 0 && existingPort.Port == port.Port && existingPort.Protocol == port.Protocol {
+				port.NodePort = existingPort.NodePort
+			}
+		}
+	}
+}
+
+// createOrUpdateService creates the service, or updates it if it already exists (see createOrUpdate). Returns the service as returned by
+// the API server and the operation that was performed.
+func (u *upRunner) createOrUpdateService(service *v1.Service) (*v1.Service, string, error) {
+	return createOrUpdate[*v1.Service](u, u.k8sServiceClient, "service", service, prepareServiceUpdate)
+}
+
+func (u *upRunner) createOrUpdateSecret(secret *v1.Secret) (string, error) {
+	_, op, err := createOrUpdate[*v1.Secret](u, u.k8sSecretClient, "secret", secret, nil)
+	return op, err
+}
+
+func (u *upRunner) createOrUpdateConfigMap(configMap *v1.ConfigMap) (string, error) {
+	_, op, err := createOrUpdate[*v1.ConfigMap](u, u.k8sConfigMapClient, "configmap", configMap, nil)
+	return op, err
+}
+
+func (u *upRunner) createOrUpdateStatefulSet(statefulSet *appsV1.StatefulSet) (string, error) {
+	_, op, err := createOrUpdate[*appsV1.StatefulSet](u, u.k8sStatefulSetClient, "statefulset", statefulSet, nil)
+	return op, err
+}
+
+func (u *upRunner) createOrUpdateDeployment(deployment *appsV1.Deployment) (string, error) {
+	_, op, err := createOrUpdate[*appsV1.Deployment](u, u.k8sDeploymentClient, "deployment", deployment, nil)
+	return op, err
+}
+
+func (u *upRunner) createOrUpdateNetworkPolicy(policy *networkingV1.NetworkPolicy) (string, error) {
+	_, op, err := createOrUpdate[*networkingV1.NetworkPolicy](u, u.k8sNetworkPolicyClient, "networkpolicy", policy, nil)
+	return op, err
+}
+
+// createPodResource creates the pod. Unlike the other resources, existing pods are not updated, because most fields of a pod are
+// immutable.
+func (u *upRunner) createPodResource(pod *v1.Pod) (*v1.Pod, error) {
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	result, err := u.k8sPodClient.Create(ctx, pod, u.createOptions())
+	if k8sError.IsAlreadyExists(err) {
+		return result, err
+	}
+	return result, u.applyError(ctx, "pod", pod.ObjectMeta.Name, err)
+}
+
+// waitTimeoutError returns the error reported when the apps did not become ready within Options.WaitTimeout.
+func (u *upRunner) waitTimeoutError() error {
+	var notReady []string
+	for app := range u.appsToBeStarted {
+		notReady = append(notReady, app.name()+" (waiting for dependencies)")
+	}
+	for app := range u.appsThatNeedToBeReady {
+		if app.maxObservedPodStatus < podStatusReady {
+			notReady = append(notReady, app.name()+" (not ready)")
+		}
+	}
+	sort.Strings(notReady)
+	return fmt.Errorf("services did not become ready within the timeout of %s (see --timeout): %s", u.opts.WaitTimeout,
+		strings.Join(notReady, ", "))
+}
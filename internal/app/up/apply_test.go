@@ -0,0 +1,170 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApplyError_Timeout(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			ApplyTimeout: time.Nanosecond,
+			Context:      context.Background(),
+		},
+	}
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	<-ctx.Done()
+	err := u.applyError(ctx, "service", "a", fmt.Errorf("context deadline exceeded"))
+	if err == nil || !strings.Contains(err.Error(), "apply timeout") {
+		t.Error(err)
+	}
+}
+
+func TestApplyError_NoTimeout(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fail()
+	}
+	errExpected := fmt.Errorf("forbidden")
+	if err := u.applyError(ctx, "service", "a", errExpected); err != errExpected {
+		t.Error(err)
+	}
+}
+
+func TestWaitTimeoutError_ListsApps(t *testing.T) {
+	a := newTestApp("a")
+	b := newTestApp("b")
+	b.maxObservedPodStatus = podStatusReady
+	c := newTestApp("c")
+	u := &upRunner{
+		appsToBeStarted: map[*app]bool{
+			a: true,
+		},
+		appsThatNeedToBeReady: map[*app]bool{
+			b: true,
+			c: true,
+		},
+		opts: &Options{
+			WaitTimeout: time.Minute,
+		},
+	}
+	err := u.waitTimeoutError()
+	if err == nil || !strings.HasSuffix(err.Error(), ": a (waiting for dependencies), c (not ready)") {
+		t.Error(err)
+	}
+}
+
+func newTestCreateOrUpdateRunner(recreate string) (*upRunner, *fake.Clientset) {
+	cfg := newTestConfig()
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	clientset := fake.NewSimpleClientset()
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			Context:  context.Background(),
+			Recreate: recreate,
+		},
+		k8sServiceClient: clientset.CoreV1().Services(""),
+	}
+	return u, clientset
+}
+
+func newTestOwnedService(u *upRunner) *v1.Service {
+	service := &v1.Service{}
+	k8smeta.InitObjectMeta(u.cfg, &service.ObjectMeta, u.cfg.Services["a"])
+	return service
+}
+
+func TestCreateOrUpdateService_Created(t *testing.T) {
+	u, _ := newTestCreateOrUpdateRunner(RecreateChanged)
+	_, op, err := u.createOrUpdateService(newTestOwnedService(u))
+	if err != nil || op != "created" {
+		t.Error(op, err)
+	}
+}
+
+func TestCreateOrUpdateService_UpdatedIfOwned(t *testing.T) {
+	u, clientset := newTestCreateOrUpdateRunner(RecreateChanged)
+	existing := newTestOwnedService(u)
+	existing.ObjectMeta.ResourceVersion = "7"
+	existing.Spec.ClusterIP = "10.0.0.1"
+	_ = clientset.Tracker().Add(existing)
+	service := newTestOwnedService(u)
+	result, op, err := u.createOrUpdateService(service)
+	if err != nil || op != "updated" {
+		t.Fatal(op, err)
+	}
+	if service.ObjectMeta.ResourceVersion != "7" || result.Spec.ClusterIP != "10.0.0.1" {
+		t.Error(service.ObjectMeta.ResourceVersion, result.Spec.ClusterIP)
+	}
+}
+
+func TestCreateOrUpdateService_LeftUntouched(t *testing.T) {
+	u, clientset := newTestCreateOrUpdateRunner(RecreateNever)
+	_ = clientset.Tracker().Add(newTestOwnedService(u))
+	_, op, err := u.createOrUpdateService(newTestOwnedService(u))
+	if err != nil || op != "left untouched" {
+		t.Error(op, err)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() != "get" {
+			t.Error(action)
+		}
+	}
+}
+
+func TestCreateOrUpdateService_NotOwnedError(t *testing.T) {
+	u, clientset := newTestCreateOrUpdateRunner(RecreateChanged)
+	existing := newTestOwnedService(u)
+	// A resource of another environment (or not created by kube-compose at all) with the same name.
+	existing.ObjectMeta.Labels = map[string]string{"env": "456"}
+	_ = clientset.Tracker().Add(existing)
+	_, _, err := u.createOrUpdateService(newTestOwnedService(u))
+	if err == nil || !strings.Contains(err.Error(), k8smeta.ErrorResourcesModifiedExternally().Error()) {
+		t.Error(err)
+	}
+}
+
+func TestPrepareServiceUpdate_PreservesAllocatedFields(t *testing.T) {
+	existing := &v1.Service{
+		Spec: v1.ServiceSpec{
+			ClusterIP:  "10.0.0.1",
+			ClusterIPs: []string{"10.0.0.1"},
+			Ports: []v1.ServicePort{
+				{Port: 80, Protocol: v1.ProtocolTCP, NodePort: 30080},
+			},
+		},
+	}
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "a"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Port: 80, Protocol: v1.ProtocolTCP},
+				{Port: 443, Protocol: v1.ProtocolTCP},
+			},
+		},
+	}
+	prepareServiceUpdate(existing, service)
+	if service.Spec.ClusterIP != "10.0.0.1" || len(service.Spec.ClusterIPs) != 1 {
+		t.Error(service.Spec)
+	}
+	if service.Spec.Ports[0].NodePort != 30080 || service.Spec.Ports[1].NodePort != 0 {
+		t.Error(service.Spec.Ports)
+	}
+}
@@ -0,0 +1,135 @@
+package up
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ApplyOrderDeps creates the workload of each app once the depends_on conditions of the app are satisfied (the default).
+	ApplyOrderDeps = "deps"
+	// ApplyOrderKind creates resources grouped by kind, ignoring depends_on: first the Services of all apps, then their workloads.
+	ApplyOrderKind = "kind"
+	// ApplyOrderManifest creates the resources of each app in the order in which the apps are declared in the docker compose files,
+	// ignoring depends_on.
+	ApplyOrderManifest = "manifest"
+)
+
+// appsInManifestOrder returns the apps to be started in the order in which their docker compose services are declared. Apps whose
+// declaration order is unknown are appended, sorted by name.
+func (u *upRunner) appsInManifestOrder() []*app {
+	var result []*app
+	added := map[*app]bool{}
+	for _, name := range u.cfg.ServiceOrder {
+		if app := u.apps[name]; app != nil && u.appsToBeStarted[app] {
+			added[app] = true
+			result = append(result, app)
+		}
+	}
+	var remaining []*app
+	for app := range u.appsToBeStarted {
+		if !added[app] {
+			remaining = append(remaining, app)
+		}
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].name() < remaining[j].name()
+	})
+	return append(result, remaining...)
+}
+
+// appsInNameOrder returns the apps to be started sorted by name.
+func (u *upRunner) appsInNameOrder() []*app {
+	var result []*app
+	for app := range u.appsToBeStarted {
+		result = append(result, app)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name() < result[j].name()
+	})
+	return result
+}
+
+// createServiceAndAddHostAlias creates (or updates) the Service of an app, and returns hostAliases with a host alias of the cluster IP of the
+// Service appended (unless host aliases are skipped or the Service is headless).
+func (u *upRunner) createServiceAndAddHostAlias(app *app, hostAliases []v1.HostAlias) ([]v1.HostAlias, error) {
+	service := u.newService(app)
+	result, op, err := u.createOrUpdateService(service)
+	if err != nil {
+		return nil, err
+	}
+	app.newLogEntry().Debugf("%s k8s service %s", op, service.ObjectMeta.Name)
+	if result != nil && result.Spec.ClusterIP != "" && result.Spec.ClusterIP != v1.ClusterIPNone && !u.opts.SkipHostAliases {
+		hostAliases = append(hostAliases, v1.HostAlias{
+			IP:        result.Spec.ClusterIP,
+			Hostnames: app.hostnames(),
+		})
+	}
+	return hostAliases, nil
+}
+
+// runApplyOrdered creates the resources of all apps to be started according to Options.ApplyOrder, without waiting for depends_on
+// conditions. Secrets, ConfigMaps and network policies have already been created at this point. Host aliases are taken from the cluster
+// IPs returned by the API server when Services are created, so pods only get host aliases of Services that were created before them (and
+// of external services).
+func (u *upRunner) runApplyOrdered() error {
+	hostAliases := []v1.HostAlias{}
+	if !u.opts.SkipHostAliases {
+		hostAliases = append(hostAliases, u.getExternalHostAliases()...)
+	}
+	if u.opts.ApplyOrder == ApplyOrderKind {
+		apps := u.appsInNameOrder()
+		for _, app := range apps {
+			err := u.createHeadlessNameServices(app)
+			if err != nil {
+				return err
+			}
+			if !app.hasService() {
+				continue
+			}
+			hostAliases, err = u.createServiceAndAddHostAlias(app, hostAliases)
+			if err != nil {
+				return err
+			}
+		}
+		err := u.createExternalNameServices()
+		if err != nil {
+			return err
+		}
+		err = u.createExternalLinkServices()
+		if err != nil {
+			return err
+		}
+		for _, app := range apps {
+			err = u.createWorkload(app, hostAliases)
+			if err != nil {
+				return err
+			}
+			delete(u.appsToBeStarted, app)
+		}
+		return nil
+	}
+	for _, app := range u.appsInManifestOrder() {
+		err := u.createHeadlessNameServices(app)
+		if err != nil {
+			return err
+		}
+		if app.hasService() {
+			hostAliases, err = u.createServiceAndAddHostAlias(app, hostAliases)
+			if err != nil {
+				return err
+			}
+		}
+		err = u.createWorkload(app, hostAliases)
+		if err != nil {
+			return err
+		}
+		delete(u.appsToBeStarted, app)
+	}
+	err := u.createExternalNameServices()
+	if err != nil {
+		return err
+	}
+	return u.createExternalLinkServices()
+}
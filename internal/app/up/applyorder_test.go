@@ -0,0 +1,153 @@
+package up
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func newTestApplyOrderRunner(applyOrder string) (*upRunner, *fake.Clientset) {
+	cfg := newTestConfig()
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	cfg.ServiceOrder = []string{"d", "b", "a"}
+	for _, name := range []string{"a", "b"} {
+		cfg.Services[name].Ports = []config.Port{
+			{Port: 80, Protocol: "tcp"},
+		}
+	}
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			ApplyOrder: applyOrder,
+			Context:    context.Background(),
+		},
+	}
+	u.initApps()
+	// Skip creating a pull secret, the apps have no image.
+	u.secretsDeployed[""] = true
+	u.appsToBeStarted = map[*app]bool{}
+	for _, a := range u.apps {
+		u.appsToBeStarted[a] = true
+		// Skip resolving images.
+		a.imageInfo.once.Do(func() {})
+	}
+	clientset := fake.NewSimpleClientset()
+	u.k8sPodClient = clientset.CoreV1().Pods("")
+	u.k8sServiceClient = clientset.CoreV1().Services("")
+	u.k8sJobClient = clientset.BatchV1().Jobs("")
+	return u, clientset
+}
+
+// createdNames returns the resource and name of each create action, in order.
+func createdNames(clientset *fake.Clientset) []string {
+	var result []string
+	for _, action := range clientset.Actions() {
+		if createAction, ok := action.(k8sTesting.CreateAction); ok {
+			obj := createAction.GetObject()
+			name := ""
+			if accessor, ok := obj.(interface{ GetName() string }); ok {
+				name = accessor.GetName()
+			}
+			result = append(result, action.GetResource().Resource+"/"+name)
+		}
+	}
+	return result
+}
+
+func assertStrings(t *testing.T, actual, expected []string) {
+	if len(actual) != len(expected) {
+		t.Fatal(actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatal(actual)
+		}
+	}
+}
+
+func TestAppsInManifestOrder(t *testing.T) {
+	u, _ := newTestApplyOrderRunner(ApplyOrderManifest)
+	var names []string
+	for _, a := range u.appsInManifestOrder() {
+		names = append(names, a.name())
+	}
+	// c is not in ServiceOrder, so it is appended.
+	assertStrings(t, names, []string{"d", "b", "a", "c"})
+}
+
+func TestRunApplyOrdered_Kind(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderKind)
+	err := u.runApplyOrdered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, createdNames(clientset), []string{
+		"services/a-123", "services/b-123", "pods/a-123", "pods/b-123", "jobs/c-123", "pods/d-123",
+	})
+	if len(u.appsToBeStarted) != 0 || len(u.appsThatNeedToBeReady) != 4 {
+		t.Error(u.appsToBeStarted, u.appsThatNeedToBeReady)
+	}
+}
+
+func TestRunApplyOrdered_KindHostAliases(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderKind)
+	// The fake clientset does not allocate cluster IPs.
+	clientset.PrependReactor("create", "services", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		service := action.(k8sTesting.CreateAction).GetObject().(*v1.Service)
+		service.Spec.ClusterIP = "10.0.0.1"
+		return false, nil, nil
+	})
+	err := u.runApplyOrdered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := clientset.CoreV1().Pods("").Get(context.Background(), "d-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Services are created before workloads, so pods get host aliases of the Services of all apps.
+	if len(pod.Spec.HostAliases) != 2 {
+		t.Error(pod.Spec.HostAliases)
+	}
+}
+
+func TestRunApplyOrdered_Manifest(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderManifest)
+	err := u.runApplyOrdered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, createdNames(clientset), []string{
+		"pods/d-123", "services/b-123", "pods/b-123", "services/a-123", "pods/a-123", "jobs/c-123",
+	})
+}
+
+func TestRunApplyOrdered_ExposeOnlyHasNoService(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderManifest)
+	u.apps["d"].composeService.DockerComposeService.Expose = []dockerComposeConfig.PortBinding{
+		{Internal: 9000, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+	}
+	err := u.runApplyOrdered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, createdNames(clientset), []string{
+		"pods/d-123", "services/b-123", "pods/b-123", "services/a-123", "pods/a-123", "jobs/c-123",
+	})
+	pod, err := clientset.CoreV1().Pods("").Get(context.Background(), "d-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerPorts := pod.Spec.Containers[0].Ports
+	if len(containerPorts) != 1 || containerPorts[0].ContainerPort != 9000 {
+		t.Error(containerPorts)
+	}
+}
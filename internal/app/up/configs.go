@@ -0,0 +1,189 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The key of the value of a docker compose config in the data of its ConfigMap.
+const configDataKey = "value"
+
+type appConfig struct {
+	// True if the ConfigMap is managed outside of the docker compose project.
+	external bool
+	// The key of the value of the config in the data of the ConfigMap. Set by createConfigs for external configs.
+	key string
+	// The name of the ConfigMap.
+	k8sName string
+	mode    *int32
+	target  string
+}
+
+func (u *upRunner) getConfigK8sName(cfg *dockerComposeConfig.ComposeConfig) string {
+	if cfg.External {
+		return cfg.ExternalName
+	}
+	return k8smeta.GetK8sNameFromEscapedName(u.cfg, util.EscapeName(cfg.Name)+"-config")
+}
+
+// readConfigValue reads the value of a config with a content, environment or file source.
+func readConfigValue(cfg *dockerComposeConfig.ComposeConfig) ([]byte, error) {
+	switch {
+	case cfg.File != "":
+		value, err := secretFileReader(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the value of config %s: %v", cfg.Name, err)
+		}
+		return value, nil
+	case cfg.Environment != "":
+		value, ok := secretEnvGetter(cfg.Environment)
+		if !ok {
+			return nil, fmt.Errorf("the value of config %s is read from the environment variable %s, but that variable is not set",
+				cfg.Name, cfg.Environment)
+		}
+		return []byte(value), nil
+	}
+	return []byte(cfg.Content), nil
+}
+
+// initConfigs resolves the configs of the apps to be started, and reads the values of the configs that are not external (see initSecrets).
+// Kubernetes cannot set the owner of the files of a ConfigMap volume, so the uid and gid of configs are ignored with a warning.
+func (u *upRunner) initConfigs() error {
+	u.configValues = map[string][]byte{}
+	u.externalConfigKeys = map[string]string{}
+	for app := range u.appsToBeStarted {
+		for _, serviceConfig := range app.composeService.DockerComposeService.Configs {
+			cfg := u.cfg.Configs[serviceConfig.Source]
+			k8sName := u.getConfigK8sName(cfg)
+			if cfg.External {
+				u.externalConfigKeys[k8sName] = ""
+			} else if _, ok := u.configValues[k8sName]; !ok {
+				value, err := readConfigValue(cfg)
+				if err != nil {
+					return err
+				}
+				u.configValues[k8sName] = value
+			}
+			if serviceConfig.UID != nil || serviceConfig.GID != nil {
+				log.Warnf("ignoring the uid and gid of config %s of docker compose service %s, because Kubernetes cannot set the owner of "+
+					"the files of a ConfigMap", cfg.Name, app.name())
+			}
+			app.configs = append(app.configs, &appConfig{
+				external: cfg.External,
+				key:      configDataKey,
+				k8sName:  k8sName,
+				mode:     serviceConfig.Mode,
+				target:   serviceConfig.Target,
+			})
+		}
+	}
+	return nil
+}
+
+// resolveExternalConfig verifies that an external ConfigMap exists, and returns the key of its value (see resolveExternalKey).
+func (u *upRunner) resolveExternalConfig(name string) (string, error) {
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	configMap, err := u.k8sConfigMapClient.Get(ctx, name, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return "", fmt.Errorf("the external config %s does not exist", name)
+	} else if err != nil {
+		return "", err
+	}
+	var keys []string
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	for key := range configMap.BinaryData {
+		keys = append(keys, key)
+	}
+	return resolveExternalKey("config", name, keys)
+}
+
+// createConfigs creates (or updates) the ConfigMaps of the docker compose configs of the apps to be started. Like Secrets, these ConfigMaps
+// are shared by apps. The ConfigMaps of external configs are not created, but are resolved (see resolveExternalConfig).
+func (u *upRunner) createConfigs() error {
+	for name := range u.externalConfigKeys {
+		key, err := u.resolveExternalConfig(name)
+		if err != nil {
+			return err
+		}
+		u.externalConfigKeys[name] = key
+	}
+	for app := range u.appsToBeStarted {
+		for _, cfg := range app.configs {
+			if cfg.external {
+				cfg.key = u.externalConfigKeys[cfg.k8sName]
+			}
+		}
+	}
+	for _, name := range u.configNames() {
+		configMap := u.newConfigMap(name)
+		op, err := u.createOrUpdateConfigMap(configMap)
+		if err != nil {
+			return err
+		}
+		log.Debugf("%s configmap %s", op, name)
+	}
+	return nil
+}
+
+// configNames returns the names of the ConfigMaps of the docker compose configs that are not external, sorted by name.
+func (u *upRunner) configNames() []string {
+	var names []string
+	for name := range u.configValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newConfigMap builds the ConfigMap with the given name of a docker compose config, see initConfigs. Values that are not valid UTF-8 are
+// stored as binary data.
+func (u *upRunner) newConfigMap(name string) *v1.ConfigMap {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: k8smeta.EnvironmentLabels(u.cfg),
+		},
+	}
+	value := u.configValues[name]
+	if utf8.Valid(value) {
+		configMap.Data = map[string]string{
+			configDataKey: string(value),
+		}
+	} else {
+		configMap.BinaryData = map[string][]byte{
+			configDataKey: value,
+		}
+	}
+	k8smeta.InitOwnerReferences(u.cfg, &configMap.ObjectMeta)
+	return configMap
+}
+
+// createPodConfigVolumes mounts each config of an app as a read-only file at the target of the config, with the mode of the config (if
+// set).
+func (a *app) createPodConfigVolumes(pod *v1.Pod) {
+	for i, cfg := range a.configs {
+		addPodFileVolume(pod, v1.Volume{
+			Name: fmt.Sprintf("config%d", i+1),
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: cfg.k8sName,
+					},
+					Items: newFileVolumeItems(cfg.key, cfg.mode),
+				},
+			},
+		}, cfg.target)
+	}
+}
@@ -0,0 +1,202 @@
+package up
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestConfigsRunner() *upRunner {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "123"
+	cfg.Configs = map[string]*dockerComposeConfig.ComposeConfig{
+		"app_conf": {
+			Content: "debug=true\n",
+			Name:    "app_conf",
+		},
+	}
+	cfg.Services["a"].DockerComposeService.Configs = []dockerComposeConfig.ServiceConfig{
+		{
+			Mode:   util.NewInt32(0440),
+			Source: "app_conf",
+			Target: "/etc/app/app.conf",
+		},
+	}
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{
+		u.apps["a"]: true,
+	}
+	return u
+}
+
+func TestInitConfigs_ContentSuccess(t *testing.T) {
+	u := newTestConfigsRunner()
+	err := u.initConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configMap := u.newConfigMap("app9cxconf-config-123")
+	if configMap.Data[configDataKey] != "debug=true\n" || configMap.Labels[u.cfg.EnvironmentLabel] != "123" {
+		t.Error(configMap)
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	u.apps["a"].createPodConfigVolumes(pod)
+	if len(pod.Spec.Volumes) != 1 || pod.Spec.Volumes[0].ConfigMap.Name != "app9cxconf-config-123" {
+		t.Fatal(pod.Spec.Volumes)
+	}
+	items := pod.Spec.Volumes[0].ConfigMap.Items
+	if len(items) != 1 || items[0].Key != configDataKey || items[0].Mode == nil || *items[0].Mode != 0440 {
+		t.Error(items)
+	}
+	volumeMounts := pod.Spec.Containers[0].VolumeMounts
+	if len(volumeMounts) != 1 || volumeMounts[0].MountPath != "/etc/app/app.conf" || !volumeMounts[0].ReadOnly {
+		t.Error(volumeMounts)
+	}
+}
+
+func TestInitConfigs_FileSuccess(t *testing.T) {
+	u := newTestConfigsRunner()
+	u.cfg.Configs["app_conf"] = &dockerComposeConfig.ComposeConfig{
+		File: "/project/app.conf",
+		Name: "app_conf",
+	}
+	withMockedSecretFiles(map[string]string{
+		"/project/app.conf": "debug=false\n",
+	}, func() {
+		err := u.initConfigs()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	if string(u.configValues["app9cxconf-config-123"]) != "debug=false\n" {
+		t.Error(u.configValues)
+	}
+}
+
+func TestInitConfigs_EnvVarNotSetError(t *testing.T) {
+	u := newTestConfigsRunner()
+	u.cfg.Configs["app_conf"] = &dockerComposeConfig.ComposeConfig{
+		Environment: "APP_CONF",
+		Name:        "app_conf",
+	}
+	withMockedSecretEnv(map[string]string{}, func() {
+		err := u.initConfigs()
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestNewConfigMap_BinaryData(t *testing.T) {
+	u := newTestConfigsRunner()
+	u.configValues = map[string][]byte{
+		"app9cxconf-config-123": {0xff, 0xfe},
+	}
+	configMap := u.newConfigMap("app9cxconf-config-123")
+	if len(configMap.Data) != 0 || len(configMap.BinaryData[configDataKey]) != 2 {
+		t.Error(configMap)
+	}
+}
+
+func newTestExternalConfigRunner(objects ...*v1.ConfigMap) *upRunner {
+	u := newTestConfigsRunner()
+	u.opts.Context = context.Background()
+	u.cfg.Configs["app_conf"] = &dockerComposeConfig.ComposeConfig{
+		External:     true,
+		ExternalName: "prod-app-conf",
+		Name:         "app_conf",
+	}
+	clientset := fake.NewSimpleClientset()
+	for _, object := range objects {
+		_ = clientset.Tracker().Add(object)
+	}
+	u.k8sConfigMapClient = clientset.CoreV1().ConfigMaps("")
+	return u
+}
+
+func TestCreateConfigs_ExternalSuccess(t *testing.T) {
+	u := newTestExternalConfigRunner(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "prod-app-conf",
+		},
+		Data: map[string]string{
+			"app.conf": "debug=true\n",
+		},
+	})
+	err := u.initConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(u.configValues) != 0 {
+		t.Error(u.configValues)
+	}
+	err = u.createConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	u.apps["a"].createPodConfigVolumes(pod)
+	if len(pod.Spec.Volumes) != 1 || pod.Spec.Volumes[0].ConfigMap.Name != "prod-app-conf" {
+		t.Fatal(pod.Spec.Volumes)
+	}
+	items := pod.Spec.Volumes[0].ConfigMap.Items
+	if len(items) != 1 || items[0].Key != "app.conf" {
+		t.Error(items)
+	}
+}
+
+func TestCreateConfigs_ExternalNotFoundError(t *testing.T) {
+	u := newTestExternalConfigRunner()
+	err := u.initConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = u.createConfigs()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestCreateConfigs_CreatesConfigMap(t *testing.T) {
+	u := newTestExternalConfigRunner()
+	u.cfg.Configs["app_conf"] = &dockerComposeConfig.ComposeConfig{
+		Content: "debug=true\n",
+		Name:    "app_conf",
+	}
+	err := u.initConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = u.createConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configMap, err := u.k8sConfigMapClient.Get(context.Background(), "app9cxconf-config-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configMap.Data[configDataKey] != "debug=true\n" {
+		t.Error(configMap.Data)
+	}
+}
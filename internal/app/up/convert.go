@@ -0,0 +1,209 @@
+package up
+
+import (
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	gvkConfigMap     = v1.SchemeGroupVersion.WithKind("ConfigMap")
+	gvkPod           = v1.SchemeGroupVersion.WithKind("Pod")
+	gvkSecret        = v1.SchemeGroupVersion.WithKind("Secret")
+	gvkService       = v1.SchemeGroupVersion.WithKind("Service")
+	gvkStatefulSet   = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}
+	gvkDeployment    = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	gvkJob           = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+	gvkNetworkPolicy = schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}
+)
+
+type converter struct {
+	u       *upRunner
+	objects []runtime.Object
+	// The registries whose pull secret has been added.
+	pullSecrets map[string]bool
+}
+
+func (c *converter) add(obj runtime.Object, gvk schema.GroupVersionKind) {
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	c.objects = append(c.objects, obj)
+}
+
+// redactSecret replaces the values of secret by placeholder, unless Options.IncludeSecretValues is set, because the resources returned by
+// Convert are typically written to files. The keys are kept, so that the Secret can still be mounted once its values are filled in.
+func (c *converter) redactSecret(secret *v1.Secret, placeholder []byte) *v1.Secret {
+	if c.u.opts.IncludeSecretValues {
+		return secret
+	}
+	for key := range secret.Data {
+		secret.Data[key] = placeholder
+	}
+	return secret
+}
+
+// initApps marks each app that matches the filter as to be started, and resolves its image without the docker daemon: the image of the
+// docker compose service is used as is.
+func (c *converter) initApps() {
+	u := c.u
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{}
+	for _, a := range u.apps {
+		if !u.cfg.MatchesFilter(a.composeService) {
+			continue
+		}
+		u.appsToBeStarted[a] = true
+		a.imageInfo.podImage = a.composeService.DockerComposeService.Image
+		a.imageInfo.podImagePullPolicy = imagePullPolicyForImage(a.imageInfo.podImage)
+		u.initAppUserWithoutImage(a)
+		a.imageInfo.once.Do(func() {})
+		if a.composeService.Stateful {
+			initVolumeClaims(a)
+		} else if len(a.composeService.DockerComposeService.Volumes) > 0 {
+			a.newLogEntry().Warn("ignoring volumes: bind mounted volumes require building an image, which convert does not do")
+		}
+	}
+}
+
+func (c *converter) addServices(apps []*app) {
+	u := c.u
+	for _, app := range apps {
+		if app.hasService() {
+			c.add(u.newService(app), gvkService)
+		}
+		for _, service := range u.newHeadlessNameServices(app) {
+			c.add(service, gvkService)
+		}
+	}
+	for _, name := range u.externalServiceNames() {
+		externalService := u.opts.External[name]
+		if !externalService.isIP() {
+			c.add(u.newExternalNameService(u.cfg.Services[name], externalService), gvkService)
+		}
+	}
+	for _, app := range apps {
+		for _, externalLink := range app.externalLinks {
+			if !externalLink.externalService.isIP() {
+				c.add(u.newExternalLinkService(app, externalLink), gvkService)
+			}
+		}
+	}
+}
+
+// addPodPullSecrets adds the pull secret of the registry of the app's image once, if needed (see getAppPullSecretCredentials), and adds
+// it to the image pull secrets of the pod.
+func (c *converter) addPodPullSecrets(app *app, pod *v1.Pod) error {
+	u := c.u
+	registryHost, user, pass, ok := u.getAppPullSecretCredentials(app)
+	if !ok {
+		return nil
+	}
+	if !c.pullSecrets[registryHost] {
+		secret, err := u.newPullSecret(registryHost, user, pass)
+		if err != nil {
+			return err
+		}
+		c.add(c.redactSecret(secret, []byte(`{"auths":{}}`)), gvkSecret)
+		c.pullSecrets[registryHost] = true
+	}
+	pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: u.pullSecretNameForRegistry(registryHost)})
+	return nil
+}
+
+func (c *converter) addWorkloads(apps []*app) error {
+	u := c.u
+	var hostAliases []v1.HostAlias
+	if !u.opts.SkipHostAliases {
+		hostAliases = u.getExternalHostAliases()
+	}
+	for _, app := range apps {
+		pod, err := u.newPod(app, hostAliases)
+		if err != nil {
+			return err
+		}
+		err = c.addPodPullSecrets(app, pod)
+		if err != nil {
+			return err
+		}
+		if app.composeService.Stateful {
+			c.add(u.newHeadlessService(app), gvkService)
+			c.add(u.newStatefulSet(app, pod), gvkStatefulSet)
+		} else if app.isJob() {
+			job, err := u.newJob(app, pod)
+			if err != nil {
+				return err
+			}
+			c.add(job, gvkJob)
+		} else if u.isDeployment(app) {
+			c.add(u.newDeployment(app, pod), gvkDeployment)
+		} else {
+			for _, replicaPod := range app.newReplicaPods(pod) {
+				c.add(replicaPod, gvkPod)
+			}
+		}
+	}
+	return nil
+}
+
+// Convert translates the docker compose services that match the filter of cfg to the Kubernetes resources that up would create, without
+// talking to the cluster or the docker daemon. Consequently, images are not pushed to the cluster's image storage (the image of each
+// docker compose service is used as is), bind mounted volumes are ignored and pods have no host aliases of
+// the cluster IPs of Services (other services are still reachable by the names of their Services, if the environment ID is not appended).
+// The values of Secrets are only included if Options.IncludeSecretValues is set. The resources are returned in the order in which they
+// can be applied.
+func Convert(cfg *config.Config, opts *Options) ([]runtime.Object, error) {
+	c := &converter{
+		u: &upRunner{
+			cfg:  cfg,
+			opts: opts,
+		},
+		pullSecrets: map[string]bool{},
+	}
+	u := c.u
+	c.initApps()
+	err := u.resolveExternalLinks()
+	if err != nil {
+		return nil, err
+	}
+	err = u.initSecrets()
+	if err != nil {
+		return nil, err
+	}
+	if len(u.externalSecretKeys) > 0 {
+		log.Warnf("the keys of external secrets cannot be resolved without the cluster, assuming key %#v", secretDataKey)
+	}
+	var secretNames []string
+	for name := range u.secretValues {
+		secretNames = append(secretNames, name)
+	}
+	sort.Strings(secretNames)
+	for _, name := range secretNames {
+		c.add(c.redactSecret(u.newSecret(name), []byte{}), gvkSecret)
+	}
+	err = u.initConfigs()
+	if err != nil {
+		return nil, err
+	}
+	if len(u.externalConfigKeys) > 0 {
+		log.Warnf("the keys of external configs cannot be resolved without the cluster, assuming key %#v", configDataKey)
+	}
+	for _, name := range u.configNames() {
+		c.add(u.newConfigMap(name), gvkConfigMap)
+	}
+	apps := u.appsInDependencyOrder()
+	if u.opts.DefaultDenyIngress {
+		c.add(u.newDefaultDenyIngressPolicy(), gvkNetworkPolicy)
+		for _, app := range apps {
+			c.add(u.newAllowIngressPolicy(app), gvkNetworkPolicy)
+		}
+	}
+	c.addServices(apps)
+	err = c.addWorkloads(apps)
+	if err != nil {
+		return nil, err
+	}
+	return c.objects, nil
+}
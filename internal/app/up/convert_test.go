@@ -0,0 +1,235 @@
+package up
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+func newTestConvertConfig() *config.Config {
+	cfg := newTestConfig()
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	cfg.Services["a"].DockerComposeService.Image = "ubuntu:latest"
+	cfg.Services["a"].Ports = []config.Port{
+		{Port: 80, Protocol: "tcp"},
+	}
+	cfg.Services["b"].Stateful = true
+	for _, service := range cfg.Services {
+		cfg.AddToFilter(service)
+	}
+	return cfg
+}
+
+func TestConvert_Success(t *testing.T) {
+	cfg := newTestConvertConfig()
+	cfg.Secrets = map[string]*dockerComposeConfig.Secret{
+		"db_password": {
+			Environment: "DB_PASSWORD",
+			Name:        "db_password",
+		},
+	}
+	cfg.Services["a"].DockerComposeService.Secrets = []dockerComposeConfig.ServiceSecret{
+		{
+			Source: "db_password",
+			Target: "/run/secrets/db_password",
+		},
+	}
+	var objects []string
+	withMockedSecretEnv(map[string]string{
+		"DB_PASSWORD": "hunter2",
+	}, func() {
+		result, err := Convert(cfg, &Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, obj := range result {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			objects = append(objects, obj.GetObjectKind().GroupVersionKind().Kind+"/"+accessor.GetName())
+			switch typed := obj.(type) {
+			case *v1.Secret:
+				// The values of secrets are only included with Options.IncludeSecretValues.
+				if value, ok := typed.Data[secretDataKey]; !ok || len(value) != 0 {
+					t.Error(typed.Data)
+				}
+			case *v1.Pod:
+				if typed.ObjectMeta.Name == "a-123" && typed.Spec.Containers[0].Image != "ubuntu:latest" {
+					t.Error(typed.Spec.Containers[0].Image)
+				}
+				if len(typed.Spec.ImagePullSecrets) != 0 {
+					t.Error(typed.Spec.ImagePullSecrets)
+				}
+			case *appsV1.StatefulSet:
+				if typed.APIVersion != "apps/v1" {
+					t.Error(typed.TypeMeta)
+				}
+			}
+		}
+	})
+	// Apps are converted in dependency order: c and d before a, and b last.
+	assertStrings(t, objects, []string{
+		"Secret/db9cxpassword-secret-123",
+		"Service/a-123",
+		"Job/c-123",
+		"Pod/d-123",
+		"Pod/a-123",
+		"Service/b-123-headless",
+		"StatefulSet/b-123",
+	})
+}
+
+func TestConvert_RunAsUser(t *testing.T) {
+	cfg := newTestConvertConfig()
+	cfg.Services["a"].DockerComposeService.User = util.NewString("1000:1001")
+	cfg.Services["d"].DockerComposeService.User = util.NewString("postgres")
+	objects, err := Convert(cfg, &Options{RunAsUser: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range objects {
+		pod, ok := obj.(*v1.Pod)
+		if !ok {
+			continue
+		}
+		securityContext := pod.Spec.Containers[0].SecurityContext
+		switch pod.ObjectMeta.Name {
+		case "a-123":
+			if securityContext == nil || *securityContext.RunAsUser != 1000 || *securityContext.RunAsGroup != 1001 {
+				t.Error(securityContext)
+			}
+		case "d-123":
+			// The user is not numeric, and convert does not inspect the image.
+			if securityContext != nil && securityContext.RunAsUser != nil {
+				t.Error(securityContext)
+			}
+		}
+	}
+}
+
+func TestConvert_ExternalService(t *testing.T) {
+	cfg := newTestConvertConfig()
+	cfg.Skip(cfg.Services["d"])
+	objects, err := Convert(cfg, &Options{
+		External: map[string]ExternalService{
+			"d": {Host: "d.example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, obj := range objects {
+		if service, ok := obj.(*v1.Service); ok && service.Spec.Type == v1.ServiceTypeExternalName {
+			found = service.Spec.ExternalName == "d.example.com"
+		}
+	}
+	if !found {
+		t.Error(objects)
+	}
+}
+
+func TestConvert_NoService(t *testing.T) {
+	cfg := newTestConvertConfig()
+	cfg.Services["a"].NoService = true
+	objects, err := Convert(cfg, &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range objects {
+		// The headless Service of the stateful app b is still needed to govern its StatefulSet.
+		if service, ok := obj.(*v1.Service); ok && service.ObjectMeta.Name != "b-123-headless" {
+			t.Error(service.ObjectMeta.Name)
+		}
+	}
+}
+
+func TestConvert_PullSecrets(t *testing.T) {
+	cfg := newTestConvertConfig()
+	cfg.Services["a"].DockerComposeService.Image = "registry.example.com/a:latest"
+	cfg.Services["d"].DockerComposeService.Image = "registry.example.com/d:latest"
+	objects, err := Convert(cfg, &Options{
+		RegistryUser: "user",
+		RegistryPass: "pass",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pullSecrets []string
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *v1.Secret:
+			if obj.Type == v1.SecretTypeDockerConfigJson {
+				pullSecrets = append(pullSecrets, obj.ObjectMeta.Name)
+				if string(obj.Data[v1.DockerConfigJsonKey]) != `{"auths":{}}` {
+					t.Error(string(obj.Data[v1.DockerConfigJsonKey]))
+				}
+			}
+		case *v1.Pod:
+			if obj.ObjectMeta.Name == "a-123" || obj.ObjectMeta.Name == "d-123" {
+				if len(obj.Spec.ImagePullSecrets) != 1 || obj.Spec.ImagePullSecrets[0].Name != "pull-secret-registry9bkexample9bkcom-123" {
+					t.Error(obj.ObjectMeta.Name, obj.Spec.ImagePullSecrets)
+				}
+			}
+		}
+	}
+	// The pull secret of a registry is added once, before the workloads that use it.
+	if len(pullSecrets) != 1 {
+		t.Error(pullSecrets)
+	}
+}
+
+func TestConvert_IncludeSecretValues(t *testing.T) {
+	cfg := newTestConvertConfig()
+	cfg.Services["a"].DockerComposeService.Image = "registry.example.com/a:latest"
+	cfg.Secrets = map[string]*dockerComposeConfig.Secret{
+		"db_password": {
+			Environment: "DB_PASSWORD",
+			Name:        "db_password",
+		},
+	}
+	cfg.Services["a"].DockerComposeService.Secrets = []dockerComposeConfig.ServiceSecret{
+		{
+			Source: "db_password",
+			Target: "/run/secrets/db_password",
+		},
+	}
+	withMockedSecretEnv(map[string]string{
+		"DB_PASSWORD": "hunter2",
+	}, func() {
+		objects, err := Convert(cfg, &Options{
+			IncludeSecretValues: true,
+			RegistryUser:        "user",
+			RegistryPass:        "pass",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		secretCount := 0
+		for _, obj := range objects {
+			secret, ok := obj.(*v1.Secret)
+			if !ok {
+				continue
+			}
+			secretCount++
+			if secret.Type == v1.SecretTypeDockerConfigJson {
+				if !strings.Contains(string(secret.Data[v1.DockerConfigJsonKey]), "registry.example.com") {
+					t.Error(string(secret.Data[v1.DockerConfigJsonKey]))
+				}
+			} else if string(secret.Data[secretDataKey]) != "hunter2" {
+				t.Error(secret.Data)
+			}
+		}
+		if secretCount != 2 {
+			t.Error(secretCount)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isDeployment returns whether the app is deployed as a Deployment, see Options.AsDeployment. Stateful apps and jobs keep their own kind of
+// workload.
+func (u *upRunner) isDeployment(a *app) bool {
+	return u.opts.AsDeployment && !a.composeService.Stateful && !a.isJob()
+}
+
+// newDeployment builds the Deployment of an app, using pod as the pod template.
+func (u *upRunner) newDeployment(app *app, pod *v1.Pod) *appsV1.Deployment {
+	template := v1.PodTemplateSpec{
+		ObjectMeta: *pod.ObjectMeta.DeepCopy(),
+		Spec:       *pod.Spec.DeepCopy(),
+	}
+	template.ObjectMeta.Name = ""
+	// The pods of the template are owned by their controller.
+	template.ObjectMeta.OwnerReferences = nil
+	// Kubernetes only accepts the restart policy Always for pods of a Deployment.
+	restart := app.composeService.DockerComposeService.Restart
+	if restart != "" && restart != "always" && restart != "unless-stopped" {
+		app.newLogEntry().Warnf("ignoring restart policy %#v: the pods of a Deployment always restart", restart)
+	}
+	template.Spec.RestartPolicy = v1.RestartPolicyAlways
+	replicas := app.getReplicas()
+	deployment := &appsV1.Deployment{
+		Spec: appsV1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
+			},
+			Template: template,
+		},
+	}
+	k8smeta.InitObjectMeta(u.cfg, &deployment.ObjectMeta, app.composeService)
+	return deployment
+}
+
+// createDeployment creates (or updates) the Deployment of an app.
+func (u *upRunner) createDeployment(app *app, pod *v1.Pod) error {
+	deployment := u.newDeployment(app, pod)
+	op, err := u.createOrUpdateDeployment(deployment)
+	if err != nil {
+		return err
+	}
+	app.newLogEntry().Debugf("%s deployment %s", op, deployment.ObjectMeta.Name)
+	return nil
+}
+
+// deleteDeployment deletes the Deployment of an app, including its pods.
+func (u *upRunner) deleteDeployment(app *app) error {
+	propagationPolicy := metav1.DeletePropagationBackground
+	err := u.k8sDeploymentClient.Delete(u.opts.Context, k8smeta.GetK8sName(app.composeService, u.cfg), metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	})
+	if k8sError.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
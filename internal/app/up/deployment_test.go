@@ -0,0 +1,123 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestDeploymentRunner() *upRunner {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			AsDeployment: true,
+		},
+	}
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.EnvironmentID = "123"
+	return u
+}
+
+func TestIsDeployment(t *testing.T) {
+	u := newTestDeploymentRunner()
+	if !u.isDeployment(newTestApp("b")) {
+		t.Error("expected b to be a deployment")
+	}
+	if u.isDeployment(newTestApp("c")) {
+		t.Error("expected c to be a job")
+	}
+	if u.isDeployment(newTestStatefulApp()) {
+		t.Error("expected a stateful app not to be a deployment")
+	}
+	u.opts.AsDeployment = false
+	if u.isDeployment(newTestApp("b")) {
+		t.Error("expected b not to be a deployment")
+	}
+}
+
+func TestNewDeployment_Success(t *testing.T) {
+	u := newTestDeploymentRunner()
+	a := newTestApp("b")
+	probe := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			Exec: &v1.ExecAction{
+				Command: []string{"true"},
+			},
+		},
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:           "b",
+					LivenessProbe:  probe,
+					ReadinessProbe: probe,
+					VolumeMounts: []v1.VolumeMount{
+						{
+							Name:      "vol1",
+							MountPath: "/data",
+						},
+					},
+				},
+			},
+			RestartPolicy: v1.RestartPolicyAlways,
+			Volumes: []v1.Volume{
+				{
+					Name: "vol1",
+				},
+			},
+		},
+	}
+	k8smeta.InitObjectMeta(u.cfg, &pod.ObjectMeta, a.composeService)
+	deployment := u.newDeployment(a, pod)
+	if deployment.ObjectMeta.Name != "b-123" || deployment.Spec.Template.ObjectMeta.Name != "" {
+		t.Error(deployment.ObjectMeta.Name, deployment.Spec.Template.ObjectMeta.Name)
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 1 {
+		t.Error(deployment.Spec.Replicas)
+	}
+	if deployment.Spec.Selector.MatchLabels["env"] != "123" {
+		t.Error(deployment.Spec.Selector)
+	}
+	for key, value := range deployment.Spec.Selector.MatchLabels {
+		if deployment.Spec.Template.ObjectMeta.Labels[key] != value {
+			t.Errorf("the pod template does not match selector label %s=%s", key, value)
+		}
+	}
+	if !reflect.DeepEqual(deployment.Spec.Template.Spec, pod.Spec) {
+		t.Error(deployment.Spec.Template.Spec)
+	}
+}
+
+func TestNewDeployment_Replicas(t *testing.T) {
+	u := newTestDeploymentRunner()
+	a := newTestApp("b")
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		Replicas: util.NewInt32(3),
+	}
+	deployment := u.newDeployment(a, &v1.Pod{})
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 3 {
+		t.Error(deployment.Spec.Replicas)
+	}
+}
+
+func TestNewDeployment_RestartPolicyAlways(t *testing.T) {
+	u := newTestDeploymentRunner()
+	a := newTestApp("a")
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+	deployment := u.newDeployment(a, pod)
+	if deployment.Spec.Template.Spec.RestartPolicy != v1.RestartPolicyAlways {
+		t.Error(deployment.Spec.Template.Spec.RestartPolicy)
+	}
+	if pod.Spec.RestartPolicy != v1.RestartPolicyNever {
+		t.Error("pod was mutated")
+	}
+}
@@ -0,0 +1,189 @@
+package up
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ServiceDescription summarizes how a docker compose service is translated to Kubernetes resources, and the status of the deployed
+// service (if any).
+type ServiceDescription struct {
+	// The fully resolved docker compose service.
+	ComposeService *config.Service
+	// The name of the generated Kubernetes resources.
+	K8sName string
+	// The kinds of the generated Kubernetes resources.
+	Kinds []string
+	Image string
+	// The number of environment variables.
+	EnvCount int
+	// Human readable descriptions of the volume mounts of the container.
+	Mounts []string
+	// The readiness probe generated from the healthcheck of the docker compose service. Nil if the service does not have a healthcheck
+	// (the healthcheck of the image is only known once the image has been inspected).
+	ReadinessProbe *v1.Probe
+	// The resource limits and requests of the container.
+	Resources     v1.ResourceRequirements
+	RestartPolicy v1.RestartPolicy
+	// The pods of the service that are deployed. Nil if the service is not deployed.
+	Pods []*PodDescription
+}
+
+// PodDescription is the live status of a deployed pod.
+type PodDescription struct {
+	Name string
+	// One of "ready", "started", "completed" and "other".
+	Status string
+	// The error that explains the status of the pod, if any (e.g. the image could not be pulled).
+	Err error
+}
+
+// Describe translates a docker compose service the same way as up does, and queries the status of its pods. The image of the service is
+// not inspected, so fields that depend on the image are not described.
+func Describe(cfg *config.Config, composeService *config.Service) (*ServiceDescription, error) {
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	d, err := newServiceDescription(cfg, composeService)
+	if err != nil {
+		return nil, err
+	}
+	err = u.describePods(d)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// newServiceDescription describes the workload that up would create for a docker compose service, with the image of the service used as
+// is (see useImagesAsIs). Bind mounted volumes are described from the docker compose service, because the pod only has these once the
+// volume init image has been built.
+func newServiceDescription(cfg *config.Config, composeService *config.Service) (*ServiceDescription, error) {
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	u.initApps()
+	a := u.apps[composeService.Name()]
+	if a == nil {
+		return nil, fmt.Errorf("service %s is not deployed by up", composeService.Name())
+	}
+	u.appsToBeStarted = map[*app]bool{a: true}
+	u.useImagesAsIs()
+	if composeService.Stateful {
+		initVolumeClaims(a)
+	}
+	err := u.initSecrets()
+	if err != nil {
+		return nil, err
+	}
+	err = u.initConfigs()
+	if err != nil {
+		return nil, err
+	}
+	pod, err := u.newPod(a, nil)
+	if err != nil {
+		return nil, err
+	}
+	d := &ServiceDescription{
+		ComposeService: composeService,
+		K8sName:        pod.ObjectMeta.Name,
+	}
+	podSpec := &pod.Spec
+	switch {
+	case composeService.Stateful:
+		d.Kinds = append(d.Kinds, "StatefulSet", "Service (headless)")
+		podSpec = &u.newStatefulSet(a, pod).Spec.Template.Spec
+	case a.isJob():
+		d.Kinds = append(d.Kinds, "Job")
+		job, err := u.newJob(a, pod)
+		if err != nil {
+			return nil, err
+		}
+		podSpec = &job.Spec.Template.Spec
+	default:
+		d.Kinds = append(d.Kinds, "Pod")
+	}
+	if a.hasService() {
+		d.Kinds = append(d.Kinds, "Service")
+	}
+	container := &podSpec.Containers[0]
+	d.Image = container.Image
+	d.EnvCount = len(container.Env)
+	d.ReadinessProbe = container.ReadinessProbe
+	d.Resources = container.Resources
+	d.RestartPolicy = podSpec.RestartPolicy
+	for _, volumeMount := range container.VolumeMounts {
+		d.Mounts = append(d.Mounts, describeMount(volumeMount.MountPath, describeVolume(podSpec, volumeMount.Name), volumeMount.ReadOnly))
+	}
+	if !composeService.Stateful {
+		for _, serviceVolume := range composeService.DockerComposeService.Volumes {
+			if appVolume := initVolumeInfoGetAppVolume(a, serviceVolume); appVolume != nil {
+				d.Mounts = append(d.Mounts, describeMount(appVolume.containerPath, "bind mount of "+appVolume.resolvedHostPath,
+					appVolume.readOnly))
+			}
+		}
+	}
+	return d, nil
+}
+
+// describeVolume describes the source of the volume of a pod with the given name. Volumes that are not volumes of the pod are volume
+// claims of the StatefulSet.
+func describeVolume(podSpec *v1.PodSpec, name string) string {
+	for i := range podSpec.Volumes {
+		volume := &podSpec.Volumes[i]
+		if volume.Name != name {
+			continue
+		}
+		switch {
+		case volume.Secret != nil:
+			return "secret " + volume.Secret.SecretName
+		case volume.ConfigMap != nil:
+			return "config map " + volume.ConfigMap.Name
+		case volume.EmptyDir != nil && volume.EmptyDir.Medium == v1.StorageMediumMemory:
+			return "tmpfs"
+		default:
+			return "volume " + name
+		}
+	}
+	return "volume claim " + name
+}
+
+func describeMount(containerPath, source string, readOnly bool) string {
+	s := fmt.Sprintf("%s (%s)", containerPath, source)
+	if readOnly {
+		s += " read-only"
+	}
+	return s
+}
+
+func (u *upRunner) describePods(d *ServiceDescription) error {
+	k8sClientset, err := kubernetes.NewForConfig(u.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	podList, err := k8sClientset.CoreV1().Pods(u.cfg.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(k8smeta.InitCommonLabels(u.cfg, d.ComposeService, nil)).String(),
+	})
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(podList.Items); i++ {
+		pod := &podList.Items[i]
+		s, err := parsePodStatus(pod)
+		d.Pods = append(d.Pods, &PodDescription{
+			Name:   pod.ObjectMeta.Name,
+			Status: s.String(),
+			Err:    err,
+		})
+	}
+	return nil
+}
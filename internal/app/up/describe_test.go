@@ -0,0 +1,89 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNewServiceDescription_Pod(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "123"
+	composeService := cfg.Services["a"]
+	composeService.DockerComposeService.Environment = map[string]string{
+		"KEY": "VALUE",
+	}
+	composeService.DockerComposeService.Image = "ubuntu:latest"
+	d, err := newServiceDescription(cfg, composeService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.K8sName != "a-123" || len(d.Kinds) != 1 || d.Kinds[0] != "Pod" {
+		t.Error(d.K8sName, d.Kinds)
+	}
+	if d.Image != "ubuntu:latest" || d.EnvCount != 1 || d.RestartPolicy != v1.RestartPolicyNever || d.ReadinessProbe != nil {
+		t.Error(d)
+	}
+}
+
+func TestNewServiceDescription_Resources(t *testing.T) {
+	cfg := newTestConfig()
+	composeService := cfg.Services["a"]
+	composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		Resources: &dockerComposeConfig.Resources{
+			Limits: &dockerComposeConfig.ResourceSpec{
+				MilliCPUs: 500,
+			},
+			Reservations: &dockerComposeConfig.ResourceSpec{
+				MemoryBytes: 256 << 20,
+			},
+		},
+	}
+	d, err := newServiceDescription(cfg, composeService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu := d.Resources.Limits[v1.ResourceCPU]; cpu.String() != "500m" {
+		t.Error(cpu.String())
+	}
+	if memory := d.Resources.Requests[v1.ResourceMemory]; memory.String() != "256Mi" {
+		t.Error(memory.String())
+	}
+}
+
+func TestNewServiceDescription_Stateful(t *testing.T) {
+	cfg := newTestConfig()
+	a := newTestStatefulApp()
+	a.composeService.Ports = []config.Port{
+		{
+			Port:     5432,
+			Protocol: "tcp",
+		},
+	}
+	cfg.Services["b"] = a.composeService
+	d, err := newServiceDescription(cfg, a.composeService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Kinds) != 3 || d.Kinds[0] != "StatefulSet" || d.Kinds[2] != "Service" {
+		t.Error(d.Kinds)
+	}
+	if d.RestartPolicy != v1.RestartPolicyAlways {
+		t.Error(d.RestartPolicy)
+	}
+	if len(d.Mounts) != 2 || d.Mounts[0] != "/var/lib/data (volume claim data)" {
+		t.Error(d.Mounts)
+	}
+}
+
+func TestNewServiceDescription_Skipped(t *testing.T) {
+	cfg := newTestConfig()
+	composeService := cfg.Services["a"]
+	delete(cfg.Services, "a")
+	_, err := newServiceDescription(cfg, composeService)
+	if err == nil {
+		t.Fail()
+	}
+}
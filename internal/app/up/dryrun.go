@@ -0,0 +1,264 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DryRunNone submits all resources to the cluster (the default).
+	DryRunNone = "none"
+	// DryRunClient prints the resources that would be submitted, without talking to the cluster.
+	DryRunClient = "client"
+	// DryRunServer submits all resources with DryRun set, so that the API server validates them (schema, admission webhooks) without
+	// persisting anything.
+	DryRunServer = "server"
+)
+
+func (u *upRunner) isDryRunServer() bool {
+	return u.opts.DryRun == DryRunServer
+}
+
+func (u *upRunner) isDryRunClient() bool {
+	return u.opts.DryRun == DryRunClient
+}
+
+func (u *upRunner) createOptions() metav1.CreateOptions {
+	if u.isDryRunServer() {
+		return metav1.CreateOptions{
+			DryRun: []string{metav1.DryRunAll},
+		}
+	}
+	return metav1.CreateOptions{}
+}
+
+func (u *upRunner) updateOptions() metav1.UpdateOptions {
+	if u.isDryRunServer() {
+		return metav1.UpdateOptions{
+			DryRun: []string{metav1.DryRunAll},
+		}
+	}
+	return metav1.UpdateOptions{}
+}
+
+// appsInDependencyOrder returns the apps to be started such that each app appears after all apps it depends on. Apps that are not ordered
+// by depends_on are sorted by name, so that the order is stable across runs. The depends_on relation is acyclic (this is validated when
+// loading the docker compose configuration).
+func (u *upRunner) appsInDependencyOrder() []*app {
+	var names []string
+	for a := range u.appsToBeStarted {
+		names = append(names, a.name())
+	}
+	sort.Strings(names)
+	visited := map[*app]bool{}
+	var result []*app
+	var visit func(a *app)
+	visit = func(a *app) {
+		if visited[a] {
+			return
+		}
+		visited[a] = true
+		var dependencies []string
+		for name := range a.composeService.DockerComposeService.DependsOn {
+			dependencies = append(dependencies, name)
+		}
+		sort.Strings(dependencies)
+		for _, name := range dependencies {
+			if dependency := u.apps[name]; u.appsToBeStarted[dependency] {
+				visit(dependency)
+			}
+		}
+		result = append(result, a)
+	}
+	for _, name := range names {
+		visit(u.apps[name])
+	}
+	return result
+}
+
+// useImagesAsIs resolves the image of each app to be started without the docker daemon: the image of the docker compose service is used as
+// is, so that a dry run does not pull, build or push images. Bind mounted volumes are ignored, because these require building an image.
+func (u *upRunner) useImagesAsIs() {
+	for a := range u.appsToBeStarted {
+		a.imageInfo.podImage = a.composeService.DockerComposeService.Image
+		a.imageInfo.podImagePullPolicy = imagePullPolicyForImage(a.imageInfo.podImage)
+		u.initAppUserWithoutImage(a)
+		a.imageInfo.once.Do(func() {})
+		if len(a.volumes) > 0 {
+			a.newLogEntry().Warn("dry run: ignoring bind mounted volumes, because these require building an image")
+			a.volumes = nil
+		}
+	}
+}
+
+// initAppUserWithoutImage resolves the user of an app whose image is not inspected (see useImagesAsIs), if Options.RunAsUser is set. Only a
+// numeric user of the docker compose service can be resolved without the image, otherwise runAsUser is left unset with a warning.
+func (u *upRunner) initAppUserWithoutImage(a *app) {
+	if !u.opts.RunAsUser {
+		return
+	}
+	if userRaw := a.composeService.DockerComposeService.User; userRaw != nil {
+		user, err := docker.ParseUserinfo(*userRaw)
+		if err == nil && user.IsNumeric() {
+			a.imageInfo.user = user
+			return
+		}
+	}
+	a.newLogEntry().Warn("not setting runAsUser, because the user of the service is not numeric and the image is not inspected")
+}
+
+// runDryRunClient prints the resources that would be submitted to the cluster, in the order in which these can be applied. The resources
+// are built the same way as by convert, so these are not validated by the API server.
+func (u *upRunner) runDryRunClient() error {
+	objects, err := Convert(u.cfg, u.opts)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		kind := strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind)
+		log.Infof("dry run: %s %s would be created or updated\n", kind, accessor.GetName())
+	}
+	log.Infof("dry run: %d resource(s) would be created or updated (use --dry-run=%s to validate them with the API server)\n",
+		len(objects), DryRunServer)
+	return nil
+}
+
+// runDryRunServer submits the Services and Pods (or StatefulSets and Jobs) of all apps to the API server in dependency order, with DryRun set. Rejections are
+// reported per resource and do not stop validation of the other resources.
+func (u *upRunner) runDryRunServer() error {
+	u.useImagesAsIs()
+	apps := u.appsInDependencyOrder()
+	rejected := 0
+	hostAliases := []v1.HostAlias{}
+	if u.opts.CreateNamespace {
+		if err := u.createNamespace(); err != nil {
+			rejected++
+			log.Errorf("dry run: namespace %s was rejected: %v", u.cfg.Namespace, err)
+		}
+	}
+	if u.opts.UseOwnerRefs {
+		if err := u.createOwner(); err != nil {
+			rejected++
+			log.Errorf("dry run: the owner configmap was rejected: %v", err)
+		}
+	}
+	if err := u.createSecrets(); err != nil {
+		rejected++
+		log.Errorf("dry run: a secret was rejected: %v", err)
+	}
+	if err := u.createConfigs(); err != nil {
+		rejected++
+		log.Errorf("dry run: a configmap was rejected: %v", err)
+	}
+	if u.opts.DefaultDenyIngress {
+		if err := u.createNetworkPolicies(); err != nil {
+			rejected++
+			log.Errorf("dry run: a networkpolicy was rejected: %v", err)
+		}
+	}
+	for _, app := range apps {
+		if err := u.createHeadlessNameServices(app); err != nil {
+			rejected++
+			app.newLogEntry().Errorf("dry run: a k8s service of a host name was rejected: %v", err)
+		}
+		if !app.hasService() {
+			continue
+		}
+		service := u.newService(app)
+		result, op, err := u.createOrUpdateService(service)
+		if err != nil {
+			rejected++
+			app.newLogEntry().Errorf("dry run: k8s service %s was rejected: %v", service.ObjectMeta.Name, err)
+			continue
+		}
+		app.newLogEntry().Infof("dry run: k8s service %s would be %s", service.ObjectMeta.Name, op)
+		if result.Spec.ClusterIP != "" && result.Spec.ClusterIP != v1.ClusterIPNone && !u.opts.SkipHostAliases {
+			hostAliases = append(hostAliases, v1.HostAlias{
+				IP:        result.Spec.ClusterIP,
+				Hostnames: app.hostnames(),
+			})
+		}
+	}
+	if err := u.createExternalNameServices(); err != nil {
+		rejected++
+		log.Errorf("dry run: a k8s service of an external service was rejected: %v", err)
+	}
+	if err := u.createExternalLinkServices(); err != nil {
+		rejected++
+		log.Errorf("dry run: a k8s service of an external link was rejected: %v", err)
+	}
+	if !u.opts.SkipHostAliases {
+		hostAliases = append(hostAliases, u.getExternalHostAliases()...)
+	}
+	for _, app := range apps {
+		pod, err := u.newPod(app, hostAliases)
+		if err != nil {
+			return err
+		}
+		u.createPodPullSecrets(app, pod)
+		if app.composeService.Stateful {
+			err = u.createStatefulSet(app, pod)
+			if err != nil {
+				rejected++
+				app.newLogEntry().Errorf("dry run: statefulset %s was rejected: %v", pod.ObjectMeta.Name, err)
+			} else {
+				app.newLogEntry().Infof("dry run: statefulset %s would be created", pod.ObjectMeta.Name)
+			}
+			continue
+		}
+		if app.isJob() {
+			err = u.createJob(app, pod)
+			if err != nil {
+				rejected++
+				app.newLogEntry().Errorf("dry run: job %s was rejected: %v", pod.ObjectMeta.Name, err)
+			} else {
+				app.newLogEntry().Infof("dry run: job %s would be created", pod.ObjectMeta.Name)
+			}
+			continue
+		}
+		if u.isDeployment(app) {
+			err = u.createDeployment(app, pod)
+			if err != nil {
+				rejected++
+				app.newLogEntry().Errorf("dry run: deployment %s was rejected: %v", pod.ObjectMeta.Name, err)
+			} else {
+				app.newLogEntry().Infof("dry run: deployment %s would be created", pod.ObjectMeta.Name)
+			}
+			continue
+		}
+		for _, replicaPod := range app.newReplicaPods(pod) {
+			_, err = u.createPodResource(replicaPod)
+			if k8sError.IsAlreadyExists(err) {
+				if ownedErr := checkExistingIsOwned[*v1.Pod](u, u.k8sPodClient, "pod", replicaPod); ownedErr != nil {
+					err = ownedErr
+				}
+			}
+			switch {
+			case k8sError.IsAlreadyExists(err):
+				app.newLogEntry().Infof("dry run: pod %s already exists", replicaPod.ObjectMeta.Name)
+			case err != nil:
+				rejected++
+				app.newLogEntry().Errorf("dry run: pod %s was rejected: %v", replicaPod.ObjectMeta.Name, err)
+			default:
+				app.newLogEntry().Infof("dry run: pod %s would be created", replicaPod.ObjectMeta.Name)
+			}
+		}
+	}
+	if rejected > 0 {
+		return fmt.Errorf("dry run: %d resource(s) were rejected by the API server", rejected)
+	}
+	log.Infof("dry run: all resources were accepted by the API server\n")
+	return nil
+}
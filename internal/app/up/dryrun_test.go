@@ -0,0 +1,171 @@
+package up
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAppsInDependencyOrder(t *testing.T) {
+	cfg := newTestConfig()
+	for _, service := range cfg.Services {
+		cfg.AddToFilter(service)
+	}
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{}
+	for _, a := range u.apps {
+		u.appsToBeStarted[a] = true
+	}
+	var names []string
+	for _, a := range u.appsInDependencyOrder() {
+		names = append(names, a.name())
+	}
+	expected := []string{"c", "d", "a", "b"}
+	if len(names) != len(expected) {
+		t.Fatal(names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatal(names)
+		}
+	}
+}
+
+func TestCreateOptions_DryRunServer(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			DryRun: DryRunServer,
+		},
+	}
+	createOptions := u.createOptions()
+	if len(createOptions.DryRun) != 1 || createOptions.DryRun[0] != metav1.DryRunAll {
+		t.Error(createOptions)
+	}
+	updateOptions := u.updateOptions()
+	if len(updateOptions.DryRun) != 1 || updateOptions.DryRun[0] != metav1.DryRunAll {
+		t.Error(updateOptions)
+	}
+}
+
+func TestCreateOptions_DryRunNone(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			DryRun: DryRunNone,
+		},
+	}
+	if createOptions := u.createOptions(); createOptions.DryRun != nil {
+		t.Error(createOptions)
+	}
+}
+
+func TestUseImagesAsIs(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["a"].DockerComposeService.Image = "ubuntu:latest"
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	u.initApps()
+	a := u.apps["a"]
+	a.volumes = []*appVolume{
+		{containerPath: "/data"},
+	}
+	u.appsToBeStarted = map[*app]bool{
+		a: true,
+	}
+	u.useImagesAsIs()
+	if a.imageInfo.podImage != "ubuntu:latest" || a.volumes != nil {
+		t.Error(a.imageInfo.podImage, a.volumes)
+	}
+	// The image has been resolved, so that the docker daemon is not used.
+	if err := u.getAppImageInfoOnce(a); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunDryRunClient_Success(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConvertConfig(),
+		opts: &Options{
+			DryRun: DryRunClient,
+		},
+	}
+	err := u.run()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunDryRunClient_RunAsUser(t *testing.T) {
+	cfg := newTestConvertConfig()
+	cfg.Services["a"].DockerComposeService.User = util.NewString("postgres")
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			DryRun:    DryRunClient,
+			RunAsUser: true,
+		},
+	}
+	err := u.run()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunDryRunServer_RunAsUser(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner("")
+	u.opts.DryRun = DryRunServer
+	u.opts.RunAsUser = true
+	u.cfg.Services["a"].DockerComposeService.User = util.NewString("1000:1001")
+	u.cfg.Services["d"].DockerComposeService.User = util.NewString("postgres")
+	err := u.runDryRunServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := clientset.CoreV1().Pods("").Get(context.Background(), "a-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	securityContext := pod.Spec.Containers[0].SecurityContext
+	if securityContext == nil || *securityContext.RunAsUser != 1000 || *securityContext.RunAsGroup != 1001 {
+		t.Error(securityContext)
+	}
+	// The user is not numeric, and a dry run does not inspect the image.
+	pod, err = clientset.CoreV1().Pods("").Get(context.Background(), "d-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if securityContext = pod.Spec.Containers[0].SecurityContext; securityContext == nil || securityContext.RunAsUser != nil {
+		t.Error(securityContext)
+	}
+}
+
+func TestRunDryRunServer_PullSecrets(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner("")
+	u.opts.DryRun = DryRunServer
+	u.opts.RegistryUser = "user"
+	u.opts.RegistryPass = "pass"
+	u.k8sSecretClient = clientset.CoreV1().Secrets("")
+	u.cfg.Services["d"].DockerComposeService.Image = "registry.example.com/d:latest"
+	err := u.runDryRunServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := clientset.CoreV1().Pods("").Get(context.Background(), "d-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pod.Spec.ImagePullSecrets) != 1 {
+		t.Fatal(pod.Spec.ImagePullSecrets)
+	}
+	_, err = clientset.CoreV1().Secrets("").Get(context.Background(), pod.Spec.ImagePullSecrets[0].Name, metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,129 @@
+package up
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+)
+
+// explainer summarizes Kubernetes events of the pods of apps (see Options.Explain).
+type explainer struct {
+	// Maps names of pods to apps.
+	apps map[string]*app
+	// The time at which the first event of each pod was observed, used to report the time elapsed since then.
+	firstSeen map[string]time.Time
+	now       func() time.Time
+	start     time.Time
+}
+
+func (u *upRunner) newExplainer() *explainer {
+	e := &explainer{
+		apps:      map[string]*app{},
+		firstSeen: map[string]time.Time{},
+		now:       time.Now,
+	}
+	e.start = e.now()
+	for _, app := range u.apps {
+		name := k8smeta.GetK8sName(app.composeService, u.cfg)
+		if app.composeService.Stateful {
+			// The pods of a StatefulSet are named after the StatefulSet, suffixed by their ordinal.
+			for i := int32(0); i < app.getReplicas(); i++ {
+				e.apps[fmt.Sprintf("%s-%d", name, i)] = app
+			}
+			continue
+		}
+		for _, podName := range app.getReplicaPodNames(name) {
+			e.apps[podName] = app
+		}
+	}
+	return e
+}
+
+// summarize returns the app of the pod of an event and a human readable summary of the event. Returns a nil app if the event should not be
+// reported, because it is not an event of a pod of an app or it occurred before the explainer was created.
+func (e *explainer) summarize(event *v1.Event) (*app, string) {
+	app := e.apps[event.InvolvedObject.Name]
+	if app == nil {
+		return nil, ""
+	}
+	if !event.LastTimestamp.IsZero() && event.LastTimestamp.Time.Before(e.start) {
+		return nil, ""
+	}
+	podName := event.InvolvedObject.Name
+	firstSeen, ok := e.firstSeen[podName]
+	if !ok {
+		firstSeen = e.now()
+		e.firstSeen[podName] = firstSeen
+	}
+	var summary string
+	switch event.Reason {
+	case "Scheduled":
+		summary = "scheduled"
+	case "FailedScheduling":
+		summary = "cannot be scheduled: " + event.Message
+	case "Pulling":
+		summary = "pulling image"
+	case "Pulled":
+		summary = "pulled image"
+	case "Failed", "ErrImagePull":
+		summary = "failed: " + event.Message
+	case "Created":
+		summary = "created container"
+	case "Started":
+		summary = "started container"
+	case "BackOff":
+		summary = "container is restarting after it terminated (back-off)"
+	case "Killing":
+		summary = "stopping container"
+	case "Unhealthy":
+		summary = e.summarizeUnhealthy(app, event)
+	default:
+		summary = fmt.Sprintf("%s: %s", event.Reason, event.Message)
+	}
+	return app, fmt.Sprintf("pod %s: %s (%s)", podName, summary, e.now().Sub(firstSeen).Round(time.Second))
+}
+
+func (e *explainer) summarizeUnhealthy(app *app, event *v1.Event) string {
+	if !strings.HasPrefix(event.Message, "Readiness probe failed") {
+		return event.Message
+	}
+	count := event.Count
+	if count == 0 {
+		count = 1
+	}
+	if probe := app.GetReadinessProbe(); probe != nil && probe.FailureThreshold > 0 {
+		return fmt.Sprintf("readiness probe failed (%d/%d)", count, probe.FailureThreshold)
+	}
+	return fmt.Sprintf("readiness probe failed (%d)", count)
+}
+
+// runExplainEvents watches the events of pods and logs summaries of events of pods of apps, until the context of the options is done.
+func (u *upRunner) runExplainEvents() {
+	e := u.newExplainer()
+	watch, err := u.k8sClientset.CoreV1().Events(u.cfg.Namespace).Watch(u.opts.Context, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod",
+	})
+	if err != nil {
+		log.Warnf("cannot explain progress, because watching events failed: %v", err)
+		return
+	}
+	defer watch.Stop()
+	for watchEvent := range watch.ResultChan() {
+		if watchEvent.Type != k8swatch.Added && watchEvent.Type != k8swatch.Modified {
+			continue
+		}
+		event, ok := watchEvent.Object.(*v1.Event)
+		if !ok {
+			continue
+		}
+		if app, summary := e.summarize(event); app != nil {
+			app.newLogEntry().Info(summary)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package up
+
+import (
+	"testing"
+	"time"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestExplainer() (*explainer, *time.Time) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+	}
+	u.cfg.EnvironmentID = "123"
+	u.initApps()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := u.newExplainer()
+	e.now = func() time.Time {
+		return now
+	}
+	e.start = now
+	return e, &now
+}
+
+func TestExplainerSummarize_PullingImage(t *testing.T) {
+	e, now := newTestExplainer()
+	event := &v1.Event{
+		InvolvedObject: v1.ObjectReference{Name: "a-123"},
+		Reason:         "Scheduled",
+	}
+	_, _ = e.summarize(event)
+	*now = now.Add(12 * time.Second)
+	event.Reason = "Pulling"
+	app, summary := e.summarize(event)
+	if app == nil || app.name() != "a" || summary != "pod a-123: pulling image (12s)" {
+		t.Error(summary)
+	}
+}
+
+func TestExplainerSummarize_ReadinessProbeFailed(t *testing.T) {
+	e, _ := newTestExplainer()
+	e.apps["b-123"].composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		Retries: 3,
+		Test:    []string{"true"},
+	}
+	_, summary := e.summarize(&v1.Event{
+		Count:          2,
+		InvolvedObject: v1.ObjectReference{Name: "b-123"},
+		Message:        "Readiness probe failed: exit code 1",
+		Reason:         "Unhealthy",
+	})
+	if summary != "pod b-123: readiness probe failed (2/3) (0s)" {
+		t.Error(summary)
+	}
+}
+
+func TestExplainerSummarize_IgnoresOldAndUnknownEvents(t *testing.T) {
+	e, now := newTestExplainer()
+	app, _ := e.summarize(&v1.Event{
+		InvolvedObject: v1.ObjectReference{Name: "unknown-123"},
+	})
+	if app != nil {
+		t.Fail()
+	}
+	app, _ = e.summarize(&v1.Event{
+		InvolvedObject: v1.ObjectReference{Name: "a-123"},
+		LastTimestamp:  metav1.NewTime(now.Add(-time.Minute)),
+	})
+	if app != nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,174 @@
+package up
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func (e ExternalService) isIP() bool {
+	return net.ParseIP(e.Host) != nil
+}
+
+func (u *upRunner) externalServiceNames() []string {
+	var names []string
+	for name := range u.opts.External {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getExternalHostAliases returns host aliases that resolve the names of external services with IP addresses, sorted by name.
+func (u *upRunner) getExternalHostAliases() []v1.HostAlias {
+	var hostAliases []v1.HostAlias
+	for _, name := range u.externalServiceNames() {
+		externalService := u.opts.External[name]
+		if !externalService.isIP() {
+			continue
+		}
+		hostAliases = append(hostAliases, v1.HostAlias{
+			IP: externalService.Host,
+			Hostnames: []string{
+				name,
+			},
+		})
+	}
+	return hostAliases
+}
+
+// newExternalNameService builds a Service of type ExternalName, so that in-cluster DNS resolves the name of the service to the DNS name
+// of the external service.
+func (u *upRunner) newExternalNameService(composeService *config.Service, externalService ExternalService) *v1.Service {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			ExternalName: externalService.Host,
+			Type:         v1.ServiceTypeExternalName,
+		},
+	}
+	if externalService.Port != 0 {
+		service.Spec.Ports = []v1.ServicePort{
+			{
+				Name:       fmt.Sprintf("tcp%d", externalService.Port),
+				Port:       externalService.Port,
+				Protocol:   v1.ProtocolTCP,
+				TargetPort: intstr.FromInt(int(externalService.Port)),
+			},
+		}
+	}
+	k8smeta.InitObjectMeta(u.cfg, &service.ObjectMeta, composeService)
+	return service
+}
+
+func (u *upRunner) createExternalNameServices() error {
+	for _, name := range u.externalServiceNames() {
+		externalService := u.opts.External[name]
+		if externalService.isIP() {
+			continue
+		}
+		service := u.newExternalNameService(u.cfg.Services[name], externalService)
+		_, op, err := u.createOrUpdateService(service)
+		if err != nil {
+			return err
+		}
+		log.Debugf("%s k8s service %s of external service %s", op, service.ObjectMeta.Name, name)
+	}
+	return nil
+}
+
+type appExternalLink struct {
+	alias           string
+	externalService ExternalService
+}
+
+// resolveExternalLinks resolves the targets of the external_links of the apps to be started. The target of an external link is resolved
+// from Options.External if the target appears there, and otherwise the target must be a fully qualified DNS name. Aliases of external
+// links whose targets are DNS names become the names of Services, so all apps share the aliases: an alias must have the same target in
+// all apps, and such aliases only resolve if the environment ID is not appended to the names of Services.
+func (u *upRunner) resolveExternalLinks() error {
+	type aliasTarget struct {
+		app             *app
+		externalService ExternalService
+	}
+	aliasTargets := map[string]aliasTarget{}
+	for _, app := range u.appsInNameOrder() {
+		for _, externalLink := range app.composeService.DockerComposeService.ExternalLinks {
+			externalService, ok := u.opts.External[externalLink.Target]
+			if !ok {
+				if !strings.Contains(externalLink.Target, ".") || len(validation.IsDNS1123Subdomain(externalLink.Target)) > 0 {
+					return fmt.Errorf("service %s has an external link to %#v, which is neither a DNS name nor set with --external", app.name(),
+						externalLink.Target)
+				}
+				externalService.Host = externalLink.Target
+			}
+			if !externalService.isIP() && !u.cfg.EnvironmentIDNoAppend {
+				return fmt.Errorf("service %s has an external link to the DNS name %s with alias %s, which requires --env-id-no-append so "+
+					"that the alias resolves (or set the target to an IP address with --external)", app.name(), externalService.Host,
+					externalLink.Alias)
+			}
+			if other, ok := aliasTargets[externalLink.Alias]; ok && other.externalService != externalService {
+				return fmt.Errorf("services %s and %s have external links with the alias %s to different targets", other.app.name(),
+					app.name(), externalLink.Alias)
+			}
+			aliasTargets[externalLink.Alias] = aliasTarget{
+				app:             app,
+				externalService: externalService,
+			}
+			app.externalLinks = append(app.externalLinks, &appExternalLink{
+				alias:           externalLink.Alias,
+				externalService: externalService,
+			})
+		}
+	}
+	return nil
+}
+
+// getExternalLinkHostAliases returns host aliases that resolve the aliases of external links of an app whose targets are IP addresses.
+func getExternalLinkHostAliases(app *app) []v1.HostAlias {
+	var hostAliases []v1.HostAlias
+	for _, externalLink := range app.externalLinks {
+		if !externalLink.externalService.isIP() {
+			continue
+		}
+		hostAliases = append(hostAliases, v1.HostAlias{
+			IP: externalLink.externalService.Host,
+			Hostnames: []string{
+				externalLink.alias,
+			},
+		})
+	}
+	return hostAliases
+}
+
+// newExternalLinkService builds a Service of type ExternalName for an external link of an app, named after the alias of the link.
+func (u *upRunner) newExternalLinkService(app *app, externalLink *appExternalLink) *v1.Service {
+	service := u.newExternalNameService(app.composeService, externalLink.externalService)
+	service.ObjectMeta.Name = k8smeta.GetK8sNameFromEscapedName(u.cfg, util.EscapeName(externalLink.alias))
+	return service
+}
+
+func (u *upRunner) createExternalLinkServices() error {
+	for _, app := range u.appsInDependencyOrder() {
+		for _, externalLink := range app.externalLinks {
+			if externalLink.externalService.isIP() {
+				continue
+			}
+			service := u.newExternalLinkService(app, externalLink)
+			_, op, err := u.createOrUpdateService(service)
+			if err != nil {
+				return err
+			}
+			app.newLogEntry().Debugf("%s k8s service %s of external link %s", op, service.ObjectMeta.Name, externalLink.alias)
+		}
+	}
+	return nil
+}
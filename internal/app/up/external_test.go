@@ -0,0 +1,134 @@
+package up
+
+import (
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNewExternalNameService(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "123"
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	service := u.newExternalNameService(cfg.Services["a"], ExternalService{
+		Host: "db.example.com",
+		Port: 5432,
+	})
+	if service.Spec.Type != v1.ServiceTypeExternalName || service.Spec.ExternalName != "db.example.com" {
+		t.Error(service.Spec)
+	}
+	if service.ObjectMeta.Name != "a-123" || len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Port != 5432 {
+		t.Error(service)
+	}
+}
+
+func TestGetExternalHostAliases_SkipsDNSNames(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			External: map[string]ExternalService{
+				"a": {Host: "db.example.com"},
+				"b": {Host: "10.0.0.2"},
+			},
+		},
+	}
+	hostAliases := u.getExternalHostAliases()
+	if len(hostAliases) != 1 || hostAliases[0].Hostnames[0] != "b" {
+		t.Error(hostAliases)
+	}
+}
+
+func newTestExternalLinksRunner() *upRunner {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "123"
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			External: map[string]ExternalService{
+				"realmysql": {Host: "10.0.0.2"},
+			},
+		},
+	}
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{
+		u.apps["a"]: true,
+	}
+	return u
+}
+
+func TestResolveExternalLinks_Success(t *testing.T) {
+	u := newTestExternalLinksRunner()
+	u.cfg.EnvironmentIDNoAppend = true
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.ExternalLinks = []dockerComposeConfig.ExternalLink{
+		{Target: "realmysql", Alias: "mysql"},
+		{Target: "cache.example.com", Alias: "redis"},
+	}
+	err := u.resolveExternalLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostAliases := getExternalLinkHostAliases(a)
+	if len(hostAliases) != 1 || hostAliases[0].IP != "10.0.0.2" || hostAliases[0].Hostnames[0] != "mysql" {
+		t.Error(hostAliases)
+	}
+	service := u.newExternalLinkService(a, a.externalLinks[1])
+	if service.ObjectMeta.Name != "redis" || service.Spec.ExternalName != "cache.example.com" {
+		t.Error(service)
+	}
+}
+
+func TestResolveExternalLinks_UnresolvedError(t *testing.T) {
+	u := newTestExternalLinksRunner()
+	u.apps["a"].composeService.DockerComposeService.ExternalLinks = []dockerComposeConfig.ExternalLink{
+		{Target: "redis_1", Alias: "redis"},
+	}
+	err := u.resolveExternalLinks()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestResolveExternalLinks_DNSNameEnvIDAppendedError(t *testing.T) {
+	u := newTestExternalLinksRunner()
+	u.apps["a"].composeService.DockerComposeService.ExternalLinks = []dockerComposeConfig.ExternalLink{
+		{Target: "cache.example.com", Alias: "redis"},
+	}
+	err := u.resolveExternalLinks()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestResolveExternalLinks_AliasConflictError(t *testing.T) {
+	u := newTestExternalLinksRunner()
+	u.cfg.EnvironmentIDNoAppend = true
+	u.appsToBeStarted[u.apps["b"]] = true
+	u.apps["a"].composeService.DockerComposeService.ExternalLinks = []dockerComposeConfig.ExternalLink{
+		{Target: "cache.example.com", Alias: "redis"},
+	}
+	u.apps["b"].composeService.DockerComposeService.ExternalLinks = []dockerComposeConfig.ExternalLink{
+		{Target: "othercache.example.com", Alias: "redis"},
+	}
+	err := u.resolveExternalLinks()
+	if err == nil || err.Error() != "services a and b have external links with the alias redis to different targets" {
+		t.Error(err)
+	}
+}
+
+func TestResolveExternalLinks_AliasSameTarget(t *testing.T) {
+	u := newTestExternalLinksRunner()
+	u.appsToBeStarted[u.apps["b"]] = true
+	for _, name := range []string{"a", "b"} {
+		u.apps[name].composeService.DockerComposeService.ExternalLinks = []dockerComposeConfig.ExternalLink{
+			{Target: "realmysql", Alias: "mysql"},
+		}
+	}
+	err := u.resolveExternalLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
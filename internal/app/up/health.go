@@ -0,0 +1,100 @@
+package up
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ServiceHealth is the readiness of the pods of a docker compose service that has a readiness gate.
+type ServiceHealth struct {
+	Name string
+	// The number of pods that are ready.
+	Ready int
+	// The number of pods that are not ready.
+	NotReady int
+}
+
+// IsHealthy returns true if and only if the service has at least one pod and all its pods are ready.
+func (h *ServiceHealth) IsHealthy() bool {
+	return h.Ready > 0 && h.NotReady == 0
+}
+
+// hasReadinessGate returns true if the docker compose service has a readiness probe of "x-kube-compose"."probes" or a healthcheck, or if
+// any of its deployed pods has a readiness probe (e.g. from the healthcheck of the image). The readiness probe of "x-kube-compose"."probes"
+// is set even if the healthcheck is disabled (see app.GetReadinessProbe).
+func hasReadinessGate(composeService *config.Service, pods []*v1.Pod) bool {
+	if composeService.Probes != nil && composeService.Probes.Readiness != nil {
+		return true
+	}
+	dcService := composeService.DockerComposeService
+	if dcService.HealthcheckDisabled {
+		return false
+	}
+	if dcService.Healthcheck != nil {
+		return true
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if container.ReadinessProbe != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newServiceHealths determines the health of each of the docker compose services that has a readiness gate, given the pods of the
+// environment. A pod is ready if up would consider the depends_on condition service_healthy satisfied (see parsePodStatus). The result is
+// sorted by name.
+func newServiceHealths(cfg *config.Config, composeServices []*config.Service, pods []v1.Pod) []*ServiceHealth {
+	podsByService := map[*config.Service][]*v1.Pod{}
+	for i := 0; i < len(pods); i++ {
+		if composeService := k8smeta.FindFromObjectMeta(cfg, &pods[i].ObjectMeta); composeService != nil {
+			podsByService[composeService] = append(podsByService[composeService], &pods[i])
+		}
+	}
+	var result []*ServiceHealth
+	for _, composeService := range composeServices {
+		servicePods := podsByService[composeService]
+		if !hasReadinessGate(composeService, servicePods) {
+			continue
+		}
+		h := &ServiceHealth{
+			Name: composeService.Name(),
+		}
+		for _, pod := range servicePods {
+			// Errors are ignored, because they only explain why a pod is not ready.
+			if s, _ := parsePodStatus(pod); s == podStatusReady {
+				h.Ready++
+			} else {
+				h.NotReady++
+			}
+		}
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Health queries the readiness of the pods of the specified docker compose services. Services without a readiness gate are omitted.
+func Health(cfg *config.Config, composeServices []*config.Service) ([]*ServiceHealth, error) {
+	k8sClientset, err := kubernetes.NewForConfig(cfg.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	podList, err := k8sClientset.CoreV1().Pods(cfg.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: cfg.EnvironmentLabel + "=" + cfg.EnvironmentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newServiceHealths(cfg, composeServices, podList.Items), nil
+}
@@ -0,0 +1,78 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestHealthPod(cfg *config.Config, name string, ready, readinessProbe bool) v1.Pod {
+	pod := v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	if readinessProbe {
+		pod.Spec.Containers[0].ReadinessProbe = &v1.Probe{}
+	}
+	if ready {
+		pod.Status.Conditions = []v1.PodCondition{
+			{Type: v1.PodReady, Status: v1.ConditionTrue},
+		}
+	}
+	k8smeta.InitObjectMeta(cfg, &pod.ObjectMeta, cfg.Services[name])
+	return pod
+}
+
+func TestNewServiceHealths(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["c"].DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{}
+	cfg.Services["d"].DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{}
+	pods := []v1.Pod{
+		// a has a readiness probe from the healthcheck of its image.
+		newTestHealthPod(cfg, "a", true, true),
+		// b has no readiness gate.
+		newTestHealthPod(cfg, "b", false, false),
+		newTestHealthPod(cfg, "c", false, true),
+		// d is not deployed.
+	}
+	var composeServices []*config.Service
+	for _, name := range []string{"a", "b", "c", "d"} {
+		composeServices = append(composeServices, cfg.Services[name])
+	}
+	healths := newServiceHealths(cfg, composeServices, pods)
+	if len(healths) != 3 {
+		t.Fatal(healths)
+	}
+	if *healths[0] != (ServiceHealth{Name: "a", Ready: 1}) || !healths[0].IsHealthy() {
+		t.Error(healths[0])
+	}
+	if *healths[1] != (ServiceHealth{Name: "c", NotReady: 1}) || healths[1].IsHealthy() {
+		t.Error(healths[1])
+	}
+	if *healths[2] != (ServiceHealth{Name: "d"}) || healths[2].IsHealthy() {
+		t.Error(healths[2])
+	}
+}
+
+func TestHasReadinessGate_ProbesWithHealthcheckDisabled(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name:                "a",
+		HealthcheckDisabled: true,
+	})
+	if hasReadinessGate(composeService, nil) {
+		t.Fail()
+	}
+	composeService.Probes = &config.Probes{
+		Readiness: &v1.Probe{},
+	}
+	if !hasReadinessGate(composeService, nil) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,117 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// imagePrep bounds the number of steps of the preparation of images (pulling, building volume init images, retagging and pushing) that run
+// concurrently to Options.MaxParallel, and cancels the preparation of all images when a step fails. The images of all apps are prepared
+// regardless of depends_on, which only orders the creation of pods.
+type imagePrep struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// Holds a value for each running step.
+	slots chan struct{}
+	mutex sync.Mutex
+	// The first error of a step, and the app of that step.
+	err       error
+	failedApp *app
+}
+
+// newImagePrep creates an imagePrep whose context is derived from ctx. If maxParallel is not positive then runtime.NumCPU() steps run
+// concurrently.
+func newImagePrep(ctx context.Context, maxParallel int) *imagePrep {
+	if maxParallel <= 0 {
+		maxParallel = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &imagePrep{
+		ctx:    ctx,
+		cancel: cancel,
+		slots:  make(chan struct{}, maxParallel),
+	}
+}
+
+// run runs step, a step of the preparation of the images of app, once fewer than the maximum number of steps are running. The first step
+// that fails cancels the context of the preparation, and steps that fail or are skipped after the cancellation return an error that
+// names the cause, so that the error of the step that failed first is reported regardless of which app reports its error first.
+func (p *imagePrep) run(a *app, step func() error) error {
+	// A select with multiple ready cases chooses one at random, so a free slot must not win over the cancellation.
+	if p.ctx.Err() != nil {
+		return p.cancelledError(a)
+	}
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.ctx.Done():
+		return p.cancelledError(a)
+	}
+	err := step()
+	<-p.slots
+	if err == nil {
+		return nil
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.err != nil {
+		return p.cancelledErrorLocked(a)
+	}
+	p.err = err
+	p.failedApp = a
+	p.cancel()
+	return err
+}
+
+func (p *imagePrep) cancelledError(a *app) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.cancelledErrorLocked(a)
+}
+
+func (p *imagePrep) cancelledErrorLocked(a *app) error {
+	if p.err == nil {
+		// The parent context was cancelled.
+		return p.ctx.Err()
+	}
+	return fmt.Errorf("preparing the images of %s was cancelled, because preparing the images of %s failed: %v", a.name(),
+		p.failedApp.name(), p.err)
+}
+
+// imageContext returns the context of the docker operations that prepare images, which is cancelled when the preparation of any image
+// fails (see imagePrep).
+func (u *upRunner) imageContext() context.Context {
+	if u.imagePrep == nil {
+		return u.opts.Context
+	}
+	return u.imagePrep.ctx
+}
+
+// runImagePrepStep runs a step of the preparation of the images of an app (see imagePrep.run). Steps run immediately if images are not
+// prepared by run (e.g. in tests).
+func (u *upRunner) runImagePrepStep(a *app, step func() error) error {
+	if u.imagePrep == nil {
+		return step()
+	}
+	return u.imagePrep.run(a, step)
+}
+
+// startImagePrep starts preparing the images of the apps to be started in the background. Errors are returned when the images are needed
+// (see getAppImageInfoOnce and getAppVolumeInitImageOnce).
+func (u *upRunner) startImagePrep() {
+	u.imagePrep = newImagePrep(u.opts.Context, u.opts.MaxParallel)
+	for app := range u.appsToBeStarted {
+		// Begin pulling and pushing images immediately...
+		// The error returned by getAppImageInfoOnce will be handled later, hence the nolint.
+		//nolint
+		go u.getAppImageInfoOnce(app)
+
+		// Start building the volume init image, if needed.
+		if len(app.volumes) > 0 {
+			// The error returned by getAppVolumeInitImageOnce will be handled later, hence the nolint.
+			//nolint
+			go u.getAppVolumeInitImageOnce(app)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewImagePrep_DefaultMaxParallel(t *testing.T) {
+	p := newImagePrep(context.Background(), 0)
+	defer p.cancel()
+	if cap(p.slots) != runtime.NumCPU() {
+		t.Error(cap(p.slots))
+	}
+}
+
+func TestImagePrepRun_Bounded(t *testing.T) {
+	p := newImagePrep(context.Background(), 2)
+	defer p.cancel()
+	var mutex sync.Mutex
+	running := 0
+	maxRunning := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.run(newTestApp("a"), func() error {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxRunning != 2 {
+		t.Error(maxRunning)
+	}
+}
+
+func TestImagePrepRun_ErrorCancels(t *testing.T) {
+	p := newImagePrep(context.Background(), 2)
+	defer p.cancel()
+	a := newTestApp("a")
+	b := newTestApp("b")
+	started := make(chan struct{})
+	errB := make(chan error)
+	go func() {
+		errB <- p.run(b, func() error {
+			close(started)
+			<-p.ctx.Done()
+			return p.ctx.Err()
+		})
+	}()
+	<-started
+	errA := p.run(a, func() error {
+		return fmt.Errorf("push failed")
+	})
+	if errA == nil || errA.Error() != "push failed" {
+		t.Error(errA)
+	}
+	// The error of b names the error of a, instead of the cancellation of the context.
+	if err := <-errB; err == nil || !strings.Contains(err.Error(), "because preparing the images of a failed: push failed") {
+		t.Error(err)
+	}
+	// Steps that have not started are skipped.
+	err := p.run(b, func() error {
+		t.Error("step of a cancelled preparation was run")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "push failed") {
+		t.Error(err)
+	}
+}
+
+func TestRunImagePrepStep_WithoutImagePrep(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	ran := false
+	err := u.runImagePrepStep(newTestApp("a"), func() error {
+		ran = true
+		return nil
+	})
+	if err != nil || !ran {
+		t.Error(err, ran)
+	}
+	if u.imageContext() != u.opts.Context {
+		t.Fail()
+	}
+}
@@ -0,0 +1,199 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// The interval and timeout of polling the status of a Job after one of its pods terminated, since the Job controller updates the status
+// of a Job after the status of its pods.
+var (
+	jobStatusPollInterval = time.Second
+	jobStatusPollTimeout  = time.Minute
+)
+
+// isCompletionDependency returns whether a docker compose service of cfg depends on composeService with the condition
+// service_completed_successfully.
+func isCompletionDependency(cfg *config.Config, composeService *config.Service) bool {
+	for _, dependent := range cfg.Services {
+		healthiness, ok := dependent.DockerComposeService.DependsOn[composeService.Name()]
+		if ok && healthiness == dockerComposeConfig.ServiceCompletedSuccessfully {
+			return true
+		}
+	}
+	return false
+}
+
+// isJob returns whether the app is deployed as a Job. Docker compose services with restart policy on-failure are meant to run to success,
+// which is what Jobs do. Services with restart policy no that other services depend on with condition service_completed_successfully are
+// deployed as a Job as well, because a Job (unlike a bare pod) reliably records that it has succeeded. Stateful apps are always deployed
+// as a StatefulSet.
+func (a *app) isJob() bool {
+	if a.composeService.Stateful {
+		return false
+	}
+	restartPolicy, _, _ := parseRestart(a.composeService.DockerComposeService.Restart)
+	return restartPolicy == v1.RestartPolicyOnFailure || (a.completionDependency && restartPolicy == v1.RestartPolicyNever)
+}
+
+// newJob builds the Job of an app, using pod as the pod template. The back-off limit of the Job is the maximum number of retries of the
+// restart policy on-failure:<max-retries>, and the default back-off limit of Kubernetes otherwise.
+func (u *upRunner) newJob(app *app, pod *v1.Pod) (*batchV1.Job, error) {
+	restartPolicy, maxRetries, err := parseRestart(app.composeService.DockerComposeService.Restart)
+	if err != nil {
+		return nil, err
+	}
+	if app.getReplicas() != 1 {
+		app.newLogEntry().Warnf("ignoring deploy.replicas: a job runs a single pod to completion")
+	}
+	template := v1.PodTemplateSpec{
+		ObjectMeta: *pod.ObjectMeta.DeepCopy(),
+		Spec:       *pod.Spec.DeepCopy(),
+	}
+	template.ObjectMeta.Name = ""
+	// The pods of the template are owned by their controller.
+	template.ObjectMeta.OwnerReferences = nil
+	template.Spec.RestartPolicy = v1.RestartPolicyOnFailure
+	if restartPolicy == v1.RestartPolicyNever {
+		// Restart policy no means that the task is not retried.
+		template.Spec.RestartPolicy = v1.RestartPolicyNever
+		maxRetries = new(int32)
+	}
+	one := int32(1)
+	job := &batchV1.Job{
+		Spec: batchV1.JobSpec{
+			BackoffLimit: maxRetries,
+			Completions:  &one,
+			Parallelism:  &one,
+			Template:     template,
+		},
+	}
+	k8smeta.InitObjectMeta(u.cfg, &job.ObjectMeta, app.composeService)
+	return job, nil
+}
+
+// createJob creates the Job of an app. The pod template of a Job cannot be updated, so an existing Job that is owned by the environment is
+// left untouched (a Job whose configuration has changed is deleted beforehand, see Options.Recreate).
+func (u *upRunner) createJob(app *app, pod *v1.Pod) error {
+	job, err := u.newJob(app, pod)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	_, err = u.k8sJobClient.Create(ctx, job, u.createOptions())
+	if k8sError.IsAlreadyExists(err) {
+		err = checkExistingIsOwned[*batchV1.Job](u, u.k8sJobClient, "job", job)
+		if err != nil {
+			return err
+		}
+		app.newLogEntry().Debugf("job %s already exists", job.ObjectMeta.Name)
+		return nil
+	}
+	if err != nil {
+		return u.applyError(ctx, "job", job.ObjectMeta.Name, err)
+	}
+	app.newLogEntry().Debugf("created job %s", job.ObjectMeta.Name)
+	return nil
+}
+
+// deleteJob deletes the Job of an app, including its pods.
+func (u *upRunner) deleteJob(app *app) error {
+	propagationPolicy := metav1.DeletePropagationBackground
+	err := u.k8sJobClient.Delete(u.opts.Context, k8smeta.GetK8sName(app.composeService, u.cfg), metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	})
+	if k8sError.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// getJobFailure returns an error describing why the Job of an app failed, or nil if the Job has not failed. The pods of a Job are
+// deleted when the Job fails, so this is checked when a pod of a Job is deleted.
+func (u *upRunner) getJobFailure(app *app) error {
+	name := k8smeta.GetK8sName(app.composeService, u.cfg)
+	job, err := u.k8sJobClient.Get(u.opts.Context, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchV1.JobFailed && condition.Status == v1.ConditionTrue {
+			return fmt.Errorf("job %s failed (%s): %s", name, condition.Reason, condition.Message)
+		}
+	}
+	return nil
+}
+
+// waitForJobCompletion polls the status of the Job of an app until the Job has succeeded or failed. An error is returned if the Job has
+// failed, or if its status is not updated within jobStatusPollTimeout.
+func (u *upRunner) waitForJobCompletion(app *app) error {
+	name := k8smeta.GetK8sName(app.composeService, u.cfg)
+	var errFailed error
+	err := wait.PollUntilContextTimeout(u.opts.Context, jobStatusPollInterval, jobStatusPollTimeout, true,
+		func(ctx context.Context) (bool, error) {
+			job, err := u.k8sJobClient.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			if job.Status.Succeeded > 0 {
+				return true, nil
+			}
+			for _, condition := range job.Status.Conditions {
+				if condition.Type == batchV1.JobFailed && condition.Status == v1.ConditionTrue {
+					errFailed = fmt.Errorf("job %s failed (%s): %s", name, condition.Reason, condition.Message)
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+	if err != nil {
+		return fmt.Errorf("error while waiting for job %s to complete: %v", name, err)
+	}
+	return errFailed
+}
+
+// jobCompletion is the result of waitForJobCompletion for an app.
+type jobCompletion struct {
+	app *app
+	err error
+}
+
+// startWaitForJobCompletion waits for the Job of an app in the background once a pod of the Job has completed, so that the events of
+// other pods are handled in the meantime. The result is handled by handleJobCompletion.
+func (u *upRunner) startWaitForJobCompletion(app *app) {
+	if app.waitingForJob {
+		return
+	}
+	app.waitingForJob = true
+	go func() {
+		u.jobCompletions <- jobCompletion{
+			app: app,
+			err: u.waitForJobCompletion(app),
+		}
+	}()
+}
+
+// handleJobCompletion sets app.jobSucceeded if the Job of an app has succeeded, and creates the pods of the dependents that were waiting
+// for the Job. An error is returned if the Job has failed.
+func (u *upRunner) handleJobCompletion(completion jobCompletion) error {
+	app := completion.app
+	app.waitingForJob = false
+	if completion.err != nil {
+		app.setPhase(reporter.PhaseFailed)
+		return completion.err
+	}
+	app.jobSucceeded = true
+	return u.createPodsIfNeeded()
+}
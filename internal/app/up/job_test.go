@@ -0,0 +1,330 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseRestart_OnFailureMaxRetries(t *testing.T) {
+	restartPolicy, maxRetries, err := parseRestart("on-failure:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restartPolicy != v1.RestartPolicyOnFailure || maxRetries == nil || *maxRetries != 2 {
+		t.Error(restartPolicy, maxRetries)
+	}
+}
+
+func TestParseRestart_OnFailureInvalidMaxRetries(t *testing.T) {
+	for _, restart := range []string{"on-failure:", "on-failure:-1", "on-failure:two"} {
+		_, _, err := parseRestart(restart)
+		if err == nil {
+			t.Error(restart)
+		}
+	}
+}
+
+func TestAppIsJob(t *testing.T) {
+	if newTestApp("a").isJob() || newTestApp("b").isJob() || !newTestApp("c").isJob() {
+		t.Fail()
+	}
+	a := newTestApp("c")
+	a.composeService.Stateful = true
+	if a.isJob() {
+		t.Error("stateful app")
+	}
+}
+
+func TestNewJob_BackoffLimit(t *testing.T) {
+	a := newTestApp("c")
+	a.composeService.DockerComposeService.Restart = "on-failure:2"
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.EnvironmentID = "123"
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "c",
+				},
+			},
+			RestartPolicy: v1.RestartPolicyOnFailure,
+		},
+	}
+	job, err := u.newJob(a, pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ObjectMeta.Name != "c-123" || job.Spec.BackoffLimit == nil || *job.Spec.BackoffLimit != 2 {
+		t.Error(job.ObjectMeta.Name, job.Spec.BackoffLimit)
+	}
+	if job.Spec.Completions == nil || *job.Spec.Completions != 1 {
+		t.Error(job.Spec.Completions)
+	}
+	if job.Spec.Template.Spec.RestartPolicy != v1.RestartPolicyOnFailure || job.Spec.Template.ObjectMeta.Name != "" {
+		t.Error(job.Spec.Template)
+	}
+}
+
+func TestNewJob_DefaultBackoffLimit(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	job, err := u.newJob(newTestApp("c"), &v1.Pod{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Spec.BackoffLimit != nil {
+		t.Error(*job.Spec.BackoffLimit)
+	}
+}
+
+func TestGetJobFailure(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	u.cfg.EnvironmentID = "123"
+	a := newTestApp("c")
+	clientset := fake.NewSimpleClientset()
+	u.k8sJobClient = clientset.BatchV1().Jobs("")
+	job, err := u.newJob(a, &v1.Pod{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = clientset.Tracker().Add(job)
+	if err = u.getJobFailure(a); err != nil {
+		t.Error(err)
+	}
+	job.Status.Conditions = []batchV1.JobCondition{
+		{
+			Type:   batchV1.JobFailed,
+			Status: v1.ConditionTrue,
+			Reason: "BackoffLimitExceeded",
+		},
+	}
+	_ = clientset.Tracker().Update(batchV1.SchemeGroupVersion.WithResource("jobs"), job, "")
+	if err = u.getJobFailure(a); err == nil {
+		t.Fail()
+	}
+}
+
+func newTestCreateJobRunner() (*upRunner, *fake.Clientset) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	u.cfg.EnvironmentID = "123"
+	u.cfg.EnvironmentLabel = "env"
+	clientset := fake.NewSimpleClientset()
+	u.k8sJobClient = clientset.BatchV1().Jobs("")
+	return u, clientset
+}
+
+func TestCreateJob_AlreadyExistsOwned(t *testing.T) {
+	u, clientset := newTestCreateJobRunner()
+	a := newTestApp("c")
+	a.reporterRow = reporter.NewPlain(io.Discard).AddRow("c")
+	job, err := u.newJob(a, &v1.Pod{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = clientset.Tracker().Add(job)
+	if err = u.createJob(a, &v1.Pod{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateJob_AlreadyExistsNotOwnedError(t *testing.T) {
+	u, clientset := newTestCreateJobRunner()
+	a := newTestApp("c")
+	_ = clientset.Tracker().Add(&batchV1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "c-123",
+		},
+	})
+	err := u.createJob(a, &v1.Pod{})
+	if err == nil || !strings.Contains(err.Error(), "was not created by kube-compose for environment 123") {
+		t.Error(err)
+	}
+}
+
+func TestIsCompletionDependency(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["a"].DockerComposeService.DependsOn["b"] = dockerComposeConfig.ServiceCompletedSuccessfully
+	if !isCompletionDependency(cfg, cfg.Services["b"]) {
+		t.Error("b")
+	}
+	if isCompletionDependency(cfg, cfg.Services["c"]) || isCompletionDependency(cfg, cfg.Services["d"]) {
+		t.Error("c or d")
+	}
+}
+
+func TestAppIsJob_CompletionDependency(t *testing.T) {
+	// Service a has restart policy no.
+	a := newTestApp("a")
+	a.completionDependency = true
+	if !a.isJob() {
+		t.Error("restart no")
+	}
+	// Service b has restart policy always, so it never completes and stays a pod.
+	b := newTestApp("b")
+	b.completionDependency = true
+	if b.isJob() {
+		t.Error("restart always")
+	}
+}
+
+func TestNewJob_RestartNo(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	a := newTestApp("a")
+	a.completionDependency = true
+	job, err := u.newJob(a, &v1.Pod{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Spec.Template.Spec.RestartPolicy != v1.RestartPolicyNever {
+		t.Error(job.Spec.Template.Spec.RestartPolicy)
+	}
+	if job.Spec.BackoffLimit == nil || *job.Spec.BackoffLimit != 0 {
+		t.Error(job.Spec.BackoffLimit)
+	}
+}
+
+func newTestWaitForJobCompletionRunner(t *testing.T, status batchV1.JobStatus) (*upRunner, *app) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	u.cfg.EnvironmentID = "123"
+	a := newTestApp("c")
+	clientset := fake.NewSimpleClientset()
+	u.k8sJobClient = clientset.BatchV1().Jobs("")
+	job, err := u.newJob(a, &v1.Pod{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Status = status
+	_ = clientset.Tracker().Add(job)
+	return u, a
+}
+
+func TestWaitForJobCompletion_Succeeded(t *testing.T) {
+	u, a := newTestWaitForJobCompletionRunner(t, batchV1.JobStatus{
+		Succeeded: 1,
+	})
+	if err := u.waitForJobCompletion(a); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForJobCompletion_Failed(t *testing.T) {
+	u, a := newTestWaitForJobCompletionRunner(t, batchV1.JobStatus{
+		Conditions: []batchV1.JobCondition{
+			{
+				Type:   batchV1.JobFailed,
+				Status: v1.ConditionTrue,
+				Reason: "BackoffLimitExceeded",
+			},
+		},
+	})
+	if err := u.waitForJobCompletion(a); err == nil {
+		t.Fail()
+	}
+}
+
+func TestWaitForJobCompletion_Timeout(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		jobStatusPollInterval = interval
+		jobStatusPollTimeout = timeout
+	}(jobStatusPollInterval, jobStatusPollTimeout)
+	jobStatusPollInterval = time.Millisecond
+	jobStatusPollTimeout = 10 * time.Millisecond
+	u, a := newTestWaitForJobCompletionRunner(t, batchV1.JobStatus{})
+	if err := u.waitForJobCompletion(a); err == nil {
+		t.Fail()
+	}
+}
+
+func TestStartWaitForJobCompletion_Succeeded(t *testing.T) {
+	u, a := newTestWaitForJobCompletionRunner(t, batchV1.JobStatus{
+		Succeeded: 1,
+	})
+	u.jobCompletions = make(chan jobCompletion, 1)
+	u.startWaitForJobCompletion(a)
+	// The Job is polled once at a time.
+	u.startWaitForJobCompletion(a)
+	completion := <-u.jobCompletions
+	if completion.app != a || completion.err != nil {
+		t.Fatal(completion)
+	}
+	if len(u.jobCompletions) != 0 {
+		t.Fail()
+	}
+	u.appsToBeStarted = map[*app]bool{}
+	if err := u.handleJobCompletion(completion); err != nil {
+		t.Fatal(err)
+	}
+	if !a.jobSucceeded || a.waitingForJob {
+		t.Fail()
+	}
+}
+
+func TestHandleJobCompletion_Failed(t *testing.T) {
+	a := newTestApp("c")
+	a.reporterRow = reporter.NewPlain(io.Discard).AddRow("c")
+	a.waitingForJob = true
+	u := &upRunner{}
+	err := u.handleJobCompletion(jobCompletion{
+		app: a,
+		err: fmt.Errorf("job c-123 failed"),
+	})
+	if err == nil || a.jobSucceeded || a.waitingForJob {
+		t.Fail()
+	}
+}
+
+func TestCreatePodsIfNeeded_WaitsForJobSucceeded(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+	}
+	u.cfg.Services["a"].DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"c": dockerComposeConfig.ServiceCompletedSuccessfully,
+	}
+	u.initApps()
+	a := u.apps["a"]
+	c := u.apps["c"]
+	// The pod of the Job has completed, but the Job has not succeeded yet.
+	c.maxObservedPodStatus = podStatusCompleted
+	u.appsToBeStarted = map[*app]bool{a: true}
+	if err := u.createPodsIfNeeded(); err != nil {
+		t.Fatal(err)
+	}
+	if !u.appsToBeStarted[a] {
+		t.Fail()
+	}
+}
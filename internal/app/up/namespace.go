@@ -0,0 +1,45 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newNamespace builds the namespace of the environment (see Options.CreateNamespace). The namespace is labeled with the environment, so
+// that down --delete-namespace only deletes namespaces that were created by kube-compose for the environment.
+func (u *upRunner) newNamespace() *v1.Namespace {
+	return &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   u.cfg.Namespace,
+			Labels: k8smeta.EnvironmentLabels(u.cfg),
+		},
+	}
+}
+
+// createNamespace creates the namespace of the environment if it does not exist. An existing namespace is left untouched, regardless of
+// who created it, because namespaces are commonly shared by environments.
+func (u *upRunner) createNamespace() error {
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	_, err := u.k8sNamespaceClient.Get(ctx, u.cfg.Namespace, metav1.GetOptions{})
+	if err == nil {
+		log.Debugf("namespace %s already exists", u.cfg.Namespace)
+		return nil
+	}
+	if !k8sError.IsNotFound(err) {
+		return u.applyError(ctx, "namespace", u.cfg.Namespace, err)
+	}
+	_, err = u.k8sNamespaceClient.Create(ctx, u.newNamespace(), u.createOptions())
+	if err != nil {
+		return u.applyError(ctx, "namespace", u.cfg.Namespace, err)
+	}
+	if u.isDryRunServer() {
+		log.Infof("dry run: namespace %s would be created", u.cfg.Namespace)
+	} else {
+		log.Infof("created namespace %s", u.cfg.Namespace)
+	}
+	return nil
+}
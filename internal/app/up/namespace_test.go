@@ -0,0 +1,66 @@
+package up
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestNamespaceRunner(objects ...*v1.Namespace) *upRunner {
+	cfg := newTestConfig()
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	cfg.Namespace = "ns"
+	clientset := fake.NewSimpleClientset()
+	for _, object := range objects {
+		_ = clientset.Tracker().Add(object)
+	}
+	return &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			Context:         context.Background(),
+			CreateNamespace: true,
+		},
+		k8sNamespaceClient: clientset.CoreV1().Namespaces(),
+	}
+}
+
+func TestCreateNamespace_Created(t *testing.T) {
+	u := newTestNamespaceRunner()
+	err := u.createNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespace, err := u.k8sNamespaceClient.Get(context.Background(), "ns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace.Labels["env"] != "123" {
+		t.Error(namespace.Labels)
+	}
+}
+
+func TestCreateNamespace_ExistsNoOp(t *testing.T) {
+	u := newTestNamespaceRunner(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "ns",
+			Labels: map[string]string{
+				"team": "a",
+			},
+		},
+	})
+	err := u.createNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespace, err := u.k8sNamespaceClient.Get(context.Background(), "ns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := namespace.Labels["env"]; ok || namespace.Labels["team"] != "a" {
+		t.Error(namespace.Labels)
+	}
+}
@@ -0,0 +1,99 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	log "github.com/sirupsen/logrus"
+	networkingV1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (u *upRunner) environmentSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			u.cfg.EnvironmentLabel: u.cfg.EnvironmentID,
+		},
+	}
+}
+
+func (u *upRunner) getDefaultDenyIngressPolicyName() string {
+	if u.cfg.EnvironmentIDNoAppend {
+		return "default-deny-ingress"
+	}
+	return "default-deny-ingress-" + u.cfg.EnvironmentID
+}
+
+// newDefaultDenyIngressPolicy builds a NetworkPolicy that selects all pods of the environment and has no ingress rules, so that all
+// ingress traffic to these pods is denied unless another policy allows it. The policy does not have the annotation of a docker compose
+// service, because it applies to the environment as a whole.
+func (u *upRunner) newDefaultDenyIngressPolicy() *networkingV1.NetworkPolicy {
+	policy := &networkingV1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   u.getDefaultDenyIngressPolicyName(),
+			Labels: k8smeta.EnvironmentLabels(u.cfg),
+		},
+		Spec: networkingV1.NetworkPolicySpec{
+			PodSelector: *u.environmentSelector(),
+			PolicyTypes: []networkingV1.PolicyType{
+				networkingV1.PolicyTypeIngress,
+			},
+		},
+	}
+	k8smeta.InitOwnerReferences(u.cfg, &policy.ObjectMeta)
+	return policy
+}
+
+// newAllowIngressPolicy builds a NetworkPolicy that allows ingress traffic to the pods of an app from all pods of the environment, like the
+// default network of docker compose. Traffic is allowed on all ports, because containers on a docker compose network can reach each other
+// on ports that are not declared in ports or expose.
+func (u *upRunner) newAllowIngressPolicy(app *app) *networkingV1.NetworkPolicy {
+	policy := &networkingV1.NetworkPolicy{
+		Spec: networkingV1.NetworkPolicySpec{
+			Ingress: []networkingV1.NetworkPolicyIngressRule{
+				{
+					From: []networkingV1.NetworkPolicyPeer{
+						{
+							PodSelector: u.environmentSelector(),
+						},
+					},
+				},
+			},
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
+			},
+			PolicyTypes: []networkingV1.PolicyType{
+				networkingV1.PolicyTypeIngress,
+			},
+		},
+	}
+	k8smeta.InitObjectMeta(u.cfg, &policy.ObjectMeta, app.composeService)
+	policy.ObjectMeta.Name = k8smeta.TruncateName(u.cfg, policy.ObjectMeta.Name+"-allow-ingress")
+	return policy
+}
+
+// createNetworkPolicies creates (or updates) the default deny ingress policy of the environment and the allow policies of all apps to be
+// started. The policies are created before any pod, so that pods are never reachable from outside the environment.
+func (u *upRunner) createNetworkPolicies() error {
+	policy := u.newDefaultDenyIngressPolicy()
+	op, err := u.createOrUpdateNetworkPolicy(policy)
+	if err != nil {
+		return err
+	}
+	u.logNetworkPolicy(op, policy)
+	for _, app := range u.appsInDependencyOrder() {
+		policy = u.newAllowIngressPolicy(app)
+		op, err = u.createOrUpdateNetworkPolicy(policy)
+		if err != nil {
+			return err
+		}
+		u.logNetworkPolicy(op, policy)
+	}
+	return nil
+}
+
+func (u *upRunner) logNetworkPolicy(op string, policy *networkingV1.NetworkPolicy) {
+	if u.isDryRunServer() {
+		log.Infof("dry run: networkpolicy %s would be %s", policy.ObjectMeta.Name, op)
+	} else {
+		log.Debugf("%s networkpolicy %s", op, policy.ObjectMeta.Name)
+	}
+}
@@ -0,0 +1,64 @@
+package up
+
+import (
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+func newTestNetworkPolicyRunner() *upRunner {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.EnvironmentID = "123"
+	return u
+}
+
+func TestNewDefaultDenyIngressPolicy_Success(t *testing.T) {
+	u := newTestNetworkPolicyRunner()
+	policy := u.newDefaultDenyIngressPolicy()
+	if policy.ObjectMeta.Name != "default-deny-ingress-123" || policy.ObjectMeta.Labels["env"] != "123" {
+		t.Error(policy.ObjectMeta)
+	}
+	if policy.Spec.PodSelector.MatchLabels["env"] != "123" || len(policy.Spec.Ingress) != 0 || len(policy.Spec.PolicyTypes) != 1 {
+		t.Error(policy.Spec)
+	}
+}
+
+func TestNewAllowIngressPolicy_Ports(t *testing.T) {
+	u := newTestNetworkPolicyRunner()
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{
+			Internal: 8080,
+			Protocol: "tcp",
+		},
+	}
+	a.composeService.DockerComposeService.Expose = []dockerComposeConfig.PortBinding{
+		{
+			Internal: 53,
+			Protocol: "udp",
+		},
+	}
+	policy := u.newAllowIngressPolicy(a)
+	if policy.ObjectMeta.Name != "a-123-allow-ingress" || policy.Spec.PodSelector.MatchLabels["app"] != "a" {
+		t.Error(policy.ObjectMeta, policy.Spec.PodSelector)
+	}
+	// Pods of the environment can reach each other on all ports, not only on the declared ports.
+	if len(policy.Spec.Ingress) != 1 || len(policy.Spec.Ingress[0].Ports) != 0 {
+		t.Fatal(policy.Spec.Ingress)
+	}
+	if policy.Spec.Ingress[0].From[0].PodSelector.MatchLabels["env"] != "123" {
+		t.Error(policy.Spec.Ingress[0].From)
+	}
+}
+
+func TestNewAllowIngressPolicy_NoPorts(t *testing.T) {
+	u := newTestNetworkPolicyRunner()
+	policy := u.newAllowIngressPolicy(newTestApp("b"))
+	if len(policy.Spec.Ingress) != 1 || len(policy.Spec.Ingress[0].Ports) != 0 {
+		t.Error(policy.Spec.Ingress)
+	}
+}
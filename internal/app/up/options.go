@@ -2,21 +2,87 @@ package up
 
 import (
 	"context"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
 )
 
+// ExternalService is the address of a docker compose service that is not deployed to the cluster (see config.Config.Skip), but is
+// reachable from within the cluster.
+type ExternalService struct {
+	// An IP address or a DNS name.
+	Host string
+	// Zero if no port was specified.
+	Port int32
+}
+
 type Options struct {
-	Context    context.Context
-	Detach     bool
+	// Bounds each request that creates or updates a resource. Zero means no timeout.
+	ApplyTimeout time.Duration
+	// One of ApplyOrderDeps, ApplyOrderKind and ApplyOrderManifest.
+	ApplyOrder string
+	// True to deploy each service that is neither stateful nor a job as a Deployment with deploy.replicas replicas (1 if not set),
+	// instead of as a bare Pod.
+	AsDeployment bool
+	Context      context.Context
+	// True to create the namespace of the environment if it does not exist.
+	CreateNamespace bool
+	// True to deny all ingress traffic to the pods of the environment, except traffic between pods of the environment.
+	DefaultDenyIngress bool
+	Detach             bool
+	// One of DryRunNone and DryRunServer.
+	DryRun     string
 	EventDiffs bool
-	Reporter   *reporter.Reporter
+	// True to log summaries of Kubernetes events of pods (e.g. an image is being pulled or a readiness probe failed).
+	Explain bool
+	// Maps names of skipped docker compose services to their addresses. IP addresses are added to the host aliases of pods, and DNS names
+	// are published with Services of type ExternalName.
+	External map[string]ExternalService
+	// True to create the Service of each docker compose service without a cluster IP (clusterIP: None), so that its name resolves to the
+	// IPs of the service's pods through the cluster DNS, instead of adding the cluster IPs of Services to the host aliases of pods.
+	HeadlessServices bool
+	// True to include the values of secrets and the credentials of pull secrets in the Secrets returned by Convert. Otherwise their values
+	// are empty (or an empty docker config of pull secrets).
+	IncludeSecretValues bool
+	// The maximum number of steps of the preparation of images (e.g. building a volume init image or pushing an image) that run
+	// concurrently. Zero means runtime.NumCPU().
+	MaxParallel int
+	// The image pull policy of all containers of pods (Always, IfNotPresent or Never). Empty means that the policy of each image depends on
+	// how it reaches the cluster and on its tag (see imagePullPolicyForImage).
+	PullPolicy string
+	// The number of times a push of an image that failed with a retryable error is retried (see isRetryablePushError).
+	PushRetries int
+	// The delay before the first retry of a failed push, which doubles with each retry.
+	PushRetryDelay time.Duration
+	// One of RecreateNever, RecreateChanged and RecreateAlways.
+	Recreate string
+	Reporter *reporter.Reporter
 	// True to set runAsUser/runAsGroup for each pod based on the user of the pod's image and the "user" key of the pod's docker-compose
 	// service.
-	RunAsUser       bool
-	RegistryUser    string
-	RegistryPass    string
-	SkipHostAliases bool
-	SkipPush        bool
-	TailLines       int64
+	RunAsUser    bool
+	RegistryUser string
+	RegistryPass string
+	// A repository path that is prepended to the names of pushed images (e.g. "team-a"). Empty means no prefix.
+	RegistryPrefix string
+	// If true then symlinks in bind mounted volumes whose target is outside the volume are skipped with a warning, instead of failing.
+	SkipEscapingSymlinks bool
+	SkipHostAliases      bool
+	SkipPush             bool
+	// The storage class of the volume claim templates of stateful services. Empty means the default storage class of the cluster.
+	StorageClass string
+	TailLines    int64
+	// One of TransferPush and TransferSaveLoad.
+	Transfer string
+	// The image of the pods that load images into the container runtime of each node, if Transfer is TransferSaveLoad. Empty means
+	// DefaultTransferLoaderImage.
+	TransferLoaderImage string
+	// True to create a ConfigMap that owns all resources of the environment, so that deleting the ConfigMap deletes the environment through
+	// the garbage collector of Kubernetes.
+	UseOwnerRefs bool
+	// The base image of the helper images of bind mounted volumes. Empty means the volume_init_base_image of x-kube-compose.
+	VolumeInitBaseImage string
+	// The delay before each (re)connection to the logs of a container, when not detached.
+	WaitBeforeLogs time.Duration
+	// Bounds the time spent waiting for pods to become ready. Zero means no timeout.
+	WaitTimeout time.Duration
 }
@@ -0,0 +1,42 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newOwner builds the ConfigMap that owns all resources of the environment (see Options.UseOwnerRefs). Like the default deny ingress
+// policy, it does not have the annotation of a docker compose service, because it belongs to the environment as a whole.
+func (u *upRunner) newOwner() *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   k8smeta.GetOwnerName(u.cfg),
+			Labels: k8smeta.EnvironmentLabels(u.cfg),
+		},
+	}
+}
+
+// createOwner creates (or updates) the owner of the environment before any other resource, and sets cfg.OwnerReference so that all
+// resources that are created afterwards are owned by it (see k8smeta.InitOwnerReferences). The owner is in the namespace of the
+// environment, because owner references cannot refer to resources in other namespaces.
+func (u *upRunner) createOwner() error {
+	owner := u.newOwner()
+	result, op, err := createOrUpdate[*v1.ConfigMap](u, u.k8sConfigMapClient, "configmap", owner, nil)
+	if err != nil {
+		return err
+	}
+	if u.isDryRunServer() {
+		log.Infof("dry run: configmap %s would be %s", owner.ObjectMeta.Name, op)
+	} else {
+		log.Debugf("%s configmap %s", op, owner.ObjectMeta.Name)
+	}
+	u.cfg.OwnerReference = &metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       result.ObjectMeta.Name,
+		UID:        result.ObjectMeta.UID,
+	}
+	return nil
+}
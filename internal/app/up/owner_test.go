@@ -0,0 +1,72 @@
+package up
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestOwnerRunner() *upRunner {
+	cfg := newTestConfig()
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	clientset := fake.NewSimpleClientset()
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			Context:      context.Background(),
+			Recreate:     RecreateChanged,
+			UseOwnerRefs: true,
+		},
+		k8sConfigMapClient: clientset.CoreV1().ConfigMaps(""),
+	}
+	u.initApps()
+	return u
+}
+
+func TestCreateOwner_Success(t *testing.T) {
+	u := newTestOwnerRunner()
+	err := u.createOwner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerReference := u.cfg.OwnerReference
+	if ownerReference == nil || ownerReference.Kind != "ConfigMap" || ownerReference.Name != "kube-compose-123" {
+		t.Fatal(ownerReference)
+	}
+	// Resources of docker compose services and of the environment as a whole are owned.
+	secret := u.newSecret("secret1")
+	policy := u.newDefaultDenyIngressPolicy()
+	service := u.newService(u.apps["a"])
+	for _, ownerReferences := range [][]metav1.OwnerReference{
+		secret.ObjectMeta.OwnerReferences,
+		policy.ObjectMeta.OwnerReferences,
+		service.ObjectMeta.OwnerReferences,
+	} {
+		if len(ownerReferences) != 1 || ownerReferences[0].Name != ownerReference.Name {
+			t.Error(ownerReferences)
+		}
+	}
+}
+
+func TestCreateOwner_PodTemplateNotOwned(t *testing.T) {
+	u := newTestOwnerRunner()
+	err := u.createOwner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{}},
+		},
+	}
+	k8smeta.InitObjectMeta(u.cfg, &pod.ObjectMeta, u.apps["a"].composeService)
+	deployment := u.newDeployment(u.apps["a"], pod)
+	if len(deployment.ObjectMeta.OwnerReferences) != 1 || len(deployment.Spec.Template.ObjectMeta.OwnerReferences) != 0 {
+		t.Error(deployment.ObjectMeta.OwnerReferences, deployment.Spec.Template.ObjectMeta.OwnerReferences)
+	}
+}
@@ -0,0 +1,72 @@
+package up
+
+import (
+	"strings"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+const nodeLabelsPrefix = "node.labels."
+
+// Maps node attributes of swarm placement constraints to the well-known labels of Kubernetes nodes.
+var placementConstraintNodeLabels = map[string]string{
+	"node.hostname":      v1.LabelHostname,
+	"node.platform.arch": v1.LabelArchStable,
+	"node.platform.os":   v1.LabelOSStable,
+}
+
+// createPodPlacement translates the placement constraints of an app to the node selector and node affinity of a pod. Constraints of the
+// form "attribute == value" become entries of the node selector, and constraints of the forms "attribute != value" and "attribute in
+// (value1, value2)" become node selector requirements with the operators NotIn and In respectively. Constraints that refer to swarm
+// specific attributes (e.g. node.role and engine.labels) cannot be translated and are ignored with a warning.
+func (a *app) createPodPlacement(podSpec *v1.PodSpec) {
+	deploy := a.composeService.DockerComposeService.Deploy
+	if deploy == nil {
+		return
+	}
+	var requirements []v1.NodeSelectorRequirement
+	for _, constraint := range deploy.PlacementConstraints {
+		label, ok := placementConstraintNodeLabels[constraint.Attribute]
+		if !ok {
+			if !strings.HasPrefix(constraint.Attribute, nodeLabelsPrefix) {
+				a.newLogEntry().Warnf("ignoring placement constraint on %#v: only node labels, node.hostname and node.platform can be "+
+					"translated", constraint.Attribute)
+				continue
+			}
+			label = constraint.Attribute[len(nodeLabelsPrefix):]
+		}
+		switch constraint.Operator {
+		case dockerComposeConfig.PlacementConstraintEqual:
+			if podSpec.NodeSelector == nil {
+				podSpec.NodeSelector = map[string]string{}
+			}
+			podSpec.NodeSelector[label] = constraint.Values[0]
+		case dockerComposeConfig.PlacementConstraintNotEqual:
+			requirements = append(requirements, v1.NodeSelectorRequirement{
+				Key:      label,
+				Operator: v1.NodeSelectorOpNotIn,
+				Values:   constraint.Values,
+			})
+		case dockerComposeConfig.PlacementConstraintIn:
+			requirements = append(requirements, v1.NodeSelectorRequirement{
+				Key:      label,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   constraint.Values,
+			})
+		}
+	}
+	if len(requirements) > 0 {
+		podSpec.Affinity = &v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{
+						{
+							MatchExpressions: requirements,
+						},
+					},
+				},
+			},
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package up
+
+import (
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestCreatePodPlacement_NodeSelector(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		PlacementConstraints: []dockerComposeConfig.PlacementConstraint{
+			{Attribute: "node.labels.zone", Operator: dockerComposeConfig.PlacementConstraintEqual, Values: []string{"us-east"}},
+			{Attribute: "node.platform.os", Operator: dockerComposeConfig.PlacementConstraintEqual, Values: []string{"linux"}},
+		},
+	}
+	podSpec := &v1.PodSpec{}
+	a.createPodPlacement(podSpec)
+	if len(podSpec.NodeSelector) != 2 || podSpec.NodeSelector["zone"] != "us-east" || podSpec.NodeSelector[v1.LabelOSStable] != "linux" {
+		t.Error(podSpec.NodeSelector)
+	}
+	if podSpec.Affinity != nil {
+		t.Error(podSpec.Affinity)
+	}
+}
+
+func TestCreatePodPlacement_NodeAffinity(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		PlacementConstraints: []dockerComposeConfig.PlacementConstraint{
+			{Attribute: "node.labels.zone", Operator: dockerComposeConfig.PlacementConstraintNotEqual, Values: []string{"us-east"}},
+			{Attribute: "node.role", Operator: dockerComposeConfig.PlacementConstraintEqual, Values: []string{"manager"}},
+		},
+	}
+	podSpec := &v1.PodSpec{}
+	a.createPodPlacement(podSpec)
+	if podSpec.NodeSelector != nil || podSpec.Affinity == nil {
+		t.Fatal(podSpec)
+	}
+	terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchExpressions) != 1 {
+		t.Fatal(terms)
+	}
+	requirement := terms[0].MatchExpressions[0]
+	if requirement.Key != "zone" || requirement.Operator != v1.NodeSelectorOpNotIn || requirement.Values[0] != "us-east" {
+		t.Error(requirement)
+	}
+}
+
+func TestCreatePodPlacement_In(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		PlacementConstraints: []dockerComposeConfig.PlacementConstraint{
+			{Attribute: "node.labels.zone", Operator: dockerComposeConfig.PlacementConstraintIn, Values: []string{"us-east", "us-west"}},
+		},
+	}
+	podSpec := &v1.PodSpec{}
+	a.createPodPlacement(podSpec)
+	if podSpec.NodeSelector != nil || podSpec.Affinity == nil {
+		t.Fatal(podSpec)
+	}
+	terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchExpressions) != 1 {
+		t.Fatal(terms)
+	}
+	requirement := terms[0].MatchExpressions[0]
+	if requirement.Key != "zone" || requirement.Operator != v1.NodeSelectorOpIn || len(requirement.Values) != 2 {
+		t.Error(requirement)
+	}
+}
@@ -0,0 +1,56 @@
+package up
+
+import (
+	dockerRef "github.com/docker/distribution/reference"
+	v1 "k8s.io/api/core/v1"
+)
+
+// imagePullPolicyForImage returns the image pull policy of an image that is used as is, like docker: images that are untagged or tagged
+// latest may change, so these are always pulled, whereas images with any other tag or a digest are only pulled if they are not present on
+// the node. Returns the empty string (i.e. the default of the cluster) if the image reference cannot be parsed.
+func imagePullPolicyForImage(image string) v1.PullPolicy {
+	ref, err := dockerRef.ParseAnyReference(image)
+	if err != nil {
+		return ""
+	}
+	if _, isDigested := ref.(dockerRef.Digested); isDigested {
+		return v1.PullIfNotPresent
+	}
+	tag := getTag(ref)
+	if tag == "" || tag == "latest" {
+		return v1.PullAlways
+	}
+	return v1.PullIfNotPresent
+}
+
+// isDigestReference returns true if the image reference has a digest, and therefore always refers to the same image.
+func isDigestReference(image string) bool {
+	ref, err := dockerRef.ParseAnyReference(image)
+	if err != nil {
+		return false
+	}
+	_, isDigested := ref.(dockerRef.Digested)
+	return isDigested
+}
+
+// pushedImagePullPolicy returns the image pull policy of an image that was pushed by pushImage: a pushed tag is overwritten by every run,
+// so it is always pulled, whereas a digest reference is only pulled if it is not present on the node.
+func pushedImagePullPolicy(podImage string) v1.PullPolicy {
+	if isDigestReference(podImage) {
+		return v1.PullIfNotPresent
+	}
+	return v1.PullAlways
+}
+
+// applyPullPolicy overrides the image pull policy of all containers of the pod with Options.PullPolicy, if set.
+func (u *upRunner) applyPullPolicy(pod *v1.Pod) {
+	if u.opts.PullPolicy == "" {
+		return
+	}
+	for i := range pod.Spec.InitContainers {
+		pod.Spec.InitContainers[i].ImagePullPolicy = v1.PullPolicy(u.opts.PullPolicy)
+	}
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].ImagePullPolicy = v1.PullPolicy(u.opts.PullPolicy)
+	}
+}
@@ -0,0 +1,77 @@
+package up
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestImagePullPolicyForImage(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected v1.PullPolicy
+	}{
+		{"ubuntu", v1.PullAlways},
+		{"ubuntu:latest", v1.PullAlways},
+		{"registry.example.com:5000/team/app", v1.PullAlways},
+		{"ubuntu:22.04", v1.PullIfNotPresent},
+		{"registry.example.com:5000/team/app:1.2.3", v1.PullIfNotPresent},
+		{"ubuntu@sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859", v1.PullIfNotPresent},
+		{"ubuntu:latest@sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859", v1.PullIfNotPresent},
+		{"Invalid:Image:Reference", ""},
+	}
+	for _, testCase := range testCases {
+		if actual := imagePullPolicyForImage(testCase.image); actual != testCase.expected {
+			t.Errorf("image %#v: expected %#v but got %#v", testCase.image, testCase.expected, actual)
+		}
+	}
+}
+
+func TestPushedImagePullPolicy(t *testing.T) {
+	podImage := "registry:5000/ns/a@sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859"
+	if pullPolicy := pushedImagePullPolicy(podImage); pullPolicy != v1.PullIfNotPresent {
+		t.Error(pullPolicy)
+	}
+	if pullPolicy := pushedImagePullPolicy("registry:5000/ns/a:123-main"); pullPolicy != v1.PullAlways {
+		t.Error(pullPolicy)
+	}
+}
+
+func TestApplyPullPolicy_Forced(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			PullPolicy: string(v1.PullNever),
+		},
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				{ImagePullPolicy: v1.PullAlways},
+			},
+			Containers: []v1.Container{
+				{ImagePullPolicy: v1.PullIfNotPresent},
+			},
+		},
+	}
+	u.applyPullPolicy(pod)
+	if pod.Spec.InitContainers[0].ImagePullPolicy != v1.PullNever || pod.Spec.Containers[0].ImagePullPolicy != v1.PullNever {
+		t.Error(pod.Spec)
+	}
+}
+
+func TestApplyPullPolicy_NotForced(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{},
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{ImagePullPolicy: v1.PullIfNotPresent},
+			},
+		},
+	}
+	u.applyPullPolicy(pod)
+	if pod.Spec.Containers[0].ImagePullPolicy != v1.PullIfNotPresent {
+		t.Error(pod.Spec)
+	}
+}
@@ -0,0 +1,140 @@
+package up
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	dockerRef "github.com/docker/distribution/reference"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The domain of Docker Hub, whose images are pulled without a pull secret.
+const dockerHubDomain = "docker.io"
+
+// dockerConfigJSON is the format of the data of a Secret of type kubernetes.io/dockerconfigjson, which is the format of
+// ~/.docker/config.json.
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigJSONAuth `json:"auths"`
+}
+
+type dockerConfigJSONAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// The base64 encoding of username:password.
+	Auth string `json:"auth"`
+}
+
+// newDockerConfigJSON serializes the credentials of a registry in the format of a Secret of type kubernetes.io/dockerconfigjson.
+func newDockerConfigJSON(registryHost, user, pass string) ([]byte, error) {
+	return json.Marshal(&dockerConfigJSON{
+		Auths: map[string]dockerConfigJSONAuth{
+			registryHost: {
+				Username: user,
+				Password: pass,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(user + ":" + pass)),
+			},
+		},
+	})
+}
+
+// getRegistryHost returns the host of the registry of an image reference (e.g. docker.io for ubuntu:latest), or the empty string if the
+// image reference cannot be parsed.
+func getRegistryHost(image string) string {
+	named, err := dockerRef.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	return dockerRef.Domain(named)
+}
+
+func (u *upRunner) pullSecretNameForRegistry(registryHost string) string {
+	return k8smeta.GetK8sNameFromEscapedName(u.cfg, "pull-secret-"+util.EscapeName(registryHost))
+}
+
+// getPullSecretCredentials returns the credentials of the pull secret of a registry: Options.RegistryUser and Options.RegistryPass if a
+// registry password is given, and otherwise the credentials of the registry in the docker config (see docker login). Returns false if
+// there are no credentials for the registry.
+func (u *upRunner) getPullSecretCredentials(registryHost string) (user, pass string, ok bool) {
+	if u.opts.RegistryPass != "" {
+		return u.opts.RegistryUser, u.opts.RegistryPass, true
+	}
+	if _, err, done := u.readAuthConfigurations(); done && err != nil {
+		return "", "", false
+	}
+	authConfig, ok := u.authConfigurations.Configs[registryHost]
+	if !ok || authConfig.Password == "" {
+		return "", "", false
+	}
+	return authConfig.Username, authConfig.Password, true
+}
+
+// newPullSecret builds the pull secret of a registry with the specified credentials. Like the Secrets of docker compose secrets, pull
+// secrets are shared by apps, so they are labeled with the environment but are not annotated with a docker compose service.
+func (u *upRunner) newPullSecret(registryHost, user, pass string) (*v1.Secret, error) {
+	data, err := newDockerConfigJSON(registryHost, user, pass)
+	if err != nil {
+		return nil, err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   u.pullSecretNameForRegistry(registryHost),
+			Labels: k8smeta.EnvironmentLabels(u.cfg),
+		},
+		Type: v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			v1.DockerConfigJsonKey: data,
+		},
+	}
+	k8smeta.InitOwnerReferences(u.cfg, &secret.ObjectMeta)
+	return secret, nil
+}
+
+// createSecretForRegistry creates (or updates) the pull secret of a registry once per run, and returns its name.
+func (u *upRunner) createSecretForRegistry(registryHost, user, pass string) (string, error) {
+	name := u.pullSecretNameForRegistry(registryHost)
+	if u.secretsDeployed[registryHost] {
+		return name, nil
+	}
+	secret, err := u.newPullSecret(registryHost, user, pass)
+	if err != nil {
+		return name, err
+	}
+	op, err := u.createOrUpdateSecret(secret)
+	if err != nil {
+		return name, err
+	}
+	log.Debugf("%s secret %s", op, name)
+	u.secretsDeployed[registryHost] = true
+	return name, nil
+}
+
+// getAppPullSecretCredentials returns the registry of the app's image and the credentials of its pull secret. Returns false if the pod
+// does not need a pull secret: there are no credentials for the registry (see getPullSecretCredentials), or the image is on Docker Hub
+// (the credentials are meant for a private registry).
+func (u *upRunner) getAppPullSecretCredentials(app *app) (registryHost, user, pass string, ok bool) {
+	registryHost = getRegistryHost(app.imageInfo.podImage)
+	if registryHost == "" || registryHost == dockerHubDomain {
+		return "", "", "", false
+	}
+	user, pass, ok = u.getPullSecretCredentials(registryHost)
+	return registryHost, user, pass, ok
+}
+
+// createPodPullSecrets creates (or updates) the pull secret of the registry of the app's image, if needed (see
+// getAppPullSecretCredentials), and adds it to the image pull secrets of the pod.
+func (u *upRunner) createPodPullSecrets(app *app, pod *v1.Pod) {
+	registryHost, user, pass, ok := u.getAppPullSecretCredentials(app)
+	if !ok {
+		return
+	}
+	pullSecret, err := u.createSecretForRegistry(registryHost, user, pass)
+	if err != nil {
+		app.newLogEntry().Warnf("failed to create pull secret %s (but continuing...): %v", pullSecret, err)
+		return
+	}
+	pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: pullSecret})
+}
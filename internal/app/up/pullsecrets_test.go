@@ -0,0 +1,155 @@
+package up
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	alternateDockerClient "github.com/fsouza/go-dockerclient"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewDockerConfigJSON_Success(t *testing.T) {
+	data, err := newDockerConfigJSON("registry.example.com:5000", "henk", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"auths":{"registry.example.com:5000":{"username":"henk","password":"hunter2","auth":"aGVuazpodW50ZXIy"}}}`
+	if string(data) != expected {
+		t.Error(string(data))
+	}
+}
+
+func TestNewDockerConfigJSON_RoundTrip(t *testing.T) {
+	data, err := newDockerConfigJSON("registry.example.com", "user\"1", "p@ss:word")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded dockerConfigJSON
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := decoded.Auths["registry.example.com"]
+	if auth.Username != "user\"1" || auth.Password != "p@ss:word" || auth.Auth != "dXNlciIxOnBAc3M6d29yZA==" {
+		t.Error(auth)
+	}
+}
+
+func TestGetRegistryHost(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected string
+	}{
+		{"ubuntu:latest", "docker.io"},
+		{"library/ubuntu", "docker.io"},
+		{"registry.example.com:5000/team/app:1.0", "registry.example.com:5000"},
+		{"localhost/app", "localhost"},
+		{"Invalid:Image:Reference", ""},
+	}
+	for _, testCase := range testCases {
+		if actual := getRegistryHost(testCase.image); actual != testCase.expected {
+			t.Errorf("image %#v: expected %#v but got %#v", testCase.image, testCase.expected, actual)
+		}
+	}
+}
+
+func newTestPullSecretRunner(registryPass string) (*upRunner, *fake.Clientset) {
+	cfg := newTestConfig()
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	clientset := fake.NewSimpleClientset()
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			Context:      context.Background(),
+			Recreate:     RecreateChanged,
+			RegistryUser: "henk",
+			RegistryPass: registryPass,
+		},
+		k8sSecretClient: clientset.CoreV1().Secrets(""),
+		// No credentials of docker login, so that the docker config of the user running the tests is not read.
+		authConfigurations: &alternateDockerClient.AuthConfigurations{
+			Configs: map[string]alternateDockerClient.AuthConfiguration{},
+		},
+	}
+	u.initApps()
+	return u, clientset
+}
+
+func TestCreatePodPullSecrets_Created(t *testing.T) {
+	u, clientset := newTestPullSecretRunner("hunter2")
+	for _, name := range []string{"a", "b"} {
+		a := u.apps[name]
+		a.imageInfo.podImage = "registry.example.com/team/" + name + ":1.0"
+		pod := &v1.Pod{}
+		u.createPodPullSecrets(a, pod)
+		if len(pod.Spec.ImagePullSecrets) != 1 || pod.Spec.ImagePullSecrets[0].Name != u.pullSecretNameForRegistry("registry.example.com") {
+			t.Fatal(pod.Spec.ImagePullSecrets)
+		}
+	}
+	secrets, err := clientset.CoreV1().Secrets("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets.Items) != 1 {
+		t.Fatal(secrets.Items)
+	}
+	secret := secrets.Items[0]
+	if secret.Type != v1.SecretTypeDockerConfigJson || len(secret.Data[v1.DockerConfigJsonKey]) == 0 || secret.Labels["env"] != "123" {
+		t.Error(secret)
+	}
+}
+
+func TestCreatePodPullSecrets_NoCredentials(t *testing.T) {
+	u, clientset := newTestPullSecretRunner("")
+	a := u.apps["a"]
+	a.imageInfo.podImage = "registry.example.com/team/a:1.0"
+	pod := &v1.Pod{}
+	u.createPodPullSecrets(a, pod)
+	if len(pod.Spec.ImagePullSecrets) != 0 {
+		t.Error(pod.Spec.ImagePullSecrets)
+	}
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Error(actions)
+	}
+}
+
+func TestCreatePodPullSecrets_DockerLoginCredentials(t *testing.T) {
+	u, clientset := newTestPullSecretRunner("")
+	u.authConfigurations.Configs["registry.example.com"] = alternateDockerClient.AuthConfiguration{
+		Username: "jan",
+		Password: "hunter3",
+	}
+	a := u.apps["a"]
+	a.imageInfo.podImage = "registry.example.com/team/a:1.0"
+	pod := &v1.Pod{}
+	u.createPodPullSecrets(a, pod)
+	if len(pod.Spec.ImagePullSecrets) != 1 {
+		t.Fatal(pod.Spec.ImagePullSecrets)
+	}
+	secret, err := clientset.CoreV1().Secrets("").Get(context.Background(), pod.Spec.ImagePullSecrets[0].Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config dockerConfigJSON
+	if err = json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &config); err != nil {
+		t.Fatal(err)
+	}
+	if auth := config.Auths["registry.example.com"]; auth.Username != "jan" || auth.Password != "hunter3" {
+		t.Error(config)
+	}
+}
+
+func TestCreatePodPullSecrets_DockerHubSkipped(t *testing.T) {
+	u, _ := newTestPullSecretRunner("hunter2")
+	a := u.apps["a"]
+	a.imageInfo.podImage = "ubuntu:latest"
+	pod := &v1.Pod{}
+	u.createPodPullSecrets(a, pod)
+	if len(pod.Spec.ImagePullSecrets) != 0 {
+		t.Error(pod.Spec.ImagePullSecrets)
+	}
+}
@@ -0,0 +1,71 @@
+package up
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+)
+
+const (
+	// DefaultPushRetries is the default number of times a failed push of an image is retried.
+	DefaultPushRetries = 3
+	// DefaultPushRetryDelay is the default delay before the first retry of a failed push, which doubles with each retry.
+	DefaultPushRetryDelay = time.Second
+)
+
+// Substrings of the (lower case) messages of push errors that a retry cannot fix, because the registry rejected the credentials (401) or
+// denied access to the repository (403). The docker daemon reports these errors as messages in the push stream, without status codes.
+var fatalPushErrorMessages = []string{
+	"unauthorized",
+	"authentication required",
+	"forbidden",
+	"denied",
+	"application not registered with aad",
+}
+
+// isRetryablePushError returns false if a retry of the push cannot succeed: the registry rejected the credentials or denied access, the
+// image does not exist locally, or the push was cancelled. Other errors (e.g. network errors and 5xx responses of the registry) are assumed
+// to be transient.
+func isRetryablePushError(err error) bool {
+	if errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) || errdefs.IsNotFound(err) || errdefs.IsInvalidParameter(err) ||
+		errdefs.IsContext(err) {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fatal := range fatalPushErrorMessages {
+		if strings.Contains(msg, fatal) {
+			return false
+		}
+	}
+	return true
+}
+
+// pushImageWithRetries pushes an image, retrying retryable errors (see isRetryablePushError) up to Options.PushRetries times with
+// exponential backoff starting at Options.PushRetryDelay. Each attempt is a progress task of the app, so that retries are reported.
+func (u *upRunner) pushImageWithRetries(pusher docker.ImagePusher, a *app, image, registryAuth, imageDescr string) (string, error) {
+	delay := u.opts.PushRetryDelay
+	for attempt := 0; ; attempt++ {
+		name := "pushing " + imageDescr
+		if attempt > 0 {
+			name += fmt.Sprintf(" (retry %d/%d)", attempt, u.opts.PushRetries)
+		}
+		pt := a.reporterRow.AddProgressTask(name)
+		digest, err := docker.PushImage(u.imageContext(), pusher, image, registryAuth, func(push *docker.PullOrPush) {
+			pt.Update(push.Progress())
+		})
+		pt.Done()
+		if err == nil || attempt >= u.opts.PushRetries || !isRetryablePushError(err) {
+			return digest, err
+		}
+		a.newLogEntry().Warnf("pushing %s failed, retrying in %s (%d/%d): %v", image, delay, attempt+1, u.opts.PushRetries, err)
+		select {
+		case <-u.imageContext().Done():
+			return "", u.imageContext().Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
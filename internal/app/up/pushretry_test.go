@@ -0,0 +1,117 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	dockerApiTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/pkg/errors"
+)
+
+const testPushDigest = "sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859"
+
+// mockImagePusher replies to each push with the next of errs, where a nil error is a successful push.
+type mockImagePusher struct {
+	errs   []error
+	pushes int
+}
+
+func (m *mockImagePusher) ImagePush(ctx context.Context, image string, pushOptions dockerApiTypes.ImagePushOptions) (io.ReadCloser,
+	error) {
+	err := m.errs[m.pushes]
+	m.pushes++
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(fmt.Sprintf(`{"status":"123-main: digest: %s size: 528"}`, testPushDigest))), nil
+}
+
+func newTestPushRetriesRunner(pushRetries int) (*upRunner, *app) {
+	u := &upRunner{
+		opts: &Options{
+			Context:     context.Background(),
+			PushRetries: pushRetries,
+		},
+	}
+	a := newTestApp("a")
+	a.reporterRow = reporter.NewPlain(io.Discard).AddRow("a")
+	return u, a
+}
+
+func TestIsRetryablePushError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.New("dial tcp 10.0.0.1:5000: connect: connection refused"), true},
+		{errors.New("error while pushing image: received unexpected HTTP status: 503 Service Unavailable"), true},
+		{errors.New("unknown error while pushing image"), true},
+		{errors.New("error while pushing image: unauthorized: authentication required"), false},
+		{errors.New("error while pushing image: denied: requested access to the resource is denied"), false},
+		{errors.New("error while pushing image: received unexpected HTTP status: 403 Forbidden"), false},
+		{errdefs.Unauthorized(errors.New("bad credentials")), false},
+		{errdefs.NotFound(errors.New("no such image")), false},
+		{errors.Wrap(context.Canceled, "pushing"), false},
+	}
+	for _, testCase := range testCases {
+		if actual := isRetryablePushError(testCase.err); actual != testCase.expected {
+			t.Errorf("error %#v: expected %v but got %v", testCase.err.Error(), testCase.expected, actual)
+		}
+	}
+}
+
+func TestPushImageWithRetries_RetrySuccess(t *testing.T) {
+	u, a := newTestPushRetriesRunner(3)
+	pusher := &mockImagePusher{
+		errs: []error{errors.New("connection reset by peer"), errors.New("502 Bad Gateway"), nil},
+	}
+	digest, err := u.pushImageWithRetries(pusher, a, "registry:5000/ns/a:123-main", "", "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != testPushDigest || pusher.pushes != 3 {
+		t.Error(digest, pusher.pushes)
+	}
+}
+
+func TestPushImageWithRetries_RetriesExhausted(t *testing.T) {
+	u, a := newTestPushRetriesRunner(1)
+	pusher := &mockImagePusher{
+		errs: []error{errors.New("connection reset by peer"), errors.New("connection reset by peer"), nil},
+	}
+	_, err := u.pushImageWithRetries(pusher, a, "registry:5000/ns/a:123-main", "", "image")
+	if err == nil || pusher.pushes != 2 {
+		t.Error(err, pusher.pushes)
+	}
+}
+
+func TestPushImageWithRetries_FatalError(t *testing.T) {
+	u, a := newTestPushRetriesRunner(3)
+	pusher := &mockImagePusher{
+		errs: []error{errors.New("unauthorized: authentication required"), nil},
+	}
+	_, err := u.pushImageWithRetries(pusher, a, "registry:5000/ns/a:123-main", "", "image")
+	if err == nil || pusher.pushes != 1 {
+		t.Error(err, pusher.pushes)
+	}
+}
+
+func TestPushImageWithRetries_Cancelled(t *testing.T) {
+	u, a := newTestPushRetriesRunner(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	u.opts.Context = ctx
+	u.opts.PushRetryDelay = DefaultPushRetryDelay
+	pusher := &mockImagePusher{
+		errs: []error{errors.New("connection reset by peer"), nil},
+	}
+	_, err := u.pushImageWithRetries(pusher, a, "registry:5000/ns/a:123-main", "", "image")
+	if err != context.Canceled || pusher.pushes != 1 {
+		t.Error(err, pusher.pushes)
+	}
+}
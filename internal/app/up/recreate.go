@@ -0,0 +1,137 @@
+package up
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	log "github.com/sirupsen/logrus"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// RecreateNever leaves existing resources untouched.
+	RecreateNever = "never"
+	// RecreateChanged recreates the pods of services whose configuration has changed since they were created (the default), and updates
+	// other existing resources in place.
+	RecreateChanged = "changed"
+	// RecreateAlways recreates the pods of all services, and updates other existing resources in place.
+	RecreateAlways = "always"
+)
+
+// The interval at which pods that are being deleted are polled.
+const recreatePollInterval = time.Second
+
+// configHash returns a hash of the docker compose configuration of an app and of the resolved images of its pod, so that the pod is
+// recreated if an image has changed (e.g. if a rebuilt image was pushed, or the contents of a bind mounted volume have changed). The x-
+// properties are not hashed, because these are only relevant to the extent that they are reflected in other fields. The images must have
+// been resolved (see getAppImageInfoOnce and getAppVolumeInitImageOnce).
+func configHash(a *app) (string, error) {
+	dockerComposeService := *a.composeService.DockerComposeService
+	dockerComposeService.XProperties = nil
+	data, err := json.Marshal(&struct {
+		Service         *dockerComposeConfig.Service
+		Image           string
+		VolumeInitImage string
+	}{
+		Service:         &dockerComposeService,
+		Image:           a.imageInfo.podImage,
+		VolumeInitImage: a.volumeInitImage.podImage,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// shouldUpdateExisting returns true if resources that already exist should be updated.
+func (u *upRunner) shouldUpdateExisting() bool {
+	return u.opts.Recreate != RecreateNever
+}
+
+// deletePodsToBeRecreated deletes the existing pods of apps to be started according to Options.Recreate, and waits until these pods have
+// been deleted. This is done before any pod is created, so that the status of deleted pods is not observed when checking depends_on
+// conditions. With RecreateChanged, this waits for the images of the apps that have existing pods (see configHash).
+func (u *upRunner) deletePodsToBeRecreated() error {
+	if !u.shouldUpdateExisting() {
+		return nil
+	}
+	listOptions := metav1.ListOptions{
+		LabelSelector: u.cfg.EnvironmentLabel + "=" + u.cfg.EnvironmentID,
+	}
+	podList, err := u.k8sPodClient.List(u.opts.Context, listOptions)
+	if err != nil {
+		return err
+	}
+	var deleted []string
+	for i := 0; i < len(podList.Items); i++ {
+		pod := &podList.Items[i]
+		app := u.findAppFromObjectMeta(&pod.ObjectMeta)
+		if app == nil || !u.appsToBeStarted[app] || pod.ObjectMeta.DeletionTimestamp != nil {
+			continue
+		}
+		if u.opts.Recreate == RecreateChanged {
+			err = u.getAppImageInfoOnce(app)
+			if err != nil {
+				return err
+			}
+			if len(app.volumes) > 0 {
+				err = u.getAppVolumeInitImageOnce(app)
+				if err != nil {
+					return err
+				}
+			}
+			hash, err := configHash(app)
+			if err != nil {
+				return err
+			}
+			if pod.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] == hash {
+				continue
+			}
+		}
+		if app.isJob() {
+			// The Job controller would replace a deleted pod of the Job, so the Job itself is deleted.
+			err = u.deleteJob(app)
+		} else if u.isDeployment(app) {
+			// Likewise, the Deployment controller would replace a deleted pod of the Deployment.
+			err = u.deleteDeployment(app)
+		} else {
+			err = u.k8sPodClient.Delete(u.opts.Context, pod.ObjectMeta.Name, metav1.DeleteOptions{})
+		}
+		if err != nil && !k8sError.IsNotFound(err) {
+			return err
+		}
+		app.newLogEntry().Infof("recreating pod %s", pod.ObjectMeta.Name)
+		deleted = append(deleted, pod.ObjectMeta.Name)
+	}
+	for _, name := range deleted {
+		err = u.waitForPodDeleted(name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *upRunner) waitForPodDeleted(name string) error {
+	err := wait.PollUntilContextCancel(u.opts.Context, recreatePollInterval, true, func(ctx context.Context) (bool, error) {
+		_, err := u.k8sPodClient.Get(ctx, name, metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for pod %s to be deleted: %v", name, err)
+	}
+	log.Debugf("deleted pod %s", name)
+	return nil
+}
@@ -0,0 +1,141 @@
+package up
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigHash_Changed(t *testing.T) {
+	a := newTestApp("a")
+	hash1, err := configHash(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.composeService.DockerComposeService.XProperties = map[string]interface{}{
+		"x-nested": map[interface{}]interface{}{
+			"key": "value",
+		},
+	}
+	hash2, err := configHash(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash1 != hash2 {
+		t.Error("x- properties changed the hash")
+	}
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"KEY": "VALUE",
+	}
+	hash3, _ := configHash(a)
+	if hash1 == hash3 {
+		t.Error("environment did not change the hash")
+	}
+	a.imageInfo.podImage = "registry:5000/ns/a@sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859"
+	hash4, _ := configHash(a)
+	if hash3 == hash4 {
+		t.Error("image did not change the hash")
+	}
+	a.volumeInitImage.podImage = "registry:5000/ns/a-init@sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859"
+	hash5, _ := configHash(a)
+	if hash4 == hash5 {
+		t.Error("volume init image did not change the hash")
+	}
+}
+
+func newTestRecreateRunner(recreate string) *upRunner {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			Context:  context.Background(),
+			Recreate: recreate,
+		},
+	}
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.EnvironmentID = "123"
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{
+		u.apps["a"]: true,
+		u.apps["b"]: true,
+	}
+	for _, a := range u.apps {
+		// The images are resolved.
+		a.imageInfo.podImage = a.name() + ":latest"
+		a.imageInfo.once.Do(func() {})
+	}
+	hashA, _ := configHash(u.apps["a"])
+	var objects = []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					k8smeta.ConfigHashAnnotationName: hashA,
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					k8smeta.ConfigHashAnnotationName: "outdated",
+				},
+			},
+		},
+		{},
+	}
+	for i, name := range []string{"a", "b", "c"} {
+		k8smeta.InitObjectMeta(u.cfg, &objects[i].ObjectMeta, u.cfg.Services[name])
+	}
+	clientset := fake.NewSimpleClientset(objects[0], objects[1], objects[2])
+	u.k8sPodClient = clientset.CoreV1().Pods("")
+	return u
+}
+
+func podNames(t *testing.T, u *upRunner) map[string]bool {
+	podList, err := u.k8sPodClient.List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, pod := range podList.Items {
+		names[pod.ObjectMeta.Name] = true
+	}
+	return names
+}
+
+func TestDeletePodsToBeRecreated_Changed(t *testing.T) {
+	u := newTestRecreateRunner(RecreateChanged)
+	err := u.deletePodsToBeRecreated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := podNames(t, u)
+	if !names["a-123"] || names["b-123"] || !names["c-123"] {
+		t.Error(names)
+	}
+}
+
+func TestDeletePodsToBeRecreated_Always(t *testing.T) {
+	u := newTestRecreateRunner(RecreateAlways)
+	err := u.deletePodsToBeRecreated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := podNames(t, u)
+	if names["a-123"] || names["b-123"] || !names["c-123"] {
+		t.Error(names)
+	}
+}
+
+func TestDeletePodsToBeRecreated_Never(t *testing.T) {
+	u := newTestRecreateRunner(RecreateNever)
+	err := u.deletePodsToBeRecreated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(podNames(t, u)) != 3 {
+		t.Fail()
+	}
+}
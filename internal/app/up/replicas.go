@@ -0,0 +1,67 @@
+package up
+
+import (
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// getReplicas returns the number of replicas of an app, which is deploy.replicas of the docker compose service (1 if not set).
+func (a *app) getReplicas() int32 {
+	deploy := a.composeService.DockerComposeService.Deploy
+	if deploy == nil || deploy.Replicas == nil {
+		return 1
+	}
+	return *deploy.Replicas
+}
+
+// getReplicaPodNames returns the names of the pods of the replicas of an app, given the name of the pod of the app. The pod of an app with
+// one replica keeps its name, otherwise the name of each pod is suffixed by the index of its replica (e.g. web-0 and web-1).
+func (a *app) getReplicaPodNames(name string) []string {
+	replicas := a.getReplicas()
+	if replicas == 1 {
+		return []string{name}
+	}
+	names := make([]string, replicas)
+	for i := range names {
+		names[i] = name + "-" + strconv.Itoa(i)
+	}
+	return names
+}
+
+// newReplicaPods returns the pods of the replicas of an app, see getReplicaPodNames. The pods share the labels of pod, so that the Service
+// of the app load balances across them.
+func (a *app) newReplicaPods(pod *v1.Pod) []*v1.Pod {
+	names := a.getReplicaPodNames(pod.ObjectMeta.Name)
+	if len(names) == 1 {
+		return []*v1.Pod{pod}
+	}
+	pods := make([]*v1.Pod, len(names))
+	for i, name := range names {
+		pods[i] = pod.DeepCopy()
+		pods[i].ObjectMeta.Name = name
+	}
+	return pods
+}
+
+// createReplicaPods creates the pods of the replicas of an app that is deployed as bare pods. Existing pods that are owned by the
+// environment are left untouched.
+func (u *upRunner) createReplicaPods(app *app, pod *v1.Pod) error {
+	for _, replicaPod := range app.newReplicaPods(pod) {
+		_, err := u.createPodResource(replicaPod)
+		if k8sError.IsAlreadyExists(err) {
+			err = checkExistingIsOwned[*v1.Pod](u, u.k8sPodClient, "pod", replicaPod)
+			if err != nil {
+				return err
+			}
+			app.newLogEntry().Debugf("pod %s already exists", replicaPod.ObjectMeta.Name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		app.newLogEntry().Debugf("created pod %s", replicaPod.ObjectMeta.Name)
+	}
+	return nil
+}
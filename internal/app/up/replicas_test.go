@@ -0,0 +1,111 @@
+package up
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetReplicaPodNames_Default(t *testing.T) {
+	a := newTestApp("a")
+	assertStrings(t, a.getReplicaPodNames("a-123"), []string{"a-123"})
+}
+
+func TestGetReplicaPodNames_Replicas(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		Replicas: util.NewInt32(3),
+	}
+	assertStrings(t, a.getReplicaPodNames("a-123"), []string{"a-123-0", "a-123-1", "a-123-2"})
+}
+
+func TestNewReplicaPods_Success(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		Replicas: util.NewInt32(2),
+	}
+	pod := &v1.Pod{}
+	pod.ObjectMeta.Name = "a-123"
+	pod.ObjectMeta.Labels = map[string]string{
+		"env": "123",
+	}
+	pods := a.newReplicaPods(pod)
+	if len(pods) != 2 || pods[0].ObjectMeta.Name != "a-123-0" || pods[1].ObjectMeta.Name != "a-123-1" {
+		t.Fatal(pods)
+	}
+	if pods[1].ObjectMeta.Labels["env"] != "123" {
+		t.Error(pods[1].ObjectMeta.Labels)
+	}
+	if pod.ObjectMeta.Name != "a-123" {
+		t.Error("pod was mutated")
+	}
+}
+
+func TestCreateWorkload_Replicas(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderDeps)
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		Replicas: util.NewInt32(2),
+	}
+	err := u.createWorkload(a, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, createdNames(clientset), []string{"pods/a-123-0", "pods/a-123-1"})
+	if !u.appsThatNeedToBeReady[a] {
+		t.Error("expected a to need to be ready")
+	}
+}
+
+func TestCreateWorkload_ZeroReplicas(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderDeps)
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		Replicas: util.NewInt32(0),
+	}
+	err := u.createWorkload(a, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, createdNames(clientset), nil)
+	if u.appsThatNeedToBeReady[a] || a.maxObservedPodStatus != podStatusReady {
+		t.Error(u.appsThatNeedToBeReady[a], a.maxObservedPodStatus)
+	}
+}
+
+func TestCreateReplicaPods_AlreadyExists(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	u.cfg.EnvironmentID = "123"
+	u.cfg.EnvironmentLabel = "env"
+	clientset := fake.NewSimpleClientset()
+	u.k8sPodClient = clientset.CoreV1().Pods("")
+	a := newTestApp("a")
+	pod := &v1.Pod{}
+	k8smeta.InitObjectMeta(u.cfg, &pod.ObjectMeta, a.composeService)
+	_ = clientset.Tracker().Add(pod.DeepCopy())
+	if err := u.createReplicaPods(a, pod); err != nil {
+		t.Error(err)
+	}
+	// A pod with the same name that was not created for the environment is not adopted.
+	_ = clientset.Tracker().Update(v1.SchemeGroupVersion.WithResource("pods"), &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: pod.ObjectMeta.Name,
+		},
+	}, "")
+	err := u.createReplicaPods(a, pod)
+	if err == nil || !strings.Contains(err.Error(), "was not created by kube-compose for environment 123") {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,34 @@
+package up
+
+import (
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newResourceList(spec *dockerComposeConfig.ResourceSpec) v1.ResourceList {
+	if spec == nil || (spec.MilliCPUs == 0 && spec.MemoryBytes == 0) {
+		return nil
+	}
+	resourceList := v1.ResourceList{}
+	if spec.MilliCPUs > 0 {
+		resourceList[v1.ResourceCPU] = *resource.NewMilliQuantity(spec.MilliCPUs, resource.DecimalSI)
+	}
+	if spec.MemoryBytes > 0 {
+		resourceList[v1.ResourceMemory] = *resource.NewQuantity(spec.MemoryBytes, resource.BinarySI)
+	}
+	return resourceList
+}
+
+// createContainerResources translates deploy.resources of an app to the resources of its container: limits become limits and
+// reservations become requests.
+func (a *app) createContainerResources() v1.ResourceRequirements {
+	var requirements v1.ResourceRequirements
+	deploy := a.composeService.DockerComposeService.Deploy
+	if deploy == nil || deploy.Resources == nil {
+		return requirements
+	}
+	requirements.Limits = newResourceList(deploy.Resources.Limits)
+	requirements.Requests = newResourceList(deploy.Resources.Reservations)
+	return requirements
+}
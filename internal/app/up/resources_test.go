@@ -0,0 +1,44 @@
+package up
+
+import (
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestCreateContainerResources_Success(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Deploy = &dockerComposeConfig.Deploy{
+		Resources: &dockerComposeConfig.Resources{
+			Limits: &dockerComposeConfig.ResourceSpec{
+				MilliCPUs:   500,
+				MemoryBytes: 512 << 20,
+			},
+			Reservations: &dockerComposeConfig.ResourceSpec{
+				MemoryBytes: 256 << 20,
+			},
+		},
+	}
+	resources := a.createContainerResources()
+	if cpu := resources.Limits[v1.ResourceCPU]; cpu.String() != "500m" {
+		t.Error(cpu.String())
+	}
+	if memory := resources.Limits[v1.ResourceMemory]; memory.String() != "512Mi" {
+		t.Error(memory.String())
+	}
+	if _, ok := resources.Requests[v1.ResourceCPU]; ok {
+		t.Error(resources.Requests)
+	}
+	if memory := resources.Requests[v1.ResourceMemory]; memory.String() != "256Mi" {
+		t.Error(memory.String())
+	}
+}
+
+func TestCreateContainerResources_NoDeploy(t *testing.T) {
+	a := newTestApp("a")
+	resources := a.createContainerResources()
+	if resources.Limits != nil || resources.Requests != nil {
+		t.Error(resources)
+	}
+}
@@ -0,0 +1,213 @@
+package up
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The key of the value of a docker compose secret in the data of its Kubernetes Secret.
+const secretDataKey = "value"
+
+// The path of the file of a secret or config in its volume, which is mounted at the target of the secret or config using a sub path.
+const fileVolumePath = "value"
+
+// Used to read the values of secrets and configs with an environment source, can be overridden by tests.
+var secretEnvGetter = os.LookupEnv
+
+// Used to read the values of secrets and configs with a file source, can be overridden by tests.
+var secretFileReader = ioutil.ReadFile
+
+type appSecret struct {
+	// True if the Kubernetes Secret is managed outside of the docker compose project.
+	external bool
+	// The key of the value of the secret in the data of the Kubernetes Secret. Set by createSecrets for external secrets.
+	key string
+	// The name of the Kubernetes Secret.
+	k8sName string
+	mode    *int32
+	target  string
+}
+
+func (u *upRunner) getSecretK8sName(secret *dockerComposeConfig.Secret) string {
+	if secret.External {
+		return secret.ExternalName
+	}
+	return k8smeta.GetK8sNameFromEscapedName(u.cfg, util.EscapeName(secret.Name)+"-secret")
+}
+
+// readSecretValue reads the value of a secret with an environment or file source.
+func readSecretValue(secret *dockerComposeConfig.Secret) ([]byte, error) {
+	if secret.File != "" {
+		value, err := secretFileReader(secret.File)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the value of secret %s: %v", secret.Name, err)
+		}
+		return value, nil
+	}
+	value, ok := secretEnvGetter(secret.Environment)
+	if !ok {
+		return nil, fmt.Errorf("the value of secret %s is read from the environment variable %s, but that variable is not set",
+			secret.Name, secret.Environment)
+	}
+	return []byte(value), nil
+}
+
+// initSecrets resolves the secrets of the apps to be started, and reads the values of the secrets with an environment or file source. It
+// is an error if such an environment variable is not set or such a file cannot be read. External secrets are not read, their Kubernetes
+// Secrets must already exist.
+func (u *upRunner) initSecrets() error {
+	u.secretValues = map[string][]byte{}
+	u.externalSecretKeys = map[string]string{}
+	for app := range u.appsToBeStarted {
+		for _, serviceSecret := range app.composeService.DockerComposeService.Secrets {
+			secret := u.cfg.Secrets[serviceSecret.Source]
+			k8sName := u.getSecretK8sName(secret)
+			if secret.External {
+				u.externalSecretKeys[k8sName] = ""
+			} else if _, ok := u.secretValues[k8sName]; !ok {
+				value, err := readSecretValue(secret)
+				if err != nil {
+					return err
+				}
+				u.secretValues[k8sName] = value
+			}
+			app.secrets = append(app.secrets, &appSecret{
+				external: secret.External,
+				key:      secretDataKey,
+				k8sName:  k8sName,
+				mode:     serviceSecret.Mode,
+				target:   serviceSecret.Target,
+			})
+		}
+	}
+	return nil
+}
+
+// resolveExternalSecret verifies that an external Kubernetes Secret exists, and returns the key of its value: the key "value" if the Secret
+// has it (like the Secrets created by kube-compose), otherwise its only key.
+func (u *upRunner) resolveExternalSecret(name string) (string, error) {
+	ctx, cancel := u.applyContext()
+	defer cancel()
+	secret, err := u.k8sSecretClient.Get(ctx, name, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return "", fmt.Errorf("the external secret %s does not exist", name)
+	} else if err != nil {
+		return "", err
+	}
+	var keys []string
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	return resolveExternalKey("secret", name, keys)
+}
+
+// resolveExternalKey returns the key of the value of an external secret or config, given the keys of its Kubernetes resource: the key
+// "value" if it exists, otherwise the only key.
+func resolveExternalKey(kind, name string, keys []string) (string, error) {
+	for _, key := range keys {
+		if key == secretDataKey {
+			return key, nil
+		}
+	}
+	if len(keys) != 1 {
+		return "", fmt.Errorf("the external %s %s must have a key %#v or exactly one key", kind, name, secretDataKey)
+	}
+	return keys[0], nil
+}
+
+// createSecrets creates (or updates) the Kubernetes Secrets of the docker compose secrets of the apps to be started. These Secrets are
+// shared by apps, so they are labeled with the environment but are not annotated with a docker compose service. The Kubernetes Secrets of
+// external secrets are not created, but are resolved (see resolveExternalSecret).
+func (u *upRunner) createSecrets() error {
+	for name := range u.externalSecretKeys {
+		key, err := u.resolveExternalSecret(name)
+		if err != nil {
+			return err
+		}
+		u.externalSecretKeys[name] = key
+	}
+	for app := range u.appsToBeStarted {
+		for _, secret := range app.secrets {
+			if secret.external {
+				secret.key = u.externalSecretKeys[secret.k8sName]
+			}
+		}
+	}
+	var names []string
+	for name := range u.secretValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		secret := u.newSecret(name)
+		op, err := u.createOrUpdateSecret(secret)
+		if err != nil {
+			return err
+		}
+		log.Debugf("%s secret %s", op, name)
+	}
+	return nil
+}
+
+// newSecret builds the Kubernetes Secret with the given name of a docker compose secret, see initSecrets.
+func (u *upRunner) newSecret(name string) *v1.Secret {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: k8smeta.EnvironmentLabels(u.cfg),
+		},
+		Data: map[string][]byte{
+			secretDataKey: u.secretValues[name],
+		},
+	}
+	k8smeta.InitOwnerReferences(u.cfg, &secret.ObjectMeta)
+	return secret
+}
+
+// createPodSecretVolumes mounts each secret of an app as a read-only file at the target of the secret, with the mode of the secret (if
+// set).
+func (a *app) createPodSecretVolumes(pod *v1.Pod) {
+	for i, secret := range a.secrets {
+		addPodFileVolume(pod, v1.Volume{
+			Name: fmt.Sprintf("secret%d", i+1),
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: secret.k8sName,
+					Items:      newFileVolumeItems(secret.key, secret.mode),
+				},
+			},
+		}, secret.target)
+	}
+}
+
+// newFileVolumeItems returns the items of a Secret or ConfigMap volume that project the value at key to a single file with the given mode.
+func newFileVolumeItems(key string, mode *int32) []v1.KeyToPath {
+	return []v1.KeyToPath{
+		{
+			Key:  key,
+			Mode: mode,
+			Path: fileVolumePath,
+		},
+	}
+}
+
+// addPodFileVolume adds a volume with a single file (see newFileVolumeItems) to the pod, and mounts the file read-only at target.
+func addPodFileVolume(pod *v1.Pod, volume v1.Volume, target string) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+		MountPath: target,
+		Name:      volume.Name,
+		ReadOnly:  true,
+		SubPath:   fileVolumePath,
+	})
+}
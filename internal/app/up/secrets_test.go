@@ -0,0 +1,228 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func withMockedSecretEnv(env map[string]string, cb func()) {
+	orig := secretEnvGetter
+	defer func() {
+		secretEnvGetter = orig
+	}()
+	secretEnvGetter = func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	cb()
+}
+
+func newTestSecretsRunner() *upRunner {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "123"
+	cfg.Secrets = map[string]*dockerComposeConfig.Secret{
+		"db_password": {
+			Environment: "DB_PASSWORD",
+			Name:        "db_password",
+		},
+	}
+	cfg.Services["a"].DockerComposeService.Secrets = []dockerComposeConfig.ServiceSecret{
+		{
+			Mode:   util.NewInt32(0440),
+			Source: "db_password",
+			Target: "/run/secrets/db_password",
+		},
+	}
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{
+		u.apps["a"]: true,
+	}
+	return u
+}
+
+func TestInitSecrets_Success(t *testing.T) {
+	u := newTestSecretsRunner()
+	withMockedSecretEnv(map[string]string{
+		"DB_PASSWORD": "hunter2",
+	}, func() {
+		err := u.initSecrets()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	if string(u.secretValues["db9cxpassword-secret-123"]) != "hunter2" {
+		t.Error(u.secretValues)
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	u.apps["a"].createPodSecretVolumes(pod)
+	if len(pod.Spec.Volumes) != 1 || pod.Spec.Volumes[0].Secret.SecretName != "db9cxpassword-secret-123" {
+		t.Fatal(pod.Spec.Volumes)
+	}
+	items := pod.Spec.Volumes[0].Secret.Items
+	if len(items) != 1 || items[0].Mode == nil || *items[0].Mode != 288 {
+		t.Error(items)
+	}
+	volumeMounts := pod.Spec.Containers[0].VolumeMounts
+	if len(volumeMounts) != 1 || volumeMounts[0].MountPath != "/run/secrets/db_password" || !volumeMounts[0].ReadOnly {
+		t.Error(volumeMounts)
+	}
+}
+
+func TestInitSecrets_EnvVarNotSetError(t *testing.T) {
+	u := newTestSecretsRunner()
+	withMockedSecretEnv(map[string]string{}, func() {
+		err := u.initSecrets()
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func withMockedSecretFiles(files map[string]string, cb func()) {
+	orig := secretFileReader
+	defer func() {
+		secretFileReader = orig
+	}()
+	secretFileReader = func(name string) ([]byte, error) {
+		if value, ok := files[name]; ok {
+			return []byte(value), nil
+		}
+		return nil, fmt.Errorf("open %s: no such file or directory", name)
+	}
+	cb()
+}
+
+func TestInitSecrets_FileSuccess(t *testing.T) {
+	u := newTestSecretsRunner()
+	u.cfg.Secrets["db_password"] = &dockerComposeConfig.Secret{
+		File: "/project/db_password.txt",
+		Name: "db_password",
+	}
+	withMockedSecretFiles(map[string]string{
+		"/project/db_password.txt": "hunter2\n",
+	}, func() {
+		err := u.initSecrets()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	if string(u.secretValues["db9cxpassword-secret-123"]) != "hunter2\n" {
+		t.Error(u.secretValues)
+	}
+}
+
+func TestInitSecrets_FileNotFoundError(t *testing.T) {
+	u := newTestSecretsRunner()
+	u.cfg.Secrets["db_password"] = &dockerComposeConfig.Secret{
+		File: "/project/db_password.txt",
+		Name: "db_password",
+	}
+	withMockedSecretFiles(map[string]string{}, func() {
+		err := u.initSecrets()
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func newTestExternalSecretRunner(objects ...*v1.Secret) *upRunner {
+	u := newTestSecretsRunner()
+	u.opts.Context = context.Background()
+	u.cfg.Secrets["db_password"] = &dockerComposeConfig.Secret{
+		External:     true,
+		ExternalName: "prod-db-password",
+		Name:         "db_password",
+	}
+	clientset := fake.NewSimpleClientset()
+	for _, object := range objects {
+		_ = clientset.Tracker().Add(object)
+	}
+	u.k8sSecretClient = clientset.CoreV1().Secrets("")
+	return u
+}
+
+func TestCreateSecrets_ExternalSuccess(t *testing.T) {
+	u := newTestExternalSecretRunner(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "prod-db-password",
+		},
+		Data: map[string][]byte{
+			"password": []byte("hunter2"),
+		},
+	})
+	err := u.initSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(u.secretValues) != 0 {
+		t.Error(u.secretValues)
+	}
+	err = u.createSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	u.apps["a"].createPodSecretVolumes(pod)
+	if len(pod.Spec.Volumes) != 1 || pod.Spec.Volumes[0].Secret.SecretName != "prod-db-password" {
+		t.Fatal(pod.Spec.Volumes)
+	}
+	items := pod.Spec.Volumes[0].Secret.Items
+	if len(items) != 1 || items[0].Key != "password" {
+		t.Error(items)
+	}
+}
+
+func TestCreateSecrets_ExternalNotFoundError(t *testing.T) {
+	u := newTestExternalSecretRunner()
+	err := u.initSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = u.createSecrets()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestCreateSecrets_ExternalAmbiguousKeyError(t *testing.T) {
+	u := newTestExternalSecretRunner(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "prod-db-password",
+		},
+		Data: map[string][]byte{
+			"password": []byte("hunter2"),
+			"username": []byte("henk"),
+		},
+	})
+	err := u.initSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = u.createSecrets()
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,147 @@
+package up
+
+import (
+	"fmt"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The storage requested by each volume claim template of a stateful app.
+const defaultVolumeClaimSize = "1Gi"
+
+type appVolumeClaim struct {
+	name          string
+	readOnly      bool
+	containerPath string
+}
+
+// initVolumeClaims derives the volume claim templates of a stateful app from its named and anonymous volumes. The data of bind mounted
+// volumes lives on the host, so these cannot be translated to volume claims and are ignored.
+func initVolumeClaims(a *app) {
+	for i, serviceVolume := range a.composeService.DockerComposeService.Volumes {
+		if serviceVolume.Short == nil {
+			// TODO https://github.com/kube-compose/kube-compose/issues/161 support long volume syntax
+			continue
+		}
+		claim := &appVolumeClaim{
+			containerPath: serviceVolume.Short.ContainerPath,
+			readOnly:      serviceVolume.Short.HasMode && serviceVolume.Short.Mode == "ro",
+		}
+		switch {
+		case serviceVolume.Short.IsNamedVolume():
+			claim.name = util.EscapeName(serviceVolume.Short.HostPath)
+		case serviceVolume.Short.HasHostPath:
+			a.newLogEntry().Warnf("ignoring bind mounted volume with host path %#v: stateful services only support named and anonymous "+
+				"volumes", serviceVolume.Short.HostPath)
+			continue
+		default:
+			claim.name = fmt.Sprintf("anon%d", i+1)
+		}
+		a.volumeClaims = append(a.volumeClaims, claim)
+	}
+}
+
+func (u *upRunner) getStorageClassName() *string {
+	if u.opts.StorageClass == "" {
+		return nil
+	}
+	return util.NewString(u.opts.StorageClass)
+}
+
+func getHeadlessServiceName(u *upRunner, app *app) string {
+	return k8smeta.TruncateName(u.cfg, k8smeta.GetK8sName(app.composeService, u.cfg)+"-headless")
+}
+
+// newHeadlessService builds the headless Service that governs the network identity of the pods of a stateful app.
+func (u *upRunner) newHeadlessService(app *app) *v1.Service {
+	service := u.newService(app)
+	service.ObjectMeta.Name = getHeadlessServiceName(u, app)
+	service.Spec.ClusterIP = v1.ClusterIPNone
+	// Headless Services must be of type ClusterIP, the Service of the app (if any) has the type and node port of the app.
+	service.Spec.Type = v1.ServiceTypeClusterIP
+	for i := range service.Spec.Ports {
+		service.Spec.Ports[i].NodePort = 0
+	}
+	return service
+}
+
+// newStatefulSet builds the StatefulSet of a stateful app, using pod as the pod template.
+func (u *upRunner) newStatefulSet(app *app, pod *v1.Pod) *appsV1.StatefulSet {
+	template := v1.PodTemplateSpec{
+		ObjectMeta: *pod.ObjectMeta.DeepCopy(),
+		Spec:       *pod.Spec.DeepCopy(),
+	}
+	template.ObjectMeta.Name = ""
+	// The pods of the template are owned by their controller.
+	template.ObjectMeta.OwnerReferences = nil
+	// Kubernetes only accepts the restart policy Always for pods of a StatefulSet.
+	restart := app.composeService.DockerComposeService.Restart
+	if restart != "" && restart != "always" && restart != "unless-stopped" {
+		app.newLogEntry().Warnf("ignoring restart policy %#v: stateful services always restart", restart)
+	}
+	template.Spec.RestartPolicy = v1.RestartPolicyAlways
+
+	var volumeClaimTemplates []v1.PersistentVolumeClaim
+	container := &template.Spec.Containers[0]
+	for _, claim := range app.volumeClaims {
+		volumeClaimTemplates = append(volumeClaimTemplates, v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   claim.name,
+				Labels: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				StorageClassName: u.getStorageClassName(),
+				AccessModes: []v1.PersistentVolumeAccessMode{
+					v1.ReadWriteOnce,
+				},
+				Resources: v1.VolumeResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceStorage: resource.MustParse(defaultVolumeClaimSize),
+					},
+				},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      claim.name,
+			MountPath: claim.containerPath,
+			ReadOnly:  claim.readOnly,
+		})
+	}
+
+	statefulSet := &appsV1.StatefulSet{
+		Spec: appsV1.StatefulSetSpec{
+			Replicas: util.NewInt32(app.getReplicas()),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
+			},
+			ServiceName:          getHeadlessServiceName(u, app),
+			Template:             template,
+			VolumeClaimTemplates: volumeClaimTemplates,
+		},
+	}
+	k8smeta.InitObjectMeta(u.cfg, &statefulSet.ObjectMeta, app.composeService)
+	return statefulSet
+}
+
+// createStatefulSet creates (or updates) the headless Service and the StatefulSet of a stateful app.
+func (u *upRunner) createStatefulSet(app *app, pod *v1.Pod) error {
+	service := u.newHeadlessService(app)
+	_, op, err := u.createOrUpdateService(service)
+	if err != nil {
+		return err
+	}
+	app.newLogEntry().Debugf("%s k8s service %s", op, service.ObjectMeta.Name)
+
+	statefulSet := u.newStatefulSet(app, pod)
+	op, err = u.createOrUpdateStatefulSet(statefulSet)
+	if err != nil {
+		return err
+	}
+	app.newLogEntry().Debugf("%s statefulset %s", op, statefulSet.ObjectMeta.Name)
+	return nil
+}
@@ -0,0 +1,153 @@
+package up
+
+import (
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestStatefulApp() *app {
+	a := newTestApp("b")
+	a.composeService.Stateful = true
+	a.composeService.DockerComposeService.Volumes = []dockerComposeConfig.ServiceVolume{
+		{
+			Short: &dockerComposeConfig.PathMapping{
+				HasHostPath:   true,
+				HostPath:      "data",
+				ContainerPath: "/var/lib/data",
+			},
+		},
+		{
+			Short: &dockerComposeConfig.PathMapping{
+				HasHostPath:   true,
+				HostPath:      "/home/henk/config",
+				ContainerPath: "/etc/config",
+			},
+		},
+		{
+			Short: &dockerComposeConfig.PathMapping{
+				ContainerPath: "/var/cache",
+				HasMode:       true,
+				Mode:          "ro",
+			},
+		},
+	}
+	return a
+}
+
+func TestInitVolumeClaims_Success(t *testing.T) {
+	a := newTestStatefulApp()
+	initVolumeClaims(a)
+	if len(a.volumeClaims) != 2 {
+		t.Fatal(a.volumeClaims)
+	}
+	if *a.volumeClaims[0] != (appVolumeClaim{name: "data", containerPath: "/var/lib/data"}) {
+		t.Error(a.volumeClaims[0])
+	}
+	if *a.volumeClaims[1] != (appVolumeClaim{name: "anon3", containerPath: "/var/cache", readOnly: true}) {
+		t.Error(a.volumeClaims[1])
+	}
+}
+
+func TestNewStatefulSet_Success(t *testing.T) {
+	a := newTestStatefulApp()
+	initVolumeClaims(a)
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.EnvironmentID = "123"
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "b",
+				},
+			},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+	statefulSet := u.newStatefulSet(a, pod)
+	if statefulSet.ObjectMeta.Name != "b-123" || statefulSet.Spec.ServiceName != "b-123-headless" {
+		t.Error(statefulSet.ObjectMeta.Name, statefulSet.Spec.ServiceName)
+	}
+	if statefulSet.Spec.Template.Spec.RestartPolicy != v1.RestartPolicyAlways {
+		t.Error(statefulSet.Spec.Template.Spec.RestartPolicy)
+	}
+	if len(statefulSet.Spec.VolumeClaimTemplates) != 2 || len(statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts) != 2 {
+		t.Fatal(statefulSet.Spec)
+	}
+	if len(pod.Spec.Containers[0].VolumeMounts) != 0 {
+		t.Error("pod was mutated")
+	}
+	if statefulSet.Spec.Selector.MatchLabels["env"] != "123" {
+		t.Error(statefulSet.Spec.Selector)
+	}
+	if statefulSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName != nil {
+		t.Error(statefulSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
+	}
+}
+
+func TestNewStatefulSet_StorageClass(t *testing.T) {
+	a := newTestStatefulApp()
+	initVolumeClaims(a)
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			StorageClass: "fast",
+		},
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "b",
+				},
+			},
+		},
+	}
+	statefulSet := u.newStatefulSet(a, pod)
+	for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+		if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName != "fast" {
+			t.Error(claim.Spec.StorageClassName)
+		}
+		if claim.Spec.Resources.Requests.Storage().String() != defaultVolumeClaimSize {
+			t.Error(claim.Spec.Resources.Requests)
+		}
+	}
+}
+
+func TestNewHeadlessService_Success(t *testing.T) {
+	a := newTestStatefulApp()
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.cfg.EnvironmentIDNoAppend = true
+	service := u.newHeadlessService(a)
+	if service.ObjectMeta.Name != "b-headless" || service.Spec.ClusterIP != v1.ClusterIPNone {
+		t.Error(service)
+	}
+}
+
+func TestNewHeadlessService_NodePort(t *testing.T) {
+	a := newTestStatefulApp()
+	a.composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+	}
+	a.composeService.ServiceType = v1.ServiceTypeNodePort
+	a.composeService.NodePort = 30080
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	service := u.newHeadlessService(a)
+	if service.Spec.Type != v1.ServiceTypeClusterIP || service.Spec.ClusterIP != v1.ClusterIPNone {
+		t.Error(service.Spec)
+	}
+	if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].NodePort != 0 {
+		t.Error(service.Spec.Ports)
+	}
+}
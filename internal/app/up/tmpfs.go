@@ -0,0 +1,32 @@
+package up
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// createPodTmpfsVolumes adds an in-memory emptyDir volume to the pod for each tmpfs mount of the app's docker compose service. The volumes
+// are named tmpfs1, tmpfs2, etc. so that they do not collide with the volumes of bind mounted volumes and secrets.
+func (a *app) createPodTmpfsVolumes(pod *v1.Pod) {
+	for i, mount := range a.composeService.DockerComposeService.Tmpfs {
+		volumeName := fmt.Sprintf("tmpfs%d", i+1)
+		emptyDir := &v1.EmptyDirVolumeSource{
+			Medium: v1.StorageMediumMemory,
+		}
+		if mount.SizeBytes > 0 {
+			emptyDir.SizeLimit = resource.NewQuantity(mount.SizeBytes, resource.BinarySI)
+		}
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: volumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: emptyDir,
+			},
+		})
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+			MountPath: mount.ContainerPath,
+			Name:      volumeName,
+		})
+	}
+}
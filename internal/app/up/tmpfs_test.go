@@ -0,0 +1,59 @@
+package up
+
+import (
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestAppCreatePodTmpfsVolumes(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Tmpfs = []dockerComposeConfig.TmpfsMount{
+		{
+			ContainerPath: "/tmp",
+		},
+		{
+			ContainerPath: "/run",
+			SizeBytes:     64 * 1024 * 1024,
+		},
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					VolumeMounts: []v1.VolumeMount{
+						{
+							Name:      "vol1",
+							MountPath: "/etc/app.conf",
+						},
+					},
+				},
+			},
+			Volumes: []v1.Volume{
+				{
+					Name: "vol1",
+				},
+			},
+		},
+	}
+	a.createPodTmpfsVolumes(pod)
+	if len(pod.Spec.Volumes) != 3 {
+		t.Fatal(pod.Spec.Volumes)
+	}
+	volume := pod.Spec.Volumes[1]
+	if volume.Name != "tmpfs1" || volume.EmptyDir == nil || volume.EmptyDir.Medium != v1.StorageMediumMemory ||
+		volume.EmptyDir.SizeLimit != nil {
+		t.Error(volume)
+	}
+	volume = pod.Spec.Volumes[2]
+	if volume.Name != "tmpfs2" || volume.EmptyDir == nil || volume.EmptyDir.SizeLimit == nil ||
+		volume.EmptyDir.SizeLimit.String() != "64Mi" {
+		t.Error(volume)
+	}
+	volumeMounts := pod.Spec.Containers[0].VolumeMounts
+	if len(volumeMounts) != 3 || volumeMounts[1].Name != "tmpfs1" || volumeMounts[1].MountPath != "/tmp" ||
+		volumeMounts[2].Name != "tmpfs2" || volumeMounts[2].MountPath != "/run" {
+		t.Error(volumeMounts)
+	}
+}
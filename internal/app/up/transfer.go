@@ -0,0 +1,264 @@
+package up
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	// TransferPush pushes images to the cluster's image storage (the default).
+	TransferPush = "push"
+	// TransferSaveLoad saves images with the docker daemon and loads them into the container runtime of each node of the cluster, so
+	// that no registry is needed.
+	TransferSaveLoad = "save-load"
+	// DefaultTransferLoaderImage is the default of Options.TransferLoaderImage.
+	DefaultTransferLoaderImage = "busybox:stable"
+)
+
+// imageLoaderCommand is executed in the loader pod of each node, and imports the image archive read from stdin into the k8s.io namespace
+// of containerd (the namespace of images that the kubelet uses). The loader pod shares the PID namespace of the node, so that the command
+// can enter the mount namespace of the node's init process.
+var imageLoaderCommand = []string{"nsenter", "--target", "1", "--mount", "--", "ctr", "--namespace", "k8s.io", "images", "import", "-"}
+
+// How long to wait for the loader pods to start running.
+const imageLoaderStartTimeout = 5 * time.Minute
+
+// execInPod runs a command in a container of a running pod, streaming stdin to it. It is a variable so that it can be mocked.
+var execInPod = func(config *rest.Config, req *rest.Request, stdin io.Reader, stdout, stderr io.Writer) error {
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	return executor.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+type imageLoaders struct {
+	err  error
+	once sync.Once
+	// The names of the loader pods, one per node.
+	pods       []string
+	deleteOnce sync.Once
+}
+
+func (u *upRunner) isTransferSaveLoad() bool {
+	return u.opts.Transfer == TransferSaveLoad
+}
+
+func getImageLoaderPodName(u *upRunner, nodeName string) string {
+	return k8smeta.TruncateName(u.cfg, "image-loader-"+nodeName+"-"+u.cfg.EnvironmentID)
+}
+
+// newImageLoaderPod builds the loader pod of a node. The pod only has the environment label, so that it is removed by down but is not
+// mistaken for the pod of a docker compose service.
+func (u *upRunner) newImageLoaderPod(nodeName string) *v1.Pod {
+	image := u.opts.TransferLoaderImage
+	if image == "" {
+		image = DefaultTransferLoaderImage
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   getImageLoaderPodName(u, nodeName),
+			Labels: k8smeta.EnvironmentLabels(u.cfg),
+		},
+		Spec: v1.PodSpec{
+			AutomountServiceAccountToken: util.NewBool(false),
+			Containers: []v1.Container{
+				{
+					Name:    "loader",
+					Image:   image,
+					Command: []string{"sh", "-c", "while true; do sleep 3600; done"},
+					SecurityContext: &v1.SecurityContext{
+						Privileged: util.NewBool(true),
+					},
+				},
+			},
+			HostPID:       true,
+			NodeName:      nodeName,
+			RestartPolicy: v1.RestartPolicyNever,
+			// Images must be loaded on every node, including nodes that are tainted.
+			Tolerations: []v1.Toleration{
+				{
+					Operator: v1.TolerationOpExists,
+				},
+			},
+		},
+	}
+}
+
+func isNodeReady(node *v1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// initImageLoaders creates the loader pod of each ready node, and waits until all loader pods are running.
+func (u *upRunner) initImageLoaders() error {
+	nodes, err := u.k8sClientset.CoreV1().Nodes().List(u.opts.Context, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing the nodes to load images into")
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if !isNodeReady(node) {
+			continue
+		}
+		pod := u.newImageLoaderPod(node.ObjectMeta.Name)
+		_, err = u.createPodResource(pod)
+		if err != nil && !k8sError.IsAlreadyExists(err) {
+			return err
+		}
+		u.imageLoaders.pods = append(u.imageLoaders.pods, pod.ObjectMeta.Name)
+	}
+	if len(u.imageLoaders.pods) == 0 {
+		return fmt.Errorf("there are no ready nodes to load images into")
+	}
+	deadline := time.Now().Add(imageLoaderStartTimeout)
+	for _, name := range u.imageLoaders.pods {
+		for {
+			pod, err := u.k8sPodClient.Get(u.opts.Context, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if pod.Status.Phase == v1.PodRunning {
+				break
+			}
+			if pod.Status.Phase == v1.PodFailed || pod.Status.Phase == v1.PodSucceeded {
+				return fmt.Errorf("image loader pod %s terminated, delete it and try again", name)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("image loader pod %s did not start running within %s", name, imageLoaderStartTimeout)
+			}
+			time.Sleep(time.Second)
+		}
+	}
+	return nil
+}
+
+func (u *upRunner) initImageLoadersOnce() error {
+	u.imageLoaders.once.Do(func() {
+		u.imageLoaders.err = u.initImageLoaders()
+	})
+	return u.imageLoaders.err
+}
+
+// deleteImageLoaders deletes the loader pods, which are privileged and share the PID namespace of their node, so they must not keep running
+// once all images have been transferred. Loader pods that are being created are deleted once their creation finishes, and no loader
+// pods are created afterwards.
+func (u *upRunner) deleteImageLoaders() {
+	u.imageLoaders.deleteOnce.Do(func() {
+		u.imageLoaders.once.Do(func() {
+			u.imageLoaders.err = fmt.Errorf("the image loader pods have been deleted")
+		})
+		for _, name := range u.imageLoaders.pods {
+			err := u.k8sPodClient.Delete(u.opts.Context, name, metav1.DeleteOptions{})
+			if err != nil && !k8sError.IsNotFound(err) {
+				log.Warnf("could not delete image loader pod %s: %v", name, err)
+			}
+		}
+	})
+}
+
+// deleteImageLoadersWhenDone waits until the images of all apps to be started have been prepared, and then deletes the loader pods (see
+// deleteImageLoaders).
+func (u *upRunner) deleteImageLoadersWhenDone() {
+	for app := range u.appsToBeStarted {
+		// Errors are handled when the images are needed.
+		_ = u.getAppImageInfoOnce(app)
+		if len(app.volumes) > 0 {
+			_ = u.getAppVolumeInitImageOnce(app)
+		}
+	}
+	u.deleteImageLoaders()
+}
+
+// saveImage writes the image archive of imageRef to a temporary file, and returns the name of that file.
+func (u *upRunner) saveImage(imageRef string) (string, error) {
+	r, err := u.dockerClient.ImageSave(u.imageContext(), []string{imageRef})
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseAndLogError(r)
+	file, err := ioutil.TempFile("", "kube-compose-image-*.tar")
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseAndLogError(file)
+	_, err = io.Copy(file, r)
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func (u *upRunner) loadImage(podName, archive string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(file)
+	req := u.k8sClientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(u.cfg.Namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: "loader",
+			Command:   imageLoaderCommand,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	return execInPod(u.cfg.KubeConfig, req, file, ioutil.Discard, ioutil.Discard)
+}
+
+// transferImage saves the image imageRef with the docker daemon, and streams it to the loader pod of each node, which loads it into the
+// node's container runtime.
+func (u *upRunner) transferImage(a *app, imageRef, imageDescr string) error {
+	err := u.initImageLoadersOnce()
+	if err != nil {
+		return err
+	}
+	pt := a.reporterRow.AddProgressTask("transferring " + imageDescr)
+	defer pt.Done()
+	a.setPhase(reporter.PhasePushing)
+	archive, err := u.saveImage(imageRef)
+	if err != nil {
+		return errors.Wrapf(err, "saving %s", imageRef)
+	}
+	defer os.Remove(archive)
+	for i, podName := range u.imageLoaders.pods {
+		err = u.loadImage(podName, archive)
+		if err != nil {
+			return errors.Wrapf(err, "loading %s with image loader pod %s", imageRef, podName)
+		}
+		pt.Update(float64(i+1) / float64(len(u.imageLoaders.pods)))
+	}
+	return nil
+}
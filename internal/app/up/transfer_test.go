@@ -0,0 +1,152 @@
+package up
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestNewImageLoaderPod_Success(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.EnvironmentID = "123"
+	pod := u.newImageLoaderPod("node1")
+	if pod.ObjectMeta.Name != "image-loader-node1-123" || pod.Spec.NodeName != "node1" || !pod.Spec.HostPID {
+		t.Error(pod)
+	}
+	if len(pod.ObjectMeta.Labels) != 1 || pod.ObjectMeta.Labels["env"] != "123" {
+		t.Error(pod.ObjectMeta.Labels)
+	}
+	container := pod.Spec.Containers[0]
+	if container.Image != DefaultTransferLoaderImage || container.SecurityContext == nil || !*container.SecurityContext.Privileged {
+		t.Error(container)
+	}
+}
+
+func TestNewImageLoaderPod_LoaderImage(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			TransferLoaderImage: "registry.example.com/busybox:1",
+		},
+	}
+	pod := u.newImageLoaderPod("node1")
+	if pod.Spec.Containers[0].Image != "registry.example.com/busybox:1" {
+		t.Error(pod.Spec.Containers[0].Image)
+	}
+}
+
+func TestIsNodeReady(t *testing.T) {
+	node := &v1.Node{
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+	if !isNodeReady(node) {
+		t.Error("ready node")
+	}
+	node.Spec.Unschedulable = true
+	if isNodeReady(node) {
+		t.Error("unschedulable node")
+	}
+	if isNodeReady(&v1.Node{}) {
+		t.Error("node without conditions")
+	}
+}
+
+func TestLoadImage_Success(t *testing.T) {
+	archive, err := ioutil.TempFile("", "kube-compose-test-*.tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(archive.Name())
+	_, _ = archive.WriteString("image")
+	_ = archive.Close()
+
+	config := &rest.Config{Host: "https://127.0.0.1:6443"}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &upRunner{
+		cfg:          newTestConfig(),
+		k8sClientset: clientset,
+		opts:         &Options{},
+	}
+	u.cfg.KubeConfig = config
+	u.cfg.Namespace = "ns"
+	orig := execInPod
+	defer func() {
+		execInPod = orig
+	}()
+	var url string
+	var stdin bytes.Buffer
+	execInPod = func(_ *rest.Config, req *rest.Request, r io.Reader, _, _ io.Writer) error {
+		url = req.URL().String()
+		_, err := io.Copy(&stdin, r)
+		return err
+	}
+	err = u.loadImage("image-loader-node1", archive.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(url, "/namespaces/ns/pods/image-loader-node1/exec") || !strings.Contains(url, "command=ctr") {
+		t.Error(url)
+	}
+	if stdin.String() != "image" {
+		t.Error(stdin.String())
+	}
+}
+
+func TestDeleteImageLoadersWhenDone_Success(t *testing.T) {
+	u := &upRunner{
+		appsToBeStarted: map[*app]bool{},
+		cfg:             newTestConfig(),
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	u.cfg.EnvironmentID = "123"
+	loader := u.newImageLoaderPod("node1")
+	clientset := fake.NewSimpleClientset(loader)
+	u.k8sPodClient = clientset.CoreV1().Pods("")
+	u.imageLoaders.once.Do(func() {
+		u.imageLoaders.pods = []string{loader.ObjectMeta.Name, "image-loader-node2-123"}
+	})
+	u.deleteImageLoadersWhenDone()
+	pods, err := u.k8sPodClient.List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods.Items) != 0 {
+		t.Error(pods.Items)
+	}
+}
+
+func TestDeleteImageLoaders_BeforeCreation(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	u.deleteImageLoaders()
+	// Loader pods are not created once they have been deleted.
+	if err := u.initImageLoadersOnce(); err == nil {
+		t.Fail()
+	}
+}
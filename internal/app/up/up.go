@@ -6,11 +6,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"os"
 	"regexp"
 	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,7 +27,6 @@ import (
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
-	"github.com/kube-compose/kube-compose/pkg/expanduser"
 	goDigest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -36,29 +35,20 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	k8swatch "k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	clientAppsV1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	clientBatchV1 "k8s.io/client-go/kubernetes/typed/batch/v1"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	clientNetworkingV1 "k8s.io/client-go/kubernetes/typed/networking/v1"
 )
 
-// This doesn't deserve the name palette.
-var appColorPalette = []string{
-	//"0;37", // gray -- skip: not colored
-	//"0;36", // cyan -- skip: same as INFO
-	"0;35", // magenta
-	"0;34", // blue
-	"0;33", // yellow
-	"0;32", // green
-	"0;31", // red
-
-	"1;37", // bright gray
-	"1;36", // bright cyan
-	"1;35", // bright magenta
-	"1;34", // bright blue
-	"1;33", // bright yellow
-	"1;32", // bright green
-	"1;31", // bright red
-}
+// Bounds the reconnection attempts of a log stream of a container (see streamPodLogs).
+const (
+	streamPodLogsMaxRetries    = 60
+	streamPodLogsRetryInterval = time.Second
+)
 
 type appImageInfo struct {
 	err                error
@@ -90,35 +80,66 @@ type app struct {
 	serviceClusterIP                     string
 	imageInfo                            appImageInfo
 	maxObservedPodStatus                 podStatus
-	containersForWhichWeAreStreamingLogs map[string]bool
+	containersForWhichWeAreStreamingLogs map[string]int32
+	externalLinks                        []*appExternalLink
+	secrets                              []*appSecret
+	configs                              []*appConfig
 	color                                string
 	coloredName                          string
 	reporterRow                          *reporter.Row
 	volumes                              []*appVolume
+	volumeClaims                         []*appVolumeClaim
 	volumeInitImage                      appVolumesInitImage
 	lastEventObject                      *runtime.Object
+	// True if another service depends on this app with condition service_completed_successfully (see isJob).
+	completionDependency bool
+	// True if the Job of the app has succeeded (see waitForJobCompletion).
+	jobSucceeded bool
+	// True while the status of the Job of the app is polled (see startWaitForJobCompletion).
+	waitingForJob bool
 }
 
+// hasService returns whether the app gets a Kubernetes Service. Apps without ports (e.g. batch jobs and workers) have no network endpoint,
+// so a Service would be noise.
 func (a *app) hasService() bool {
-	return len(a.composeService.Ports) > 0
+	return len(a.composeService.Ports) > 0 && !a.composeService.NoService
 }
 
 func (a *app) name() string {
 	return a.composeService.Name()
 }
 
+// hostnames returns the host names under which other pods reach the app: its name and the aliases of its networks.
+func (a *app) hostnames() []string {
+	result := []string{a.name()}
+	for _, alias := range a.composeService.DockerComposeService.NetworkAliases() {
+		if alias != a.name() {
+			result = append(result, alias)
+		}
+	}
+	return result
+}
+
 func (a *app) newLogEntry() *log.Entry {
 	return log.WithFields(log.Fields{
 		"service": a.name(),
 	})
 }
 
+// setPhase reports a phase transition of the app to the reporter (see reporter.Phase). Invalid transitions, e.g. a push that finishes
+// after the app started waiting for its dependencies, are ignored.
+func (a *app) setPhase(p reporter.Phase) {
+	if a.reporterRow != nil && a.reporterRow.SetPhase(p) {
+		a.newLogEntry().Debugf("phase %s", p)
+	}
+}
+
 // Color diff the incoming event with the last seen event for this app
 func (a *app) diffEvent(event *k8swatch.Event, u *upRunner) {
 	diff := cmp.Diff(a.lastEventObject, &event.Object)
 
-	diff = u.diffRegexpDel.ReplaceAllString(diff, "- \x1b[0;31m${1}\x1b[0m")
-	diff = u.diffRegexpAdd.ReplaceAllString(diff, "+ \x1b[0;32m${1}\x1b[0m")
+	diff = u.diffRegexpDel.ReplaceAllString(diff, "- "+util.AnsiColorWrap("${1}", "0;31", "0"))
+	diff = u.diffRegexpAdd.ReplaceAllString(diff, "+ "+util.AnsiColorWrap("${1}", "0;32", "0"))
 
 	log.Debugf("Service %s event %s diff %s", a.coloredName, event.Type, diff)
 
@@ -139,25 +160,43 @@ type localImagesCache struct {
 }
 
 type upRunner struct {
-	authConfigurations    *alternateDockerClient.AuthConfigurations
-	apps                  map[string]*app
-	appsThatNeedToBeReady map[*app]bool
-	appsToBeStarted       map[*app]bool
-	cfg                   *config.Config
-	completedChannels     []chan interface{}
-	diffRegexpDel         *regexp.Regexp
-	diffRegexpAdd         *regexp.Regexp
-	dockerClient          *dockerClient.Client
-	k8sClientset          *kubernetes.Clientset
-	k8sServiceClient      clientV1.ServiceInterface
-	k8sSecretClient       clientV1.SecretInterface
-	k8sPodClient          clientV1.PodInterface
-	hostAliases           hostAliases
-	localImagesCache      localImagesCache
-	maxServiceNameLength  int
-	opts                  *Options
-	secretsDeployed       map[string]bool
-	totalVolumeCount      int
+	authConfigurations     *alternateDockerClient.AuthConfigurations
+	authConfigsMutex       sync.Mutex
+	apps                   map[string]*app
+	appsThatNeedToBeReady  map[*app]bool
+	appsToBeStarted        map[*app]bool
+	cfg                    *config.Config
+	completedChannels      []chan interface{}
+	diffRegexpDel          *regexp.Regexp
+	diffRegexpAdd          *regexp.Regexp
+	dockerClient           *dockerClient.Client
+	k8sClientset           *kubernetes.Clientset
+	k8sConfigMapClient     clientV1.ConfigMapInterface
+	k8sNamespaceClient     clientV1.NamespaceInterface
+	k8sServiceClient       clientV1.ServiceInterface
+	k8sSecretClient        clientV1.SecretInterface
+	k8sPodClient           clientV1.PodInterface
+	k8sStatefulSetClient   clientAppsV1.StatefulSetInterface
+	k8sDeploymentClient    clientAppsV1.DeploymentInterface
+	k8sJobClient           clientBatchV1.JobInterface
+	k8sNetworkPolicyClient clientNetworkingV1.NetworkPolicyInterface
+	hostAliases            hostAliases
+	imageLoaders           imageLoaders
+	imagePrep              *imagePrep
+	jobCompletions         chan jobCompletion
+	localImagesCache       localImagesCache
+	maxServiceNameLength   int
+	opts                   *Options
+	secretsDeployed        map[string]bool
+	// Maps names of Kubernetes Secrets of docker compose secrets to their values.
+	secretValues map[string][]byte
+	// Maps names of pre-existing Kubernetes Secrets of external docker compose secrets to the key of their value.
+	externalSecretKeys map[string]string
+	// Maps names of ConfigMaps of docker compose configs to their values.
+	configValues map[string][]byte
+	// Maps names of pre-existing ConfigMaps of external docker compose configs to the key of their value.
+	externalConfigKeys map[string]string
+	totalVolumeCount   int
 }
 
 func (u *upRunner) initKubernetesClientset() error {
@@ -167,9 +206,15 @@ func (u *upRunner) initKubernetesClientset() error {
 		return err
 	}
 	u.k8sClientset = k8sClientset
+	u.k8sConfigMapClient = u.k8sClientset.CoreV1().ConfigMaps(u.cfg.Namespace)
+	u.k8sNamespaceClient = u.k8sClientset.CoreV1().Namespaces()
 	u.k8sServiceClient = u.k8sClientset.CoreV1().Services(u.cfg.Namespace)
 	u.k8sSecretClient = u.k8sClientset.CoreV1().Secrets(u.cfg.Namespace)
 	u.k8sPodClient = u.k8sClientset.CoreV1().Pods(u.cfg.Namespace)
+	u.k8sStatefulSetClient = u.k8sClientset.AppsV1().StatefulSets(u.cfg.Namespace)
+	u.k8sJobClient = u.k8sClientset.BatchV1().Jobs(u.cfg.Namespace)
+	u.k8sDeploymentClient = u.k8sClientset.AppsV1().Deployments(u.cfg.Namespace)
+	u.k8sNetworkPolicyClient = u.k8sClientset.NetworkingV1().NetworkPolicies(u.cfg.Namespace)
 	return nil
 }
 
@@ -183,9 +228,9 @@ func (u *upRunner) initAppsToBeStarted() {
 		a.reporterRow = u.opts.Reporter.AddRow(a.name())
 		u.appsToBeStarted[a] = true
 
-		a.color = appColorPalette[colorIndex]
+		a.color = util.AnsiColorPalette[colorIndex]
 		a.coloredName = util.AnsiColorWrap(a.name(), a.color, "0")
-		colorIndex = (colorIndex + 1) % len(appColorPalette)
+		colorIndex = (colorIndex + 1) % len(util.AnsiColorPalette)
 		if len(a.name()) > u.maxServiceNameLength {
 			u.maxServiceNameLength = len(a.name())
 		}
@@ -200,6 +245,10 @@ func (u *upRunner) initVolumeInfoWarnOnce(s string) {
 
 func (u *upRunner) initVolumeInfo() {
 	for a := range u.appsToBeStarted {
+		if a.composeService.Stateful {
+			initVolumeClaims(a)
+			continue
+		}
 		for _, serviceVolume := range a.composeService.DockerComposeService.Volumes {
 			appVolume := initVolumeInfoGetAppVolume(a, serviceVolume)
 			if appVolume == nil {
@@ -209,14 +258,14 @@ func (u *upRunner) initVolumeInfo() {
 			u.initVolumeInfoWarnOnce("bind mounted volumes are not synced between containers and the host (see " +
 				"https://github.com/kube-compose/kube-compose#limitations)")
 			flag := false
-			if u.cfg.ClusterImageStorage.Docker == nil && u.cfg.ClusterImageStorage.DockerRegistry == nil {
+			if u.cfg.ClusterImageStorage.Docker == nil && u.cfg.ClusterImageStorage.DockerRegistry == nil && !u.isTransferSaveLoad() {
 				u.initVolumeInfoWarnOnce("disabling bind mounted volumes: cluster_image_storage is missing (see " +
 					"https://github.com/kube-compose/kube-compose#volumes)")
 				flag = true
 			}
-			if u.cfg.VolumeInitBaseImage == nil {
-				u.initVolumeInfoWarnOnce("disabling bind mounted volumes: volumes_init_base_image is missing (see " +
-					"https://github.com/kube-compose/kube-compose#volumes)")
+			if u.getVolumeInitBaseImage() == "" {
+				u.initVolumeInfoWarnOnce("disabling bind mounted volumes: volume_init_base_image is missing and --volume-init-image is " +
+					"not set (see https://github.com/kube-compose/kube-compose#volumes)")
 				flag = true
 			}
 			if flag {
@@ -229,6 +278,27 @@ func (u *upRunner) initVolumeInfo() {
 	}
 }
 
+// getVolumeInitBaseImage returns the base image of the helper images of bind mounted volumes, where --volume-init-image takes precedence
+// over volume_init_base_image of x-kube-compose. Returns the empty string if neither is set.
+func (u *upRunner) getVolumeInitBaseImage() string {
+	if u.opts.VolumeInitBaseImage != "" {
+		return u.opts.VolumeInitBaseImage
+	}
+	if u.cfg.VolumeInitBaseImage != nil {
+		return *u.cfg.VolumeInitBaseImage
+	}
+	return ""
+}
+
+// getVolumeInitShell returns the shell that runs the entrypoint of the helper images of bind mounted volumes, which is
+// volume_init_shell of x-kube-compose or /bin/sh if that is not set.
+func (u *upRunner) getVolumeInitShell() string {
+	if u.cfg.VolumeInitShell != nil && *u.cfg.VolumeInitShell != "" {
+		return *u.cfg.VolumeInitShell
+	}
+	return defaultVolumeInitShell
+}
+
 func initVolumeInfoGetAppVolume(a *app, serviceVolume dockerComposeConfig.ServiceVolume) *appVolume {
 	r := &appVolume{}
 	if serviceVolume.Short != nil {
@@ -276,18 +346,37 @@ func (u *upRunner) getAppVolumeInitImage(a *app) error {
 	for _, volume := range a.volumes {
 		bindMountHostFiles = append(bindMountHostFiles, volume.resolvedHostPath)
 	}
-	r, err := buildVolumeInitImage(u.opts.Context, u.dockerClient, bindMountHostFiles, *u.cfg.VolumeInitBaseImage)
+	// The tar options may depend on the image of the app, which is a separate step of the preparation of images.
+	opts, err := u.getAppVolumeInitTarOptions(a)
+	if err != nil {
+		return err
+	}
+	return u.runImagePrepStep(a, func() error {
+		return u.buildAndPushVolumeInitImage(a, bindMountHostFiles, opts)
+	})
+}
+
+func (u *upRunner) buildAndPushVolumeInitImage(a *app, bindMountHostFiles []string, opts *bindMountTarOptions) error {
+	a.setPhase(reporter.PhaseBuilding)
+	r, err := buildVolumeInitImage(u.imageContext(), u.dockerClient, bindMountHostFiles, u.getVolumeInitBaseImage(),
+		u.getVolumeInitShell(), opts)
 	if err != nil {
 		return err
 	}
 	a.volumeInitImage.sourceImageID = r.imageID
 	tag := u.cfg.EnvironmentID + "-volumeinit"
-	if u.cfg.ClusterImageStorage.Docker != nil {
+	if u.cfg.ClusterImageStorage.Docker != nil || u.isTransferSaveLoad() {
 		imageRef := fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName, a.composeService.NameEscaped, tag)
-		err = u.dockerClient.ImageTag(u.opts.Context, a.volumeInitImage.sourceImageID, imageRef)
+		err = u.dockerClient.ImageTag(u.imageContext(), a.volumeInitImage.sourceImageID, imageRef)
 		if err != nil {
 			return err
 		}
+		if u.isTransferSaveLoad() {
+			err = u.transferImage(a, imageRef, "volume init image")
+			if err != nil {
+				return err
+			}
+		}
 		a.volumeInitImage.podImage = imageRef
 		a.volumeInitImage.podImagePullPolicy = v1.PullNever
 	} else {
@@ -296,34 +385,38 @@ func (u *upRunner) getAppVolumeInitImage(a *app) error {
 		if err != nil {
 			return err
 		}
-		a.volumeInitImage.podImagePullPolicy = v1.PullAlways
+		a.volumeInitImage.podImagePullPolicy = pushedImagePullPolicy(a.volumeInitImage.podImage)
 	}
 	return nil
 }
 
+// getPushImagePath returns the repository path of pushed images (below the host of the docker registry), which is the namespace prefixed
+// by Options.RegistryPrefix.
+func (u *upRunner) getPushImagePath() string {
+	if u.opts.RegistryPrefix == "" {
+		return u.cfg.Namespace
+	}
+	return u.opts.RegistryPrefix + "/" + u.cfg.Namespace
+}
+
 func (u *upRunner) pushImage(sourceImageID, name, tag, imageDescr string, a *app) (podImage string, err error) {
 	var registryInCluster = u.cfg.ClusterImageStorage.DockerRegistry.HostInCluster
-	var imagePath = u.cfg.Namespace
+	var imagePath = u.getPushImagePath()
 
-	pt := a.reporterRow.AddProgressTask("pushing " + imageDescr)
-	defer pt.Done()
-	a.reporterRow.AddStatus(reporter.StatusDockerPush)
-	defer a.reporterRow.RemoveStatus(reporter.StatusDockerPush)
+	a.setPhase(reporter.PhasePushing)
 	imagePush := fmt.Sprintf("%s/%s/%s:%s", u.cfg.ClusterImageStorage.DockerRegistry.Host, imagePath, name, tag)
-	err = u.dockerClient.ImageTag(u.opts.Context, sourceImageID, imagePush)
+	err = u.dockerClient.ImageTag(u.imageContext(), sourceImageID, imagePush)
 	if err != nil {
 		log.Warnf("tagging %s as %s failed with: %s (does the source image exist locally?)\n", sourceImageID, imagePush, err)
 		return
 	}
 	var digest string
-	registryAuth, _ := u.getAuthForImage(imagePush, a)
+	registryAuth, _ := u.getAuthForImage(imagePush)
 	if u.opts.SkipPush {
 		log.Debugf("--no-push %s\n", imagePush)
 	} else {
 		log.Debugf("pushing %s\n", imagePush)
-		digest, err = docker.PushImage(u.opts.Context, u.dockerClient, imagePush, registryAuth, func(push *docker.PullOrPush) {
-			pt.Update(push.Progress())
-		})
+		digest, err = u.pushImageWithRetries(u.dockerClient, a, imagePush, registryAuth, imageDescr)
 		if err != nil {
 			if strings.Contains(err.Error(), "Application not registered with AAD") {
 				log.Warnf("saw 'Application not registered with AAD': ACR credentials expired?")
@@ -337,11 +430,55 @@ func (u *upRunner) pushImage(sourceImageID, name, tag, imageDescr string, a *app
 	// - the original from sourceImageID
 	// - hardcoded "docker-registry.default.svc:5000"
 	// - specified u.cfg.ClusterImageStorage.DockerRegistry.Host
-	podImage = fmt.Sprintf("%s/%s/%s:%s", registryInCluster, imagePath, name, tag)
-	log.Tracef("podImage: %s (%s)\n", podImage, digest)
+	podImage = pushedPodImage(fmt.Sprintf("%s/%s/%s", registryInCluster, imagePath, name), tag, digest)
+	log.Tracef("podImage: %s\n", podImage)
 	return
 }
 
+// pushedPodImage returns the image reference that pods use to pull a pushed image. The tag is reused by every run of the environment, so
+// the canonical digest reference is returned if the digest of the push is known (i.e. unless pushing is skipped), and the tag otherwise.
+func pushedPodImage(repository, tag, digest string) string {
+	if digest != "" {
+		return repository + "@" + digest
+	}
+	return repository + ":" + tag
+}
+
+// getAppVolumeInitTarOptions returns the options of the tar archive of the helper image of bind mounted volumes, so that the copied
+// files are owned by the user of the docker compose service. If the service does not set a user then the files are owned by root (see
+// buildVolumeInitImageGetDockerfile).
+func (u *upRunner) getAppVolumeInitTarOptions(a *app) (*bindMountTarOptions, error) {
+	userRaw := a.composeService.DockerComposeService.User
+	if userRaw == nil {
+		return &bindMountTarOptions{
+			skipEscapingSymlinks: u.opts.SkipEscapingSymlinks,
+		}, nil
+	}
+	user, err := docker.ParseUserinfo(*userRaw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "docker-compose service %s has an invalid user %#v", a.name(), *userRaw)
+	}
+	if !user.IsNumeric() {
+		err = u.getAppImageInfoOnce(a)
+		if err != nil {
+			return nil, err
+		}
+		if a.imageInfo.user != nil {
+			user = a.imageInfo.user
+		} else {
+			err = getUserinfoFromImage(u.imageContext(), u.dockerClient, a.imageInfo.sourceImageID, user)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error getting uid/gid of user %#v of docker-compose service %s", *userRaw, a.name())
+			}
+		}
+	}
+	return &bindMountTarOptions{
+		uid:                  user.UID,
+		gid:                  user.GID,
+		skipEscapingSymlinks: u.opts.SkipEscapingSymlinks,
+	}, nil
+}
+
 func (u *upRunner) getAppVolumeInitImageOnce(a *app) error {
 	a.volumeInitImage.once.Do(func() {
 		a.volumeInitImage.err = u.getAppVolumeInitImage(a)
@@ -353,20 +490,46 @@ func (u *upRunner) initApps() {
 	u.apps = make(map[string]*app, len(u.cfg.Services))
 	u.appsThatNeedToBeReady = map[*app]bool{}
 	u.secretsDeployed = map[string]bool{}
+	// Buffered so that waiting for a Job never blocks, at most one wait is in progress per app.
+	u.jobCompletions = make(chan jobCompletion, len(u.cfg.Services))
 	u.diffRegexpDel = regexp.MustCompile(`(?m)^- (.+)$`)
 	u.diffRegexpAdd = regexp.MustCompile(`(?m)^\+ (.+)$`)
 	for _, composeService := range u.cfg.Services {
+		if u.cfg.IsSkipped(composeService) {
+			continue
+		}
 		app := &app{
+			completionDependency:                 isCompletionDependency(u.cfg, composeService),
 			composeService:                       composeService,
-			containersForWhichWeAreStreamingLogs: make(map[string]bool),
+			containersForWhichWeAreStreamingLogs: make(map[string]int32),
 		}
 		app.imageInfo.once = &sync.Once{}
 		app.volumeInitImage.once = &sync.Once{}
 		u.apps[app.name()] = app
+		u.warnAboutIgnoredLabels(app)
+	}
+}
+
+// warnAboutIgnoredLabels warns about the labels of the app's docker compose service that are not added to its resources (see
+// k8smeta.InitObjectMeta).
+func (u *upRunner) warnAboutIgnoredLabels(a *app) {
+	var names []string
+	for name := range a.composeService.DockerComposeService.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch {
+		case k8smeta.IsReservedLabel(u.cfg, name):
+			a.newLogEntry().Warnf("ignoring label %#v: the label is reserved by kube-compose", name)
+		case len(validation.IsQualifiedName(name)) > 0:
+			a.newLogEntry().Warnf("ignoring label %#v: the name is not a valid Kubernetes label or annotation name", name)
+		}
 	}
 }
 
 func (u *upRunner) getAppImageInfo(app *app) error {
+	app.setPhase(reporter.PhaseResolving)
 	sourceImage := app.composeService.DockerComposeService.Image
 	if sourceImage == "" {
 		return fmt.Errorf("docker compose service %s has no image or its image is the empty string, and building images is not supported",
@@ -389,7 +552,7 @@ func (u *upRunner) getAppImageInfo(app *app) error {
 		}
 		return errors.Wrapf(err, "getAppImageInfoEnsureSourceImageID")
 	}
-	inspect, inspectRaw, err := u.dockerClient.ImageInspectWithRaw(u.opts.Context, app.imageInfo.sourceImageID)
+	inspect, inspectRaw, err := u.dockerClient.ImageInspectWithRaw(u.imageContext(), app.imageInfo.sourceImageID)
 	if err != nil {
 		return errors.Wrapf(err, "ImageInspectWithRaw")
 	}
@@ -412,9 +575,21 @@ func (u *upRunner) getAppImageInfo(app *app) error {
 func (u *upRunner) getAppImageEnsureCorrectPodImage(a *app, sourceImageRef dockerRef.Reference, sourceImage string) error {
 	tag := u.cfg.EnvironmentID + "-main"
 	switch {
+	case u.isTransferSaveLoad():
+		imageRef := fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName, a.composeService.NameEscaped, tag)
+		err := u.dockerClient.ImageTag(u.imageContext(), a.imageInfo.sourceImageID, imageRef)
+		if err != nil {
+			return err
+		}
+		err = u.transferImage(a, imageRef, "image")
+		if err != nil {
+			return err
+		}
+		a.imageInfo.podImage = imageRef
+		a.imageInfo.podImagePullPolicy = v1.PullNever
 	case u.cfg.ClusterImageStorage.Docker != nil:
 		imageRef := fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName, a.composeService.NameEscaped, tag)
-		err := u.dockerClient.ImageTag(u.opts.Context, a.imageInfo.sourceImageID, imageRef)
+		err := u.dockerClient.ImageTag(u.imageContext(), a.imageInfo.sourceImageID, imageRef)
 		if err != nil {
 			return err
 		}
@@ -429,6 +604,7 @@ func (u *upRunner) getAppImageEnsureCorrectPodImage(a *app, sourceImageRef docke
 		if slices.Contains(skipHosts, registryHost) {
 			log.Debugf("skipping %s push to %s: will download in-cluster.\n", sourceImage, u.cfg.ClusterImageStorage.DockerRegistry.Host)
 			a.imageInfo.podImage = sourceImage
+			a.imageInfo.podImagePullPolicy = imagePullPolicyForImage(sourceImage)
 			return nil
 		}
 
@@ -436,6 +612,7 @@ func (u *upRunner) getAppImageEnsureCorrectPodImage(a *app, sourceImageRef docke
 		if err != nil {
 			return errors.Wrapf(err, "failure with %s", sourceImage)
 		}
+		a.imageInfo.podImagePullPolicy = pushedImagePullPolicy(a.imageInfo.podImage)
 	case a.imageInfo.podImage == "":
 		_, sourceImageIsNamed := sourceImageRef.(dockerRef.Named)
 		if !sourceImageIsNamed {
@@ -445,6 +622,9 @@ func (u *upRunner) getAppImageEnsureCorrectPodImage(a *app, sourceImageRef docke
 		}
 		a.imageInfo.podImage = sourceImage
 	}
+	if a.imageInfo.podImagePullPolicy == "" {
+		a.imageInfo.podImagePullPolicy = imagePullPolicyForImage(a.imageInfo.podImage)
+	}
 	return nil
 }
 
@@ -462,7 +642,7 @@ func (u *upRunner) getAppImageInfoEnsureSourceImageID(sourceImage string, source
 			return err
 		}
 		a.imageInfo.sourceImageID, a.imageInfo.podImage, err = resolveLocalImageAfterPull(
-			u.opts.Context, u.dockerClient, sourceImageNamed, digest)
+			u.imageContext(), u.dockerClient, sourceImageNamed, digest)
 		if err != nil {
 			return err
 		}
@@ -478,64 +658,15 @@ func (u *upRunner) getAppImageInfoEnsureSourceImageID(sourceImage string, source
 func (u *upRunner) getAppImageInfoPullImage(sourceImageRef dockerRef.Reference, a *app) (string, error) {
 	pt := a.reporterRow.AddProgressTask("pulling image")
 	defer pt.Done()
-	a.reporterRow.AddStatus(reporter.StatusDockerPull)
-	defer a.reporterRow.RemoveStatus(reporter.StatusDockerPull)
 
-	auth, _ := u.getAuthForImage(sourceImageRef.String(), a)
+	auth, _ := u.getAuthForImage(sourceImageRef.String())
 
-	return docker.PullImage(u.opts.Context, u.dockerClient, sourceImageRef.String(), auth, func(pull *docker.PullOrPush) {
+	return docker.PullImage(u.imageContext(), u.dockerClient, sourceImageRef.String(), auth, func(pull *docker.PullOrPush) {
 		pt.Update(pull.Progress())
 	})
 }
 
-func (u *upRunner) createSecretForRegistry(registryHost string, a *app) (string, error) {
-	name := u.pullSecretNameForRegistry(registryHost)
-	if u.secretsDeployed[registryHost] {
-		return name, nil
-	}
-	// Note this 'true' also holds for Registries that have no auth info
-	u.secretsDeployed[registryHost] = true
-
-	_, err, _ := u.readAuthConfigurations()
-
-	path := expanduser.ExpandUser("~/.docker/config.json")
-	secretData, err := ioutil.ReadFile(path)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	secret := &v1.Secret{
-		Type:       "kubernetes.io/dockerconfigjson",
-		StringData: map[string]string{".dockerconfigjson": string(secretData)},
-	}
-
-	// TODO look at if we need to change InitObjectMeta() here
-	k8smeta.InitObjectMeta(u.cfg, &secret.ObjectMeta, a.composeService)
-
-	secret.ObjectMeta.Name = u.pullSecretNameForRegistry(registryHost)
-	// TODO: secret.ObjectMeta.OwnerReferences
-
-	_, err = u.k8sSecretClient.Create(u.opts.Context, secret, metav1.CreateOptions{})
-	op := "created"
-	if k8sError.IsAlreadyExists(err) {
-		_, err = u.k8sSecretClient.Update(u.opts.Context, secret, metav1.UpdateOptions{})
-		op = "updated"
-	}
-	switch {
-	case err != nil:
-		log.Warnf("Failed creating %s: %s\n", secret.ObjectMeta.Name, err)
-	default:
-		log.Debugf("%s secret %s\n", op, secret.ObjectMeta.Name)
-	}
-
-	return name, err
-}
-
-func (u *upRunner) pullSecretNameForRegistry(registryHost string) string {
-	return fmt.Sprintf("pull-secret-%s-%s", u.cfg.EnvironmentID, registryHost)
-}
-
-func (u *upRunner) getAuthForImage(sourceImageRef string, a *app) (string, error) {
+func (u *upRunner) getAuthForImage(sourceImageRef string) (string, error) {
 	s, err, done := u.readAuthConfigurations()
 	if done {
 		return s, err
@@ -561,18 +692,13 @@ func (u *upRunner) getAuthForImage(sourceImageRef string, a *app) (string, error
 
 	b64AuthConfig := base64.StdEncoding.EncodeToString(authConfigBytes)
 
-	//registryAuth := u.authConfigurations.Configs[registryHost]
-	if len(authConfigBytes) > 0 {
-		name, err := u.createSecretForRegistry(registryHost, a)
-		if err != nil {
-			log.Debugf("Failed to create secret %s (but continuing...)\n", name)
-		}
-	}
-
 	return b64AuthConfig, nil
 }
 
 func (u *upRunner) readAuthConfigurations() (string, error, bool) {
+	// Images are prepared concurrently.
+	u.authConfigsMutex.Lock()
+	defer u.authConfigsMutex.Unlock()
 	if u.authConfigurations == nil {
 		authConfigurations, err := alternateDockerClient.NewAuthConfigurationsFromDockerCfg()
 		if err != nil {
@@ -601,10 +727,11 @@ func (u *upRunner) getAppImageInfoUser(a *app, inspect *dockerTypes.ImageInspect
 			return errors.Wrapf(err, "docker-compose service %s has an invalid user %#v", a.name(), *userRaw)
 		}
 	}
-	if user.UID == nil || (user.Group != "" && user.GID == nil) {
+	// Numeric users are used as is, so that the image does not need to be run (and does not need to have /etc/passwd and /etc/group).
+	if !user.IsNumeric() {
 		// TODO https://github.com/kube-compose/kube-compose/issues/70 confirm whether docker and our pod spec will produce the same default
 		// group if a UID is set but no GID
-		err := getUserinfoFromImage(u.opts.Context, u.dockerClient, a.imageInfo.sourceImageID, user)
+		err := getUserinfoFromImage(u.imageContext(), u.dockerClient, a.imageInfo.sourceImageID, user)
 		if err != nil {
 			return errors.Wrapf(err, "error getting uid/gid from image %#v", sourceImage)
 		}
@@ -615,7 +742,9 @@ func (u *upRunner) getAppImageInfoUser(a *app, inspect *dockerTypes.ImageInspect
 
 func (u *upRunner) getAppImageInfoOnce(app *app) error {
 	app.imageInfo.once.Do(func() {
-		app.imageInfo.err = u.getAppImageInfo(app)
+		app.imageInfo.err = u.runImagePrepStep(app, func() error {
+			return u.getAppImageInfo(app)
+		})
 	})
 	return app.imageInfo.err
 }
@@ -633,8 +762,13 @@ func (u *upRunner) waitForServiceClusterIPUpdate(service *v1.Service) (*app, err
 	if app == nil {
 		return nil, nil
 	}
-	if !slices.Contains([]v1.ServiceType{"ClusterIP", "ExternalName"}, service.Spec.Type) {
-		return app, k8smeta.ErrorWrapResourcesModifiedExternally("While waiting for updated ClusterIP saw unexpected Spec.Type '%s' for service %#v", service.Spec.Type, service)
+	if service.Spec.ClusterIP == v1.ClusterIPNone {
+		// Headless services of stateful apps do not have a cluster IP to alias.
+		return app, nil
+	}
+	if !slices.Contains([]v1.ServiceType{"ClusterIP", "ExternalName", app.composeService.ServiceType}, service.Spec.Type) {
+		return app, k8smeta.ErrorWrapResourcesModifiedExternally("While waiting for updated ClusterIP saw unexpected Spec.Type '%s' for "+
+			"service %#v", service.Spec.Type, service)
 	}
 	app.serviceClusterIP = service.Spec.ClusterIP
 	return app, nil
@@ -732,44 +866,126 @@ func (u *upRunner) waitForServiceClusterIP(expected int) error {
 	return u.waitForServiceClusterIPWatch(expected, remaining, watch.ResultChan())
 }
 
-func (u *upRunner) createServicesAndGetPodHostAliases() ([]v1.HostAlias, error) {
+// getServicePort returns the port of the Kubernetes Service for a port of a docker compose service. Other containers reach a docker compose
+// service on its internal ports, so the internal port is used, unless a single published port is set with the long syntax.
+func getServicePort(port dockerComposeConfig.PortBinding) int32 {
+	if port.Mode != "" && port.ExternalMin >= 0 && port.ExternalMin == port.ExternalMax {
+		return port.ExternalMin
+	}
+	return port.Internal
+}
+
+// newService builds the Kubernetes Service of an app. The caller must ensure that the app has a service (see hasService).
+func (u *upRunner) newService(app *app) *v1.Service {
+	servicePorts := make([]v1.ServicePort, len(app.composeService.DockerComposeService.Ports))
+	for i, port := range app.composeService.DockerComposeService.Ports {
+		servicePort := getServicePort(port)
+		servicePorts[i] = v1.ServicePort{
+			Name:       fmt.Sprintf("%s%d", port.Protocol, servicePort),
+			Port:       servicePort,
+			Protocol:   v1.Protocol(strings.ToUpper(port.Protocol)),
+			TargetPort: intstr.FromInt(int(port.Internal)),
+		}
+	}
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Ports:    servicePorts,
+			Selector: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
+			Type:     v1.ServiceTypeClusterIP,
+		},
+	}
+	if app.composeService.ServiceType != "" {
+		service.Spec.Type = app.composeService.ServiceType
+	}
+	// Only Services of type NodePort and LoadBalancer have node ports. The config ensures that a Service with a node port has one port.
+	if app.composeService.NodePort != 0 && service.Spec.Type != v1.ServiceTypeClusterIP && len(servicePorts) == 1 {
+		service.Spec.Ports[0].NodePort = app.composeService.NodePort
+	}
+	// Headless Services must be of type ClusterIP, so --headless-services does not apply to Services of other types.
+	if u.opts.HeadlessServices && service.Spec.Type == v1.ServiceTypeClusterIP {
+		service.Spec.ClusterIP = v1.ClusterIPNone
+	}
+	k8smeta.InitObjectMeta(u.cfg, &service.ObjectMeta, app.composeService)
+	return service
+}
+
+// newHeadlessNameServices builds the headless Services that make the host names of an app (see hostnames) resolvable by the cluster DNS
+// with --headless-services, because pods do not get host aliases then: a Service per network alias, and a Service named after the app if
+// it has no Service of its own because it has no ports. The Services select the pods of the app like the Service of the app.
+func (u *upRunner) newHeadlessNameServices(app *app) []*v1.Service {
+	if !u.opts.HeadlessServices || app.composeService.NoService {
+		return nil
+	}
+	hostnames := app.hostnames()
+	if app.hasService() {
+		hostnames = hostnames[1:]
+	}
+	var services []*v1.Service
+	for _, hostname := range hostnames {
+		service := u.newService(app)
+		service.ObjectMeta.Name = k8smeta.GetK8sNameFromEscapedName(u.cfg, util.EscapeName(hostname))
+		service.Spec.ClusterIP = v1.ClusterIPNone
+		// Headless Services must be of type ClusterIP, the Service of the app (if any) has the type and node port of the app.
+		service.Spec.Type = v1.ServiceTypeClusterIP
+		for i := range service.Spec.Ports {
+			service.Spec.Ports[i].NodePort = 0
+		}
+		services = append(services, service)
+	}
+	return services
+}
+
+// createHeadlessNameServices creates (or updates) the Services returned by newHeadlessNameServices.
+func (u *upRunner) createHeadlessNameServices(app *app) error {
+	for _, service := range u.newHeadlessNameServices(app) {
+		_, op, err := u.createOrUpdateService(service)
+		if err != nil {
+			return err
+		}
+		app.newLogEntry().Debugf("%s k8s service %s", op, service.ObjectMeta.Name)
+	}
+	return nil
+}
+
+// createServices creates (or updates) the Kubernetes Services of all apps, including those of external services and external links.
+// Returns the number of Services of apps.
+func (u *upRunner) createServices() (int, error) {
+	err := u.createExternalNameServices()
+	if err != nil {
+		return 0, err
+	}
+	err = u.createExternalLinkServices()
+	if err != nil {
+		return 0, err
+	}
 	expectedServiceCount := 0
 	for _, app := range u.apps {
+		if err := u.createHeadlessNameServices(app); err != nil {
+			return 0, err
+		}
 		if !app.hasService() {
 			continue
 		}
 		expectedServiceCount++
-		servicePorts := make([]v1.ServicePort, len(app.composeService.DockerComposeService.Ports))
-		for i, port := range app.composeService.DockerComposeService.Ports {
-			servicePorts[i] = v1.ServicePort{
-				Name:       fmt.Sprintf("%s%d", port.Protocol, port.Internal),
-				Port:       port.Internal,
-				Protocol:   v1.Protocol(strings.ToUpper(port.Protocol)),
-				TargetPort: intstr.FromInt(int(port.Internal)),
-			}
-		}
-		service := &v1.Service{
-			Spec: v1.ServiceSpec{
-				Ports:    servicePorts,
-				Selector: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
-				Type:     v1.ServiceType("ClusterIP"),
-			},
-		}
-		k8smeta.InitObjectMeta(u.cfg, &service.ObjectMeta, app.composeService)
-		_, err := u.k8sServiceClient.Create(u.opts.Context, service, metav1.CreateOptions{})
-		op := "created"
-		if k8sError.IsAlreadyExists(err) {
-			_, err = u.k8sServiceClient.Update(u.opts.Context, service, metav1.UpdateOptions{})
-			op = "updated"
-		}
+		service := u.newService(app)
+		_, op, err := u.createOrUpdateService(service)
 		switch {
 		case err != nil:
-			return nil, err
+			return 0, err
 		default:
 			app.newLogEntry().Debugf("%s k8s service %s", op, service.ObjectMeta.Name)
 		}
 	}
-	if expectedServiceCount == 0 {
+	return expectedServiceCount, nil
+}
+
+func (u *upRunner) createServicesAndGetPodHostAliases() ([]v1.HostAlias, error) {
+	expectedServiceCount, err := u.createServices()
+	if err != nil {
+		return nil, err
+	}
+	// Headless Services have no cluster IP, their names are resolved by the cluster DNS instead.
+	if expectedServiceCount == 0 || u.opts.HeadlessServices {
 		return nil, nil
 	}
 	return u.getPodHostAliasesCore(expectedServiceCount)
@@ -785,10 +1001,8 @@ func (u *upRunner) getPodHostAliasesCore(expectedServiceCount int) ([]v1.HostAli
 	for _, app := range u.apps {
 		if app.hasService() {
 			hostAliases[i] = v1.HostAlias{
-				IP: app.serviceClusterIP,
-				Hostnames: []string{
-					app.name(),
-				},
+				IP:        app.serviceClusterIP,
+				Hostnames: app.hostnames(),
 			}
 			i++
 		}
@@ -798,7 +1012,7 @@ func (u *upRunner) getPodHostAliasesCore(expectedServiceCount int) ([]v1.HostAli
 
 func (u *upRunner) initLocalImages() error {
 	u.localImagesCache.once.Do(func() {
-		imageSummarySlice, err := u.dockerClient.ImageList(u.opts.Context, dockerTypes.ImageListOptions{
+		imageSummarySlice, err := u.dockerClient.ImageList(u.imageContext(), dockerTypes.ImageListOptions{
 			All: true,
 		})
 		var imageIDSet *digestset.Set
@@ -831,26 +1045,43 @@ func (u *upRunner) createServicesAndGetPodHostAliasesOnce() ([]v1.HostAlias, err
 		if u.opts.SkipHostAliases {
 			u.hostAliases.v = []v1.HostAlias{}
 		} else {
-			u.hostAliases.v = v
+			u.hostAliases.v = append(v, u.getExternalHostAliases()...)
 		}
 		u.hostAliases.err = err
 	})
 	return u.hostAliases.v, u.hostAliases.err
 }
 
-func getRestartPolicyforService(app *app) v1.RestartPolicy {
-	var restartPolicy v1.RestartPolicy
-	switch app.composeService.DockerComposeService.Restart {
-	case "no":
-		restartPolicy = v1.RestartPolicyNever
-	case "always":
-		restartPolicy = v1.RestartPolicyAlways
+// parseRestart parses the restart policy of a docker compose service. Kubernetes has no equivalent of unless-stopped, because pods cannot
+// be stopped, so it is mapped to Always. The maximum number of retries of on-failure:<max-retries> is returned, if set.
+func parseRestart(restart string) (v1.RestartPolicy, *int32, error) {
+	const onFailurePrefix = "on-failure:"
+	if strings.HasPrefix(restart, onFailurePrefix) {
+		maxRetries, err := strconv.ParseInt(restart[len(onFailurePrefix):], 10, 32)
+		if err != nil || maxRetries < 0 {
+			return "", nil, fmt.Errorf("restart policy %#v has an invalid maximum number of retries", restart)
+		}
+		return v1.RestartPolicyOnFailure, util.NewInt32(int32(maxRetries)), nil
+	}
+	switch restart {
+	case "", "no":
+		return v1.RestartPolicyNever, nil, nil
+	case "always", "unless-stopped":
+		return v1.RestartPolicyAlways, nil, nil
 	case "on-failure":
-		restartPolicy = v1.RestartPolicyOnFailure
-	default:
-		restartPolicy = v1.RestartPolicyNever
+		return v1.RestartPolicyOnFailure, nil, nil
 	}
-	return restartPolicy
+	return "", nil, fmt.Errorf("unsupported restart policy %#v, must be one of \"no\", \"always\", \"on-failure\", "+
+		"\"on-failure:<max-retries>\" and \"unless-stopped\"", restart)
+}
+
+// getRestartPolicyforService maps the restart policy of the docker compose service to the restart policy of its pod.
+func getRestartPolicyforService(app *app) (v1.RestartPolicy, error) {
+	restartPolicy, _, err := parseRestart(app.composeService.DockerComposeService.Restart)
+	if err != nil {
+		return "", errors.Wrapf(err, "service %s", app.name())
+	}
+	return restartPolicy, nil
 }
 
 // GetReadinessProbe converts the image/docker-compose healthcheck to a readiness probe to implement depends_on condition: service_healthy
@@ -859,6 +1090,9 @@ func getRestartPolicyforService(app *app) v1.RestartPolicy {
 // ... so we're not doubling up on healthchecks. We accept that this may lead to calls failing due to removal backend pods from load
 // balancers.
 func (a *app) GetReadinessProbe() *v1.Probe {
+	if a.composeService.Probes != nil && a.composeService.Probes.Readiness != nil {
+		return a.composeService.Probes.Readiness.DeepCopy()
+	}
 	if !a.composeService.DockerComposeService.HealthcheckDisabled {
 		if a.composeService.DockerComposeService.Healthcheck != nil {
 			return createReadinessProbeFromDockerHealthcheck(a.composeService.DockerComposeService.Healthcheck)
@@ -869,6 +1103,33 @@ func (a *app) GetReadinessProbe() *v1.Probe {
 	return nil
 }
 
+// GetStartupProbe converts the start_period of the image/docker-compose healthcheck to a startup probe (see GetReadinessProbe).
+func (a *app) GetStartupProbe() *v1.Probe {
+	if a.composeService.Probes != nil && a.composeService.Probes.Startup != nil {
+		return a.composeService.Probes.Startup.DeepCopy()
+	}
+	if !a.composeService.DockerComposeService.HealthcheckDisabled {
+		if a.composeService.DockerComposeService.Healthcheck != nil {
+			return createStartupProbeFromDockerHealthcheck(a.composeService.DockerComposeService.Healthcheck)
+		} else if a.imageInfo.imageHealthcheck != nil {
+			return createStartupProbeFromDockerHealthcheck(a.imageInfo.imageHealthcheck)
+		}
+	}
+	return nil
+}
+
+// GetLivenessProbe returns the liveness probe of "x-kube-compose"."probes". Healthchecks are never mapped to liveness probes, because
+// docker does not restart unhealthy containers either.
+func (a *app) GetLivenessProbe() *v1.Probe {
+	if a.composeService.Probes != nil {
+		return a.composeService.Probes.Liveness.DeepCopy()
+	}
+	return nil
+}
+
+// GetArgsAndCommand sets the command and args of the container from the docker compose service. Kubernetes names these the opposite of
+// docker: the entrypoint becomes the command of the container and the command becomes the args, so that overriding only the command of
+// a service keeps the entrypoint of the image.
 func (a *app) GetArgsAndCommand(c *v1.Container) error {
 	// docker-compose does not ignore the entrypoint if it is an empty array. For example: if the entrypoint is empty but the command is not
 	// empty then the entrypoint becomes the command. But the Kubernetes client treats an empty entrypoint array as an unset entrypoint,
@@ -889,22 +1150,128 @@ func (a *app) GetArgsAndCommand(c *v1.Container) error {
 }
 
 func (u *upRunner) createSecurityContext(a *app) *v1.SecurityContext {
-	if u.opts.RunAsUser || a.composeService.DockerComposeService.Privileged {
-		securityContext := &v1.SecurityContext{}
-		if u.opts.RunAsUser {
+	dockerComposeService := a.composeService.DockerComposeService
+	capabilities := createCapabilities(dockerComposeService)
+	if u.opts.RunAsUser || dockerComposeService.Privileged || dockerComposeService.ReadOnly || capabilities != nil {
+		securityContext := &v1.SecurityContext{
+			Capabilities: capabilities,
+		}
+		// The user is not resolved if the image is not inspected and the user is not numeric (see initAppUserWithoutImage).
+		if u.opts.RunAsUser && a.imageInfo.user != nil {
 			securityContext.RunAsUser = a.imageInfo.user.UID
 			if a.imageInfo.user.GID != nil {
 				securityContext.RunAsGroup = a.imageInfo.user.GID
 			}
 		}
-		if a.composeService.DockerComposeService.Privileged {
+		if dockerComposeService.Privileged {
+			a.newLogEntry().Warn("the container is privileged, which is forbidden by clusters that enforce the baseline or restricted " +
+				"Pod Security Standard")
 			securityContext.Privileged = util.NewBool(true)
 		}
+		if dockerComposeService.ReadOnly {
+			if !hasWritableMounts(dockerComposeService) {
+				a.newLogEntry().Warn("the root file system of the container is read-only and the container has no writable mounts, " +
+					"consider adding a tmpfs mount (e.g. at /tmp)")
+			}
+			securityContext.ReadOnlyRootFilesystem = util.NewBool(true)
+		}
 		return securityContext
 	}
 	return nil
 }
 
+// hasWritableMounts returns true if and only if the docker compose service has a tmpfs mount or a volume that is not read-only, which
+// are the places where a container with a read-only root file system can write to.
+func hasWritableMounts(dockerComposeService *dockerComposeConfig.Service) bool {
+	if len(dockerComposeService.Tmpfs) > 0 {
+		return true
+	}
+	for _, serviceVolume := range dockerComposeService.Volumes {
+		if serviceVolume.Short != nil && !(serviceVolume.Short.HasMode && serviceVolume.Short.Mode == "ro") {
+			return true
+		}
+	}
+	return false
+}
+
+// createCapabilities translates cap_add and cap_drop of a docker compose service to the capabilities of a container. Returns nil if the
+// service does not add or drop capabilities.
+func createCapabilities(dockerComposeService *dockerComposeConfig.Service) *v1.Capabilities {
+	if len(dockerComposeService.CapAdd) == 0 && len(dockerComposeService.CapDrop) == 0 {
+		return nil
+	}
+	capabilities := &v1.Capabilities{}
+	for _, name := range dockerComposeService.CapAdd {
+		capabilities.Add = append(capabilities.Add, v1.Capability(name))
+	}
+	for _, name := range dockerComposeService.CapDrop {
+		capabilities.Drop = append(capabilities.Drop, v1.Capability(name))
+	}
+	return capabilities
+}
+
+// createPodDNSConfig translates the DNS settings of the app's docker compose service into the pod's dnsConfig. Name servers, search domains
+// and resolver options are merged by Kubernetes with those of the default dnsPolicy ClusterFirst, so that the names of Services (e.g. of
+// headless services and external services) are still resolved by the cluster DNS, which comes first.
+func (a *app) createPodDNSConfig() *v1.PodDNSConfig {
+	dockerComposeService := a.composeService.DockerComposeService
+	if len(dockerComposeService.DNSOptions) == 0 && len(dockerComposeService.DNSServers) == 0 && len(dockerComposeService.DNSSearch) == 0 {
+		return nil
+	}
+	if len(dockerComposeService.DNSServers) > 2 {
+		// Kubernetes uses at most 3 name servers, the first of which is the cluster DNS.
+		a.newLogEntry().Warnf("only the first 2 name servers of dns are used, because the cluster DNS comes first")
+	}
+	dnsConfig := &v1.PodDNSConfig{
+		Nameservers: dockerComposeService.DNSServers,
+		Searches:    dockerComposeService.DNSSearch,
+	}
+	for _, dnsOption := range dockerComposeService.DNSOptions {
+		dnsConfig.Options = append(dnsConfig.Options, v1.PodDNSConfigOption{
+			Name:  dnsOption.Name,
+			Value: dnsOption.Value,
+		})
+	}
+	return dnsConfig
+}
+
+// createPodHostAliases returns the host aliases of the app's pod: hostAliases (i.e. those of services), followed by the host aliases of
+// external links and of the extra_hosts of the app's docker compose service. hostAliases is not modified.
+func (a *app) createPodHostAliases(hostAliases []v1.HostAlias) []v1.HostAlias {
+	result := append(hostAliases[:len(hostAliases):len(hostAliases)], getExternalLinkHostAliases(a)...)
+	// The host names of extra hosts are grouped by IP address, so that each IP address has a single host alias.
+	indexByIP := map[string]int{}
+	for _, extraHost := range a.composeService.DockerComposeService.ExtraHosts {
+		if i, ok := indexByIP[extraHost.IP]; ok {
+			result[i].Hostnames = append(result[i].Hostnames, extraHost.Hostname)
+			continue
+		}
+		indexByIP[extraHost.IP] = len(result)
+		result = append(result, v1.HostAlias{
+			IP: extraHost.IP,
+			Hostnames: []string{
+				extraHost.Hostname,
+			},
+		})
+	}
+	return result
+}
+
+// getTerminationGracePeriodSeconds translates the stop_grace_period of the app's docker compose service to the termination grace period
+// of its pod. Kubernetes only accepts whole seconds, so fractions of seconds are rounded up. Returns nil if stop_grace_period is not set,
+// in which case the default of Kubernetes applies.
+func (a *app) getTerminationGracePeriodSeconds() *int64 {
+	stopGracePeriod := a.composeService.DockerComposeService.StopGracePeriod
+	if stopGracePeriod == nil {
+		return nil
+	}
+	seconds := int64(*stopGracePeriod / time.Second)
+	if *stopGracePeriod%time.Second != 0 {
+		seconds++
+	}
+	return &seconds
+}
+
 func (u *upRunner) createPodVolumes(a *app, pod *v1.Pod) error {
 	if len(a.volumes) == 0 {
 		return nil
@@ -947,20 +1314,89 @@ func (u *upRunner) createPodVolumes(a *app, pod *v1.Pod) error {
 	return nil
 }
 
-func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
-	err := u.getAppImageInfoOnce(app)
+func (u *upRunner) createPod(app *app) error {
+	hostAliases, err := u.createServicesAndGetPodHostAliasesOnce()
 	if err != nil {
-		return nil, errors.Wrapf(err, "creating %s pod", app.name())
+		if err.Error() == "Unauthorized" {
+			log.Warnf("%s: while accessing k8s (are you logged in?)", err)
+		}
+		return err
 	}
-	readinessProbe := app.GetReadinessProbe()
+	return u.createWorkload(app, hostAliases)
+}
+
+// createWorkload creates the Pods of the replicas (or StatefulSet, if the app is stateful, or Job, if the app is a job, or Deployment, see
+// Options.AsDeployment) of an app.
+func (u *upRunner) createWorkload(app *app, hostAliases []v1.HostAlias) error {
+	pod, err := u.newPod(app, hostAliases)
+	if err != nil {
+		app.setPhase(reporter.PhaseFailed)
+		return err
+	}
+	u.createPodPullSecrets(app, pod)
+	app.setPhase(reporter.PhaseCreating)
+	switch {
+	case app.composeService.Stateful:
+		err = u.createStatefulSet(app, pod)
+	case app.isJob():
+		err = u.createJob(app, pod)
+	case u.isDeployment(app):
+		err = u.createDeployment(app, pod)
+	default:
+		err = u.createReplicaPods(app, pod)
+	}
+	if err != nil {
+		return err
+	}
+	if app.getReplicas() == 0 && !app.isJob() {
+		// There are no pods to wait for, so the app is considered ready (e.g. so that dependent apps are started).
+		app.newLogEntry().Info("deploy.replicas is 0, not waiting for pods")
+		u.setAppMaxObservedPodStatus(app, podStatusReady)
+		return nil
+	}
+	u.appsThatNeedToBeReady[app] = true
+	return nil
+}
 
+// newContainerPorts returns the container ports of an app: its ports followed by its exposed ports. Exposed ports are declared on the
+// container only, they do not cause the app to get a Kubernetes Service (see hasService).
+func newContainerPorts(app *app) []v1.ContainerPort {
 	containerPorts := make([]v1.ContainerPort, len(app.composeService.Ports))
 	for i, port := range app.composeService.Ports {
 		containerPorts[i] = v1.ContainerPort{
 			ContainerPort: port.Port,
+			HostPort:      port.HostPort,
 			Protocol:      v1.Protocol(strings.ToUpper(port.Protocol)),
 		}
 	}
+	for _, portBinding := range app.composeService.DockerComposeService.Expose {
+		containerPort := v1.ContainerPort{
+			ContainerPort: portBinding.Internal,
+			Protocol:      v1.Protocol(strings.ToUpper(portBinding.Protocol)),
+		}
+		duplicate := false
+		for _, containerPort2 := range containerPorts {
+			if containerPort2.ContainerPort == containerPort.ContainerPort && containerPort2.Protocol == containerPort.Protocol {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			containerPorts = append(containerPorts, containerPort)
+		}
+	}
+	return containerPorts
+}
+
+// newPod builds the Kubernetes Pod of an app, without submitting it to the cluster.
+func (u *upRunner) newPod(app *app, hostAliases []v1.HostAlias) (*v1.Pod, error) {
+	err := u.getAppImageInfoOnce(app)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s pod", app.name())
+	}
+	readinessProbe := app.GetReadinessProbe()
+
+	containerPorts := newContainerPorts(app)
 	var envVars []v1.EnvVar
 	envVarCount := len(app.composeService.DockerComposeService.Environment)
 	if envVarCount > 0 {
@@ -974,14 +1410,10 @@ func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
 			i++
 		}
 	}
-	hostAliases, err := u.createServicesAndGetPodHostAliasesOnce()
+	restartPolicy, err := getRestartPolicyforService(app)
 	if err != nil {
-		if err.Error() == "Unauthorized" {
-			log.Warnf("%s: while accessing k8s (are you logged in?)", err)
-		}
 		return nil, err
 	}
-
 	pod := &v1.Pod{
 		Spec: v1.PodSpec{
 			// new(bool) allocates a bool, sets it to false, and returns a pointer to it.
@@ -991,18 +1423,24 @@ func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
 					Env:             envVars,
 					Image:           app.imageInfo.podImage,
 					ImagePullPolicy: app.imageInfo.podImagePullPolicy,
+					LivenessProbe:   app.GetLivenessProbe(),
 					Name:            app.composeService.NameEscaped,
 					Ports:           containerPorts,
 					ReadinessProbe:  readinessProbe,
+					Resources:       app.createContainerResources(),
 					SecurityContext: u.createSecurityContext(app),
+					StartupProbe:    app.GetStartupProbe(),
 					WorkingDir:      app.composeService.DockerComposeService.WorkingDir,
 				},
 			},
-			HostAliases:   hostAliases,
-			RestartPolicy: getRestartPolicyforService(app),
+			DNSConfig:                     app.createPodDNSConfig(),
+			HostAliases:                   app.createPodHostAliases(hostAliases),
+			RestartPolicy:                 restartPolicy,
+			TerminationGracePeriodSeconds: app.getTerminationGracePeriodSeconds(),
 		},
 	}
-	u.createPodPullSecrets(app, pod, err)
+	app.createPodPlacement(&pod.Spec)
+	initPodServiceAccount(pod)
 
 	app.newLogEntry().Tracef("creating %s", pod)
 
@@ -1016,19 +1454,21 @@ func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	podServer, err := u.k8sPodClient.Create(context.Background(), pod, metav1.CreateOptions{})
-	if k8sError.IsAlreadyExists(err) {
-		app.newLogEntry().Debugf("pod %s already exists", pod.ObjectMeta.Name)
-	} else if err != nil {
+	// The hash includes the volume init image, which is resolved by createPodVolumes.
+	hash, err := configHash(app)
+	if err != nil {
 		return nil, err
 	}
-	app.newLogEntry().Debugf("created pod %s", pod.ObjectMeta.Name)
-	u.appsThatNeedToBeReady[app] = true
-	return podServer, nil
+	pod.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] = hash
+	app.createPodSecretVolumes(pod)
+	app.createPodConfigVolumes(pod)
+	app.createPodTmpfsVolumes(pod)
+	u.applyPullPolicy(pod)
+	return pod, nil
 }
 
-func (u *upRunner) createPodPullSecrets(app *app, pod *v1.Pod, err error) {
+// initPodServiceAccount sets the service account and image pull secret of the pod from the environment, if set.
+func initPodServiceAccount(pod *v1.Pod) {
 	serviceAccountName := os.Getenv("POD_SPEC_SERVICE_ACCOUNT")
 	if serviceAccountName != "" {
 		pod.Spec.ServiceAccountName = serviceAccountName
@@ -1038,27 +1478,10 @@ func (u *upRunner) createPodPullSecrets(app *app, pod *v1.Pod, err error) {
 	if imagePullSecret != "" {
 		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: imagePullSecret})
 	}
-
-	registryHost := strings.Split(app.imageInfo.podImage, "/")[0]
-	pullSecret, err := u.createSecretForRegistry(registryHost, app)
-	if err == nil {
-		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: pullSecret})
-	} else {
-		log.Warnf("Failed to create secret %s (but continuing...)\n", pullSecret)
-	}
-}
-
-func isPodReady(pod *v1.Pod) bool {
-	for _, condition := range pod.Status.Conditions {
-		if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
-			return true
-		}
-	}
-	return false
 }
 
 func parsePodStatus(pod *v1.Pod) (podStatus, error) {
-	if isPodReady(pod) {
+	if k8smeta.IsPodReady(pod) {
 		return podStatusReady, nil
 	}
 	runningCount := 0
@@ -1104,79 +1527,129 @@ func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 	if app == nil {
 		return nil
 	}
-	// For each container of the pod:
-	// 		if the container is running
-	//			// use app.containersForWhichWeAreStreamingLogs to determine the following condition
-	// 			if we are not already streaming logs for the container
-	//				start streaming logs for the container
 	if !u.opts.Detach && u.cfg.MatchesFilterDirectly(app.composeService) {
-		for _, containerStatus := range pod.Status.ContainerStatuses {
-			_, ok := app.containersForWhichWeAreStreamingLogs[containerStatus.Name]
-			if !ok && containerStatus.State.Running != nil {
-				app.containersForWhichWeAreStreamingLogs[containerStatus.Name] = true
-				getPodLogOptions := &v1.PodLogOptions{
-					Follow:    true,
-					TailLines: &[]int64{u.opts.TailLines}[0], // thanks Go :) @ https://stackoverflow.com/a/30716481/6209965
-					Container: containerStatus.Name,
-				}
-				completedChannel := make(chan interface{})
-				u.completedChannels = append(u.completedChannels, completedChannel)
-				go u.streamPodLogs(pod, completedChannel, getPodLogOptions, app)
-			}
-		}
+		u.streamPodLogsIfNeeded(app, pod)
 	}
 	s, err := parsePodStatus(pod)
+	if err != nil && app.isJob() && pod.Spec.RestartPolicy != v1.RestartPolicyNever {
+		// The container is restarted until the back-off limit of the Job is reached, at which point the Job fails and its pods are
+		// deleted (see runWatchPodsEvent).
+		app.newLogEntry().Warnf("%v (the job will retry)", err)
+		return nil
+	}
+	if err == nil && s == podStatusCompleted && app.isJob() && !app.jobSucceeded {
+		// Dependents with condition service_completed_successfully wait until the Job has succeeded.
+		u.startWaitForJobCompletion(app)
+	}
 	if err != nil {
-		if app.reporterRow != nil {
-			app.reporterRow.AddStatus(&reporter.Status{
-				Text:      "\x1b[31merror\x1b[0m 💣💣", // bomb+bomb
-				TextWidth: 10,
-				Priority:  4,
-			})
-		}
+		app.setPhase(reporter.PhaseFailed)
 		return err
 	}
 
 	if s > app.maxObservedPodStatus {
 		u.setAppMaxObservedPodStatus(app, s)
+	} else if app.maxObservedPodStatus == podStatusReady {
+		// A container that restarts after the app was ready (e.g. because its liveness probe failed) is reported as starting until it is
+		// ready again.
+		switch s {
+		case podStatusStarted:
+			app.setPhase(reporter.PhaseStarting)
+		case podStatusReady:
+			app.setPhase(reporter.PhaseReady)
+		}
 	}
 	return nil
 }
 
 func (u *upRunner) setAppMaxObservedPodStatus(app *app, s podStatus) {
 	app.maxObservedPodStatus = s
-	if app.reporterRow != nil {
-		switch {
-		case s == podStatusStarted:
-			app.reporterRow.AddStatus(reporter.StatusRunning)
-		case s == podStatusReady:
-			app.reporterRow.RemoveStatus(reporter.StatusRunning)
-			app.reporterRow.AddStatus(reporter.StatusReady)
-		case s >= podStatusCompleted:
-			app.reporterRow.RemoveStatus(reporter.StatusRunning)
-			app.reporterRow.RemoveStatus(reporter.StatusReady)
-			app.reporterRow.AddStatus(reporter.StatusCompleted)
-		}
+	switch {
+	case s == podStatusStarted:
+		app.setPhase(reporter.PhaseStarting)
+	case s == podStatusReady:
+		app.setPhase(reporter.PhaseReady)
+	case s >= podStatusCompleted:
+		app.setPhase(reporter.PhaseCompleted)
 	}
 	app.newLogEntry().Debugf("pod status %s", &app.maxObservedPodStatus)
 }
 
-func (u *upRunner) streamPodLogs(pod *v1.Pod, completedChannel chan interface{}, getPodLogOptions *v1.PodLogOptions, a *app) {
-	getLogsRequest := u.k8sPodClient.GetLogs(pod.ObjectMeta.Name, getPodLogOptions)
-	var bodyReader io.ReadCloser
+// streamPodLogsIfNeeded starts streaming the logs of each running container of the pod, unless they are already being streamed.
+// app.containersForWhichWeAreStreamingLogs maps each pod name and container name to the restart count of the container whose logs are
+// streamed, so that the logs of a restarted container and of a new pod of the same service (e.g. a pod of a Job that is retried) are
+// streamed as well.
+func (u *upRunner) streamPodLogsIfNeeded(app *app, pod *v1.Pod) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Running == nil {
+			continue
+		}
+		key := pod.ObjectMeta.Name + "/" + containerStatus.Name
+		restartCount, ok := app.containersForWhichWeAreStreamingLogs[key]
+		if ok && restartCount >= containerStatus.RestartCount {
+			continue
+		}
+		app.containersForWhichWeAreStreamingLogs[key] = containerStatus.RestartCount
+		getPodLogOptions := &v1.PodLogOptions{
+			Follow:    true,
+			TailLines: &[]int64{u.opts.TailLines}[0], // thanks Go :) @ https://stackoverflow.com/a/30716481/6209965
+			Container: containerStatus.Name,
+		}
+		completedChannel := make(chan interface{})
+		u.completedChannels = append(u.completedChannels, completedChannel)
+		go u.streamPodLogs(pod.ObjectMeta.Name, containerStatus.RestartCount, completedChannel, getPodLogOptions, app)
+	}
+}
+
+// streamPodLogs streams the logs of a container until the container terminates. If connecting fails or the connection drops, then
+// streamPodLogs reconnects (with the same options, so that TailLines applies to each connection) for as long as the pod exists and the
+// container has not been restarted. The logs of a restarted container are streamed by another call (see streamPodLogsIfNeeded).
+func (u *upRunner) streamPodLogs(podName string, restartCount int32, completedChannel chan interface{},
+	getPodLogOptions *v1.PodLogOptions, a *app) {
+	defer close(completedChannel)
+	for retries := 0; ; retries++ {
+		if u.opts.WaitBeforeLogs > 0 {
+			time.Sleep(u.opts.WaitBeforeLogs)
+		}
+		err := u.streamPodLogsOnce(podName, getPodLogOptions, a)
+		if err == nil {
+			return
+		}
+		if retries >= streamPodLogsMaxRetries || !u.shouldReconnectPodLogs(podName, getPodLogOptions.Container, restartCount) {
+			a.newLogEntry().Warnf("stopped streaming the logs of pod %s: %v", podName, err)
+			return
+		}
+		a.newLogEntry().Debugf("reconnecting to the logs of pod %s: %v", podName, err)
+		time.Sleep(streamPodLogsRetryInterval)
+	}
+}
+
+func (u *upRunner) streamPodLogsOnce(podName string, getPodLogOptions *v1.PodLogOptions, a *app) error {
+	getLogsRequest := u.k8sPodClient.GetLogs(podName, getPodLogOptions)
 	bodyReader, err := getLogsRequest.Stream(context.Background())
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer util.CloseAndLogError(bodyReader)
 	scanner := bufio.NewScanner(bodyReader)
 	for scanner.Scan() {
-		log.Infof("\x1b[%sm%-*s|\x1b[0m %s", a.color, u.maxServiceNameLength+3, a.name(), scanner.Text())
+		log.Infof("%s %s", util.AnsiColorWrap(fmt.Sprintf("%-*s|", u.maxServiceNameLength+3, a.name()), a.color, "0"), scanner.Text())
 	}
-	if err = scanner.Err(); err != nil {
-		log.Error(err)
+	return scanner.Err()
+}
+
+// shouldReconnectPodLogs returns true if the pod still exists and the container has not been restarted since restartCount.
+func (u *upRunner) shouldReconnectPodLogs(podName, containerName string, restartCount int32) bool {
+	pod, err := u.k8sPodClient.Get(context.Background(), podName, metav1.GetOptions{})
+	if err != nil {
+		// Transient errors are retried, but a deleted pod has no more logs.
+		return !k8sError.IsNotFound(err)
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name == containerName {
+			return containerStatus.RestartCount <= restartCount
+		}
 	}
-	close(completedChannel)
+	return true
 }
 
 func (u *upRunner) createPodsIfNeeded() error {
@@ -1195,15 +1668,21 @@ func (u *upRunner) createPodsIfNeeded() error {
 					createPod = false
 				}
 			case dockerComposeConfig.ServiceCompletedSuccessfully:
-				// Note the assumption here is made that podStatusCompleted implies successfully. PRs welcome.
-				if app2.maxObservedPodStatus != podStatusCompleted {
+				if app2.isJob() {
+					if !app2.jobSucceeded {
+						createPod = false
+					}
+				} else if app2.maxObservedPodStatus != podStatusCompleted {
+					// Note the assumption here is made that podStatusCompleted implies successfully. PRs welcome.
 					createPod = false
 				}
 			}
 		}
-		if createPod {
+		if !createPod {
+			app1.setPhase(reporter.PhaseWaitingForDeps)
+		} else {
 			app1.newLogEntry().Debugf(u.formatCreatePodReason(app1))
-			_, err := u.createPod(app1)
+			err := u.createPod(app1)
 			if err != nil {
 				return err
 			}
@@ -1242,7 +1721,7 @@ func (u *upRunner) runStartInitialPods() error {
 			continue
 		}
 		app.newLogEntry().Debug("all depends_on conditions satisfied")
-		_, err := u.createPod(app)
+		err := u.createPod(app)
 		if err != nil {
 			return err
 		}
@@ -1273,16 +1752,47 @@ func (u *upRunner) runListPodsAndCreateThemIfNeeded() (string, error) {
 }
 
 func (u *upRunner) run() error {
+	if u.isDryRunClient() {
+		return u.runDryRunClient()
+	}
 	u.initApps()
 	u.initAppsToBeStarted()
 	u.initVolumeInfo()
+	err := u.resolveExternalLinks()
+	if err != nil {
+		return err
+	}
+	err = u.initSecrets()
+	if err != nil {
+		return err
+	}
+	err = u.initConfigs()
+	if err != nil {
+		return err
+	}
 	if u.opts.SkipPush {
 		log.Warn("option --skip-push is in effect: not pushing images to remote registries (assuming that was done on a previous run)")
 	}
-	err := u.initKubernetesClientset()
+	err = u.initKubernetesClientset()
 	if err != nil {
 		return err
 	}
+	// A dry run returns before the docker client is initialized, so that it has no side effects on images.
+	if u.isDryRunServer() {
+		return u.runDryRunServer()
+	}
+	if u.opts.CreateNamespace {
+		err = u.createNamespace()
+		if err != nil {
+			return err
+		}
+	}
+	if u.opts.UseOwnerRefs {
+		err = u.createOwner()
+		if err != nil {
+			return err
+		}
+	}
 	// Initialize docker client
 	var dc *dockerClient.Client
 	dc, err = dockerClient.NewEnvClient()
@@ -1291,30 +1801,57 @@ func (u *upRunner) run() error {
 	}
 	u.dockerClient = dc
 
-	for app := range u.appsToBeStarted {
-		// Begin pulling and pushing images immediately...
-		// The error returned by getAppImageInfoOnce will be handled later, hence the nolint.
-		//nolint
-		go u.getAppImageInfoOnce(app)
-
-		// Start building the volume init image, if needed.
-		if len(app.volumes) > 0 {
-			// The error returned by getAppVolumeInitImageOnce will be handled later, hence the nolint.
-			//nolint
-			go u.getAppVolumeInitImageOnce(app)
+	if u.opts.DefaultDenyIngress {
+		err = u.createNetworkPolicies()
+		if err != nil {
+			return err
 		}
 	}
-	// Begin creating services and collecting their cluster IPs (we'll need this to
-	// set the hostAliases of each pod).
-	// The error returned by getAppImageInfoOnce will be handled later, hence the nolint.
-	//nolint
-	go u.createServicesAndGetPodHostAliasesOnce()
 
-	err = u.runStartInitialPods()
+	err = u.createSecrets()
+	if err != nil {
+		return err
+	}
+
+	err = u.createConfigs()
 	if err != nil {
 		return err
 	}
 
+	// Images are prepared before pods are recreated, because the hash of the configuration of a pod includes its images.
+	u.startImagePrep()
+	if u.isTransferSaveLoad() {
+		go u.deleteImageLoadersWhenDone()
+		defer u.deleteImageLoaders()
+	}
+	defer u.imagePrep.cancel()
+
+	err = u.deletePodsToBeRecreated()
+	if err != nil {
+		return err
+	}
+
+	if u.opts.Explain {
+		go u.runExplainEvents()
+	}
+	if u.opts.ApplyOrder == ApplyOrderKind || u.opts.ApplyOrder == ApplyOrderManifest {
+		err = u.runApplyOrdered()
+		if err != nil {
+			return err
+		}
+	} else {
+		// Begin creating services and collecting their cluster IPs (we'll need this to
+		// set the hostAliases of each pod).
+		// The error returned by getAppImageInfoOnce will be handled later, hence the nolint.
+		//nolint
+		go u.createServicesAndGetPodHostAliasesOnce()
+
+		err = u.runStartInitialPods()
+		if err != nil {
+			return err
+		}
+	}
+
 	var resourceVersion string
 	resourceVersion, err = u.runListPodsAndCreateThemIfNeeded()
 	if err != nil {
@@ -1335,7 +1872,7 @@ func (u *upRunner) runWatchPodsEvent(event *k8swatch.Event) error {
 	//log.Tracef("Incoming event %s from channel: %+v", event.Type, event.Object)
 	pod := event.Object.(*v1.Pod)
 	app := u.findAppFromObjectMeta(&pod.ObjectMeta)
-	if u.opts.EventDiffs {
+	if u.opts.EventDiffs && app != nil {
 		app.diffEvent(event, u)
 	}
 	switch event.Type {
@@ -1346,6 +1883,16 @@ func (u *upRunner) runWatchPodsEvent(event *k8swatch.Event) error {
 		}
 	case k8swatch.Deleted:
 		//pod := event.Object.(*v1.Pod)
+		if app != nil && app.isJob() {
+			if err := u.getJobFailure(app); err != nil {
+				app.setPhase(reporter.PhaseFailed)
+				return err
+			}
+		}
+		if app != nil && u.isDeployment(app) {
+			// The Deployment controller replaces deleted pods, e.g. during a rollout.
+			return nil
+		}
 		if app != nil {
 			return k8smeta.ErrorWrapResourcesModifiedExternally("runWatchPodsEvent()")
 		}
@@ -1376,12 +1923,25 @@ func (u *upRunner) runWatchPods(resourceVersion string) error {
 	}
 	defer watch.Stop()
 	eventChannel := watch.ResultChan()
+	// A nil channel blocks forever, so that there is no timeout if WaitTimeout is not set.
+	var timeoutChannel <-chan time.Time
+	if u.opts.WaitTimeout > 0 {
+		timer := time.NewTimer(u.opts.WaitTimeout)
+		defer timer.Stop()
+		timeoutChannel = timer.C
+	}
 	for {
-		event, ok := <-eventChannel
-		if !ok {
-			return fmt.Errorf("channel unexpectedly closed")
+		select {
+		case event, ok := <-eventChannel:
+			if !ok {
+				return fmt.Errorf("channel unexpectedly closed")
+			}
+			err = u.runWatchPodsEvent(&event)
+		case completion := <-u.jobCompletions:
+			err = u.handleJobCompletion(completion)
+		case <-timeoutChannel:
+			return u.waitTimeoutError()
 		}
-		err = u.runWatchPodsEvent(&event)
 		if err != nil {
 			return err
 		}
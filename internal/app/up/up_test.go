@@ -1,10 +1,23 @@
 package up
 
 import (
+	"context"
+	"io"
 	"testing"
+	"time"
 
+	"github.com/docker/distribution/digestset"
+	dockerRef "github.com/docker/distribution/reference"
+	dockerTypes "github.com/docker/docker/api/types"
 	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 )
 
@@ -46,7 +59,10 @@ func newTestApp(serviceName string) *app {
 }
 func TestRestartPolicyforService_Never(t *testing.T) {
 	app := newTestApp("a")
-	restartPolicy := getRestartPolicyforService(app)
+	restartPolicy, err := getRestartPolicyforService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if restartPolicy != TestRestartPolicyNever {
 		t.Fail()
 	}
@@ -54,21 +70,51 @@ func TestRestartPolicyforService_Never(t *testing.T) {
 
 func TestRestartPolicyforService_Always(t *testing.T) {
 	app := newTestApp("b")
-	restartPolicy := getRestartPolicyforService(app)
+	restartPolicy, err := getRestartPolicyforService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if restartPolicy != TestRestartPolicyAlways {
 		t.Fail()
 	}
 }
 func TestRestartPolicyforService_Onfailure(t *testing.T) {
 	app := newTestApp("c")
-	restartPolicy := getRestartPolicyforService(app)
+	restartPolicy, err := getRestartPolicyforService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if restartPolicy != TestRestartPolicyOnFailure {
 		t.Fail()
 	}
 }
+
+func TestRestartPolicyforService_UnlessStopped(t *testing.T) {
+	app := newTestApp("d")
+	app.composeService.DockerComposeService.Restart = "unless-stopped"
+	restartPolicy, err := getRestartPolicyforService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restartPolicy != TestRestartPolicyAlways {
+		t.Fail()
+	}
+}
+
+func TestRestartPolicyforService_UnknownError(t *testing.T) {
+	app := newTestApp("d")
+	app.composeService.DockerComposeService.Restart = "sometimes"
+	_, err := getRestartPolicyforService(app)
+	if err == nil {
+		t.Fail()
+	}
+}
 func TestRestartPolicyforService_Default(t *testing.T) {
 	app := newTestApp("d")
-	restartPolicy := getRestartPolicyforService(app)
+	restartPolicy, err := getRestartPolicyforService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if restartPolicy != TestRestartPolicyNever {
 		t.Fail()
 	}
@@ -101,6 +147,60 @@ func TestAppHasService_True(t *testing.T) {
 	}
 }
 
+func TestAppHasService_NoService(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.Ports = []config.Port{
+		{
+			Port:     1234,
+			Protocol: "tcp",
+		},
+	}
+	app.composeService.NoService = true
+	if app.hasService() {
+		t.Fail()
+	}
+}
+
+func TestAppHasService_ExposeOnly(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.Expose = []dockerComposeConfig.PortBinding{
+		{
+			Internal:    1234,
+			ExternalMin: -1,
+			ExternalMax: -1,
+			Protocol:    "tcp",
+		},
+	}
+	if app.hasService() {
+		t.Fail()
+	}
+}
+
+func TestNewContainerPorts_Expose(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.Ports = []config.Port{
+		{
+			Port:     80,
+			Protocol: "tcp",
+		},
+	}
+	app.composeService.DockerComposeService.Expose = []dockerComposeConfig.PortBinding{
+		{
+			Internal: 80,
+			Protocol: "tcp",
+		},
+		{
+			Internal: 53,
+			Protocol: "udp",
+		},
+	}
+	containerPorts := newContainerPorts(app)
+	if len(containerPorts) != 2 || containerPorts[0].ContainerPort != 80 || containerPorts[1].ContainerPort != 53 ||
+		containerPorts[1].Protocol != v1.ProtocolUDP {
+		t.Error(containerPorts)
+	}
+}
+
 func TestUpRunnerInitKubernetesClientset(t *testing.T) {
 	kubeConfig := &rest.Config{
 		Host: "http://localhost:8443/",
@@ -152,3 +252,814 @@ func TestFormatCreatePodReasonCompleted(t *testing.T) {
 		t.Error(s)
 	}
 }
+
+func TestAppCreatePodDNSConfig_None(t *testing.T) {
+	app := newTestApp("a")
+	if dnsConfig := app.createPodDNSConfig(); dnsConfig != nil {
+		t.Error(dnsConfig)
+	}
+}
+
+func TestAppCreatePodDNSConfig_Options(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.DNSOptions = []dockerComposeConfig.DNSOption{
+		{
+			Name:  "ndots",
+			Value: util.NewString("2"),
+		},
+		{
+			Name: "rotate",
+		},
+	}
+	dnsConfig := app.createPodDNSConfig()
+	if dnsConfig == nil || len(dnsConfig.Options) != 2 {
+		t.Fatal(dnsConfig)
+	}
+	if dnsConfig.Options[0].Name != "ndots" || *dnsConfig.Options[0].Value != "2" {
+		t.Error(dnsConfig.Options[0])
+	}
+	if dnsConfig.Options[1].Name != "rotate" || dnsConfig.Options[1].Value != nil {
+		t.Error(dnsConfig.Options[1])
+	}
+}
+
+func TestAppCreatePodDNSConfig_ServersAndSearch(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.DNSServers = []string{"8.8.8.8"}
+	app.composeService.DockerComposeService.DNSSearch = []string{"example.com"}
+	dnsConfig := app.createPodDNSConfig()
+	if dnsConfig == nil || len(dnsConfig.Nameservers) != 1 || dnsConfig.Nameservers[0] != "8.8.8.8" || len(dnsConfig.Searches) != 1 ||
+		dnsConfig.Searches[0] != "example.com" || len(dnsConfig.Options) != 0 {
+		t.Error(dnsConfig)
+	}
+}
+
+func TestGetExternalHostAliases(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			External: map[string]ExternalService{
+				"b": {Host: "10.0.0.2"},
+				"a": {Host: "10.0.0.1", Port: 5432},
+			},
+		},
+	}
+	hostAliases := u.getExternalHostAliases()
+	if len(hostAliases) != 2 {
+		t.Fatal(hostAliases)
+	}
+	if hostAliases[0].IP != "10.0.0.1" || hostAliases[0].Hostnames[0] != "a" || hostAliases[1].IP != "10.0.0.2" {
+		t.Error(hostAliases)
+	}
+}
+
+func TestInspectImageRawParseHealthcheck_ZeroValuesAreDefaults(t *testing.T) {
+	healthcheck, err := inspectImageRawParseHealthcheck([]byte(`{"Config":{"Healthcheck":{"Test":["CMD","true"],"Interval":0,` +
+		`"StartPeriod":5000000000}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if healthcheck.Interval != dockerComposeConfig.HealthcheckDefaultInterval ||
+		healthcheck.Timeout != dockerComposeConfig.HealthcheckDefaultTimeout ||
+		healthcheck.Retries != dockerComposeConfig.HealthcheckDefaultRetries ||
+		healthcheck.StartPeriod != 5*time.Second {
+		t.Errorf("%+v\n", *healthcheck)
+	}
+}
+
+func TestCreateStartupProbeFromDockerHealthcheck_NoStartPeriod(t *testing.T) {
+	healthcheck := &dockerComposeConfig.Healthcheck{
+		Test:     []string{"true"},
+		Interval: 10 * time.Second,
+	}
+	if probe := createStartupProbeFromDockerHealthcheck(healthcheck); probe != nil {
+		t.Error(probe)
+	}
+}
+
+func TestCreateStartupProbeFromDockerHealthcheck_Success(t *testing.T) {
+	healthcheck := &dockerComposeConfig.Healthcheck{
+		Test:        []string{"true"},
+		Interval:    10 * time.Second,
+		Retries:     3,
+		StartPeriod: 45 * time.Second,
+	}
+	probe := createStartupProbeFromDockerHealthcheck(healthcheck)
+	if probe == nil || probe.FailureThreshold != 5 || probe.PeriodSeconds != 10 || probe.Exec.Command[0] != "true" {
+		t.Error(probe)
+	}
+	readinessProbe := createReadinessProbeFromDockerHealthcheck(healthcheck)
+	if readinessProbe.FailureThreshold != 3 || readinessProbe.InitialDelaySeconds != 0 {
+		t.Error(readinessProbe)
+	}
+}
+
+func TestCreateStartupProbeFromDockerHealthcheck_StartPeriodSmallerThanInterval(t *testing.T) {
+	healthcheck := &dockerComposeConfig.Healthcheck{
+		Test:        []string{"true"},
+		Interval:    30 * time.Second,
+		StartPeriod: time.Second,
+	}
+	probe := createStartupProbeFromDockerHealthcheck(healthcheck)
+	if probe == nil || probe.FailureThreshold != 1 {
+		t.Error(probe)
+	}
+}
+
+func TestGetArgsAndCommand_ShellChainSurvives(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Command = []string{"sh", "-c", "migrate && serve"}
+	c := &v1.Container{}
+	err := a.GetArgsAndCommand(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Args) != 3 || c.Args[2] != "migrate && serve" || c.Command != nil {
+		t.Error(c.Command, c.Args)
+	}
+}
+
+func TestGetArgsAndCommand_EntrypointAndCommand(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Entrypoint = []string{"/docker-entrypoint.sh"}
+	a.composeService.DockerComposeService.Command = []string{"serve"}
+	c := &v1.Container{}
+	err := a.GetArgsAndCommand(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Command) != 1 || c.Command[0] != "/docker-entrypoint.sh" || len(c.Args) != 1 || c.Args[0] != "serve" {
+		t.Error(c.Command, c.Args)
+	}
+}
+
+func TestGetArgsAndCommand_EmptyEntrypoint(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Entrypoint = []string{}
+	a.composeService.DockerComposeService.Command = []string{"serve"}
+	c := &v1.Container{}
+	err := a.GetArgsAndCommand(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Command) != 1 || c.Command[0] != "serve" || c.Args != nil {
+		t.Error(c.Command, c.Args)
+	}
+}
+
+func TestGetArgsAndCommand_EmptyEntrypointImageCmd(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Entrypoint = []string{}
+	a.imageInfo.cmd = []string{"nginx", "-g", "daemon off;"}
+	c := &v1.Container{}
+	err := a.GetArgsAndCommand(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Command) != 3 || c.Command[0] != "nginx" || c.Args != nil {
+		t.Error(c.Command, c.Args)
+	}
+}
+
+func TestGetArgsAndCommand_EmptyEntrypointNoCommandError(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Entrypoint = []string{}
+	err := a.GetArgsAndCommand(&v1.Container{})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestNewService_LongPortSyntax(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 53, ExternalMin: 5353, ExternalMax: 5353, Protocol: "udp", Mode: "ingress"},
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+	}
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	service := u.newService(a)
+	ports := service.Spec.Ports
+	if len(ports) != 2 {
+		t.Fatal(ports)
+	}
+	if ports[0].Port != 5353 || ports[0].TargetPort.IntValue() != 53 || ports[0].Protocol != v1.ProtocolUDP {
+		t.Error(ports[0])
+	}
+	// The short syntax keeps the internal port, so that other services can reach the service on the same port as in docker compose.
+	if ports[1].Port != 80 || ports[1].TargetPort.IntValue() != 80 || ports[1].Protocol != v1.ProtocolTCP {
+		t.Error(ports[1])
+	}
+}
+
+func TestNewService_NodePort(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+	}
+	a.composeService.ServiceType = v1.ServiceTypeNodePort
+	a.composeService.NodePort = 30080
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			HeadlessServices: true,
+		},
+	}
+	service := u.newService(a)
+	if service.Spec.Type != v1.ServiceTypeNodePort || service.Spec.ClusterIP != "" {
+		t.Error(service.Spec)
+	}
+	if service.Spec.Ports[0].NodePort != 30080 {
+		t.Error(service.Spec.Ports)
+	}
+}
+
+func TestWaitForServiceClusterIPUpdate_NodePort(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.initApps()
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+	}
+	a.composeService.ServiceType = v1.ServiceTypeNodePort
+	service := u.newService(a)
+	service.Spec.ClusterIP = "10.0.0.1"
+	app, err := u.waitForServiceClusterIPUpdate(service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app != a || a.serviceClusterIP != "10.0.0.1" {
+		t.Error(app, a.serviceClusterIP)
+	}
+	// A Service of another type than declared was modified externally.
+	service.Spec.Type = v1.ServiceTypeLoadBalancer
+	_, err = u.waitForServiceClusterIPUpdate(service)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestNewService_DefaultType(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+	}
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	service := u.newService(a)
+	if service.Spec.Type != v1.ServiceTypeClusterIP || service.Spec.Ports[0].NodePort != 0 {
+		t.Error(service.Spec)
+	}
+}
+
+func TestGetPushImagePath_RegistryPrefix(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.cfg.Namespace = "ns"
+	if path := u.getPushImagePath(); path != "ns" {
+		t.Error(path)
+	}
+	u.opts.RegistryPrefix = "team-a"
+	if path := u.getPushImagePath(); path != "team-a/ns" {
+		t.Error(path)
+	}
+}
+
+func TestGetProbes_XKubeComposeOverridesHealthcheck(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		Interval:    time.Second,
+		StartPeriod: time.Minute,
+		Test:        []string{"true"},
+	}
+	readiness := &v1.Probe{PeriodSeconds: 7}
+	liveness := &v1.Probe{PeriodSeconds: 11}
+	app.composeService.Probes = &config.Probes{
+		Liveness:  liveness,
+		Readiness: readiness,
+	}
+	if probe := app.GetReadinessProbe(); probe == nil || probe.PeriodSeconds != 7 || probe == readiness {
+		t.Error(probe)
+	}
+	if probe := app.GetLivenessProbe(); probe == nil || probe.PeriodSeconds != 11 {
+		t.Error(probe)
+	}
+	// The startup probe is still derived from the healthcheck, because it is not overridden.
+	if probe := app.GetStartupProbe(); probe == nil || probe.Exec == nil {
+		t.Error(probe)
+	}
+}
+
+func newTestRunningPod(name string, restartCount int32) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:         "a",
+					RestartCount: restartCount,
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestStreamPodLogsIfNeeded_RestartsAndNewPods(t *testing.T) {
+	u := &upRunner{
+		cfg:          newTestConfig(),
+		k8sPodClient: fake.NewSimpleClientset().CoreV1().Pods(""),
+		opts:         &Options{},
+	}
+	a := newTestApp("a")
+	a.containersForWhichWeAreStreamingLogs = map[string]int32{}
+	u.streamPodLogsIfNeeded(a, newTestRunningPod("a-1", 0))
+	u.streamPodLogsIfNeeded(a, newTestRunningPod("a-1", 0))
+	if len(u.completedChannels) != 1 {
+		t.Error(len(u.completedChannels))
+	}
+	u.streamPodLogsIfNeeded(a, newTestRunningPod("a-1", 1))
+	if len(u.completedChannels) != 2 {
+		t.Error(len(u.completedChannels))
+	}
+	u.streamPodLogsIfNeeded(a, newTestRunningPod("a-2", 0))
+	if len(u.completedChannels) != 3 {
+		t.Error(len(u.completedChannels))
+	}
+	for _, completedChannel := range u.completedChannels {
+		<-completedChannel
+	}
+}
+
+func TestShouldReconnectPodLogs(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newTestRunningPod("a-1", 1))
+	u := &upRunner{
+		k8sPodClient: clientset.CoreV1().Pods(""),
+	}
+	if !u.shouldReconnectPodLogs("a-1", "a", 1) {
+		t.Error("container that has not been restarted")
+	}
+	if u.shouldReconnectPodLogs("a-1", "a", 0) {
+		t.Error("container that has been restarted")
+	}
+	if u.shouldReconnectPodLogs("a-2", "a", 0) {
+		t.Error("pod that does not exist")
+	}
+}
+
+func TestCreateSecurityContext_Capabilities(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.CapAdd = []string{"NET_ADMIN"}
+	a.composeService.DockerComposeService.CapDrop = []string{"ALL"}
+	a.imageInfo.user = &docker.Userinfo{
+		UID: &[]int64{1000}[0],
+	}
+	u := &upRunner{
+		opts: &Options{
+			RunAsUser: true,
+		},
+	}
+	securityContext := u.createSecurityContext(a)
+	if securityContext == nil || securityContext.Capabilities == nil {
+		t.Fatal(securityContext)
+	}
+	if len(securityContext.Capabilities.Add) != 1 || securityContext.Capabilities.Add[0] != "NET_ADMIN" {
+		t.Error(securityContext.Capabilities.Add)
+	}
+	if len(securityContext.Capabilities.Drop) != 1 || securityContext.Capabilities.Drop[0] != "ALL" {
+		t.Error(securityContext.Capabilities.Drop)
+	}
+	if securityContext.RunAsUser == nil || *securityContext.RunAsUser != 1000 {
+		t.Error(securityContext.RunAsUser)
+	}
+}
+
+func TestCreateSecurityContext_None(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{},
+	}
+	if securityContext := u.createSecurityContext(newTestApp("a")); securityContext != nil {
+		t.Error(securityContext)
+	}
+}
+
+func TestCreateSecurityContext_PrivilegedWithCapabilities(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Privileged = true
+	a.composeService.DockerComposeService.CapDrop = []string{"NET_RAW"}
+	a.imageInfo.user = &docker.Userinfo{
+		UID: &[]int64{1000}[0],
+		GID: &[]int64{1001}[0],
+	}
+	u := &upRunner{
+		opts: &Options{
+			RunAsUser: true,
+		},
+	}
+	securityContext := u.createSecurityContext(a)
+	if securityContext == nil || securityContext.Privileged == nil || !*securityContext.Privileged {
+		t.Fatal(securityContext)
+	}
+	if securityContext.Capabilities == nil || len(securityContext.Capabilities.Drop) != 1 {
+		t.Error(securityContext.Capabilities)
+	}
+	if securityContext.RunAsUser == nil || *securityContext.RunAsUser != 1000 || securityContext.RunAsGroup == nil ||
+		*securityContext.RunAsGroup != 1001 {
+		t.Error(securityContext.RunAsUser, securityContext.RunAsGroup)
+	}
+}
+
+func TestNewService_Headless(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			HeadlessServices: true,
+		},
+	}
+	u.initApps()
+	service := u.newService(u.apps["a"])
+	if service.Spec.ClusterIP != v1.ClusterIPNone || service.Spec.Selector["app"] != "a" {
+		t.Error(service)
+	}
+}
+
+func TestCreateServicesAndGetPodHostAliases_Headless(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderDeps)
+	u.opts.HeadlessServices = true
+	hostAliases, err := u.createServicesAndGetPodHostAliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostAliases) != 0 {
+		t.Error(hostAliases)
+	}
+	serviceList, err := clientset.CoreV1().Services("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The apps c and d have no ports, but get a headless Service without ports so that their names resolve.
+	if len(serviceList.Items) != 4 {
+		t.Fatal(serviceList.Items)
+	}
+	for _, service := range serviceList.Items {
+		if service.Spec.ClusterIP != v1.ClusterIPNone {
+			t.Error(service)
+		}
+	}
+}
+
+func TestNewHeadlessNameServices_Aliases(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentIDNoAppend = true
+	cfg.Services["a"].Ports = []config.Port{
+		{Port: 80, Protocol: "tcp"},
+	}
+	cfg.Services["a"].DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, Protocol: "tcp"},
+	}
+	cfg.Services["a"].ServiceType = v1.ServiceTypeNodePort
+	cfg.Services["a"].NodePort = 30080
+	cfg.Services["a"].DockerComposeService.Networks = []dockerComposeConfig.ServiceNetwork{
+		{Name: "default", Aliases: []string{"db"}},
+	}
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			HeadlessServices: true,
+		},
+	}
+	u.initApps()
+	services := u.newHeadlessNameServices(u.apps["a"])
+	if len(services) != 1 {
+		t.Fatal(services)
+	}
+	service := services[0]
+	if service.ObjectMeta.Name != "db" || service.Spec.ClusterIP != v1.ClusterIPNone || service.Spec.Type != v1.ServiceTypeClusterIP ||
+		service.Spec.Selector["app"] != "a" || len(service.Spec.Ports) != 1 || service.Spec.Ports[0].NodePort != 0 {
+		t.Error(service)
+	}
+}
+
+func TestNewHeadlessNameServices_NoPorts(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentIDNoAppend = true
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			HeadlessServices: true,
+		},
+	}
+	u.initApps()
+	services := u.newHeadlessNameServices(u.apps["c"])
+	if len(services) != 1 || services[0].ObjectMeta.Name != "c" || len(services[0].Spec.Ports) != 0 ||
+		services[0].Spec.ClusterIP != v1.ClusterIPNone {
+		t.Error(services)
+	}
+	u.opts.HeadlessServices = false
+	if services := u.newHeadlessNameServices(u.apps["c"]); len(services) != 0 {
+		t.Error(services)
+	}
+}
+
+func TestAppHostnames(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Networks = []dockerComposeConfig.ServiceNetwork{
+		{Name: "backend", Aliases: []string{"a", "db"}},
+		{Name: "default", Aliases: []string{"db", "database"}},
+	}
+	assertStrings(t, a.hostnames(), []string{"a", "db", "database"})
+}
+
+func TestCreateWorkload_WorkingDir(t *testing.T) {
+	u, clientset := newTestApplyOrderRunner(ApplyOrderKind)
+	u.cfg.Services["d"].DockerComposeService.WorkingDir = "/srv"
+	err := u.runApplyOrdered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := clientset.CoreV1().Pods("").Get(context.Background(), "d-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Spec.Containers[0].WorkingDir != "/srv" {
+		t.Error(pod.Spec.Containers[0].WorkingDir)
+	}
+	pod, err = clientset.CoreV1().Pods("").Get(context.Background(), "b-123", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Spec.Containers[0].WorkingDir != "" {
+		t.Error(pod.Spec.Containers[0].WorkingDir)
+	}
+}
+
+func TestAppGetTerminationGracePeriodSeconds(t *testing.T) {
+	a := newTestApp("a")
+	if seconds := a.getTerminationGracePeriodSeconds(); seconds != nil {
+		t.Error(*seconds)
+	}
+	testCases := map[time.Duration]int64{
+		0:                       0,
+		90 * time.Second:        90,
+		1500 * time.Millisecond: 2,
+	}
+	for stopGracePeriod, expected := range testCases {
+		stopGracePeriod := stopGracePeriod
+		a.composeService.DockerComposeService.StopGracePeriod = &stopGracePeriod
+		seconds := a.getTerminationGracePeriodSeconds()
+		if seconds == nil || *seconds != expected {
+			t.Error(stopGracePeriod, seconds)
+		}
+	}
+}
+
+func TestAppCreatePodHostAliases_ExtraHosts(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.ExtraHosts = []dockerComposeConfig.ExtraHost{
+		{Hostname: "api.internal", IP: "10.0.0.5"},
+		{Hostname: "db.internal", IP: "10.0.0.6"},
+		{Hostname: "api2.internal", IP: "10.0.0.5"},
+	}
+	hostAliases := []v1.HostAlias{
+		{IP: "10.96.0.10", Hostnames: []string{"b"}},
+	}
+	result := a.createPodHostAliases(hostAliases)
+	if len(result) != 3 {
+		t.Fatal(result)
+	}
+	if result[0].IP != "10.96.0.10" {
+		t.Error(result[0])
+	}
+	if result[1].IP != "10.0.0.5" || len(result[1].Hostnames) != 2 || result[1].Hostnames[0] != "api.internal" ||
+		result[1].Hostnames[1] != "api2.internal" {
+		t.Error(result[1])
+	}
+	if result[2].IP != "10.0.0.6" || len(result[2].Hostnames) != 1 || result[2].Hostnames[0] != "db.internal" {
+		t.Error(result[2])
+	}
+	if len(hostAliases) != 1 {
+		t.Error(hostAliases)
+	}
+}
+
+func TestGetAppImageInfoUser_NumericSkipsImage(t *testing.T) {
+	// The docker client is nil, so resolving the user from the image would panic.
+	u := &upRunner{
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	for _, userRaw := range []string{"1000", "1000:1001"} {
+		a := newTestApp("a")
+		a.composeService.DockerComposeService.User = util.NewString(userRaw)
+		err := u.getAppImageInfoUser(a, nil, "image")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.imageInfo.user == nil || a.imageInfo.user.UID == nil || *a.imageInfo.user.UID != 1000 {
+			t.Error(userRaw, a.imageInfo.user)
+		}
+	}
+}
+
+const testDigestedImage = "ubuntu@sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859"
+
+func TestResolveLocalImageID_Digested(t *testing.T) {
+	localImageIDSet := digestset.NewSet()
+	ref, err := dockerRef.ParseAnyReferenceWithSet(testDigestedImage, localImageIDSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localImagesCache := []dockerTypes.ImageSummary{
+		{
+			ID:          "sha256:a1",
+			RepoDigests: []string{"ubuntu@sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+			RepoTags:    []string{"ubuntu:latest"},
+		},
+		{
+			ID:          "sha256:a2",
+			RepoDigests: []string{testDigestedImage},
+		},
+	}
+	if imageID := resolveLocalImageID(ref, localImageIDSet, localImagesCache); imageID != "sha256:a2" {
+		t.Error(imageID)
+	}
+	if imageID := resolveLocalImageID(ref, localImageIDSet, localImagesCache[:1]); imageID != "" {
+		t.Error(imageID)
+	}
+}
+
+func TestNewPod_DigestedImage(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["a"].DockerComposeService.Image = testDigestedImage
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	u.initApps()
+	a := u.apps["a"]
+	ref, err := dockerRef.ParseAnyReferenceWithSet(testDigestedImage, digestset.NewSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = u.getAppImageEnsureCorrectPodImage(a, ref, testDigestedImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Skip resolving the image with the docker daemon.
+	a.imageInfo.once.Do(func() {})
+	pod, err := u.newPod(a, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := pod.Spec.Containers[0]
+	if container.Image != testDigestedImage || container.ImagePullPolicy != v1.PullIfNotPresent {
+		t.Error(container.Image, container.ImagePullPolicy)
+	}
+}
+
+func TestPushedPodImage(t *testing.T) {
+	digest := "sha256:2695d3e10e69cc500a16eae6d6629c803c43ab075fa5ce60813a0fc49c47e859"
+	if podImage := pushedPodImage("registry:5000/ns/a", "123-main", digest); podImage != "registry:5000/ns/a@"+digest {
+		t.Error(podImage)
+	}
+	// The digest is unknown if pushing is skipped.
+	if podImage := pushedPodImage("registry:5000/ns/a", "123-main", ""); podImage != "registry:5000/ns/a:123-main" {
+		t.Error(podImage)
+	}
+}
+
+func TestResolveSymlinkTarget(t *testing.T) {
+	testCases := map[string]string{
+		"/usr/lib/passwd":   "/usr/lib/passwd",
+		"/usr/../lib/group": "/lib/group",
+		"passwd.real":       "/etc/passwd.real",
+		"../usr/etc/passwd": "/usr/etc/passwd",
+	}
+	for target, expected := range testCases {
+		if actual := resolveSymlinkTarget("/etc/passwd", target); actual != expected {
+			t.Error(target, actual)
+		}
+	}
+}
+
+func TestCreateSecurityContext_ReadOnly(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.ReadOnly = true
+	u := &upRunner{
+		opts: &Options{},
+	}
+	securityContext := u.createSecurityContext(a)
+	if securityContext == nil || securityContext.ReadOnlyRootFilesystem == nil || !*securityContext.ReadOnlyRootFilesystem {
+		t.Error(securityContext)
+	}
+}
+
+func TestHasWritableMounts(t *testing.T) {
+	testCases := []struct {
+		dockerComposeService *dockerComposeConfig.Service
+		expected             bool
+	}{
+		{&dockerComposeConfig.Service{}, false},
+		{&dockerComposeConfig.Service{Tmpfs: []dockerComposeConfig.TmpfsMount{{ContainerPath: "/tmp"}}}, true},
+		{&dockerComposeConfig.Service{Volumes: []dockerComposeConfig.ServiceVolume{
+			{Short: &dockerComposeConfig.PathMapping{ContainerPath: "/data"}},
+		}}, true},
+		{&dockerComposeConfig.Service{Volumes: []dockerComposeConfig.ServiceVolume{
+			{Short: &dockerComposeConfig.PathMapping{ContainerPath: "/etc/app", HasMode: true, Mode: "ro"}},
+		}}, false},
+	}
+	for i, testCase := range testCases {
+		if actual := hasWritableMounts(testCase.dockerComposeService); actual != testCase.expected {
+			t.Errorf("test case %d: expected %t but got %t", i, testCase.expected, actual)
+		}
+	}
+}
+
+func TestEnvironmentResources_ProjectLabel(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentLabel = "env"
+	cfg.EnvironmentID = "123"
+	cfg.ProjectName = "web"
+	u := &upRunner{
+		cfg:          cfg,
+		opts:         &Options{},
+		configValues: map[string][]byte{},
+		secretValues: map[string][]byte{},
+	}
+	pullSecret, err := u.newPullSecret("registry.example.com", "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Resources that belong to the environment as a whole must be selected by down --project-name.
+	objectMetas := map[string]*metav1.ObjectMeta{
+		"secret":           &u.newSecret("a-secret").ObjectMeta,
+		"pull secret":      &pullSecret.ObjectMeta,
+		"config":           &u.newConfigMap("a-config").ObjectMeta,
+		"network policy":   &u.newDefaultDenyIngressPolicy().ObjectMeta,
+		"owner":            &u.newOwner().ObjectMeta,
+		"image loader pod": &u.newImageLoaderPod("node1").ObjectMeta,
+	}
+	for kind, objectMeta := range objectMetas {
+		if objectMeta.Labels["env"] != "123" || objectMeta.Labels[k8smeta.ProjectLabel] != "web" {
+			t.Error(kind, objectMeta.Labels)
+		}
+	}
+}
+
+func TestUpdateAppMaxObservedPodStatus_RestartAfterReady(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			Detach: true,
+		},
+	}
+	u.cfg.EnvironmentID = "123"
+	u.cfg.EnvironmentLabel = "env"
+	u.initApps()
+	a := u.apps["a"]
+	a.reporterRow = reporter.NewPlain(io.Discard).AddRow("a")
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{},
+					},
+				},
+			},
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+	k8smeta.InitObjectMeta(u.cfg, &pod.ObjectMeta, a.composeService)
+	expectedPhases := []reporter.Phase{reporter.PhaseReady, reporter.PhaseStarting, reporter.PhaseReady}
+	for i, expectedPhase := range expectedPhases {
+		// The container restarts and is not ready for the second event.
+		pod.Status.Conditions[0].Status = v1.ConditionTrue
+		if i == 1 {
+			pod.Status.Conditions[0].Status = v1.ConditionFalse
+		}
+		if err := u.updateAppMaxObservedPodStatus(pod); err != nil {
+			t.Fatal(err)
+		}
+		if a.reporterRow.Phase() != expectedPhase || a.maxObservedPodStatus != podStatusReady {
+			t.Error(i, a.reporterRow.Phase(), a.maxObservedPodStatus)
+		}
+	}
+}
@@ -12,13 +12,14 @@ import (
 	dockerClient "github.com/docker/docker/client"
 	dockerArchive "github.com/docker/docker/pkg/archive"
 	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
 	"github.com/kube-compose/kube-compose/internal/pkg/unix"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"io/ioutil"
+	"io"
 	v1 "k8s.io/api/core/v1"
 	"math"
 	"os"
@@ -62,8 +63,7 @@ func createReadinessProbeFromDockerHealthcheck(healthcheck *dockerComposeConfig.
 			},
 		},
 		// InitialDelaySeconds must always be zero so we start the healthcheck immediately.
-		// Irrespective of Docker's StartPeriod we should set this to zero.
-		// If this was a liveness probe we would have to set InitialDelaySeconds to StartPeriod.
+		// Docker's StartPeriod is mapped to a startup probe instead (see createStartupProbeFromDockerHealthcheck).
 		InitialDelaySeconds: 0,
 
 		PeriodSeconds:  int32(math.RoundToEven(healthcheck.Interval.Seconds())),
@@ -75,19 +75,42 @@ func createReadinessProbeFromDockerHealthcheck(healthcheck *dockerComposeConfig.
 	return probe
 }
 
+// createStartupProbeFromDockerHealthcheck maps Docker's start_period to a startup probe, which holds off the readiness probe until the
+// healthcheck first succeeds. The startup probe allows start_period / interval failures (rounded up, and at least one). Returns nil if the
+// healthcheck has no start period.
+func createStartupProbeFromDockerHealthcheck(healthcheck *dockerComposeConfig.Healthcheck) *v1.Probe {
+	if healthcheck == nil || healthcheck.StartPeriod <= 0 {
+		return nil
+	}
+	probe := createReadinessProbeFromDockerHealthcheck(healthcheck)
+	failureThreshold := int64(1)
+	if healthcheck.Interval > 0 {
+		failureThreshold = int64(math.Ceil(float64(healthcheck.StartPeriod) / float64(healthcheck.Interval)))
+	}
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	} else if failureThreshold > math.MaxInt32 {
+		failureThreshold = math.MaxInt32
+	}
+	probe.FailureThreshold = int32(failureThreshold)
+	return probe
+}
+
 type hasTag interface {
 	Tag() string
 }
 
 func inspectImageRawParseHealthcheck(inspectRaw []byte) (*dockerComposeConfig.Healthcheck, error) {
-	// inspectInfo's type is similar to dockerClient.ImageInspect, but it allows us to detect absent fields so we can apply default values.
+	// inspectInfo's type is similar to dockerClient.ImageInspect. Docker represents absent durations and retries as zero, so zero values
+	// are replaced by default values (see Healthcheck.ApplyDefaults).
 	var inspectInfo struct {
 		Config struct {
 			Healthcheck struct {
-				Test     []string `json:"Test"`
-				Timeout  *int64   `json:"Timeout"`
-				Interval *int64   `json:"Interval"`
-				Retries  *uint    `json:"Retries"`
+				Test        []string `json:"Test"`
+				Timeout     int64    `json:"Timeout"`
+				Interval    int64    `json:"Interval"`
+				Retries     uint     `json:"Retries"`
+				StartPeriod int64    `json:"StartPeriod"`
 			} `json:"Healthcheck"`
 		} `json:"Config"`
 	}
@@ -99,49 +122,75 @@ func inspectImageRawParseHealthcheck(inspectRaw []byte) (*dockerComposeConfig.He
 		return nil, nil
 	}
 	healthcheck := &dockerComposeConfig.Healthcheck{
-		Interval: dockerComposeConfig.HealthcheckDefaultInterval,
-		Timeout:  dockerComposeConfig.HealthcheckDefaultTimeout,
-		Retries:  dockerComposeConfig.HealthcheckDefaultRetries,
+		Interval:    time.Duration(inspectInfo.Config.Healthcheck.Interval),
+		Retries:     inspectInfo.Config.Healthcheck.Retries,
+		StartPeriod: time.Duration(inspectInfo.Config.Healthcheck.StartPeriod),
+		Test:        inspectInfo.Config.Healthcheck.Test[1:],
+		Timeout:     time.Duration(inspectInfo.Config.Healthcheck.Timeout),
 	}
 	if inspectInfo.Config.Healthcheck.Test[0] == dockerComposeConfig.HealthcheckCommandShell {
 		healthcheck.IsShell = true
 	}
-	healthcheck.Test = inspectInfo.Config.Healthcheck.Test[1:]
-	if inspectInfo.Config.Healthcheck.Timeout != nil {
-		healthcheck.Timeout = time.Duration(*inspectInfo.Config.Healthcheck.Timeout)
-	}
-	if inspectInfo.Config.Healthcheck.Interval != nil {
-		healthcheck.Interval = time.Duration(*inspectInfo.Config.Healthcheck.Interval)
-	}
-	if inspectInfo.Config.Healthcheck.Retries != nil {
-		healthcheck.Retries = *inspectInfo.Config.Healthcheck.Retries
-	}
+	healthcheck.ApplyDefaults()
 	return healthcheck, nil
 }
 
+// maxSymlinkHops bounds the number of symlinks followed by copyFileFromContainer, so that cycles of symlinks are detected.
+const maxSymlinkHops = 8
+
+// copyFileFromContainer copies the regular file srcFile of a container into the directory dstFile, following symlinks. The copy is named
+// after srcFile, even if srcFile is a symlink to a file with a different name.
 func copyFileFromContainer(ctx context.Context, dc *dockerClient.Client, containerID, srcFile, dstFile string) error {
-	readCloser, stat, err := dc.CopyFromContainer(ctx, containerID, srcFile)
-	if err != nil {
-		return err
+	resolvedSrcFile := srcFile
+	for hops := 0; ; hops++ {
+		readCloser, stat, err := dc.CopyFromContainer(ctx, containerID, resolvedSrcFile)
+		if err != nil {
+			return err
+		}
+		if (stat.Mode & os.ModeSymlink) == 0 {
+			return copyRegularFileFromArchive(readCloser, stat.Mode, srcFile, resolvedSrcFile, dstFile)
+		}
+		util.CloseAndLogError(readCloser)
+		if hops == maxSymlinkHops {
+			return fmt.Errorf("could not copy %#v because it has more than %d levels of symlinks", srcFile, maxSymlinkHops)
+		}
+		resolvedSrcFile = resolveSymlinkTarget(resolvedSrcFile, stat.LinkTarget)
 	}
+}
+
+// copyRegularFileFromArchive extracts the file resolvedSrcFile from an archive returned by CopyFromContainer into the directory dstFile,
+// renamed to the base name of srcFile. Closes readCloser.
+func copyRegularFileFromArchive(readCloser io.ReadCloser, mode os.FileMode, srcFile, resolvedSrcFile, dstFile string) error {
 	defer util.CloseAndLogError(readCloser)
-	if (stat.Mode & os.ModeType) != 0 {
-		// TODO https://github.com/kube-compose/kube-compose/issues/70 we should follow symlinks
+	if (mode & os.ModeType) != 0 {
+		// Sockets, devices, named pipes and directories cannot be copied.
 		return fmt.Errorf("could not copy %#v because it is not a regular file", srcFile)
 	}
 	srcInfo := dockerArchive.CopyInfo{
-		Path:       srcFile,
+		Path:       resolvedSrcFile,
 		Exists:     true,
 		IsDir:      false,
 		RebaseName: "",
 	}
-	err = dockerArchive.CopyTo(readCloser, srcInfo, dstFile)
+	if resolvedSrcFile != srcFile {
+		srcInfo.RebaseName = path.Base(srcFile)
+	}
+	err := dockerArchive.CopyTo(readCloser, srcInfo, dstFile)
 	if err != nil {
 		return errors.Wrapf(err, "error while copying image file %#v to local file %#v", srcFile, dstFile)
 	}
 	return nil
 }
 
+// resolveSymlinkTarget returns the path of the target of the symlink at linkPath, where a relative target is relative to the directory of
+// the symlink.
+func resolveSymlinkTarget(linkPath, target string) string {
+	if path.IsAbs(target) {
+		return path.Clean(target)
+	}
+	return path.Join(path.Dir(linkPath), target)
+}
+
 func getUserinfoFromImage(ctx context.Context, dc *dockerClient.Client, image string, user *docker.Userinfo) error {
 	containerConfig := &dockerContainers.Config{
 		Entrypoint: []string{"sh"},
@@ -158,12 +207,12 @@ func getUserinfoFromImage(ctx context.Context, dc *dockerClient.Client, image st
 			log.Error(err)
 		}
 	}()
-	tmpDir, err := ioutil.TempDir("", "kube-compose-")
+	tmpDir, err := fs.OS.TempDir("", "kube-compose-")
 	if err != nil {
 		return err
 	}
 	defer func() {
-		err = os.RemoveAll(tmpDir)
+		err = fs.OS.RemoveAll(tmpDir)
 		if err != nil {
 			log.Error(err)
 		}
@@ -198,12 +247,12 @@ func getUserinfoFromImageUID(ctx context.Context, dc *dockerClient.Client, conta
 func getUserinfoFromImageGID(ctx context.Context, dc *dockerClient.Client, containerID, tmpDir string, user *docker.Userinfo) error {
 	// TODO https://github.com/kube-compose/kube-compose/issues/70 this is not correct for non-Linux containers
 	if user.GID == nil && user.Group != "" {
-		err := copyFileFromContainer(ctx, dc, containerID, "/etc/group", tmpDir)
+		err := copyFileFromContainer(ctx, dc, containerID, unix.EtcGroup, tmpDir)
 		if err != nil {
 			return err
 		}
 		var gid *int64
-		gid, err = unix.FindUIDByNameInPasswd(path.Join(tmpDir, "group"), user.Group)
+		gid, err = unix.FindGIDByNameInGroup(path.Join(tmpDir, "group"), user.Group)
 		if err != nil {
 			return err
 		}
@@ -9,12 +9,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 
 	dockerTypes "github.com/docker/docker/api/types"
-	dockerClient "github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/kube-compose/kube-compose/internal/pkg/docker"
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
@@ -24,24 +24,43 @@ import (
 
 var tarFileInfoHeader = tar.FileInfoHeader
 
-func buildVolumeInitImageGetDockerfile(isDirSlice []bool) []byte {
+// isCaseInsensitiveFileSystem is true if the host file system is assumed to be case-insensitive, which is the default on macOS and
+// Windows.
+var isCaseInsensitiveFileSystem = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// defaultVolumeInitShell is the shell that runs the entrypoint of helper images of bind mounted volumes. It is /bin/sh because minimal
+// base images (e.g. busybox and alpine) do not ship bash.
+const defaultVolumeInitShell = "/bin/sh"
+
+// buildVolumeInitImageGetDockerfile returns the Dockerfile of a helper image of bind mounted volumes. The entrypoint copies the data of
+// each volume with a single invocation of shell, using only POSIX options of cp so that it also works under busybox. COPY ignores the
+// owner of the files in the build context, so the files are owned by root unless opts sets a uid, in which case they are copied with
+// --chown.
+func buildVolumeInitImageGetDockerfile(isDirSlice []bool, shell string, opts *bindMountTarOptions) []byte {
 	var b bytes.Buffer
 	b.WriteString(`ARG BASE_IMAGE
 FROM ${BASE_IMAGE}
 `)
+	copyInstruction := "COPY"
+	if opts != nil && opts.uid != nil {
+		copyInstruction += fmt.Sprintf(" --chown=%d", *opts.uid)
+		if opts.gid != nil {
+			copyInstruction += fmt.Sprintf(":%d", *opts.gid)
+		}
+	}
 	for i := 1; i <= len(isDirSlice); i++ {
 		if isDirSlice[i-1] {
-			fmt.Fprintf(&b, "COPY data%d/ /app/data/vol%d/\n", i, i)
+			fmt.Fprintf(&b, "%s data%d/ /app/data/vol%d/\n", copyInstruction, i, i)
 		} else {
-			fmt.Fprintf(&b, "COPY data%d /app/data/vol%d\n", i, i)
+			fmt.Fprintf(&b, "%s data%d /app/data/vol%d\n", copyInstruction, i, i)
 		}
 	}
-	b.WriteString(`ENTRYPOINT ["bash", "-c", "`)
+	fmt.Fprintf(&b, `ENTRYPOINT [%q, "-c", "`, shell)
 	for i := 1; i <= len(isDirSlice); i++ {
 		if i > 1 {
 			b.WriteString(" && ")
 		}
-		fmt.Fprintf(&b, "cp -ar /app/data/vol%d /mnt/vol%d/root", i, i)
+		fmt.Fprintf(&b, "cp -a /app/data/vol%d /mnt/vol%d/root", i, i)
 	}
 	b.WriteString(`"]
 `)
@@ -53,7 +72,21 @@ type TarWriter interface {
 	WriteHeader(header *tar.Header) error
 }
 
+// bindMountTarOptions overrides the ownership of the entries of the tar archive written by bindMountHostFileToTar, and of the files of the
+// helper image (see buildVolumeInitImageGetDockerfile). Nil fields preserve the uid and gid of the host files in the tar archive.
+type bindMountTarOptions struct {
+	uid *int64
+	gid *int64
+	// If true then symlinks whose target is outside the bind volume are skipped with a warning, instead of failing.
+	skipEscapingSymlinks bool
+	// Whether the host file system is case-insensitive, which determines whether a symlink target is within the bind volume. Nil
+	// means isCaseInsensitiveFileSystem.
+	caseInsensitive *bool
+}
+
 type bindMountHostFileToTarHelper struct {
+	caseInsensitive        bool
+	opts                   *bindMountTarOptions
 	tw                     TarWriter
 	renameTo               string
 	rootHostFile           string
@@ -114,15 +147,13 @@ func (h *bindMountHostFileToTarHelper) runDirectory(fileInfo os.FileInfo, hostFi
 
 func (h *bindMountHostFileToTarHelper) isFileWithinBindHostRoot(target string) bool {
 	// Can assume target and h.rootHostFile are cleaned.
-	// TODO https://github.com/kube-compose/kube-compose/issues/173 support case sensitive file systems
-	// We do not have to split off the prefix here, but we do so in case drive letters are case-insensitive
-	// independent of the file system.
+	// We split off the volume because drive letters are case-insensitive independent of the file system.
 	vol := filepath.VolumeName(target)
-	if vol != h.rootHostFileVol {
+	if !strings.EqualFold(vol, h.rootHostFileVol) {
 		return false
 	}
 	targetWithoutVol := target[len(vol):]
-	if strings.HasPrefix(targetWithoutVol, h.rootHostFileWithoutVol) {
+	if h.hasPrefix(targetWithoutVol, h.rootHostFileWithoutVol) {
 		if len(targetWithoutVol) == len(h.rootHostFileWithoutVol) {
 			return true
 		}
@@ -133,6 +164,14 @@ func (h *bindMountHostFileToTarHelper) isFileWithinBindHostRoot(target string) b
 	return false
 }
 
+// hasPrefix is strings.HasPrefix, except that case is ignored if the host file system is case-insensitive.
+func (h *bindMountHostFileToTarHelper) hasPrefix(s, prefix string) bool {
+	if h.caseInsensitive {
+		return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+	}
+	return strings.HasPrefix(s, prefix)
+}
+
 func (h *bindMountHostFileToTarHelper) runSymlink(fileInfo os.FileInfo, hostFile, fileNameInTar string) error {
 	// Symbolic link
 	link, err := fs.OS.Readlink(hostFile)
@@ -170,7 +209,13 @@ func (h *bindMountHostFileToTarHelper) runSymlink(fileInfo os.FileInfo, hostFile
 		header.Name = fileNameInTar
 		return h.endHeaderCommon(header)
 	}
-	return fmt.Errorf("target of symlink %#v it outside the bind volume with host %#v", hostFile, h.rootHostFile)
+	if h.opts.skipEscapingSymlinks {
+		log.Warnf("skipping symlink %#v of bind volume with host root %#v because its target %#v is outside the bind volume\n", hostFile,
+			h.rootHostFile, linkResolved)
+		return nil
+	}
+	return fmt.Errorf("symlink %#v resolves to %#v, which is outside the bind volume with host root %#v (use --skip-escaping-symlinks to "+
+		"skip such symlinks)", hostFile, linkResolved, h.rootHostFile)
 }
 
 func (h *bindMountHostFileToTarHelper) runRecursive(fileInfo os.FileInfo, hostFile, fileNameInTar string) error {
@@ -192,10 +237,14 @@ func (h *bindMountHostFileToTarHelper) runRecursive(fileInfo os.FileInfo, hostFi
 }
 
 func (h *bindMountHostFileToTarHelper) endHeaderCommon(header *tar.Header) error {
-	// TODO https://github.com/kube-compose/kube-compose/issues/154 change owner of files here, as appropriate...
-	// For example:
-	// header.Uid = ...
-	// header.Gid = ...
+	if h.opts.uid != nil {
+		header.Uid = int(*h.opts.uid)
+		header.Uname = ""
+	}
+	if h.opts.gid != nil {
+		header.Gid = int(*h.opts.gid)
+		header.Gname = ""
+	}
 	return h.tw.WriteHeader(header)
 }
 
@@ -209,11 +258,21 @@ func (h *bindMountHostFileToTarHelper) run(hostFile, fileNameInTar string) (isDi
 	return
 }
 
-func bindMountHostFileToTar(tw TarWriter, hostFile, renameTo string) (isDir bool, err error) {
+// bindMountHostFileToTar writes hostFile (recursively) to tw, renamed to renameTo. If opts is nil then the uid and gid of the host files
+// are preserved.
+func bindMountHostFileToTar(tw TarWriter, hostFile, renameTo string, opts *bindMountTarOptions) (isDir bool, err error) {
+	if opts == nil {
+		opts = &bindMountTarOptions{}
+	}
 	h := &bindMountHostFileToTarHelper{
-		tw:           tw,
-		rootHostFile: hostFile,
-		renameTo:     renameTo,
+		caseInsensitive: isCaseInsensitiveFileSystem,
+		opts:            opts,
+		tw:              tw,
+		rootHostFile:    hostFile,
+		renameTo:        renameTo,
+	}
+	if opts.caseInsensitive != nil {
+		h.caseInsensitive = *opts.caseInsensitive
 	}
 	vol := filepath.VolumeName(hostFile)
 	h.rootHostFileVol = vol
@@ -223,38 +282,58 @@ func bindMountHostFileToTar(tw TarWriter, hostFile, renameTo string) (isDir bool
 	return
 }
 
-func buildVolumeInitImageGetBuildContext(bindVolumeHostPaths []string) ([]byte, error) {
-	var tarBuffer bytes.Buffer
-	tw := tar.NewWriter(&tarBuffer)
-	defer tw.Close()
-
+// buildVolumeInitImageWriteBuildContext writes the build context of a helper image of bind mounted volumes to w as a tar archive.
+func buildVolumeInitImageWriteBuildContext(w io.Writer, bindVolumeHostPaths []string, shell string, opts *bindMountTarOptions) error {
+	tw := tar.NewWriter(w)
 	var isDirSlice []bool
 	for i, bindVolumeHostFile := range bindVolumeHostPaths {
-		isDir, err := bindMountHostFileToTar(tw, bindVolumeHostFile, fmt.Sprintf("data%d", i+1))
+		isDir, err := bindMountHostFileToTar(tw, bindVolumeHostFile, fmt.Sprintf("data%d", i+1), opts)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		isDirSlice = append(isDirSlice, isDir)
 	}
 
 	// Write Dockerfile to build context.
-	dockerFile := buildVolumeInitImageGetDockerfile(isDirSlice)
+	dockerFile := buildVolumeInitImageGetDockerfile(isDirSlice, shell, opts)
 	err := tw.WriteHeader(&tar.Header{
 		Name: "Dockerfile",
 		Size: int64(len(dockerFile)),
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 	_, err = tw.Write(dockerFile)
 	if err != nil {
-		return nil, err
-	}
-	err = tw.Flush()
-	if err != nil {
-		return nil, err
+		return err
 	}
-	return tarBuffer.Bytes(), nil
+	return tw.Close()
+}
+
+// buildVolumeInitImageStreamBuildContext returns a reader of the build context of a helper image of bind mounted volumes. The build
+// context is produced while it is read, so that it is never buffered in memory in its entirety. If producing the build context fails
+// then cancel is called, the reader returns the error and the error is sent to errChan. The caller must close the returned reader.
+func buildVolumeInitImageStreamBuildContext(
+	bindVolumeHostPaths []string,
+	shell string,
+	opts *bindMountTarOptions,
+	cancel context.CancelFunc) (buildContext io.ReadCloser, errChan <-chan error) {
+	pr, pw := io.Pipe()
+	errChanBidi := make(chan error, 1)
+	go func() {
+		err := buildVolumeInitImageWriteBuildContext(pw, bindVolumeHostPaths, shell, opts)
+		if err != nil {
+			cancel()
+		}
+		_ = pw.CloseWithError(err)
+		errChanBidi <- err
+	}()
+	return pr, errChanBidi
+}
+
+// imageBuilder is the part of the docker client that builds images.
+type imageBuilder interface {
+	ImageBuild(ctx context.Context, buildContext io.Reader, options dockerTypes.ImageBuildOptions) (dockerTypes.ImageBuildResponse, error)
 }
 
 type buildVolumeInitImageResult struct {
@@ -263,14 +342,24 @@ type buildVolumeInitImageResult struct {
 
 func buildVolumeInitImage(
 	ctx context.Context,
-	dc *dockerClient.Client,
+	dc imageBuilder,
 	bindVolumeHostPaths []string,
-	volumeInitBaseImage string) (*buildVolumeInitImageResult, error) {
-	buildContextBytes, err := buildVolumeInitImageGetBuildContext(bindVolumeHostPaths)
-	if err != nil {
-		return nil, err
+	volumeInitBaseImage, volumeInitShell string,
+	opts *bindMountTarOptions) (*buildVolumeInitImageResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	buildContext, errChan := buildVolumeInitImageStreamBuildContext(bindVolumeHostPaths, volumeInitShell, opts, cancel)
+	// waitForBuildContext unblocks the producer of the build context (if it is still writing) and returns its error, which takes
+	// precedence over errors of the build because those are likely caused by the former. Closing the reader makes a producer that is still
+	// writing fail with io.ErrClosedPipe, which is caused by the build (e.g. the docker daemon is unreachable), so that error is ignored.
+	waitForBuildContext := func() error {
+		_ = buildContext.Close()
+		err := <-errChan
+		if errors.Is(err, io.ErrClosedPipe) {
+			return nil
+		}
+		return err
 	}
-	buildContext := bytes.NewReader(buildContextBytes)
 	response, err := dc.ImageBuild(ctx, buildContext, dockerTypes.ImageBuildOptions{
 		BuildArgs: map[string]*string{
 			"BASE_IMAGE": util.NewString(volumeInitBaseImage),
@@ -280,8 +369,12 @@ func buildVolumeInitImage(
 		Remove:         true,
 	})
 	if err != nil {
+		if errBuildContext := waitForBuildContext(); errBuildContext != nil {
+			return nil, errBuildContext
+		}
 		return nil, err
 	}
+	defer response.Body.Close()
 	r := &buildVolumeInitImageResult{}
 
 	// duplicate the Reader, so we can print the json content on error
@@ -295,6 +388,9 @@ func buildVolumeInitImage(
 			if err == io.EOF {
 				break
 			}
+			if errBuildContext := waitForBuildContext(); errBuildContext != nil {
+				return nil, errBuildContext
+			}
 			return nil, err
 		}
 
@@ -302,6 +398,9 @@ func buildVolumeInitImage(
 			r.imageID = imageID
 		}
 	}
+	if err = waitForBuildContext(); err != nil {
+		return nil, err
+	}
 	if r.imageID == "" {
 		log.Warnf("ImageBuild() JSON response: %s\n", bodyContent.String())
 		return nil, fmt.Errorf("buildVolumeInitImage: could not parse image ID from docker build output stream")
@@ -3,12 +3,18 @@ package up
 import (
 	"archive/tar"
 	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
+	dockerTypes "github.com/docker/docker/api/types"
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	"github.com/pkg/errors"
 )
 
@@ -119,7 +125,7 @@ func directory(name string) mockTarWriterEntry {
 func Test_BindMountHostFileToTar_SuccessRegularFile(t *testing.T) {
 	withMockFS(vfs, func() {
 		tw := &mockTarWriter{}
-		isDir, err := bindMountHostFileToTar(tw, "orig", "renamed")
+		isDir, err := bindMountHostFileToTar(tw, "orig", "renamed", nil)
 		if err != nil {
 			t.Error(err)
 		} else {
@@ -138,10 +144,45 @@ func Test_BindMountHostFileToTar_SuccessRegularFile(t *testing.T) {
 	})
 }
 
+func Test_BindMountHostFileToTar_Ownership(t *testing.T) {
+	withMockFS(vfs, func() {
+		tw := &mockTarWriter{}
+		opts := &bindMountTarOptions{
+			uid: util.NewInt64(1000),
+			gid: util.NewInt64(1001),
+		}
+		_, err := bindMountHostFileToTar(tw, "dir2", "renamed", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tw.entries) != 3 {
+			t.Fatalf("entries: %+v", tw.entries)
+		}
+		for _, entry := range tw.entries {
+			if entry.h.Uid != 1000 || entry.h.Gid != 1001 {
+				t.Errorf("entry %s has uid %d and gid %d", entry.h.Name, entry.h.Uid, entry.h.Gid)
+			}
+		}
+	})
+}
+
+func Test_BindMountHostFileToTar_OwnershipPreserved(t *testing.T) {
+	withMockFS(vfs, func() {
+		tw := &mockTarWriter{}
+		_, err := bindMountHostFileToTar(tw, "orig", "renamed", &bindMountTarOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tw.entries, []mockTarWriterEntry{regularFile("renamed", testFileContent)}) {
+			t.Fail()
+		}
+	})
+}
+
 func Test_BindMountHostFileToTar_StatError(t *testing.T) {
 	withMockFS(vfs, func() {
 		tw := &mockTarWriter{}
-		_, err := bindMountHostFileToTar(tw, "origerr", "renamed2")
+		_, err := bindMountHostFileToTar(tw, "origerr", "renamed2", nil)
 		if err == nil {
 			t.Fail()
 		}
@@ -167,7 +208,7 @@ func Test_BindMountHostFileToTar_RegularFileTarHeaderError(t *testing.T) {
 	withTarFileInfoHeaderError(errExpected, false, func() {
 		withMockFS(vfs, func() {
 			tw := &mockTarWriter{}
-			_, errActual := bindMountHostFileToTar(tw, "orig", "renamed")
+			_, errActual := bindMountHostFileToTar(tw, "orig", "renamed", nil)
 			if errActual != errExpected {
 				t.Fail()
 			}
@@ -181,7 +222,7 @@ func Test_BindMountHostFileToTar_RegularFileWriteTarHeaderError(t *testing.T) {
 		tw := &mockTarWriter{
 			errWriteHeader: errExpected,
 		}
-		_, errActual := bindMountHostFileToTar(tw, "orig", "renamed")
+		_, errActual := bindMountHostFileToTar(tw, "orig", "renamed", nil)
 		if errActual != errExpected {
 			t.Fail()
 		}
@@ -197,7 +238,7 @@ func Test_BindMountHostFileToTar_RegularFileOpenError(t *testing.T) {
 		},
 	}), func() {
 		tw := &mockTarWriter{}
-		_, errActual := bindMountHostFileToTar(tw, "regularfileopenerror", "renamed")
+		_, errActual := bindMountHostFileToTar(tw, "regularfileopenerror", "renamed", nil)
 		if errActual != errExpected {
 			t.Fail()
 		}
@@ -213,7 +254,7 @@ func Test_BindMountHostFileToTar_DirTarHeaderError(t *testing.T) {
 			},
 		}), func() {
 			tw := &mockTarWriter{}
-			_, errActual := bindMountHostFileToTar(tw, "dir", "renamed")
+			_, errActual := bindMountHostFileToTar(tw, "dir", "renamed", nil)
 			if errActual != errExpected {
 				t.Fail()
 			}
@@ -230,7 +271,7 @@ func Test_BindMountHostFileToTar_DirectoryOpenError(t *testing.T) {
 		},
 	}), func() {
 		tw := &mockTarWriter{}
-		_, errActual := bindMountHostFileToTar(tw, "directoryopenerror", "renamed")
+		_, errActual := bindMountHostFileToTar(tw, "directoryopenerror", "renamed", nil)
 		if errActual != errExpected {
 			t.Fail()
 		}
@@ -246,7 +287,7 @@ func Test_BindMountHostFileToTar_DirectoryReadError(t *testing.T) {
 		},
 	}), func() {
 		tw := &mockTarWriter{}
-		_, errActual := bindMountHostFileToTar(tw, "directoryreaderror", "renamed")
+		_, errActual := bindMountHostFileToTar(tw, "directoryreaderror", "renamed", nil)
 		if errActual != errExpected {
 			t.Fail()
 		}
@@ -259,7 +300,7 @@ func Test_BindMountHostFileToTar_DirectoryWriteTarHeaderError(t *testing.T) {
 		tw := &mockTarWriter{
 			errWriteHeader: errExpected,
 		}
-		_, errActual := bindMountHostFileToTar(tw, "dir", "renamed")
+		_, errActual := bindMountHostFileToTar(tw, "dir", "renamed", nil)
 		if errActual != errExpected {
 			t.Fail()
 		}
@@ -269,7 +310,7 @@ func Test_BindMountHostFileToTar_DirectoryWriteTarHeaderError(t *testing.T) {
 func Test_BindMountHostFileToTar_SuccessDir(t *testing.T) {
 	withMockFS(vfs, func() {
 		tw := &mockTarWriter{}
-		isDir, err := bindMountHostFileToTar(tw, "dir", "renamed")
+		isDir, err := bindMountHostFileToTar(tw, "dir", "renamed", nil)
 		if err != nil {
 			t.Error(err)
 		} else {
@@ -293,7 +334,7 @@ func Test_BindMountHostFileToTar_SuccessDir(t *testing.T) {
 func Test_BindMountHostFileToTar_SuccessSymlink1(t *testing.T) {
 	withMockFS(vfs, func() {
 		tw := &mockTarWriter{}
-		isDir, err := bindMountHostFileToTar(tw, "dir2", "renamed")
+		isDir, err := bindMountHostFileToTar(tw, "dir2", "renamed", nil)
 		if err != nil {
 			t.Error(err)
 		} else {
@@ -321,7 +362,7 @@ func Test_BindMountHostFileToTar_SuccessSymlink2(t *testing.T) {
 		},
 	}), func() {
 		tw := &mockTarWriter{}
-		isDir, err := bindMountHostFileToTar(tw, "selflink", "renamed")
+		isDir, err := bindMountHostFileToTar(tw, "selflink", "renamed", nil)
 		if err != nil {
 			t.Error(err)
 		} else {
@@ -350,7 +391,7 @@ func Test_BindMountHostFileToTar_SymlinkResolveAbsError(t *testing.T) {
 	vfsTest.AbsError = errExpected
 	withMockFS(vfsTest, func() {
 		tw := &mockTarWriter{}
-		_, errActual := bindMountHostFileToTar(tw, "dir", "renamed")
+		_, errActual := bindMountHostFileToTar(tw, "dir", "renamed", nil)
 		if errors.Cause(errActual) != errExpected {
 			t.Fail()
 		}
@@ -368,7 +409,7 @@ func Test_BindMountHostFileToTar_SymlinkReadlinkError(t *testing.T) {
 	})
 	withMockFS(vfsTest, func() {
 		tw := &mockTarWriter{}
-		_, errActual := bindMountHostFileToTar(tw, "symlinkreadlinkerror", "renamed")
+		_, errActual := bindMountHostFileToTar(tw, "symlinkreadlinkerror", "renamed", nil)
 		if errors.Cause(errActual) != errExpected {
 			t.Fail()
 		}
@@ -378,19 +419,120 @@ func Test_BindMountHostFileToTar_SymlinkReadlinkError(t *testing.T) {
 func Test_BindMountHostFileToTar_ErrorSymlinkNotWithinBindHostRoot(t *testing.T) {
 	withMockFS(vfs, func() {
 		tw := &mockTarWriter{}
-		_, err := bindMountHostFileToTar(tw, "dir3", "renamed")
+		_, err := bindMountHostFileToTar(tw, "dir3", "renamed", nil)
 		if err == nil {
 			t.Fail()
 		}
 	})
 }
 
+func newCaseSymlinkFileSystem() *fs.InMemoryFileSystem {
+	vfsTest := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/App/file": {
+			Content: []byte(testFileContent),
+		},
+	})
+	vfsTest.Set("/App/link", &fs.InMemoryFile{
+		Content: []byte("/app/file"),
+		Mode:    os.ModeSymlink,
+	})
+	return vfsTest
+}
+
+func Test_BindMountHostFileToTar_CaseSensitive(t *testing.T) {
+	withMockFS(newCaseSymlinkFileSystem(), func() {
+		tw := &mockTarWriter{}
+		_, err := bindMountHostFileToTar(tw, "/App", "renamed", &bindMountTarOptions{
+			caseInsensitive: util.NewBool(false),
+		})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_BindMountHostFileToTar_CaseInsensitive(t *testing.T) {
+	withMockFS(newCaseSymlinkFileSystem(), func() {
+		tw := &mockTarWriter{}
+		_, err := bindMountHostFileToTar(tw, "/App", "renamed", &bindMountTarOptions{
+			caseInsensitive: util.NewBool(true),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, entry := range tw.entries {
+			if entry.h.Name == "renamed/link" {
+				found = true
+				if entry.h.Linkname != "file" {
+					t.Error(entry.h.Linkname)
+				}
+			}
+		}
+		if !found {
+			t.Fail()
+		}
+	})
+}
+
+func newEscapingSymlinkFileSystem() *fs.InMemoryFileSystem {
+	vfsTest := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/etc/shadow": {
+			Content: []byte("root:*:18000:0:99999:7:::"),
+		},
+	})
+	vfsTest.Set("/app/file", &fs.InMemoryFile{
+		Content: []byte(testFileContent),
+	})
+	vfsTest.Set("/app/shadow", &fs.InMemoryFile{
+		Content: []byte("/etc/shadow"),
+		Mode:    os.ModeSymlink,
+	})
+	return vfsTest
+}
+
+func Test_BindMountHostFileToTar_ErrorSymlinkNotWithinBindHostRootMessage(t *testing.T) {
+	withMockFS(newEscapingSymlinkFileSystem(), func() {
+		tw := &mockTarWriter{}
+		_, err := bindMountHostFileToTar(tw, "/app", "renamed", nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		for _, s := range []string{`"/app/shadow"`, `"/etc/shadow"`, `host root "/app"`} {
+			if !strings.Contains(err.Error(), s) {
+				t.Errorf("error %#v does not contain %s", err.Error(), s)
+			}
+		}
+	})
+}
+
+func Test_BindMountHostFileToTar_SkipEscapingSymlinks(t *testing.T) {
+	withMockFS(newEscapingSymlinkFileSystem(), func() {
+		tw := &mockTarWriter{}
+		_, err := bindMountHostFileToTar(tw, "/app", "renamed", &bindMountTarOptions{
+			skipEscapingSymlinks: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range tw.entries {
+			if entry.h.Name == "renamed/shadow" {
+				t.Fail()
+			}
+		}
+		if len(tw.entries) != 2 {
+			t.Logf("entries: %+v\n", tw.entries)
+			t.Fail()
+		}
+	})
+}
+
 func Test_BindMountHostFileToTar_SymlinkTarHeaderError(t *testing.T) {
 	errExpected := fmt.Errorf("symlinkTarHeaderError")
 	withTarFileInfoHeaderError(errExpected, true, func() {
 		withMockFS(vfs, func() {
 			tw := &mockTarWriter{}
-			_, errActual := bindMountHostFileToTar(tw, "dir2", "renamed")
+			_, errActual := bindMountHostFileToTar(tw, "dir2", "renamed", nil)
 			if errActual != errExpected {
 				t.Fail()
 			}
@@ -406,7 +548,7 @@ func Test_BindMountHostFileToTar_FileTypeError(t *testing.T) {
 		},
 	}), func() {
 		tw := &mockTarWriter{}
-		_, err := bindMountHostFileToTar(tw, "device", "renamed")
+		_, err := bindMountHostFileToTar(tw, "device", "renamed", nil)
 		if err == nil {
 			t.Fail()
 		}
@@ -414,12 +556,53 @@ func Test_BindMountHostFileToTar_FileTypeError(t *testing.T) {
 }
 
 func Test_BuildVolumeInitImageGetDockerfile_Success(t *testing.T) {
-	actual := buildVolumeInitImageGetDockerfile([]bool{true, false})
+	actual := buildVolumeInitImageGetDockerfile([]bool{true, false}, defaultVolumeInitShell, nil)
 	expected := []byte(`ARG BASE_IMAGE
 FROM ${BASE_IMAGE}
 COPY data1/ /app/data/vol1/
 COPY data2 /app/data/vol2
-ENTRYPOINT ["bash", "-c", "cp -ar /app/data/vol1 /mnt/vol1/root && cp -ar /app/data/vol2 /mnt/vol2/root"]
+ENTRYPOINT ["/bin/sh", "-c", "cp -a /app/data/vol1 /mnt/vol1/root && cp -a /app/data/vol2 /mnt/vol2/root"]
+`)
+	if !bytes.Equal(actual, expected) {
+		t.Logf("actual:\n%s", string(actual))
+		t.Logf("expected:\n%s", string(expected))
+		t.Fail()
+	}
+}
+
+func Test_BuildVolumeInitImageGetDockerfile_Chown(t *testing.T) {
+	actual := buildVolumeInitImageGetDockerfile([]bool{true, false}, defaultVolumeInitShell, &bindMountTarOptions{
+		uid: util.NewInt64(1000),
+		gid: util.NewInt64(1001),
+	})
+	expected := []byte(`ARG BASE_IMAGE
+FROM ${BASE_IMAGE}
+COPY --chown=1000:1001 data1/ /app/data/vol1/
+COPY --chown=1000:1001 data2 /app/data/vol2
+ENTRYPOINT ["/bin/sh", "-c", "cp -a /app/data/vol1 /mnt/vol1/root && cp -a /app/data/vol2 /mnt/vol2/root"]
+`)
+	if !bytes.Equal(actual, expected) {
+		t.Logf("actual:\n%s", string(actual))
+		t.Logf("expected:\n%s", string(expected))
+		t.Fail()
+	}
+}
+
+func Test_BuildVolumeInitImageGetDockerfile_ChownUIDOnly(t *testing.T) {
+	actual := buildVolumeInitImageGetDockerfile([]bool{false}, defaultVolumeInitShell, &bindMountTarOptions{
+		uid: util.NewInt64(1000),
+	})
+	if !bytes.Contains(actual, []byte("COPY --chown=1000 data1 /app/data/vol1\n")) {
+		t.Error(string(actual))
+	}
+}
+
+func Test_BuildVolumeInitImageGetDockerfile_Shell(t *testing.T) {
+	actual := buildVolumeInitImageGetDockerfile([]bool{true}, "/bin/ash", nil)
+	expected := []byte(`ARG BASE_IMAGE
+FROM ${BASE_IMAGE}
+COPY data1/ /app/data/vol1/
+ENTRYPOINT ["/bin/ash", "-c", "cp -a /app/data/vol1 /mnt/vol1/root"]
 `)
 	if !bytes.Equal(actual, expected) {
 		t.Logf("actual:\n%s", string(actual))
@@ -494,24 +677,151 @@ func Test_ResolveBindVolumeHostPath_SuccessAlreadyExists(t *testing.T) {
 	})
 }
 
-func Test_BuildVolumeInitImageGetBuildContext_Success(t *testing.T) {
+func Test_BuildVolumeInitImageWriteBuildContext_Success(t *testing.T) {
 	withMockFS(vfs, func() {
-		_, err := buildVolumeInitImageGetBuildContext([]string{
+		var buf bytes.Buffer
+		err := buildVolumeInitImageWriteBuildContext(&buf, []string{
 			"orig",
-		})
+		}, defaultVolumeInitShell, nil)
 		if err != nil {
 			t.Error(err)
 		}
 	})
 }
 
-func Test_BuildVolumeInitImageGetBuildContext_BindMouseHostFileToTarError(t *testing.T) {
+func Test_BuildVolumeInitImageWriteBuildContext_BindMouseHostFileToTarError(t *testing.T) {
 	withMockFS(vfs, func() {
-		_, err := buildVolumeInitImageGetBuildContext([]string{
+		var buf bytes.Buffer
+		err := buildVolumeInitImageWriteBuildContext(&buf, []string{
 			"origerr",
-		})
+		}, defaultVolumeInitShell, nil)
 		if err == nil {
 			t.Fail()
 		}
 	})
 }
+
+func Test_BuildVolumeInitImageStreamBuildContext_Success(t *testing.T) {
+	withMockFS(vfs, func() {
+		cancelled := false
+		buildContext, errChan := buildVolumeInitImageStreamBuildContext([]string{
+			"orig",
+		}, defaultVolumeInitShell, nil, func() {
+			cancelled = true
+		})
+		defer buildContext.Close()
+		tr := tar.NewReader(buildContext)
+		hasDockerfile := false
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Name == "Dockerfile" {
+				hasDockerfile = true
+			}
+		}
+		if err := <-errChan; err != nil {
+			t.Error(err)
+		}
+		if !hasDockerfile || cancelled {
+			t.Fail()
+		}
+	})
+}
+
+func Test_BuildVolumeInitImageStreamBuildContext_Error(t *testing.T) {
+	withMockFS(vfs, func() {
+		cancelled := false
+		buildContext, errChan := buildVolumeInitImageStreamBuildContext([]string{
+			"origerr",
+		}, defaultVolumeInitShell, nil, func() {
+			cancelled = true
+		})
+		defer buildContext.Close()
+		_, errRead := ioutil.ReadAll(buildContext)
+		err := <-errChan
+		if err == nil || errRead != err || !cancelled {
+			t.Fail()
+		}
+	})
+}
+
+// mockImageBuilder fails without reading the build context, like a docker client that cannot reach the docker daemon.
+type mockImageBuilder struct{}
+
+func (m *mockImageBuilder) ImageBuild(ctx context.Context, buildContext io.Reader, options dockerTypes.ImageBuildOptions) (
+	dockerTypes.ImageBuildResponse, error) {
+	return dockerTypes.ImageBuildResponse{}, errTest
+}
+
+func Test_BuildVolumeInitImage_ImageBuildError(t *testing.T) {
+	withMockFS(vfs, func() {
+		_, err := buildVolumeInitImage(context.Background(), &mockImageBuilder{}, []string{
+			"orig",
+		}, "ubuntu:latest", defaultVolumeInitShell, nil)
+		// The producer of the build context fails with io.ErrClosedPipe, which must not hide the error of the build.
+		if err != errTest {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetVolumeInitBaseImage(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	if image := u.getVolumeInitBaseImage(); image != "" {
+		t.Error(image)
+	}
+	u.cfg.VolumeInitBaseImage = util.NewString("ubuntu:latest")
+	if image := u.getVolumeInitBaseImage(); image != "ubuntu:latest" {
+		t.Error(image)
+	}
+	u.opts.VolumeInitBaseImage = "mirror.example.com/ubuntu:latest"
+	if image := u.getVolumeInitBaseImage(); image != "mirror.example.com/ubuntu:latest" {
+		t.Error(image)
+	}
+}
+
+func TestGetVolumeInitShell(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	if shell := u.getVolumeInitShell(); shell != "/bin/sh" {
+		t.Error(shell)
+	}
+	u.cfg.VolumeInitShell = util.NewString("/bin/bash")
+	if shell := u.getVolumeInitShell(); shell != "/bin/bash" {
+		t.Error(shell)
+	}
+}
+
+func TestGetAppVolumeInitTarOptions(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			Context: context.Background(),
+		},
+	}
+	a := newTestApp("a")
+	opts, err := u.getAppVolumeInitTarOptions(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.uid != nil || opts.gid != nil {
+		t.Fail()
+	}
+	a.composeService.DockerComposeService.User = util.NewString("1000:1001")
+	opts, err = u.getAppVolumeInitTarOptions(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.uid == nil || *opts.uid != 1000 || opts.gid == nil || *opts.gid != 1001 {
+		t.Fail()
+	}
+}
@@ -42,6 +42,12 @@ func ParseUserinfo(userinfoRaw string) (*Userinfo, error) {
 	return r, nil
 }
 
+// IsNumeric returns true if the user and group (if any) are numeric (e.g. "1000" or "1000:1000"), in which case the UID and GID are known
+// without looking up names in /etc/passwd and /etc/group of an image.
+func (r *Userinfo) IsNumeric() bool {
+	return r.UID != nil && (r.Group == "" || r.GID != nil)
+}
+
 func (r *Userinfo) parseUserinfoGroup(userinfoRaw string, i int) error {
 	if i >= 0 {
 		r.Group = userinfoRaw[i+1:]
@@ -41,3 +41,43 @@ func TestParseUserinfo_GIDOutOfRange(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestParseUserinfo_NumericUID(t *testing.T) {
+	user, err := ParseUserinfo("1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UID == nil || *user.UID != 1000 || user.GID != nil || !user.IsNumeric() {
+		t.Error(user)
+	}
+}
+
+func TestParseUserinfo_NumericUIDAndGID(t *testing.T) {
+	user, err := ParseUserinfo("1000:1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UID == nil || *user.UID != 1000 || user.GID == nil || *user.GID != 1001 || !user.IsNumeric() {
+		t.Error(user)
+	}
+}
+
+func TestParseUserinfo_Names(t *testing.T) {
+	user, err := ParseUserinfo("appuser:appgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UID != nil || user.User != "appuser" || user.GID != nil || user.Group != "appgroup" || user.IsNumeric() {
+		t.Error(user)
+	}
+}
+
+func TestParseUserinfo_NumericUIDAndGroupName(t *testing.T) {
+	user, err := ParseUserinfo("1000:appgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsNumeric() {
+		t.Error(user)
+	}
+}
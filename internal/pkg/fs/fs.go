@@ -26,7 +26,11 @@ type VirtualFileSystem interface {
 	Lstat(name string) (os.FileInfo, error)
 	Open(name string) (FileDescriptor, error)
 	Readlink(name string) (string, error)
+	Remove(name string) error
+	RemoveAll(name string) error
 	Stat(name string) (os.FileInfo, error)
+	TempDir(dir, pattern string) (string, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
 }
 
 type osFileSystem struct {
@@ -77,7 +81,10 @@ type InMemoryFileSystem struct {
 	AbsError   error
 	cwd        string
 	GetwdError error
+	// The absolute names of the files removed with Remove and RemoveAll, in order, so that tests can assert cleanup happened.
+	Removed    []string
 	root       *node
+	tempDirSeq int
 }
 
 var (
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+func (fs *osFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (fs *osFileSystem) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+// removeCommon removes the file at name from its parent directory. The last component of name is not resolved if it is a symlink. If all
+// is false then directories must be empty.
+func (fs *InMemoryFileSystem) removeCommon(name string, all bool) error {
+	nameAbs := trimTrailingSlashes(fs.abs(name))
+	slashPos := strings.LastIndexByte(nameAbs, '/')
+	if slashPos < 0 || nameAbs[slashPos+1:] == "" {
+		// Removing the root is not supported.
+		return errBadMode
+	}
+	parentName := nameAbs[:slashPos]
+	if parentName == "" {
+		parentName = "/"
+	}
+	parentN, _, err := fs.find(parentName, false, true)
+	if err != nil {
+		return err
+	}
+	if !parentN.mode.IsDir() {
+		return syscall.ENOTDIR
+	}
+	nameComp := nameAbs[slashPos+1:]
+	dir := parentN.extra.([]*node)
+	for i, n := range dir {
+		if n.name != nameComp {
+			continue
+		}
+		if n.err != nil {
+			return n.err
+		}
+		if !all && n.mode.IsDir() && len(n.extra.([]*node)) > 0 {
+			return syscall.ENOTEMPTY
+		}
+		parentN.extra = append(dir[:i:i], dir[i+1:]...)
+		fs.Removed = append(fs.Removed, nameAbs)
+		return nil
+	}
+	return os.ErrNotExist
+}
+
+// Remove removes the file or empty directory at name, and appends the absolute name to Removed.
+func (fs *InMemoryFileSystem) Remove(name string) error {
+	return fs.removeCommon(name, false)
+}
+
+// RemoveAll removes the file or directory (and its children) at name, and appends the absolute name to Removed. Like os.RemoveAll, nil
+// is returned if name does not exist.
+func (fs *InMemoryFileSystem) RemoveAll(name string) error {
+	err := fs.removeCommon(name, true)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func newRemoveTestFileSystem() *InMemoryFileSystem {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"/dir/file": {
+			Content: []byte("content"),
+		},
+		"/emptydir": {
+			Mode: os.ModeDir,
+		},
+		"/file": {
+			Content: []byte("content"),
+		},
+		"/link": {
+			Content: []byte("dir"),
+			Mode:    os.ModeSymlink,
+		},
+	})
+	return fs
+}
+
+func Test_VirtualFileSystem_Remove_Success(t *testing.T) {
+	fs := newRemoveTestFileSystem()
+	for _, name := range []string{"/file", "/emptydir", "/link"} {
+		err := fs.Remove(name)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		_, err = fs.Lstat(name)
+		if !os.IsNotExist(err) {
+			t.Error(name, err)
+		}
+	}
+	// The target of the removed symlink must still exist.
+	if _, err := fs.Stat("/dir/file"); err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(fs.Removed, []string{"/file", "/emptydir", "/link"}) {
+		t.Error(fs.Removed)
+	}
+}
+
+func Test_VirtualFileSystem_Remove_Errors(t *testing.T) {
+	fs := newRemoveTestFileSystem()
+	if err := fs.Remove("/dir"); err != syscall.ENOTEMPTY {
+		t.Error(err)
+	}
+	if err := fs.Remove("/nonexistent"); !os.IsNotExist(err) {
+		t.Error(err)
+	}
+	if err := fs.Remove("/file/child"); err != syscall.ENOTDIR {
+		t.Error(err)
+	}
+	if err := fs.Remove("/"); err != errBadMode {
+		t.Error(err)
+	}
+	errExpected := fmt.Errorf("injectedFault")
+	fs.Set("/faulty", &InMemoryFile{
+		Error: errExpected,
+	})
+	if err := fs.Remove("/faulty"); err != errExpected {
+		t.Error(err)
+	}
+	if len(fs.Removed) > 0 {
+		t.Error(fs.Removed)
+	}
+}
+
+func Test_VirtualFileSystem_RemoveAll_Success(t *testing.T) {
+	fs := newRemoveTestFileSystem()
+	err := fs.RemoveAll("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.Lstat("/dir/file"); !os.IsNotExist(err) {
+		t.Error(err)
+	}
+	if err = fs.RemoveAll("/nonexistent"); err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(fs.Removed, []string{"/dir"}) {
+		t.Error(fs.Removed)
+	}
+}
+
+func Test_VirtualFileSystem_RemoveAll_Relative(t *testing.T) {
+	fs := newRemoveTestFileSystem()
+	err := fs.RemoveAll("dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fs.Removed, []string{"/dir/file"}) {
+		t.Error(fs.Removed)
+	}
+}
+
+func Test_OSFileSystem_RemoveAll(t *testing.T) {
+	dir, err := OS.TempDir("", "kube-compose-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = OS.WriteFile(dir+string(os.PathSeparator)+"file", []byte("content"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = OS.Remove(dir); err == nil {
+		t.Error("expected error removing non-empty directory")
+	}
+	if err = OS.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = OS.Stat(dir); !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
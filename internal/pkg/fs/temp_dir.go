@@ -0,0 +1,42 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// inMemoryTempDir is the default directory for temporary files of InMemoryFileSystem.
+const inMemoryTempDir = "/tmp"
+
+func (fs *osFileSystem) TempDir(dir, pattern string) (string, error) {
+	return ioutil.TempDir(dir, pattern)
+}
+
+// TempDir creates a new directory in dir (or /tmp if dir is empty) and returns its name, similar to ioutil.TempDir. The name is generated
+// by replacing the last "*" of pattern with a sequence number, or appending the sequence number if pattern does not contain a "*".
+func (fs *InMemoryFileSystem) TempDir(dir, pattern string) (string, error) {
+	if dir == "" {
+		dir = inMemoryTempDir
+		err := fs.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			return "", err
+		}
+	}
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndexByte(pattern, '*'); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for {
+		fs.tempDirSeq++
+		name := trimTrailingSlashes(dir) + "/" + prefix + strconv.Itoa(fs.tempDirSeq) + suffix
+		err := fs.Mkdir(name, 0700)
+		if !os.IsExist(err) {
+			if err != nil {
+				return "", err
+			}
+			return name, nil
+		}
+	}
+}
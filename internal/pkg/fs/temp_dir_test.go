@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_VirtualFileSystem_TempDir_Default(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	name1, err := fs.TempDir("", "kube-compose-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name2, err := fs.TempDir("", "kube-compose-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name1 != "/tmp/kube-compose-1" || name2 != "/tmp/kube-compose-2" {
+		t.Error(name1, name2)
+	}
+	fileInfo, err := fs.Stat(name1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fileInfo.IsDir() {
+		t.Fail()
+	}
+}
+
+func Test_VirtualFileSystem_TempDir_Pattern(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"/work/x-1.d": {
+			Mode: os.ModeDir,
+		},
+	})
+	name, err := fs.TempDir("/work/", "x-*.d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// x-1.d already exists, so the next sequence number is used.
+	if name != "/work/x-2.d" {
+		t.Error(name)
+	}
+}
+
+func Test_VirtualFileSystem_TempDir_ENOENT(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	_, err := fs.TempDir("/nonexistent", "x")
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,51 @@
+package fs
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+func (fs *osFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// WriteFile writes data to the file name, creating it with perm if it does not exist. Like os.WriteFile, the mode of an existing file is
+// not changed.
+func (fs *InMemoryFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if (perm & os.ModeType) != 0 {
+		return errBadMode
+	}
+	n, nameRem, err := fs.find(name, false, true)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := make([]byte, len(data))
+	copy(content, data)
+	if nameRem == "" {
+		// The file exists.
+		if n.mode.IsDir() {
+			return syscall.EISDIR
+		}
+		if !n.mode.IsRegular() {
+			return errBadMode
+		}
+		if n.errOpen != nil {
+			return n.errOpen
+		}
+		n.extra = content
+		return nil
+	}
+	if strings.IndexByte(nameRem, '/') >= 0 {
+		return os.ErrNotExist
+	}
+	if !n.mode.IsDir() {
+		return syscall.ENOTDIR
+	}
+	n.dirAppend(&node{
+		extra: content,
+		mode:  perm,
+		name:  nameRem,
+	})
+	return nil
+}
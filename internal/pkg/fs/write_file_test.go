@@ -0,0 +1,134 @@
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func readFile(t *testing.T, fs VirtualFileSystem, name string) []byte {
+	fd, err := fs.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	data, err := ioutil.ReadAll(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func Test_VirtualFileSystem_WriteFile_RoundTrip(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"/dir": {
+			Mode: os.ModeDir,
+		},
+	})
+	data := []byte("content")
+	err := fs.WriteFile("/dir/file", data, 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Modifying data after the write must not modify the file.
+	data[0] = 'C'
+	if actual := readFile(t, fs, "/dir/file"); string(actual) != "content" {
+		t.Error(string(actual))
+	}
+	fileInfo, err := fs.Stat("/dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileInfo.Mode() != 0640 || fileInfo.Size() != int64(len(data)) {
+		t.Fail()
+	}
+}
+
+func Test_VirtualFileSystem_WriteFile_Overwrite(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"/file": {
+			Content: []byte("old content"),
+			Mode:    0600,
+		},
+	})
+	err := fs.WriteFile("/file", []byte("new"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := readFile(t, fs, "/file"); string(actual) != "new" {
+		t.Error(string(actual))
+	}
+	fileInfo, _ := fs.Stat("/file")
+	if fileInfo.Mode() != 0600 {
+		t.Fail()
+	}
+}
+
+func Test_VirtualFileSystem_WriteFile_Symlink(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"/file": {
+			Content: []byte("old content"),
+		},
+		"/link": {
+			Content: []byte("file"),
+			Mode:    os.ModeSymlink,
+		},
+	})
+	err := fs.WriteFile("/link", []byte("new"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := readFile(t, fs, "/file"); string(actual) != "new" {
+		t.Error(string(actual))
+	}
+}
+
+func Test_VirtualFileSystem_WriteFile_Errors(t *testing.T) {
+	errExpected := fmt.Errorf("injectedOpenError")
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"/dir": {
+			Mode: os.ModeDir,
+		},
+		"/file": {
+			Content: []byte("content"),
+		},
+		"/openerror": {
+			OpenError: errExpected,
+		},
+	})
+	if err := fs.WriteFile("/dir", nil, 0644); err != syscall.EISDIR {
+		t.Error(err)
+	}
+	if err := fs.WriteFile("/file/child", nil, 0644); err != syscall.ENOTDIR {
+		t.Error(err)
+	}
+	if err := fs.WriteFile("/nonexistent/child", nil, 0644); !os.IsNotExist(err) {
+		t.Error(err)
+	}
+	if err := fs.WriteFile("/openerror", nil, 0644); err != errExpected {
+		t.Error(err)
+	}
+	if err := fs.WriteFile("/badmode", nil, os.ModeSymlink); err != errBadMode {
+		t.Error(err)
+	}
+}
+
+func Test_OSFileSystem_WriteFile_RoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kube-compose-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "file")
+	err = OS.WriteFile(name, []byte("content"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := readFile(t, OS, name); !bytes.Equal(actual, []byte("content")) {
+		t.Error(string(actual))
+	}
+}
@@ -0,0 +1,126 @@
+package reporter
+
+// Phase is a step in the life cycle of a service. A service moves through the phases in the order in which they are declared, but can skip
+// phases (e.g. an image that does not need to be pushed skips PhasePushing). A ready service can move back to PhaseStarting when its
+// containers restart. PhaseFailed can be entered from any other phase and is final.
+type Phase int
+
+const (
+	// PhaseResolving is the phase in which the image of a service is inspected (and pulled if needed).
+	PhaseResolving Phase = iota + 1
+	// PhaseBuilding is the phase in which helper images of a service are built (e.g. to initialize bind mounted volumes).
+	PhaseBuilding
+	// PhasePushing is the phase in which images of a service are pushed to the cluster's image storage.
+	PhasePushing
+	// PhaseWaitingForDeps is the phase in which a service waits for the depends_on conditions of the service to be satisfied.
+	PhaseWaitingForDeps
+	// PhaseCreating is the phase in which the Kubernetes resources of a service have been submitted, but its containers are not running.
+	PhaseCreating
+	// PhaseStarting is the phase in which the containers of a service are running, but are not ready.
+	PhaseStarting
+	// PhaseReady is the phase in which the containers of a service are ready.
+	PhaseReady
+	// PhaseCompleted is the phase in which the containers of a service have terminated successfully.
+	PhaseCompleted
+	// PhaseFailed is the phase in which a service could not be deployed, or its containers terminated abnormally.
+	PhaseFailed
+)
+
+var (
+	statusResolving = &Status{
+		Text:      "resolving",
+		TextWidth: 9,
+	}
+	statusBuilding = &Status{
+		Text:      "building image",
+		TextWidth: 14,
+	}
+	statusWaitingForDeps = &Status{
+		Text:      "waiting for deps",
+		TextWidth: 16,
+	}
+	statusCreating = &Status{
+		Text:      "creating",
+		TextWidth: 8,
+	}
+	statusStarting = &Status{
+		Text:      "starting ⭐️", // star
+		TextWidth: 11,
+	}
+	statusFailed = &Status{
+		Text:      "\x1b[31merror\x1b[0m 💣💣", // bomb+bomb
+		TextWidth: 10,
+	}
+)
+
+var phaseNames = map[Phase]string{
+	PhaseResolving:      "Resolving",
+	PhaseBuilding:       "Building",
+	PhasePushing:        "Pushing",
+	PhaseWaitingForDeps: "WaitingForDeps",
+	PhaseCreating:       "Creating",
+	PhaseStarting:       "Starting",
+	PhaseReady:          "Ready",
+	PhaseCompleted:      "Completed",
+	PhaseFailed:         "Failed",
+}
+
+func (p Phase) String() string {
+	if name, ok := phaseNames[p]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// CanTransitionTo returns true if and only if a service in phase p can move to phase to. The zero Phase represents a service for which no
+// phase has been reported yet. Transitions are forward only, except that PhaseReady can move back to PhaseStarting (e.g. when a container
+// restarts because its liveness probe failed).
+func (p Phase) CanTransitionTo(to Phase) bool {
+	if _, ok := phaseNames[to]; !ok {
+		return false
+	}
+	return to > p || (p == PhaseReady && to == PhaseStarting)
+}
+
+func (p Phase) status() *Status {
+	switch p {
+	case PhaseResolving:
+		return statusResolving
+	case PhaseBuilding:
+		return statusBuilding
+	case PhasePushing:
+		return StatusDockerPush
+	case PhaseWaitingForDeps:
+		return statusWaitingForDeps
+	case PhaseCreating:
+		return statusCreating
+	case PhaseStarting:
+		return statusStarting
+	case PhaseReady:
+		return StatusReady
+	case PhaseCompleted:
+		return StatusCompleted
+	case PhaseFailed:
+		return statusFailed
+	}
+	return StatusWaiting
+}
+
+// Phase returns the current phase of the row, or zero if no phase has been reported.
+func (row *Row) Phase() Phase {
+	row.r.mutex.Lock()
+	defer row.r.mutex.Unlock()
+	return row.phase
+}
+
+// SetPhase moves the row to phase p, if this is a valid transition (see CanTransitionTo). Returns true if and only if the phase of the row
+// was changed.
+func (row *Row) SetPhase(p Phase) bool {
+	row.r.mutex.Lock()
+	defer row.r.mutex.Unlock()
+	if !row.phase.CanTransitionTo(p) {
+		return false
+	}
+	row.phase = p
+	return true
+}
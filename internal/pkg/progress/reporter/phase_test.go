@@ -0,0 +1,57 @@
+package reporter
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_Phase_String(t *testing.T) {
+	if PhaseWaitingForDeps.String() != "WaitingForDeps" || Phase(0).String() != "Unknown" {
+		t.Fail()
+	}
+}
+
+func Test_Phase_CanTransitionTo(t *testing.T) {
+	if !Phase(0).CanTransitionTo(PhaseResolving) {
+		t.Fail()
+	}
+	if !PhaseResolving.CanTransitionTo(PhaseCreating) {
+		t.Fail()
+	}
+	if PhaseCreating.CanTransitionTo(PhasePushing) {
+		t.Fail()
+	}
+	if !PhaseReady.CanTransitionTo(PhaseFailed) || !PhaseReady.CanTransitionTo(PhaseStarting) {
+		t.Fail()
+	}
+	if PhaseCompleted.CanTransitionTo(PhaseStarting) || PhaseStarting.CanTransitionTo(PhaseStarting) {
+		t.Fail()
+	}
+	if PhaseFailed.CanTransitionTo(PhaseFailed) || PhaseFailed.CanTransitionTo(Phase(100)) {
+		t.Fail()
+	}
+}
+
+func Test_Row_SetPhase(t *testing.T) {
+	r := New(os.Stdout)
+	row := r.AddRow("asdf")
+	if !row.SetPhase(PhasePushing) || row.Phase() != PhasePushing {
+		t.Fail()
+	}
+	if row.SetPhase(PhaseResolving) || row.Phase() != PhasePushing {
+		t.Fail()
+	}
+	if row.status() != StatusDockerPush {
+		t.Fail()
+	}
+}
+
+func Test_Row_Status_StatusOverridesPhase(t *testing.T) {
+	r := New(os.Stdout)
+	row := r.AddRow("asdf")
+	row.SetPhase(PhaseStarting)
+	row.AddStatus(StatusDockerPull)
+	if row.status() != StatusDockerPull {
+		t.Fail()
+	}
+}
@@ -0,0 +1,48 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+)
+
+var ansiEscapeRegexp = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// NewPlain creates a reporter that renders progress as plain text lines instead of a table that is redrawn with ANSI escape sequences.
+// Each refresh appends one line for every row whose status changed since the previous refresh, so that out can be a file that keeps a
+// record of the progress (e.g. in CI).
+func NewPlain(out io.Writer) *Reporter {
+	r := New(out)
+	r.plain = true
+	return r
+}
+
+// IsPlain returns true if and only if the reporter was created with NewPlain.
+func (r *Reporter) IsPlain() bool {
+	return r.plain
+}
+
+func (r *Reporter) refreshPlain() {
+	defer func() {
+		if v := recover(); v != nil {
+			if writeError, ok := v.(*writeError); ok {
+				fmt.Fprintln(os.Stderr, writeError.Error)
+			} else {
+				panic(v)
+			}
+		}
+	}()
+	r.buffer.Reset()
+	for _, row := range r.rows {
+		text := ansiEscapeRegexp.ReplaceAllString(row.status().Text, "")
+		if text == row.lastPlainStatus {
+			continue
+		}
+		row.lastPlainStatus = text
+		r.writef("%s %s: %s\n", nowFunction().Format(time.RFC3339), row.name, text)
+	}
+	r.flush()
+	r.flushLogs()
+}
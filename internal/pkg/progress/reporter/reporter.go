@@ -20,6 +20,7 @@ const (
 
 var (
 	isTerminalFunction      = IsTerminal
+	nowFunction             = time.Now
 	getTerminalSizeFunction = GetTerminalSize
 	progressBarChars        = []string{
 		" ",
@@ -78,6 +79,7 @@ type Reporter struct {
 	logBuffer           *bytes.Buffer
 	logLines            int
 	logWriter           io.Writer
+	plain               bool
 	rows                []*Row
 	out                 io.Writer
 }
@@ -149,12 +151,18 @@ func (r *Reporter) LogErrorSink() io.Writer {
 	return r.logWriter
 }
 
+// Refresh renders the progress to the output of the reporter. Unless the reporter was created with NewPlain, progress is only rendered if
+// the output is a terminal.
 func (r *Reporter) Refresh() {
-	if !r.isTerminal {
+	if !r.plain && !r.isTerminal {
 		return
 	}
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
+	if r.plain {
+		r.refreshPlain()
+		return
+	}
 	r.refresh()
 }
 
@@ -430,10 +438,12 @@ func (r *Reporter) writeCmd(s string) {
 }
 
 type Row struct {
-	name     string
-	r        *Reporter
-	tasks    []*ProgressTask
-	statuses []*Status
+	lastPlainStatus string
+	name            string
+	phase           Phase
+	r               *Reporter
+	tasks           []*ProgressTask
+	statuses        []*Status
 }
 
 func (row *Row) AddProgressTask(name string) *ProgressTask {
@@ -484,11 +494,12 @@ func (row *Row) RemoveStatus(s *Status) bool {
 	return false
 }
 
+// status returns the status to render for the row. Statuses added with AddStatus take precedence over the phase of the row.
 func (row *Row) status() *Status {
 	if len(row.statuses) > 0 {
 		return row.statuses[len(row.statuses)-1]
 	}
-	return StatusWaiting
+	return row.phase.status()
 }
 
 func (row *Row) statusBinarySearch(priority int) int {
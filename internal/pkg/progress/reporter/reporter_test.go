@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_Reporter_AddRow_Success(t *testing.T) {
@@ -494,3 +495,26 @@ func mockGetTerminalSize(w io.Writer) (width, height int, err error) {
 	height = term.height
 	return
 }
+
+func Test_Reporter_Refresh_PlainSuccess(t *testing.T) {
+	nowFunctionOrig := nowFunction
+	defer func() {
+		nowFunction = nowFunctionOrig
+	}()
+	nowFunction = func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	buffer := bytes.NewBuffer([]byte{})
+	r := NewPlain(buffer)
+	if !r.IsPlain() {
+		t.Fail()
+	}
+	row := r.AddRow("db")
+	row.SetPhase(PhaseFailed)
+	r.Refresh()
+	r.Refresh()
+	expected := "2020-01-02T03:04:05Z db: error 💣💣\n"
+	if buffer.String() != expected {
+		t.Errorf("%#v\n", buffer.String())
+	}
+}
@@ -10,10 +10,10 @@ import (
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 )
 
+const EtcGroup = "/etc/group"
 const EtcPasswd = "/etc/passwd"
 
-// FindUIDByNameInPasswd finds the UID of a user by name in an /etc/passwd file. It can also find the GID of a group by name in an
-// /etc/group file.
+// FindUIDByNameInPasswd finds the UID of a user by name in an /etc/passwd file.
 func FindUIDByNameInPasswd(file, user string) (*int64, error) {
 	uid := new(int64)
 	err := findCommon(file, findUIDByNameCallback(user, uid))
@@ -23,8 +23,7 @@ func FindUIDByNameInPasswd(file, user string) (*int64, error) {
 	return uid, nil
 }
 
-// FindUIDByNameInPasswdReader finds the UID of a user by name in a stream encoded like the contents of /etc/passwd. It can also find the
-// GID of a group by name in a stream encoded like the contents of /etc/group.
+// FindUIDByNameInPasswdReader finds the UID of a user by name in a stream encoded like the contents of /etc/passwd.
 func FindUIDByNameInPasswdReader(reader io.Reader, user string) (*int64, error) {
 	uid := new(int64)
 	err := findCommonReader(reader, findUIDByNameCallback(user, uid))
@@ -34,6 +33,28 @@ func FindUIDByNameInPasswdReader(reader io.Reader, user string) (*int64, error)
 	return uid, nil
 }
 
+// FindGIDByNameInGroup finds the GID of a group by name in an /etc/group file. Returns nil if the file does not have an entry for the
+// group.
+func FindGIDByNameInGroup(file, group string) (*int64, error) {
+	var gid *int64
+	err := findCommon(file, findGIDByNameCallback(group, &gid))
+	if err != nil {
+		return nil, err
+	}
+	return gid, nil
+}
+
+// FindGIDByNameInGroupReader finds the GID of a group by name in a stream encoded like the contents of /etc/group. Returns nil if the
+// stream does not have an entry for the group.
+func FindGIDByNameInGroupReader(reader io.Reader, group string) (*int64, error) {
+	var gid *int64
+	err := findCommonReader(reader, findGIDByNameCallback(group, &gid))
+	if err != nil {
+		return nil, err
+	}
+	return gid, nil
+}
+
 // FindHomeByUIDInPasswd finds the home directory of a user by the user's uid in an /etc/passwd file.
 func FindHomeByUIDInPasswd(file string, uid int64) (string, error) {
 	var home string
@@ -101,6 +122,26 @@ func findUIDByNameCallback(user string, uid *int64) findCommonCallback {
 	}
 }
 
+// findGIDByNameCallback parses lines of the group file format: the group name, the password, the GID and a comma separated list of
+// members (e.g. "docker:x:999:alice,bob").
+func findGIDByNameCallback(group string, gid **int64) findCommonCallback {
+	return func(line string) error {
+		parts := strings.Split(line, ":")
+		if parts[0] != group {
+			return nil
+		}
+		if len(parts) != 4 {
+			return errUnexpectedFileFormat
+		}
+		gidLocal := util.TryParseInt64(parts[2])
+		if gidLocal == nil || *gidLocal < 0 {
+			return errUnexpectedFileFormat
+		}
+		*gid = gidLocal
+		return errFindCommonBreak
+	}
+}
+
 type findCommonCallback = func(line string) error
 
 var errFindCommonBreak = fmt.Errorf("")
@@ -8,6 +8,9 @@ import (
 )
 
 var vfs fs.VirtualFileSystem = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+	EtcGroup: {
+		Content: []byte("root:x:0:\ndaemon:x:1:\nadm:x:4:syslog,alice\nsudo:x:27:alice\ndocker:x:999:alice,bob\nusers:x:100:\n"),
+	},
 	EtcPasswd: {
 		Content: []byte("root:x:0:\ndaemon:x:1:1:daemon:/daemonhomelol\nasdf\nuiderr:x::"),
 	},
@@ -95,3 +98,52 @@ func TestFindHomeByNameInPasswd_Success(t *testing.T) {
 		}
 	})
 }
+
+func TestFindGIDByNameInGroup_Success(t *testing.T) {
+	withMockFS(func() {
+		gid, err := FindGIDByNameInGroup(EtcGroup, "docker")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gid == nil || *gid != 999 {
+			t.Error(gid)
+		}
+		gid, err = FindGIDByNameInGroup(EtcGroup, "users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gid == nil || *gid != 100 {
+			t.Error(gid)
+		}
+	})
+}
+
+func TestFindGIDByNameInGroup_MemberIsNotAGroup(t *testing.T) {
+	withMockFS(func() {
+		gid, err := FindGIDByNameInGroup(EtcGroup, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gid != nil {
+			t.Error(*gid)
+		}
+	})
+}
+
+func TestFindGIDByNameInGroup_ENOENT(t *testing.T) {
+	withMockFS(func() {
+		_, err := FindGIDByNameInGroup("/asdf", "docker")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestFindGIDByNameInGroupReader_InvalidFormat(t *testing.T) {
+	for _, content := range []string{"docker:x:-1:", "docker:x:abc:", "docker:x:999"} {
+		_, err := FindGIDByNameInGroupReader(strings.NewReader(content), "docker")
+		if err == nil {
+			t.Error(content)
+		}
+	}
+}
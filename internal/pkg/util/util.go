@@ -50,6 +50,20 @@ func NewBool(v bool) *bool {
 	return vp
 }
 
+// NewInt32 allocates an int32 and initializes it to v.
+func NewInt32(v int32) *int32 {
+	vp := new(int32)
+	*vp = v
+	return vp
+}
+
+// NewInt64 allocates an int64 and initializes it to v.
+func NewInt64(v int64) *int64 {
+	vp := new(int64)
+	*vp = v
+	return vp
+}
+
 // NewString allocates a string and initializes it to v.
 func NewString(v string) *string {
 	vp := new(string)
@@ -76,8 +90,11 @@ func decodeBase36(b int) int {
 	return -1
 }
 
-// EscapeName takes an arbitrary string and maps it bijectively to the grammar '^[a-z0-9]([-a-z0-9]*[a-z0-9])?$'.
+// EscapeName takes an arbitrary non-empty string and maps it bijectively to the grammar '^[a-z0-9]([-a-z0-9]*[a-z0-9])?$'.
 // This is useful when creating Kubernetes resources.
+// Bytes [a-z0-8] and '-' (except at the start and end) are kept as is. Every other byte b (including '9') is encoded as '9' followed by
+// the base 36 digits b/36 and b%36, using the alphabet [a-z0-9]. Because b/36 <= 7 every byte fits in two digits, and because a literal
+// '9' is always escaped, UnescapeName can recover the input unambiguously.
 func EscapeName(input string) string {
 	n := len(input)
 	var sb strings.Builder
@@ -160,7 +177,7 @@ func FormatTable(rows [][]string) string {
 	for _, row := range rows {
 		for column, value := range row {
 			if column+1 >= len(maxValueWidthPerColumn) {
-				_, _ = fmt.Fprintf(&sb, value)
+				sb.WriteString(value)
 			} else {
 				_, _ = fmt.Fprintf(&sb, fmt.Sprintf("%%-%ds  ", maxValueWidthPerColumn[column]), value)
 			}
@@ -177,6 +194,43 @@ func Ternary(truthy string, otherwise string) string {
 	return otherwise
 }
 
+// AnsiColorPalette are the ANSI colors of docker compose services, e.g. of the prefixes of their log lines. This doesn't deserve the
+// name palette.
+var AnsiColorPalette = []string{
+	//"0;37", // gray -- skip: not colored
+	//"0;36", // cyan -- skip: same as INFO
+	"0;35", // magenta
+	"0;34", // blue
+	"0;33", // yellow
+	"0;32", // green
+	"0;31", // red
+
+	"1;37", // bright gray
+	"1;36", // bright cyan
+	"1;35", // bright magenta
+	"1;34", // bright blue
+	"1;33", // bright yellow
+	"1;32", // bright green
+	"1;31", // bright red
+}
+
+// colorsEnabled is false if output must not contain ANSI escape codes (see SetColorsEnabled).
+var colorsEnabled = true
+
+// SetColorsEnabled sets whether AnsiColorWrap emits ANSI escape codes, e.g. to disable colors when output is not a terminal.
+func SetColorsEnabled(enabled bool) {
+	colorsEnabled = enabled
+}
+
+// ColorsEnabled returns whether AnsiColorWrap emits ANSI escape codes.
+func ColorsEnabled() bool {
+	return colorsEnabled
+}
+
+// AnsiColorWrap wraps s in the ANSI escape codes before and after, unless colors are disabled, in which case s is returned as is.
 func AnsiColorWrap(s, before, after string) string {
+	if !colorsEnabled {
+		return s
+	}
 	return fmt.Sprintf("\x1b[%sm%s\x1b[%sm", before, s, after)
 }
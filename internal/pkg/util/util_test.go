@@ -2,7 +2,9 @@ package util
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
+	"regexp"
 	"testing"
 )
 
@@ -73,6 +75,58 @@ func TestEscapeName_Success2(t *testing.T) {
 	}
 }
 
+var escapedNameRegexp = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+
+func assertEscapeNameRoundTrip(t *testing.T, input string) {
+	escaped := EscapeName(input)
+	if !escapedNameRegexp.MatchString(escaped) {
+		t.Errorf("escaped name %q of %q does not match the grammar", escaped, input)
+	}
+	unescaped, err := UnescapeName(escaped)
+	if err != nil {
+		t.Errorf("unescaping %q: %v", escaped, err)
+	} else if unescaped != input {
+		t.Errorf("%q round-tripped to %q", input, unescaped)
+	}
+}
+
+func TestEscapeName_RoundTripAllBytes(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		b := string([]byte{byte(i)})
+		// Escaping of '-' depends on the position, so test each byte at the start, in the middle and at the end.
+		assertEscapeNameRoundTrip(t, b)
+		assertEscapeNameRoundTrip(t, b+"a")
+		assertEscapeNameRoundTrip(t, "a"+b+"a")
+		assertEscapeNameRoundTrip(t, "a"+b)
+		assertEscapeNameRoundTrip(t, b+b+b)
+	}
+}
+
+func TestEscapeName_RoundTripRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		input := make([]byte, 1+r.Intn(32))
+		for j := range input {
+			input[j] = byte(r.Intn(256))
+		}
+		assertEscapeNameRoundTrip(t, string(input))
+	}
+}
+
+func TestEscapeName_Injective(t *testing.T) {
+	seen := map[string]string{}
+	for i := 0; i < 256; i++ {
+		for j := 0; j < 256; j++ {
+			input := string([]byte{byte(i), byte(j)})
+			escaped := EscapeName(input)
+			if other, ok := seen[escaped]; ok {
+				t.Fatalf("%q and %q both escape to %q", other, input, escaped)
+			}
+			seen[escaped] = input
+		}
+	}
+}
+
 func TestTryParseInt64_Error(t *testing.T) {
 	uid := TryParseInt64("asdf")
 	if uid != nil {
@@ -95,6 +149,17 @@ func TestFormatTable(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestFormatTable_PercentSigns(t *testing.T) {
+	output := FormatTable([][]string{
+		{"NAME%s", "VALUE"},
+		{"cpu", "100%"},
+		{"url", "a%20b%s"},
+	})
+	if output != "NAME%s  VALUE\ncpu     100%\nurl     a%20b%s\n" {
+		t.Error(output)
+	}
+}
 func TestUnescapeName_Success(t *testing.T) {
 	r, err := UnescapeName("9aa9bv0a9dp9a7")
 	if r != "\x00\x390a\x7B!" || err != nil {
@@ -162,3 +227,17 @@ func Test_Ternary_Otherwise(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_AnsiColorWrap_Enabled(t *testing.T) {
+	if AnsiColorWrap("a", "31", "0") != "\x1b[31ma\x1b[0m" {
+		t.Fail()
+	}
+}
+
+func Test_AnsiColorWrap_Disabled(t *testing.T) {
+	SetColorsEnabled(false)
+	defer SetColorsEnabled(true)
+	if ColorsEnabled() || AnsiColorWrap("a", "31", "0") != "a" {
+		t.Fail()
+	}
+}
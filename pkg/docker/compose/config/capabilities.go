@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var capabilityRegexp = regexp.MustCompile("^[A-Z][A-Z0-9_]*$")
+
+// parseCapabilities normalizes each element of a cap_add or cap_drop list to the name of a Linux capability without the CAP_ prefix (e.g.
+// "CAP_NET_ADMIN" and "net_admin" both become "NET_ADMIN"), which is the form that Kubernetes expects. Duplicates are removed. Names are
+// only checked loosely, so that capabilities that are newer than kube-compose are accepted.
+func parseCapabilities(key string, caps []string) ([]string, error) {
+	var result []string
+	for _, capability := range caps {
+		name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(capability)), "CAP_")
+		if !capabilityRegexp.MatchString(name) {
+			return nil, fmt.Errorf("%s contains an invalid capability: %#v", key, capability)
+		}
+		result = addCapability(result, name)
+	}
+	return result, nil
+}
+
+func addCapability(caps []string, name string) []string {
+	for _, existing := range caps {
+		if existing == name {
+			return caps
+		}
+	}
+	return append(caps, name)
+}
+
+// mergeCapabilities returns the union of into and from, like docker compose merges cap_add and cap_drop.
+func mergeCapabilities(into, from []string) []string {
+	for _, name := range from {
+		into = addCapability(into, name)
+	}
+	return into
+}
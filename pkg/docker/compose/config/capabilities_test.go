@@ -0,0 +1,32 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCapabilities_Success(t *testing.T) {
+	caps, err := parseCapabilities("cap_add", []string{"CAP_NET_ADMIN", "sys_time", "NET_ADMIN", "ALL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(caps, []string{"NET_ADMIN", "SYS_TIME", "ALL"}) {
+		t.Error(caps)
+	}
+}
+
+func TestParseCapabilities_Invalid(t *testing.T) {
+	for _, capability := range []string{"", "CAP_", "NET ADMIN", "NET-ADMIN"} {
+		_, err := parseCapabilities("cap_drop", []string{capability})
+		if err == nil {
+			t.Error(capability)
+		}
+	}
+}
+
+func TestMergeCapabilities(t *testing.T) {
+	caps := mergeCapabilities([]string{"NET_ADMIN"}, []string{"SYS_TIME", "NET_ADMIN"})
+	if !reflect.DeepEqual(caps, []string{"NET_ADMIN", "SYS_TIME"}) {
+		t.Error(caps)
+	}
+}
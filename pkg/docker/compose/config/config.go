@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
@@ -29,67 +31,150 @@ type genericMap map[interface{}]interface{}
 // This type is used to represent extension fields, see https://docs.docker.com/compose/compose-file/#extension-fields.
 type XProperties map[string]interface{}
 
+// Decode decodes the extension field key into v (a pointer to a struct with mapdecode tags), so that tools can read their own extension
+// field (e.g. x-kube-compose) as a typed value. Keys of the extension field that v does not have are ignored, so that extension fields can
+// be extended without breaking older versions of tools. Returns false if the extension field is not set, in which case v is untouched.
+func (x XProperties) Decode(key string, v interface{}) (bool, error) {
+	value, ok := x[key]
+	if !ok {
+		return false, nil
+	}
+	return true, mapdecode.Decode(v, value, mapdecode.IgnoreUnused(true))
+}
+
 // CanonicalDockerComposeConfig is a canonical representation of docker compose configuration.
 // It represents one ore more docker compose files that have been merged together using logic close to docker compose.
 // Similarly, extends will have been processed as well (see https://docs.docker.com/compose/compose-file/compose-file-v2/#extends).
 type CanonicalDockerComposeConfig struct {
-	Services map[string]*Service
+	// The top-level configs section.
+	Configs map[string]*ComposeConfig
+	// The absolute path of the directory of the first docker compose file, which docker compose calls the project directory.
+	ProjectDir string
+	// The top-level secrets section.
+	Secrets map[string]*Secret
+	// The names of the services in the order in which they are declared in the docker compose files.
+	ServiceOrder []string
+	Services     map[string]*Service
 	// For each docker compose file that was merged together, the root level x- properties as a generic map.
 	// Givens elements e_i and e_j of the slice, with indices i and j, respectively, such that i > j, XProperties e_i have a higher priority
 	// than XProperties e_j. Intuitively, elements later in the list take precedence over those earlier in the list.
 	// The user of this package can choose to implement merging of XProperties as appropriate.
 	XProperties []XProperties
+	// The names of the variables that were substituted with the empty string because they are not set, in sorted order. Docker compose
+	// warns about these.
+	UnsetVariables []string
 }
 
 // Service is the final representation of a docker-compose service, after all docker compose files have been merged. Service
 // is a smaller piece of CanonicalDockerComposeConfig.
 type Service struct {
 	// When adding a field here, please update merge.go with the logic required to merge these fields.
+	// The Linux capabilities of cap_add and cap_drop, without the CAP_ prefix (e.g. NET_ADMIN).
+	CapAdd  []string
+	CapDrop []string
 	Command []string
+	Configs []ServiceConfig
 	// TODO https://github.com/kube-compose/kube-compose/issues/214 consider simplifying to map[string]ServiceHealthiness
-	DependsOn           map[string]ServiceHealthiness
-	Entrypoint          []string
+	DependsOn map[string]ServiceHealthiness
+	// The names of the services in DependsOn whose dependency sets restart: true. This is parsed but not acted upon.
+	DependsOnRestart map[string]bool
+	Deploy           *Deploy
+	DNSOptions       []DNSOption
+	// The IP addresses of the DNS servers of the dns field.
+	DNSServers []string
+	DNSSearch  []string
+	Entrypoint []string
+	// The absolute paths of the env_file files. Their variables have already been merged into Environment.
+	EnvFile             []string
 	Environment         map[string]string
+	Expose              []PortBinding
+	ExternalLinks       []ExternalLink
+	ExtraHosts          []ExtraHost
 	Healthcheck         *Healthcheck
 	HealthcheckDisabled bool
 	Image               string
+	Labels              map[string]string
 	Name                string
+	Networks            []ServiceNetwork
 	Ports               []PortBinding
 	Privileged          bool
-	Restart             string
-	User                *string
-	Volumes             []ServiceVolume
-	WorkingDir          string
+	// The profiles that enable the service (see https://docs.docker.com/compose/profiles/). Empty if the service is always enabled.
+	Profiles []string
+	ReadOnly bool
+	Restart  string
+	Secrets  []ServiceSecret
+	// Nil if stop_grace_period is not set.
+	StopGracePeriod *time.Duration
+	Tmpfs           []TmpfsMount
+	// The keys of the service that are not translated to Kubernetes and are ignored, sorted by key.
+	UnsupportedKeys []UnsupportedKey
+	User            *string
+	Volumes         []ServiceVolume
+	WorkingDir      string
+	// The x- properties of the service (see https://docs.docker.com/compose/compose-file/#extension-fields). When merging, the x-
+	// properties of the file with the highest priority win per property.
+	XProperties XProperties
 }
 
 // serviceInternal is a helper struct that is a smaller piece of dockerComposeFile.
 // TODO https://github.com/kube-compose/kube-compose/issues/211 merge with composeFileService struct
 type serviceInternal struct {
-	// TODO https://github.com/kube-compose/kube-compose/issues/153 interpret string command/entrypoint correctly
-	Command   *stringOrStringSlice `mapdecode:"command"`
-	DependsOn *dependsOn           `mapdecode:"depends_on"`
-	// TODO https://github.com/kube-compose/kube-compose/issues/153 interpret string command/entrypoint correctly
-	Entrypoint        *stringOrStringSlice `mapdecode:"entrypoint"`
-	Environment       *environment         `mapdecode:"environment"`
-	environmentParsed map[string]string
-	Extends           *extends `mapdecode:"extends"`
+	CapAdd              []string `mapdecode:"cap_add"`
+	capAddParsed        []string
+	CapDrop             []string `mapdecode:"cap_drop"`
+	capDropParsed       []string
+	Command             *stringOrStringSlice `mapdecode:"command"`
+	Configs             []ServiceConfig      `mapdecode:"configs"`
+	DependsOn           *dependsOn           `mapdecode:"depends_on"`
+	Deploy              *deployInternal      `mapdecode:"deploy"`
+	DNS                 *stringOrStringSlice `mapdecode:"dns"`
+	dnsServersParsed    []string
+	DNSOpt              []string `mapdecode:"dns_opt"`
+	dnsOptionsParsed    []DNSOption
+	DNSSearch           *stringOrStringSlice `mapdecode:"dns_search"`
+	dnsSearchParsed     []string
+	Entrypoint          *stringOrStringSlice `mapdecode:"entrypoint"`
+	EnvFile             *stringOrStringSlice `mapdecode:"env_file"`
+	Environment         *environment         `mapdecode:"environment"`
+	environmentParsed   map[string]string
+	Expose              []port `mapdecode:"expose"`
+	exposeParsed        []PortBinding
+	Extends             *extends `mapdecode:"extends"`
+	ExternalLinks       []string `mapdecode:"external_links"`
+	externalLinksParsed []ExternalLink
+	ExtraHosts          *extraHosts `mapdecode:"extra_hosts"`
+	extraHostsParsed    []ExtraHost
 	// The final docker compose service in CanonicalDockerComposeConfig (only set if this is not an intermediate result).
 	finalService *Service
 	Healthcheck  *healthcheckInternal `mapdecode:"healthcheck"`
 	Image        *string              `mapdecode:"image"`
+	Labels       *environment         `mapdecode:"labels"`
+	labelsParsed map[string]string
 	// Convenient copy of the name so that we do not have to pass names around to preserve context.
-	name        string
-	Ports       []port `mapdecode:"ports"`
-	portsParsed []PortBinding
-	Privileged  *bool `mapdecode:"privileged"`
+	name           string
+	Networks       *serviceNetworks `mapdecode:"networks"`
+	networksParsed []ServiceNetwork
+	Ports          []port `mapdecode:"ports"`
+	portsParsed    []PortBinding
+	Privileged     *bool    `mapdecode:"privileged"`
+	Profiles       []string `mapdecode:"profiles"`
+	profilesParsed []string
+	ReadOnly       *bool `mapdecode:"read_only"`
 	// Helper data used to detect cycles during process of extends and depends_on.
-	recStack bool
-	Restart  *string `mapdecode:"restart"`
-	User     *string `mapdecode:"user"`
+	recStack        bool
+	Restart         *string              `mapdecode:"restart"`
+	Secrets         []ServiceSecret      `mapdecode:"secrets"`
+	StopGracePeriod *string              `mapdecode:"stop_grace_period"`
+	Tmpfs           *stringOrStringSlice `mapdecode:"tmpfs"`
+	tmpfsParsed     []TmpfsMount
+	// Keys that are not translated (see unsupportedServiceKeys).
+	unsupportedKeys []UnsupportedKey
+	User            *string `mapdecode:"user"`
 	// Helper data used to detect cycles during process of extends and depends_on.
-	visited    bool
-	Volumes    []ServiceVolume `mapdecode:"volumes"`
-	WorkingDir *string         `mapdecode:"working_dir"`
+	visited     bool
+	Volumes     []ServiceVolume `mapdecode:"volumes"`
+	WorkingDir  *string         `mapdecode:"working_dir"`
+	xProperties XProperties
 }
 
 // A helper for defer
@@ -101,8 +186,12 @@ func (c *serviceInternal) clearRecStack() {
 // of the docker compose configuration.
 // TODO https://github.com/kube-compose/kube-compose/issues/211 merge with composeFile struct
 type dockerComposeFile struct {
+	Configs  map[string]*configInternal  `mapdecode:"configs"`
+	Secrets  map[string]*secretInternal  `mapdecode:"secrets"`
 	Services map[string]*serviceInternal `mapdecode:"services"`
-	version  *version.Version
+	// The names of the services in the order in which they are declared.
+	serviceOrder []string
+	version      *version.Version
 	// Extension fields at the root of the compose file represented by this struct.
 	xProperties XProperties
 	// The resolved file that contains the docker compose file represented by this struct.
@@ -122,6 +211,15 @@ type configLoader struct {
 	// A cache required to detect cycles when processing extends. Additionally, each file is only
 	// processed once so that loading of configuration is faster.
 	loadResolvedFileCache map[string]*loadResolvedFileCacheItem
+	// The set of names of variables that were substituted with the empty string because they are not set.
+	unsetVariables map[string]bool
+}
+
+func (c *configLoader) addUnsetVariable(name string) {
+	if c.unsetVariables == nil {
+		c.unsetVariables = map[string]bool{}
+	}
+	c.unsetVariables[name] = true
 }
 
 // loadFile loads the specified file. If the file has already been loaded then a cache lookup is performed.
@@ -167,7 +265,8 @@ func (c *configLoader) loadResolvedFile(resolvedFile string) (*dockerComposeFile
 	return cacheItem.parsed, cacheItem.err
 }
 
-// loadYamlFileAsGenericMap is a helper used to YAML decode a file into a map[interface{}]interface{}.
+// loadYamlFileAsGenericMap is a helper used to YAML decode a file into a map[interface{}]interface{}. The integer modes of secrets and
+// configs of services are decoded as a rawFileMode.
 func loadYamlFileAsGenericMap(file string) (genericMap, error) {
 	reader, err := fs.OS.Open(file)
 	if err != nil {
@@ -175,9 +274,9 @@ func loadYamlFileAsGenericMap(file string) (genericMap, error) {
 	}
 	defer util.CloseAndLogError(reader)
 	decoder := yaml.NewDecoder(reader)
-	var dataMap genericMap
-	err = decoder.Decode(&dataMap)
-	return dataMap, err
+	var root yamlFileRoot
+	err = decoder.Decode(&root)
+	return root.dataMap, err
 }
 
 // loadResolvedFileCore loads a docker compose file, and does any validation/canonicalization that does not require
@@ -199,7 +298,7 @@ func (c *configLoader) loadResolvedFileCore(resolvedFile string, dcFile *dockerC
 	}
 
 	// Substitute variables with environment variables.
-	err = InterpolateConfig(dataMap, c.environmentGetter, dcFile.version)
+	err = interpolateConfig(dataMap, c.environmentGetter, dcFile.version, c.addUnsetVariable)
 	if err != nil {
 		return err
 	}
@@ -217,6 +316,19 @@ func (c *configLoader) loadResolvedFileCore(resolvedFile string, dcFile *dockerC
 	if err != nil {
 		return err
 	}
+	if servicesMap, ok := asGenericMap(dataMap["services"]); ok {
+		for name, s := range dcFile.Services {
+			s.xProperties = getXProperties(servicesMap[name])
+			s.unsupportedKeys, err = getUnsupportedKeys(name, servicesMap[name])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	dcFile.serviceOrder, err = loadYamlFileServiceOrder(resolvedFile, dcFile.version.Equal(v1))
+	if err != nil {
+		return err
+	}
 
 	// validation after parsing
 	return c.parseDockerComposeFile(dcFile)
@@ -259,6 +371,20 @@ func (c *configLoader) loadStandardFiles() ([]string, error) {
 	return nil, fmt.Errorf("could not find file docker-compose.yml or docker-compose.yaml in (parents of) the current directory %#v", cwd)
 }
 
+// getProjectDir returns the absolute path of the directory of resolvedFile, interpreting relative paths relative to the current working
+// directory.
+func getProjectDir(resolvedFile string) (string, error) {
+	dir := filepath.Dir(resolvedFile)
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	cwd, err := fs.OS.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, dir), nil
+}
+
 func (c *configLoader) loadStandardFilesTry(dir, resolvedDir, override string) (resolvedFile string, err error) {
 	for _, suffix := range []string{".yml", ".yaml"} {
 		basename := "docker-compose" + override + suffix
@@ -376,22 +502,35 @@ func extendsNotFoundError(name1, file1, name2, file2 string) error {
 
 // New loads docker compose configuration from a slice of files.
 // If files is an empty slice then the standard docker compose file locations (relative to the current working directory are considered).
+// Multiple files are merged like docker compose does: later files take precedence (see mergeServices).
 func New(files []string) (*CanonicalDockerComposeConfig, error) {
+	return NewWithEnvFile(files, "")
+}
+
+// NewWithEnvFile is New, but variables are substituted with the variables of the environment, falling back to the variables of envFile.
+// If envFile is empty then the .env file of the project directory is used instead, if it exists (see DotEnvFileName).
+func NewWithEnvFile(files []string, envFile string) (*CanonicalDockerComposeConfig, error) {
+	env, err := loadDotEnvFile(files, envFile)
+	if err != nil {
+		return nil, err
+	}
 	c := &configLoader{
-		environmentGetter:     os.LookupEnv,
+		environmentGetter:     newEnvironmentGetter(env),
 		loadResolvedFileCache: map[string]*loadResolvedFileCacheItem{},
 	}
 	var resolvedFiles []string
 	if len(files) > 0 {
-		for _, file := range files {
+		for i, file := range files {
 			dcFile, err := c.loadFile(file)
 			if err != nil {
+				if len(files) > 1 {
+					return nil, errors.Wrapf(err, "docker compose file %d of %d (%#v)", i+1, len(files), file)
+				}
 				return nil, err
 			}
 			resolvedFiles = append(resolvedFiles, dcFile.resolvedFile)
 		}
 	} else {
-		var err error
 		resolvedFiles, err = c.loadStandardFiles()
 		if err != nil {
 			return nil, err
@@ -399,18 +538,30 @@ func New(files []string) (*CanonicalDockerComposeConfig, error) {
 	}
 	dcFileMerged, xProperties := c.merge(resolvedFiles)
 	for _, s := range dcFileMerged.Services {
-		err := c.processExtends(s, dcFileMerged)
+		err = c.processExtends(s, dcFileMerged)
 		if err != nil {
 			return nil, err
 		}
 	}
-	err := resolveDependsOn(dcFileMerged.Services)
+	err = resolveDependsOn(dcFileMerged.Services)
 	if err != nil {
 		return nil, err
 	}
 	// TODO https://github.com/kube-compose/kube-compose/issues/165 resolve named volumes
 	// TODO https://github.com/kube-compose/kube-compose/issues/166 error on duplicate mount points
 	configCanonical := &CanonicalDockerComposeConfig{}
+	configCanonical.ProjectDir, err = getProjectDir(resolvedFiles[0])
+	if err != nil {
+		return nil, err
+	}
+	configCanonical.Configs, err = finalizeConfigs(dcFileMerged.Configs, dcFileMerged.Services)
+	if err != nil {
+		return nil, err
+	}
+	configCanonical.Secrets, err = finalizeSecrets(dcFileMerged.Secrets, dcFileMerged.Services)
+	if err != nil {
+		return nil, err
+	}
 	configCanonical.Services = map[string]*Service{}
 	for name, s := range dcFileMerged.Services {
 		err = finalizeService(s)
@@ -419,7 +570,12 @@ func New(files []string) (*CanonicalDockerComposeConfig, error) {
 		}
 		configCanonical.Services[name] = s.finalService
 	}
+	configCanonical.ServiceOrder = c.serviceOrder(resolvedFiles, dcFileMerged.Services)
 	configCanonical.XProperties = xProperties
+	for name := range c.unsetVariables {
+		configCanonical.UnsetVariables = append(configCanonical.UnsetVariables, name)
+	}
+	sort.Strings(configCanonical.UnsetVariables)
 	return configCanonical, nil
 }
 
@@ -431,11 +587,15 @@ func (c *configLoader) merge(resolvedFiles []string) (dcFileMerged *dockerCompos
 		// messages.
 		dcFile := c.loadResolvedFileCache[resolvedFiles[0]].parsed
 		dcFileMerged = &dockerComposeFile{
+			Configs:  map[string]*configInternal{},
+			Secrets:  map[string]*secretInternal{},
 			Services: map[string]*serviceInternal{},
 			version:  dcFile.version,
 		}
 		for i := len(resolvedFiles) - 1; i >= 0; i-- {
 			dcFile := c.loadResolvedFileCache[resolvedFiles[i]].parsed
+			mergeConfigs(dcFileMerged.Configs, dcFile.Configs)
+			mergeSecrets(dcFileMerged.Secrets, dcFile.Secrets)
 			mergeServices(dcFileMerged.Services, dcFile.Services)
 			if dcFile.xProperties != nil {
 				xProperties = append(xProperties, dcFile.xProperties)
@@ -452,13 +612,30 @@ func (c *configLoader) merge(resolvedFiles []string) (dcFileMerged *dockerCompos
 }
 
 func finalizeService(s *serviceInternal) error {
+	s.finalService.CapAdd = s.capAddParsed
+	s.finalService.CapDrop = s.capDropParsed
 	if s.Command != nil {
 		s.finalService.Command = s.Command.Values
 	}
+	deploy, err := parseDeploy(s.Deploy)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.finalService.Configs = s.Configs
+	s.finalService.Deploy = deploy
+	s.finalService.DNSOptions = s.dnsOptionsParsed
+	s.finalService.DNSServers = s.dnsServersParsed
+	s.finalService.DNSSearch = s.dnsSearchParsed
 	if s.Entrypoint != nil {
 		s.finalService.Entrypoint = s.Entrypoint.Values
 	}
+	if s.EnvFile != nil {
+		s.finalService.EnvFile = s.EnvFile.Values
+	}
 	s.finalService.Environment = s.environmentParsed
+	s.finalService.Expose = s.exposeParsed
+	s.finalService.ExternalLinks = s.externalLinksParsed
+	s.finalService.ExtraHosts = s.extraHostsParsed
 
 	// Healthchecks are processed after merging.
 	healthcheck, healthcheckDisabled, err := ParseHealthcheck(s.Healthcheck)
@@ -471,25 +648,51 @@ func finalizeService(s *serviceInternal) error {
 	if s.Image != nil {
 		s.finalService.Image = *s.Image
 	}
+	s.finalService.Labels = s.labelsParsed
 	s.finalService.Name = s.name
+	s.finalService.Networks = s.networksParsed
 	s.finalService.Ports = s.portsParsed
 	if s.Privileged != nil {
 		s.finalService.Privileged = *s.Privileged
 	}
+	s.finalService.Profiles = s.profilesParsed
+	if s.ReadOnly != nil {
+		s.finalService.ReadOnly = *s.ReadOnly
+	}
 	if s.Restart != nil {
 		s.finalService.Restart = *s.Restart
 	}
+	s.finalService.Secrets = s.Secrets
+	s.finalService.StopGracePeriod, err = parseStopGracePeriod(s.StopGracePeriod)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.finalService.Tmpfs = s.tmpfsParsed
+	s.finalService.UnsupportedKeys = s.unsupportedKeys
 	s.finalService.User = s.User
 	s.finalService.Volumes = s.Volumes
 	if s.WorkingDir != nil {
 		s.finalService.WorkingDir = *s.WorkingDir
 	}
+	s.finalService.XProperties = s.xProperties
 	return nil
 }
 
+// asGenericMap converts v to a genericMap, if v is of type map[interface{}]interface{}. The YAML decoder produces nested mappings with
+// the unnamed type, so a type assertion on genericMap alone is not sufficient.
+func asGenericMap(v interface{}) (genericMap, bool) {
+	switch m := v.(type) {
+	case genericMap:
+		return m, true
+	case map[interface{}]interface{}:
+		return m, true
+	}
+	return nil, false
+}
+
 // getXProperties is a utility that gets all string properties starting with x- from gm, if gm is of type map[interface{}]interface{}.
 func getXProperties(gm interface{}) XProperties {
-	gmMap, ok := gm.(genericMap)
+	gmMap, ok := asGenericMap(gm)
 	if !ok {
 		return nil
 	}
@@ -519,39 +722,59 @@ func resolveDependsOn(services map[string]*serviceInternal) error {
 				}
 			}
 			s1.finalService.DependsOn = s1.DependsOn.Values
+			s1.finalService.DependsOnRestart = s1.DependsOn.Restart
 		}
 	}
-	for _, s1 := range services {
-		// Reset the visited marker on each service. This is a precondition of ensureNoDependsOnCycle.
-		for _, s2 := range services {
-			s2.visited = false
-		}
-		// Run the cycle detection algorithm...
-		err := ensureNoDependsOnCycle(s1, services)
-		if err != nil {
-			return err
+	// Reset the visited marker on each service. This is a precondition of ensureNoDependsOnCycle.
+	names := make([]string, 0, len(services))
+	for name, s := range services {
+		s.visited = false
+		names = append(names, name)
+	}
+	// Services are visited in order of name, so that the same cycle is reported on each run.
+	sort.Strings(names)
+	for _, name := range names {
+		if !services[name].visited {
+			err := ensureNoDependsOnCycle(name, services, nil)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// ensureNoDependsOnCycle returns an error naming the services of a cycle in the depends_on relationship that is reachable from the service
+// name1, if any. The stack holds the names of the services that depend (transitively) on name1 and are being visited.
 // https://www.geeksforgeeks.org/detect-cycle-in-a-graph/
-func ensureNoDependsOnCycle(s1 *serviceInternal, services map[string]*serviceInternal) error {
+func ensureNoDependsOnCycle(name1 string, services map[string]*serviceInternal, stack []string) error {
+	s1 := services[name1]
 	s1.visited = true
 	s1.recStack = true
 	defer s1.clearRecStack()
-	if s1.DependsOn != nil {
-		for name := range s1.DependsOn.Values {
-			s2 := services[name]
-			if !s2.visited {
-				err := ensureNoDependsOnCycle(s2, services)
-				if err != nil {
-					return err
-				}
-			} else if s2.recStack {
-				return fmt.Errorf("a service %s depends on a service %s, but this means there is a cycle in the depends_on relationship",
-					s1.name, name)
+	stack = append(stack, name1)
+	if s1.DependsOn == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s1.DependsOn.Values))
+	for name2 := range s1.DependsOn.Values {
+		names = append(names, name2)
+	}
+	sort.Strings(names)
+	for _, name2 := range names {
+		s2 := services[name2]
+		if !s2.visited {
+			err := ensureNoDependsOnCycle(name2, services, stack)
+			if err != nil {
+				return err
+			}
+		} else if s2.recStack {
+			i := len(stack) - 1
+			for stack[i] != name2 {
+				i--
 			}
+			cycle := append(append([]string{}, stack[i:]...), name2)
+			return fmt.Errorf("the depends_on relationship of services has a cycle: %s", strings.Join(cycle, " -> "))
 		}
 	}
 	return nil
@@ -559,6 +782,14 @@ func ensureNoDependsOnCycle(s1 *serviceInternal, services map[string]*serviceInt
 
 // https://github.com/docker/compose/blob/master/compose/config/config_schema_v2.1.json
 func (c *configLoader) parseDockerComposeFile(dcFile *dockerComposeFile) error {
+	err := parseConfigs(dcFile)
+	if err != nil {
+		return err
+	}
+	err = parseSecrets(dcFile)
+	if err != nil {
+		return err
+	}
 	for name, s := range dcFile.Services {
 		s.name = name
 		err := c.parseDockerComposeFileService(dcFile, s)
@@ -575,11 +806,76 @@ func (c *configLoader) parseDockerComposeFileService(dcFile *dockerComposeFile,
 	if err != nil {
 		return err
 	}
+	s.capAddParsed, err = parseCapabilities("cap_add", s.CapAdd)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.capDropParsed, err = parseCapabilities("cap_drop", s.CapDrop)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.tmpfsParsed, err = parseTmpfs(s.Tmpfs)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	if s.Labels != nil {
+		s.labelsParsed, err = parseLabels(s.Labels.Values)
+		if err != nil {
+			return errors.Wrapf(err, "service %s", s.name)
+		}
+	}
+	s.dnsOptionsParsed, err = parseDNSOptions(s.DNSOpt)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.dnsServersParsed, err = parseDNSServers(s.DNS)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.dnsSearchParsed, err = parseDNSSearch(s.DNSSearch)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.exposeParsed, err = parseExpose(s.Expose)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.externalLinksParsed, err = parseExternalLinks(s.ExternalLinks)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.extraHostsParsed, err = parseExtraHosts(s.ExtraHosts)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.networksParsed, err = parseNetworks(s.Networks)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.profilesParsed, err = parseProfiles(s.Profiles)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	err = s.Command.splitIfString()
+	if err != nil {
+		return errors.Wrapf(err, "service %s: command", s.name)
+	}
+	err = s.Entrypoint.splitIfString()
+	if err != nil {
+		return errors.Wrapf(err, "service %s: entrypoint", s.name)
+	}
+	// Variables of env_file are overridden by variables of environment.
+	s.environmentParsed, err = c.parseEnvFiles(dcFile, s)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
 	if s.Environment != nil {
-		s.environmentParsed, err = c.parseEnvironment(s.Environment.Values)
+		var environmentParsed map[string]string
+		environmentParsed, err = c.parseEnvironment(s.Environment.Values)
 		if err != nil {
 			return err
 		}
+		s.environmentParsed = mergeStringMaps(environmentParsed, s.environmentParsed)
 	}
 	// TODO https://github.com/kube-compose/kube-compose/issues/163 only resolve volume paths if volume_driver is not set.
 	for i := 0; i < len(s.Volumes); i++ {
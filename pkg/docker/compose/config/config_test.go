@@ -23,12 +23,27 @@ const testDockerComposeYmlExtendsIOError = "/docker-compose.extends-io-error.yml
 const testDockerComposeYmlExtendsDoesNotExist = "/docker-compose.extends-does-not-exist.yml"
 const testDockerComposeYmlExtendsDoesNotExistFile = "/docker-compose.extends-does-not-exist-file.yml"
 const testDockerComposeYmlExtendsInvalidDependsOn = "/docker-compose.extends-invalid-depends-on.yml"
+const testDockerComposeYmlExtendsCrossFile = "/app/docker-compose.yml"
+const testDockerComposeYmlExtendsCrossFileCycle = "/docker-compose.extends-cross-file-cycle.yml"
 const testDockerComposeYmlDependsOnDoesNotExist = "/docker-compose.depends-on-does-not-exist.yml"
 const testDockerComposeYmlDependsOnCycle1 = "/docker-compose.depends-on-cycle-1.yml"
 const testDockerComposeYmlDependsOnCycle2 = "/docker-compose.depends-on-cycle-2.yml"
+const testDockerComposeYmlDependsOnCycle3 = "/docker-compose.depends-on-cycle-3.yml"
+const testDockerComposeYmlDependsOnDiamond = "/docker-compose.depends-on-diamond.yml"
 const testDockerComposeYmlDependsOn = "/docker-compose.depends-on.yml"
 const testDockerComposeYmlInvalidHealthcheck1 = "/docker-compose.invalid-healthcheck-1.yml"
 const testDockerComposeYmlInvalidHealthcheck2 = "/docker-compose.invalid-healthcheck-2.yml"
+const testDockerComposeYmlServiceXProperties1 = "/docker-compose.service-x-properties-1.yml"
+const testDockerComposeYmlServiceXProperties2 = "/docker-compose.service-x-properties-2.yml"
+const testDockerComposeYmlDeploy = "/docker-compose.deploy.yml"
+const testDockerComposeYmlLabels = "/docker-compose.labels.yml"
+const testDockerComposeYmlProfiles = "/docker-compose.profiles.yml"
+const testDockerComposeYmlNetworks = "/docker-compose.networks.yml"
+const testDockerComposeYmlConfigs = "/docker-compose.configs.yml"
+const testDockerComposeYmlConfigsUnknown = "/docker-compose.configs-unknown.yml"
+const testDockerComposeYmlSecrets = "/docker-compose.secrets.yml"
+const testDockerComposeYmlServiceOrder = "/docker-compose.service-order.yml"
+const testDockerComposeYmlSecretsUnknown = "/docker-compose.secrets-unknown.yml"
 
 var mockFS = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
 	testDockerComposeYml: {
@@ -157,6 +172,39 @@ services:
 services:
   service1:
 	command: []
+`),
+	},
+	testDockerComposeYmlDependsOnCycle3: {
+		Content: []byte(`version: '2.3'
+services:
+  a:
+    depends_on:
+    - b
+  b:
+    depends_on:
+    - c
+  c:
+    depends_on:
+    - a
+  d:
+    depends_on:
+    - a
+`),
+	},
+	testDockerComposeYmlDependsOnDiamond: {
+		Content: []byte(`version: '2.3'
+services:
+  a:
+    depends_on:
+    - b
+    - c
+  b:
+    depends_on:
+    - d
+  c:
+    depends_on:
+    - d
+  d: {}
 `),
 	},
 	testDockerComposeYmlDependsOn: {
@@ -166,6 +214,7 @@ services:
     depends_on:
     - service2
     privileged: true
+    read_only: true
     restart: nope
     working_dir: /root
   service2:
@@ -189,6 +238,210 @@ services:
   service1:
     healthcheck:
       test: []
+`),
+	},
+	testDockerComposeYmlServiceXProperties1: {
+		Content: []byte(`version: '2.3'
+services:
+  service1:
+    x-key1: val1
+    x-key2: val2
+`),
+	},
+	testDockerComposeYmlServiceXProperties2: {
+		Content: []byte(`version: '2.3'
+services:
+  service1:
+    x-key2: val3
+`),
+	},
+	testDockerComposeYmlDeploy: {
+		Content: []byte(`version: '3.7'
+services:
+  service1:
+    deploy:
+      replicas: 2
+      placement:
+        constraints:
+        - node.labels.zone == us-east
+        - node.role!=manager
+      resources:
+        limits:
+          cpus: '0.5'
+          memory: 512M
+        reservations:
+          cpus: 0.25
+`),
+	},
+	testDockerComposeYmlLabels: {
+		Content: []byte(`version: '3'
+services:
+  mapping:
+    labels:
+      com.example.team: payments
+      com.example.port: 8080
+      com.example.flag:
+  list:
+    labels:
+    - com.example.team=payments
+    - com.example.description=a b=c
+    - com.example.flag
+`),
+	},
+	testDockerComposeYmlProfiles: {
+		Content: []byte(`version: '3'
+services:
+  web: {}
+  debug:
+    profiles: [debug, tools]
+  debug2:
+    extends:
+      service: debug
+`),
+	},
+	testDockerComposeYmlNetworks: {
+		Content: []byte(`version: '3'
+services:
+  mapping:
+    networks:
+      default:
+        aliases:
+        - db
+        - database
+      backend:
+        aliases:
+        - db
+        ipv4_address: 172.16.238.10
+      frontend:
+  list:
+    networks:
+    - default
+    - backend
+`),
+	},
+	testDockerComposeYmlServiceOrder: {
+		Content: []byte(`version: '3'
+services:
+  zeta:
+    image: zeta
+  service1:
+    image: service1
+  alpha:
+    image: alpha
+`),
+	},
+	testDockerComposeYmlSecrets: {
+		Content: []byte(`version: '3.8'
+services:
+  service1:
+    secrets:
+    - db_password
+    - source: api_key
+      target: key.txt
+      mode: 0440
+secrets:
+  db_password:
+    environment: DB_PASSWORD
+  api_key:
+    environment: API_KEY
+  cert:
+    file: ./certs/cert.pem
+  registry:
+    external: true
+    name: prod-registry
+`),
+	},
+	testDockerComposeYmlConfigs: {
+		Content: []byte(`version: '3.8'
+services:
+  service1:
+    configs:
+    - nginx_conf
+    - source: app_conf
+      target: /etc/app/app.conf
+      uid: "103"
+      gid: 103
+      mode: 0440
+configs:
+  nginx_conf:
+    file: ./nginx.conf
+  app_conf:
+    content: |
+      debug=true
+  shared:
+    external: true
+    name: prod-shared
+`),
+	},
+	testDockerComposeYmlConfigsUnknown: {
+		Content: []byte(`version: '3.8'
+services:
+  service1:
+    configs:
+    - nginx_conf
+`),
+	},
+	testDockerComposeYmlSecretsUnknown: {
+		Content: []byte(`version: '3.8'
+services:
+  service1:
+    secrets:
+    - db_password
+`),
+	},
+	testDockerComposeYmlExtendsCrossFile: {
+		Content: []byte(`version: '2.3'
+services:
+  web:
+    environment:
+      KEY1: CHILD
+    extends:
+      file: 'base/docker-compose.base.yml'
+      service: web
+    ports:
+    - '8080:80'
+`),
+	},
+	"/app/base/docker-compose.base.yml": {
+		Content: []byte(`version: '2.3'
+services:
+  web:
+    environment:
+      KEY1: BASE
+      KEY2: BASE
+    extends:
+      file: '../../docker-compose.common.yml'
+      service: common
+    ports:
+    - '9090:90'
+    volumes:
+    - './data:/data'
+`),
+	},
+	"/docker-compose.common.yml": {
+		Content: []byte(`version: '2.3'
+services:
+  common:
+    image: 'common:latest'
+    working_dir: /srv
+`),
+	},
+	testDockerComposeYmlExtendsCrossFileCycle: {
+		Content: []byte(`version: '2.3'
+services:
+  service1:
+    extends:
+      file: 'docker-compose.extends-cross-file-cycle-2.yml'
+      service: service2
+`),
+	},
+	"/docker-compose.extends-cross-file-cycle-2.yml": {
+		Content: []byte(`version: '2.3'
+services:
+  service2:
+    extends:
+      file: 'docker-compose.extends-cross-file-cycle.yml'
+      service: service1
 `),
 	},
 })
@@ -710,10 +963,8 @@ func Test_New_DependsOnCycle1(t *testing.T) {
 		_, err := New([]string{
 			testDockerComposeYmlDependsOnCycle1,
 		})
-		if err == nil {
-			t.Fail()
-		} else {
-			t.Log(err)
+		if err == nil || err.Error() != "the depends_on relationship of services has a cycle: service1 -> service2 -> service1" {
+			t.Error(err)
 		}
 	})
 }
@@ -730,6 +981,29 @@ func Test_New_DependsOnCycle2(t *testing.T) {
 		}
 	})
 }
+func Test_New_DependsOnCycle3(t *testing.T) {
+	withMockFS(func() {
+		_, err := New([]string{
+			testDockerComposeYmlDependsOnCycle3,
+		})
+		if err == nil || err.Error() != "the depends_on relationship of services has a cycle: a -> b -> c -> a" {
+			t.Error(err)
+		}
+	})
+}
+func Test_New_DependsOnDiamond(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlDependsOnDiamond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Services["a"].DependsOn) != 2 || len(c.Services["b"].DependsOn) != 1 || len(c.Services["c"].DependsOn) != 1 {
+			t.Error(c.Services)
+		}
+	})
+}
 func Test_New_DependsOnSuccess(t *testing.T) {
 	withMockFS(func() {
 		c, err := New([]string{
@@ -740,6 +1014,7 @@ func Test_New_DependsOnSuccess(t *testing.T) {
 		} else {
 			service1 := &Service{
 				Privileged: true,
+				ReadOnly:   true,
 				Restart:    "nope",
 				WorkingDir: "/root",
 			}
@@ -888,6 +1163,40 @@ func Test_New_ExtendsSuccess(t *testing.T) {
 	})
 }
 
+func Test_New_ExtendsCrossFileSuccess(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{testDockerComposeYmlExtendsCrossFile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		web := c.Services["web"]
+		if web.Image != "common:latest" || web.WorkingDir != "/srv" {
+			t.Error(web)
+		}
+		if !areStringMapsEqual(web.Environment, map[string]string{"KEY1": "CHILD", "KEY2": "BASE"}) {
+			t.Error(web.Environment)
+		}
+		if len(web.Ports) != 2 {
+			t.Error(web.Ports)
+		}
+		// Relative paths of the extended file are resolved against the directory of the extended file.
+		if len(web.Volumes) != 1 || web.Volumes[0].Short.HostPath != "/app/base/data" {
+			t.Error(web.Volumes)
+		}
+	})
+}
+
+func Test_New_ExtendsCrossFileCycle(t *testing.T) {
+	withMockFS(func() {
+		_, err := New([]string{testDockerComposeYmlExtendsCrossFileCycle})
+		if err == nil {
+			t.Fail()
+		} else {
+			t.Log(err)
+		}
+	})
+}
+
 func Test_New_ExtendsIOError(t *testing.T) {
 	withMockFS(func() {
 		_, err := New([]string{testDockerComposeYmlExtendsIOError})
@@ -1099,6 +1408,53 @@ func Test_GetXProperties_Success(t *testing.T) {
 	}
 }
 
+func Test_GetXProperties_NestedMap(t *testing.T) {
+	v := getXProperties(map[interface{}]interface{}{
+		"x-key1": "val1",
+		"key2":   "val2",
+	})
+	if len(v) != 1 || v["x-key1"] != "val1" {
+		t.Fail()
+	}
+}
+
+func Test_New_ServiceXProperties(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlServiceXProperties1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := XProperties{
+			"x-key1": "val1",
+			"x-key2": "val2",
+		}
+		if !reflect.DeepEqual(c.Services["service1"].XProperties, expected) {
+			t.Error(c.Services["service1"].XProperties)
+		}
+	})
+}
+
+func Test_New_ServiceXPropertiesMerged(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlServiceXProperties1,
+			testDockerComposeYmlServiceXProperties2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := XProperties{
+			"x-key1": "val1",
+			"x-key2": "val3",
+		}
+		if !reflect.DeepEqual(c.Services["service1"].XProperties, expected) {
+			t.Error(c.Services["service1"].XProperties)
+		}
+	})
+}
+
 func withMockFS2(vfs fs.VirtualFileSystem, cb func()) {
 	orig := fs.OS
 	defer func() {
@@ -1200,3 +1556,445 @@ func Test_LoadStandardFilesTry_LoadResolvedFileError(t *testing.T) {
 		}
 	})
 }
+
+func Test_New_ServiceDeploy(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlDeploy,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := &Deploy{
+			PlacementConstraints: []PlacementConstraint{
+				{Attribute: "node.labels.zone", Operator: PlacementConstraintEqual, Values: []string{"us-east"}},
+				{Attribute: "node.role", Operator: PlacementConstraintNotEqual, Values: []string{"manager"}},
+			},
+			Replicas: util.NewInt32(2),
+			Resources: &Resources{
+				Limits: &ResourceSpec{
+					MilliCPUs:   500,
+					MemoryBytes: 512 * 1024 * 1024,
+				},
+				Reservations: &ResourceSpec{
+					MilliCPUs: 250,
+				},
+			},
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Deploy, expected) {
+			t.Error(c.Services["service1"].Deploy)
+		}
+	})
+}
+
+func Test_GetProjectDir_Success(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/home/user/myproject": {
+			Mode: os.ModeDir,
+		},
+	})
+	withMockFS2(vfs, func() {
+		_ = vfs.Chdir("/home/user")
+		dir, err := getProjectDir("myproject/docker-compose.yml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dir != "/home/user/myproject" {
+			t.Error(dir)
+		}
+		dir, err = getProjectDir("/srv/app/docker-compose.yml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dir != "/srv/app" {
+			t.Error(dir)
+		}
+	})
+}
+
+func Test_New_ProjectDir(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlProfiles,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.ProjectDir != "/" {
+			t.Error(c.ProjectDir)
+		}
+	})
+}
+
+func Test_New_ServiceProfiles(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlProfiles,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Services["web"].Profiles != nil {
+			t.Error(c.Services["web"].Profiles)
+		}
+		expected := []string{"debug", "tools"}
+		if !reflect.DeepEqual(c.Services["debug"].Profiles, expected) {
+			t.Error(c.Services["debug"].Profiles)
+		}
+		if !reflect.DeepEqual(c.Services["debug2"].Profiles, expected) {
+			t.Error(c.Services["debug2"].Profiles)
+		}
+	})
+}
+
+func Test_New_ServiceLabels(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlLabels,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{
+			"com.example.team": "payments",
+			"com.example.port": "8080",
+			"com.example.flag": "",
+		}
+		if !reflect.DeepEqual(c.Services["mapping"].Labels, expected) {
+			t.Error(c.Services["mapping"].Labels)
+		}
+		expected = map[string]string{
+			"com.example.team":        "payments",
+			"com.example.description": "a b=c",
+			"com.example.flag":        "",
+		}
+		if !reflect.DeepEqual(c.Services["list"].Labels, expected) {
+			t.Error(c.Services["list"].Labels)
+		}
+	})
+}
+
+func Test_New_ServiceNetworks(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlNetworks,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []ServiceNetwork{
+			{Name: "backend", Aliases: []string{"db"}},
+			{Name: "default", Aliases: []string{"db", "database"}},
+			{Name: "frontend"},
+		}
+		if !reflect.DeepEqual(c.Services["mapping"].Networks, expected) {
+			t.Error(c.Services["mapping"].Networks)
+		}
+		if aliases := c.Services["mapping"].NetworkAliases(); !reflect.DeepEqual(aliases, []string{"db", "database"}) {
+			t.Error(aliases)
+		}
+		expected = []ServiceNetwork{
+			{Name: "default"},
+			{Name: "backend"},
+		}
+		if !reflect.DeepEqual(c.Services["list"].Networks, expected) {
+			t.Error(c.Services["list"].Networks)
+		}
+		if len(c.Services["list"].UnsupportedKeys) != 0 {
+			t.Error(c.Services["list"].UnsupportedKeys)
+		}
+	})
+}
+
+func Test_New_Secrets(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlSecrets,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedSecrets := map[string]*Secret{
+			"api_key":     {Environment: "API_KEY", ExternalName: "api_key", Name: "api_key"},
+			"cert":        {ExternalName: "cert", File: "/certs/cert.pem", Name: "cert"},
+			"db_password": {Environment: "DB_PASSWORD", ExternalName: "db_password", Name: "db_password"},
+			"registry":    {External: true, ExternalName: "prod-registry", Name: "registry"},
+		}
+		if !reflect.DeepEqual(c.Secrets, expectedSecrets) {
+			t.Error(c.Secrets)
+		}
+		expectedServiceSecrets := []ServiceSecret{
+			{Source: "db_password", Target: "/run/secrets/db_password"},
+			{Source: "api_key", Target: "/run/secrets/key.txt", Mode: util.NewInt32(0440)},
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Secrets, expectedServiceSecrets) {
+			t.Error(c.Services["service1"].Secrets)
+		}
+	})
+}
+
+func Test_New_Configs(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlConfigs,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedConfigs := map[string]*ComposeConfig{
+			"app_conf":   {Content: "debug=true\n", ExternalName: "app_conf", Name: "app_conf"},
+			"nginx_conf": {ExternalName: "nginx_conf", File: "/nginx.conf", Name: "nginx_conf"},
+			"shared":     {External: true, ExternalName: "prod-shared", Name: "shared"},
+		}
+		if !reflect.DeepEqual(c.Configs, expectedConfigs) {
+			t.Error(c.Configs)
+		}
+		id := int64(103)
+		expectedServiceConfigs := []ServiceConfig{
+			{Source: "nginx_conf", Target: "/nginx_conf"},
+			{Source: "app_conf", Target: "/etc/app/app.conf", Mode: util.NewInt32(0440), UID: &id, GID: &id},
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Configs, expectedServiceConfigs) {
+			t.Error(c.Services["service1"].Configs)
+		}
+	})
+}
+
+func Test_New_ConfigsUnknownError(t *testing.T) {
+	withMockFS(func() {
+		_, err := New([]string{
+			testDockerComposeYmlConfigsUnknown,
+		})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ServiceOrder(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{
+			testDockerComposeYmlDeploy,
+			testDockerComposeYmlServiceOrder,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(c.ServiceOrder, []string{"service1", "zeta", "alpha"}) {
+			t.Error(c.ServiceOrder)
+		}
+	})
+}
+
+func Test_New_SecretsUnknownError(t *testing.T) {
+	withMockFS(func() {
+		_, err := New([]string{
+			testDockerComposeYmlSecretsUnknown,
+		})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_EnvironmentAnchorTypedValues(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu
+    environment: &env
+      BIG: 1234567890123456789
+      FLAG: true
+      EMPTY: null
+  service2:
+    image: ubuntu
+    environment: *env
+`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		c, err := New([]string{"/docker-compose.yml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{
+			"BIG":  "1234567890123456789",
+			"FLAG": "true",
+		}
+		for _, name := range []string{"service1", "service2"} {
+			if !reflect.DeepEqual(c.Services[name].Environment, expected) {
+				t.Error(name, c.Services[name].Environment)
+			}
+		}
+	})
+}
+
+func Test_New_MultipleFilesMerge(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yaml": {
+			Content: []byte(`version: '2.4'
+services:
+  s:
+    image: s:1
+    environment:
+      ENV1: '1'
+      ENV2: '1'
+    ports:
+    - '8080:80'
+    volumes:
+    - '/a:/data'
+    - '/b:/config'
+`),
+		},
+		"/docker-compose.ci.yaml": {
+			Content: []byte(`version: '2.4'
+services:
+  s:
+    image: s:2
+    environment:
+      ENV2: '2'
+      ENV3: '2'
+    ports:
+    - '9090:90'
+    volumes:
+    - '/c:/data'
+`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		c, err := New([]string{"/docker-compose.yaml", "/docker-compose.ci.yaml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := c.Services["s"]
+		if s.Image != "s:2" {
+			t.Error(s.Image)
+		}
+		if !areStringMapsEqual(s.Environment, map[string]string{"ENV1": "1", "ENV2": "2", "ENV3": "2"}) {
+			t.Error(s.Environment)
+		}
+		if len(s.Ports) != 2 {
+			t.Error(s.Ports)
+		}
+		if len(s.Volumes) != 2 || s.Volumes[0].Short.HostPath != "/c" || s.Volumes[1].Short.HostPath != "/b" {
+			t.Error(s.Volumes)
+		}
+	})
+}
+
+func Test_New_MultipleFilesMissingFileError(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yaml": {
+			Content: []byte(`version: '2.4'
+services:
+  s:
+    image: s:1
+`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		_, err := New([]string{"/docker-compose.yaml", "/docker-compose.missing.yaml"})
+		if err == nil || !strings.Contains(err.Error(), "docker compose file 2 of 2") {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_New_UnsetVariables(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yaml": {
+			Content: []byte(`version: '2.4'
+services:
+  s:
+    image: 's:${KUBE_COMPOSE_TEST_TAG:-latest}'
+    environment:
+      A: '${KUBE_COMPOSE_TEST_UNSET2}'
+      B: '${KUBE_COMPOSE_TEST_UNSET1}$KUBE_COMPOSE_TEST_UNSET2'
+`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		c, err := New(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Services["s"].Image != "s:latest" {
+			t.Error(c.Services["s"].Image)
+		}
+		if !reflect.DeepEqual(c.UnsetVariables, []string{"KUBE_COMPOSE_TEST_UNSET1", "KUBE_COMPOSE_TEST_UNSET2"}) {
+			t.Error(c.UnsetVariables)
+		}
+	})
+}
+
+func TestXPropertiesDecode_Success(t *testing.T) {
+	x := XProperties{
+		"x-tool": map[interface{}]interface{}{
+			"enabled": true,
+			"unknown": "ignored",
+		},
+	}
+	var v struct {
+		Enabled bool `mapdecode:"enabled"`
+	}
+	ok, err := x.Decode("x-tool", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || !v.Enabled {
+		t.Fail()
+	}
+}
+
+func TestXPropertiesDecode_NotSet(t *testing.T) {
+	var x XProperties
+	var v struct{}
+	ok, err := x.Decode("x-tool", &v)
+	if ok || err != nil {
+		t.Fail()
+	}
+}
+
+func TestXPropertiesDecode_Error(t *testing.T) {
+	x := XProperties{
+		"x-tool": "not a mapping",
+	}
+	var v struct {
+		Enabled bool `mapdecode:"enabled"`
+	}
+	_, err := x.Decode("x-tool", &v)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_New_UnrelatedXProperties(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(`version: '3.4'
+x-foo: &common
+  image: nginx
+x-bar: [1, {a: b}]
+services:
+  web:
+    <<: *common
+    x-foo:
+      bar: [1]
+`),
+		},
+	}), func() {
+		c, err := New([]string{"/docker-compose.yml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		web := c.Services["web"]
+		if web.Image != "nginx" || len(web.UnsupportedKeys) != 0 || web.XProperties["x-foo"] == nil {
+			t.Error(web)
+		}
+		if len(c.XProperties) != 1 || c.XProperties[0]["x-bar"] == nil {
+			t.Error(c.XProperties)
+		}
+	})
+}
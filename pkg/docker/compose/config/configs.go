@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/uber-go/mapdecode"
+)
+
+// The directory relative to which the configs of a service are mounted.
+const configsDir = "/"
+
+type configInternal struct {
+	Content     *string `mapdecode:"content"`
+	Environment *string `mapdecode:"environment"`
+	External    *bool   `mapdecode:"external"`
+	File        *string `mapdecode:"file"`
+	Name        *string `mapdecode:"name"`
+}
+
+// ComposeConfig is a parsed element of the top-level configs section (see https://docs.docker.com/compose/compose-file/08-configs/).
+type ComposeConfig struct {
+	// The inline value of the config. Empty if the value of the config is not inline.
+	Content string
+	// The name of the environment variable that holds the value of the config. Empty if the value of the config is not read from the
+	// environment.
+	Environment string
+	// True if the config is managed outside of the docker compose project.
+	External bool
+	// The name of the config that is managed outside of the docker compose project (the name field). Equal to Name if not set.
+	ExternalName string
+	// The absolute path of the file that holds the value of the config. Empty if the value of the config is not read from a file.
+	File string
+	Name string
+}
+
+// ServiceConfig is a reference of a docker compose service to a config.
+type ServiceConfig struct {
+	// The group that owns the file of the config in the container. Nil if not set.
+	GID *int64
+	// The permissions of the file of the config in the container. Nil if not set.
+	Mode *int32
+	// The name of the config in the top-level configs section.
+	Source string
+	// The absolute path at which the config is mounted in the container.
+	Target string
+	// The user that owns the file of the config in the container. Nil if not set.
+	UID *int64
+}
+
+type serviceConfigHelper struct {
+	GID    interface{} `mapdecode:"gid"`
+	Mode   interface{} `mapdecode:"mode"`
+	Source string      `mapdecode:"source"`
+	Target *string     `mapdecode:"target"`
+	UID    interface{} `mapdecode:"uid"`
+}
+
+// parseFileOwner parses the uid or gid of the long syntax of configs, which docker compose documents as a string but YAML may decode as an
+// integer.
+func parseFileOwner(name string, v interface{}) (*int64, error) {
+	var id int64
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case int:
+		id = int64(t)
+	case uint64:
+		if t > math.MaxInt64 {
+			return nil, fmt.Errorf("%s %d is too large", name, t)
+		}
+		id = int64(t)
+	case string:
+		var err error
+		id, err = strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s %#v must be a number", name, t)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be an integer or a string", name)
+	}
+	if id < 0 {
+		return nil, fmt.Errorf("%s %d must not be negative", name, id)
+	}
+	return &id, nil
+}
+
+// Decode parses either the short syntax (the name of a config) or the long syntax of a config reference of a docker compose service.
+func (s *ServiceConfig) Decode(into mapdecode.Into) error {
+	var source string
+	err := into(&source)
+	if err == nil {
+		s.Source = source
+		s.Target = configsDir + source
+		return nil
+	}
+	var helper serviceConfigHelper
+	err = into(&helper)
+	if err != nil {
+		return err
+	}
+	s.Source = helper.Source
+	s.Mode, err = parseFileMode(helper.Mode)
+	if err != nil {
+		return err
+	}
+	s.UID, err = parseFileOwner("uid", helper.UID)
+	if err != nil {
+		return err
+	}
+	s.GID, err = parseFileOwner("gid", helper.GID)
+	if err != nil {
+		return err
+	}
+	s.Target = resolveMountTarget(configsDir, helper.Source, helper.Target)
+	return nil
+}
+
+func parseConfigs(dcFile *dockerComposeFile) error {
+	for name, cfg := range dcFile.Configs {
+		n := 0
+		if cfg.Content != nil {
+			n++
+		}
+		if cfg.Environment != nil {
+			n++
+		}
+		if cfg.External != nil && *cfg.External {
+			n++
+		}
+		if cfg.File != nil {
+			*cfg.File = expandPath(dcFile.resolvedFile, *cfg.File)
+			n++
+		}
+		if n != 1 {
+			return fmt.Errorf("config %s must have exactly one of the fields content, environment, external and file", name)
+		}
+	}
+	return nil
+}
+
+// mergeConfigs merges the top-level configs sections of docker compose files, where configs of into win.
+func mergeConfigs(into, from map[string]*configInternal) {
+	for name, cfg := range from {
+		if _, ok := into[name]; !ok {
+			into[name] = cfg
+		}
+	}
+}
+
+// mergeServiceConfigs merges the configs of docker compose services by source, where configs of into win.
+func mergeServiceConfigs(into, from []ServiceConfig) []ServiceConfig {
+	for _, config1 := range from {
+		found := false
+		for _, config2 := range into {
+			if config1.Source == config2.Source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			into = append(into, config1)
+		}
+	}
+	return into
+}
+
+func finalizeConfigs(configs map[string]*configInternal, services map[string]*serviceInternal) (map[string]*ComposeConfig, error) {
+	result := map[string]*ComposeConfig{}
+	for name, cfgInternal := range configs {
+		cfg := &ComposeConfig{
+			ExternalName: name,
+			Name:         name,
+		}
+		if cfgInternal.Content != nil {
+			cfg.Content = *cfgInternal.Content
+		}
+		if cfgInternal.Environment != nil {
+			cfg.Environment = *cfgInternal.Environment
+		}
+		if cfgInternal.External != nil {
+			cfg.External = *cfgInternal.External
+		}
+		if cfgInternal.File != nil {
+			cfg.File = *cfgInternal.File
+		}
+		if cfgInternal.Name != nil {
+			cfg.ExternalName = *cfgInternal.Name
+		}
+		result[name] = cfg
+	}
+	for _, s := range services {
+		for _, serviceConfig := range s.Configs {
+			if result[serviceConfig.Source] == nil {
+				return nil, fmt.Errorf("service %s refers to config %s, but no config with that name exists", s.name, serviceConfig.Source)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseConfigs_NoSourceError(t *testing.T) {
+	dcFile := &dockerComposeFile{
+		Configs: map[string]*configInternal{
+			"config1": {},
+		},
+	}
+	err := parseConfigs(dcFile)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseConfigs_MultipleSourcesError(t *testing.T) {
+	content := "a"
+	file := "config.txt"
+	dcFile := &dockerComposeFile{
+		Configs: map[string]*configInternal{
+			"config1": {
+				Content: &content,
+				File:    &file,
+			},
+		},
+	}
+	err := parseConfigs(dcFile)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseConfigs_FileResolved(t *testing.T) {
+	file := "config.txt"
+	dcFile := &dockerComposeFile{
+		resolvedFile: "/project/docker-compose.yml",
+		Configs: map[string]*configInternal{
+			"config1": {
+				File: &file,
+			},
+		},
+	}
+	err := parseConfigs(dcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != "/project/config.txt" {
+		t.Error(file)
+	}
+}
+
+func TestMergeServiceConfigs_IntoWins(t *testing.T) {
+	into := []ServiceConfig{
+		{Source: "a", Target: "/a"},
+	}
+	from := []ServiceConfig{
+		{Source: "a", Target: "/b"},
+		{Source: "c", Target: "/c"},
+	}
+	merged := mergeServiceConfigs(into, from)
+	if len(merged) != 2 || merged[0].Target != "/a" || merged[1].Source != "c" {
+		t.Error(merged)
+	}
+}
+
+func TestParseFileOwner_Success(t *testing.T) {
+	testCases := []interface{}{
+		103,
+		uint64(103),
+		"103",
+	}
+	for _, testCase := range testCases {
+		id, err := parseFileOwner("uid", testCase)
+		if err != nil {
+			t.Error(err)
+		} else if id == nil || *id != 103 {
+			t.Error(testCase, id)
+		}
+	}
+}
+
+func TestParseFileOwner_Nil(t *testing.T) {
+	id, err := parseFileOwner("uid", nil)
+	if err != nil || id != nil {
+		t.Error(id, err)
+	}
+}
+
+func TestParseFileOwner_Error(t *testing.T) {
+	testCases := []interface{}{
+		-1,
+		"root",
+		1.5,
+	}
+	for _, testCase := range testCases {
+		_, err := parseFileOwner("gid", testCase)
+		if err == nil {
+			t.Error(testCase)
+		}
+	}
+}
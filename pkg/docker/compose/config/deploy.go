@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+type deployInternal struct {
+	Placement *placementInternal `mapdecode:"placement"`
+	Replicas  *int               `mapdecode:"replicas"`
+	Resources *resourcesInternal `mapdecode:"resources"`
+}
+
+type placementInternal struct {
+	Constraints []string `mapdecode:"constraints"`
+}
+
+// Deploy is the parsed deploy field of a docker compose service (see https://docs.docker.com/compose/compose-file/deploy/).
+type Deploy struct {
+	PlacementConstraints []PlacementConstraint
+	// The number of containers of the service. Nil if deploy.replicas is not present.
+	Replicas *int32
+	// Nil if deploy.resources is not present.
+	Resources *Resources
+}
+
+// PlacementConstraintOperator is the operator of a placement constraint.
+type PlacementConstraintOperator string
+
+const (
+	// PlacementConstraintEqual is the operator of a constraint of the form "attribute == value".
+	PlacementConstraintEqual PlacementConstraintOperator = "=="
+	// PlacementConstraintNotEqual is the operator of a constraint of the form "attribute != value".
+	PlacementConstraintNotEqual PlacementConstraintOperator = "!="
+	// PlacementConstraintIn is the operator of a constraint of the form "attribute in (value1, value2)", which is not supported by swarm.
+	PlacementConstraintIn PlacementConstraintOperator = "in"
+)
+
+// PlacementConstraint is a parsed element of deploy.placement.constraints (e.g. "node.labels.zone == us-east").
+type PlacementConstraint struct {
+	// The attribute of the node that the constraint refers to (e.g. "node.labels.zone").
+	Attribute string
+	Operator  PlacementConstraintOperator
+	// The values of the constraint. Constraints with the operators == and != have exactly one value.
+	Values []string
+}
+
+var placementConstraintInRegexp = regexp.MustCompile(`^(\S*)\s+in\s+\((.*)\)$`)
+
+func parsePlacementConstraint(s string) (PlacementConstraint, error) {
+	var constraint PlacementConstraint
+	if m := placementConstraintInRegexp.FindStringSubmatch(strings.TrimSpace(s)); m != nil {
+		constraint.Attribute = m[1]
+		constraint.Operator = PlacementConstraintIn
+		for _, value := range strings.Split(m[2], ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return constraint, fmt.Errorf("deploy.placement.constraints contains a constraint with an empty value: %#v", s)
+			}
+			constraint.Values = append(constraint.Values, value)
+		}
+	} else {
+		i := strings.Index(s, string(PlacementConstraintEqual))
+		if i >= 0 {
+			constraint.Operator = PlacementConstraintEqual
+		} else if i = strings.Index(s, string(PlacementConstraintNotEqual)); i >= 0 {
+			constraint.Operator = PlacementConstraintNotEqual
+		} else {
+			return constraint, fmt.Errorf("deploy.placement.constraints contains a constraint without ==, != or in: %#v", s)
+		}
+		constraint.Attribute = strings.TrimSpace(s[:i])
+		constraint.Values = []string{strings.TrimSpace(s[i+2:])}
+	}
+	if constraint.Attribute == "" {
+		return constraint, fmt.Errorf("deploy.placement.constraints contains a constraint without an attribute: %#v", s)
+	}
+	return constraint, nil
+}
+
+// parseDeploy parses the deploy field of a docker compose service. Returns nil if the field is not present.
+func parseDeploy(d *deployInternal) (*Deploy, error) {
+	if d == nil {
+		return nil, nil
+	}
+	deploy := &Deploy{}
+	if d.Placement != nil {
+		for _, s := range d.Placement.Constraints {
+			constraint, err := parsePlacementConstraint(s)
+			if err != nil {
+				return nil, err
+			}
+			deploy.PlacementConstraints = append(deploy.PlacementConstraints, constraint)
+		}
+	}
+	if d.Replicas != nil {
+		if *d.Replicas < 0 || *d.Replicas > math.MaxInt32 {
+			return nil, fmt.Errorf("deploy.replicas must be a non-negative integer, but got %d", *d.Replicas)
+		}
+		replicas := int32(*d.Replicas)
+		deploy.Replicas = &replicas
+	}
+	var err error
+	deploy.Resources, err = parseResources(d.Resources)
+	if err != nil {
+		return nil, err
+	}
+	return deploy, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParsePlacementConstraint_NoOperatorError(t *testing.T) {
+	_, err := parsePlacementConstraint("node.labels.zone")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParsePlacementConstraint_NoAttributeError(t *testing.T) {
+	_, err := parsePlacementConstraint("== us-east")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParsePlacementConstraint_In(t *testing.T) {
+	constraint, err := parsePlacementConstraint("node.labels.zone in (us-east, us-west)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if constraint.Attribute != "node.labels.zone" || constraint.Operator != PlacementConstraintIn || len(constraint.Values) != 2 ||
+		constraint.Values[0] != "us-east" || constraint.Values[1] != "us-west" {
+		t.Error(constraint)
+	}
+}
+
+func TestParsePlacementConstraint_InEmptyValueError(t *testing.T) {
+	_, err := parsePlacementConstraint("node.labels.zone in (us-east,)")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseDeploy_Replicas(t *testing.T) {
+	replicas := 3
+	deploy, err := parseDeploy(&deployInternal{
+		Replicas: &replicas,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deploy.Replicas == nil || *deploy.Replicas != 3 {
+		t.Error(deploy.Replicas)
+	}
+}
+
+func TestParseDeploy_NegativeReplicasError(t *testing.T) {
+	replicas := -1
+	_, err := parseDeploy(&deployInternal{
+		Replicas: &replicas,
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
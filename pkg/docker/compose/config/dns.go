@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var dnsOptionNameRegexp = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.-]*$")
+
+// DNSOption is a parsed resolver option of the dns_opt field of a docker compose service (e.g. "ndots:2").
+type DNSOption struct {
+	Name string
+	// Value is nil if the option does not have a value (e.g. "rotate").
+	Value *string
+}
+
+// parseDNSOptions parses each element of a dns_opt list into a name and an optional value, splitting on the first ':'.
+func parseDNSOptions(dnsOpt []string) ([]DNSOption, error) {
+	var result []DNSOption
+	for _, opt := range dnsOpt {
+		var dnsOption DNSOption
+		i := strings.IndexByte(opt, ':')
+		if i < 0 {
+			dnsOption.Name = opt
+		} else {
+			dnsOption.Name = opt[:i]
+			value := opt[i+1:]
+			dnsOption.Value = &value
+		}
+		if !dnsOptionNameRegexp.MatchString(dnsOption.Name) {
+			return nil, fmt.Errorf("dns_opt contains an option with an invalid name: %#v", opt)
+		}
+		result = append(result, dnsOption)
+	}
+	return result, nil
+}
+
+// parseDNSServers parses the dns field of a docker compose service, which is an IP address or a list of IP addresses. Duplicates are
+// removed.
+func parseDNSServers(dns *stringOrStringSlice) ([]string, error) {
+	if dns == nil {
+		return nil, nil
+	}
+	var result []string
+	for _, server := range dns.Values {
+		if net.ParseIP(server) == nil {
+			return nil, fmt.Errorf("dns contains an invalid IP address: %#v", server)
+		}
+		result = addUniqueString(result, server)
+	}
+	return result, nil
+}
+
+// parseDNSSearch parses the dns_search field of a docker compose service, which is a domain or a list of domains. Duplicates are removed.
+func parseDNSSearch(dnsSearch *stringOrStringSlice) ([]string, error) {
+	if dnsSearch == nil {
+		return nil, nil
+	}
+	var result []string
+	for _, domain := range dnsSearch.Values {
+		if domain == "" || strings.ContainsAny(domain, " \t") {
+			return nil, fmt.Errorf("dns_search contains an invalid domain: %#v", domain)
+		}
+		result = addUniqueString(result, domain)
+	}
+	return result, nil
+}
+
+func addUniqueString(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// mergeUniqueStrings returns the union of into and from, like docker compose merges dns and dns_search.
+func mergeUniqueStrings(into, from []string) []string {
+	for _, value := range from {
+		into = addUniqueString(into, value)
+	}
+	return into
+}
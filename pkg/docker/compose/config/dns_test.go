@@ -0,0 +1,90 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDNSOptions_Success(t *testing.T) {
+	dnsOptions, err := parseDNSOptions([]string{"ndots:2", "rotate", "timeout:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dnsOptions) != 3 {
+		t.Fatal(dnsOptions)
+	}
+	if dnsOptions[0].Name != "ndots" || dnsOptions[0].Value == nil || *dnsOptions[0].Value != "2" {
+		t.Error(dnsOptions[0])
+	}
+	if dnsOptions[1].Name != "rotate" || dnsOptions[1].Value != nil {
+		t.Error(dnsOptions[1])
+	}
+	if dnsOptions[2].Name != "timeout" || dnsOptions[2].Value == nil || *dnsOptions[2].Value != "" {
+		t.Error(dnsOptions[2])
+	}
+}
+
+func TestParseDNSOptions_InvalidName(t *testing.T) {
+	_, err := parseDNSOptions([]string{":2"})
+	if err == nil {
+		t.Fail()
+	}
+	_, err = parseDNSOptions([]string{"nd ots:2"})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseDNSServers_Success(t *testing.T) {
+	servers, err := parseDNSServers(&stringOrStringSlice{
+		Values: []string{"8.8.8.8", "2001:4860:4860::8888", "8.8.8.8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(servers, []string{"8.8.8.8", "2001:4860:4860::8888"}) {
+		t.Error(servers)
+	}
+}
+
+func TestParseDNSServers_InvalidIP(t *testing.T) {
+	for _, server := range []string{"", "dns.example.com", "8.8.8"} {
+		_, err := parseDNSServers(&stringOrStringSlice{
+			Values: []string{server},
+		})
+		if err == nil {
+			t.Error(server)
+		}
+	}
+}
+
+func TestParseDNSSearch_Success(t *testing.T) {
+	domains, err := parseDNSSearch(&stringOrStringSlice{
+		IsString: true,
+		Values:   []string{"example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(domains, []string{"example.com"}) {
+		t.Error(domains)
+	}
+}
+
+func TestParseDNSSearch_Invalid(t *testing.T) {
+	for _, domain := range []string{"", "example .com"} {
+		_, err := parseDNSSearch(&stringOrStringSlice{
+			Values: []string{domain},
+		})
+		if err == nil {
+			t.Error(domain)
+		}
+	}
+}
+
+func TestMergeUniqueStrings(t *testing.T) {
+	values := mergeUniqueStrings([]string{"8.8.8.8"}, []string{"8.8.4.4", "8.8.8.8"})
+	if !reflect.DeepEqual(values, []string{"8.8.8.8", "8.8.4.4"}) {
+		t.Error(values)
+	}
+}
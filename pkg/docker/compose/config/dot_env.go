@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/pkg/errors"
+)
+
+// DotEnvFileName is the name of the file that is loaded from the project directory to supply variables for substitution, like
+// docker-compose does.
+const DotEnvFileName = ".env"
+
+// loadDotEnvFile loads the variables of envFile. If envFile is empty then the .env file in the directory of the first docker compose file
+// is loaded instead (or the current working directory if files is empty), and it is not an error if that file does not exist.
+func loadDotEnvFile(files []string, envFile string) (map[string]string, error) {
+	if envFile != "" {
+		env := map[string]string{}
+		err := parseEnvFile(envFile, nil, env)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not load env file %#v", envFile)
+		}
+		return env, nil
+	}
+	dir := ""
+	if len(files) > 0 {
+		dir = filepath.Dir(files[0])
+	} else {
+		var err error
+		dir, err = fs.OS.Getwd()
+		if err != nil {
+			return nil, err
+		}
+	}
+	file := filepath.Join(dir, DotEnvFileName)
+	env := map[string]string{}
+	err := parseEnvFile(file, nil, env)
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load env file %#v", file)
+	}
+	return env, nil
+}
+
+// newEnvironmentGetter returns a ValueGetter that looks up variables of the process environment, falling back to the variables of env.
+func newEnvironmentGetter(env map[string]string) ValueGetter {
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := env[name]
+		return value, ok
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+var mockFileSystemDotEnv = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+	"/project/docker-compose.yml": {
+		Content: []byte(`version: '3'
+services:
+  service1:
+    image: 'service1:${KUBE_COMPOSE_TEST_TAG}'
+    environment:
+      FROM_DOT_ENV: '${KUBE_COMPOSE_TEST_DOT_ENV}'
+`),
+	},
+	"/project/.env": {
+		Content: []byte(`# comment
+KUBE_COMPOSE_TEST_TAG=1.0
+export KUBE_COMPOSE_TEST_DOT_ENV="a \"quoted\" value"
+`),
+	},
+	"/other/docker-compose.yml": {
+		Content: []byte(`version: '3'
+services:
+  service1:
+    image: 'service1:${KUBE_COMPOSE_TEST_TAG:-latest}'
+`),
+	},
+	"/other/ci.env": {
+		Content: []byte("KUBE_COMPOSE_TEST_TAG='ci'\n"),
+	},
+})
+
+func Test_NewWithEnvFile_DotEnvOfProjectDirectory(t *testing.T) {
+	withMockFS2(mockFileSystemDotEnv, func() {
+		c, err := NewWithEnvFile([]string{"/project/docker-compose.yml"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		service1 := c.Services["service1"]
+		if service1.Image != "service1:1.0" {
+			t.Error(service1.Image)
+		}
+		if !reflect.DeepEqual(service1.Environment, map[string]string{"FROM_DOT_ENV": `a "quoted" value`}) {
+			t.Error(service1.Environment)
+		}
+	})
+}
+
+func Test_NewWithEnvFile_EnvironmentTakesPrecedence(t *testing.T) {
+	_ = os.Setenv("KUBE_COMPOSE_TEST_TAG", "2.0")
+	defer os.Unsetenv("KUBE_COMPOSE_TEST_TAG")
+	withMockFS2(mockFileSystemDotEnv, func() {
+		c, err := NewWithEnvFile([]string{"/project/docker-compose.yml"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Services["service1"].Image != "service1:2.0" {
+			t.Error(c.Services["service1"].Image)
+		}
+	})
+}
+
+func Test_NewWithEnvFile_ExplicitEnvFile(t *testing.T) {
+	withMockFS2(mockFileSystemDotEnv, func() {
+		c, err := NewWithEnvFile([]string{"/other/docker-compose.yml"}, "/other/ci.env")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Services["service1"].Image != "service1:ci" {
+			t.Error(c.Services["service1"].Image)
+		}
+	})
+}
+
+func Test_NewWithEnvFile_NoDotEnv(t *testing.T) {
+	withMockFS2(mockFileSystemDotEnv, func() {
+		c, err := NewWithEnvFile([]string{"/other/docker-compose.yml"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Services["service1"].Image != "service1:latest" {
+			t.Error(c.Services["service1"].Image)
+		}
+	})
+}
+
+func Test_NewWithEnvFile_MissingEnvFileError(t *testing.T) {
+	withMockFS2(mockFileSystemDotEnv, func() {
+		_, err := NewWithEnvFile([]string{"/other/docker-compose.yml"}, "/other/missing.env")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// parseEnvFileValue parses the value of a line of an env file. Values in single quotes are taken literally, values in double quotes
+// support the escape sequences \n, \t, \" and \\, and unquoted values are trimmed and end at a # that is preceded by whitespace.
+func parseEnvFileValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	switch value[0] {
+	case '\'':
+		i := strings.IndexByte(value[1:], '\'')
+		if i < 0 {
+			return "", fmt.Errorf("unterminated single quoted value")
+		}
+		return value[1 : i+1], nil
+	case '"':
+		var sb strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch value[i] {
+			case '"':
+				return sb.String(), nil
+			case '\\':
+				if i+1 < len(value) {
+					i++
+					switch value[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					case '"', '\\':
+						sb.WriteByte(value[i])
+					default:
+						sb.WriteByte('\\')
+						sb.WriteByte(value[i])
+					}
+					continue
+				}
+				sb.WriteByte('\\')
+			default:
+				sb.WriteByte(value[i])
+			}
+		}
+		return "", fmt.Errorf("unterminated double quoted value")
+	}
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i]), nil
+		}
+	}
+	return value, nil
+}
+
+// parseEnvFile parses an env file into env (see https://docs.docker.com/compose/env-file/). Both the .env file of a project and the
+// env_file of a docker compose service are parsed by this function, like docker compose does. Each line is of the form [export ]KEY=VALUE
+// (see parseEnvFileValue). Blank lines and lines starting with # are ignored. A line without a = takes the value of the variable from
+// lookup, and is ignored if lookup is nil or the variable is not set.
+func parseEnvFile(file string, lookup ValueGetter, env map[string]string) error {
+	reader, err := fs.OS.Open(file)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(reader)
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			if lookup == nil {
+				continue
+			}
+			if value, ok := lookup(line); ok {
+				env[line] = value
+			}
+			continue
+		}
+		name := strings.TrimSpace(line[:i])
+		if name == "" {
+			return fmt.Errorf("env file %#v line %d: invalid variable without a name", file, lineNumber)
+		}
+		env[name], err = parseEnvFileValue(line[i+1:])
+		if err != nil {
+			return fmt.Errorf("env file %#v line %d: %v", file, lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseEnvFiles resolves the env_file paths of a docker compose service relative to the docker compose file, and merges the parsed
+// variables of the files. Variables of later files take precedence over those of earlier files.
+func (c *configLoader) parseEnvFiles(dcFile *dockerComposeFile, s *serviceInternal) (map[string]string, error) {
+	if s.EnvFile == nil {
+		return nil, nil
+	}
+	env := map[string]string{}
+	for i, file := range s.EnvFile.Values {
+		s.EnvFile.Values[i] = expandPath(dcFile.resolvedFile, file)
+		err := parseEnvFile(s.EnvFile.Values[i], c.environmentGetter, env)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read env_file %#v", s.EnvFile.Values[i])
+		}
+	}
+	return env, nil
+}
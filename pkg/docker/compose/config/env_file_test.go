@@ -0,0 +1,139 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+var mockFileSystemEnvFile = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+	"/project/docker-compose.yml": {
+		Content: []byte(`version: '3'
+services:
+  service1:
+    env_file:
+    - common.env
+    - service1.env
+    environment:
+      OVERRIDE: inline
+`),
+	},
+	"/project/common.env": {
+		Content: []byte(`# comment
+
+A=1
+OVERRIDE=fromfile
+URL=postgres://host/db?sslmode=disable&x=y
+FROM_ENV
+export QUOTED="a \"quoted\" value" # comment
+`),
+	},
+	"/project/service1.env": {
+		Content: []byte("A=2\n"),
+	},
+})
+
+func Test_ParseEnvFileValue(t *testing.T) {
+	cases := map[string]string{
+		"":                         "",
+		" value ":                  "value",
+		"value # comment":          "value",
+		"val#ue":                   "val#ue",
+		"'single $quoted' # c":     "single $quoted",
+		`"double\nquoted\\"`:       "double\nquoted\\",
+		`"with \"escaped\" quote"`: `with "escaped" quote`,
+	}
+	for input, expected := range cases {
+		actual, err := parseEnvFileValue(input)
+		if err != nil || actual != expected {
+			t.Errorf("%#v: %#v %v", input, actual, err)
+		}
+	}
+}
+
+func Test_ParseEnvFileValue_UnterminatedError(t *testing.T) {
+	for _, input := range []string{`'value`, `"value`} {
+		if _, err := parseEnvFileValue(input); err == nil {
+			t.Error(input)
+		}
+	}
+}
+
+func Test_New_EnvFile(t *testing.T) {
+	withMockFS2(mockFileSystemEnvFile, func() {
+		c, err := New([]string{"/project/docker-compose.yml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		service1 := c.Services["service1"]
+		if service1.Environment["A"] != "2" || service1.Environment["OVERRIDE"] != "inline" {
+			t.Error(service1.Environment)
+		}
+		if !reflect.DeepEqual(service1.EnvFile, []string{"/project/common.env", "/project/service1.env"}) {
+			t.Error(service1.EnvFile)
+		}
+	})
+}
+
+func TestParseEnvFiles_MergedWithEnvironment(t *testing.T) {
+	withMockFS2(mockFileSystemEnvFile, func() {
+		c := newTestConfigLoader(map[string]string{
+			"FROM_ENV": "env",
+		})
+		s := &serviceInternal{
+			EnvFile: &stringOrStringSlice{
+				Values: []string{"common.env", "service1.env"},
+			},
+			Environment: &environment{
+				Values: []environmentNameValuePair{
+					{
+						Name: "OVERRIDE",
+						Value: &environmentValue{
+							StringValue: util.NewString("inline"),
+						},
+					},
+				},
+			},
+		}
+		dcFile := &dockerComposeFile{
+			resolvedFile: "/project/docker-compose.yml",
+		}
+		err := c.parseDockerComposeFileService(dcFile, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{
+			"A":        "2",
+			"FROM_ENV": "env",
+			"OVERRIDE": "inline",
+			"QUOTED":   `a "quoted" value`,
+			"URL":      "postgres://host/db?sslmode=disable&x=y",
+		}
+		if !reflect.DeepEqual(s.environmentParsed, expected) {
+			t.Error(s.environmentParsed)
+		}
+		if !reflect.DeepEqual(s.EnvFile.Values, []string{"/project/common.env", "/project/service1.env"}) {
+			t.Error(s.EnvFile.Values)
+		}
+	})
+}
+
+func TestParseEnvFiles_MissingFileError(t *testing.T) {
+	withMockFS2(mockFileSystemEnvFile, func() {
+		c := newTestConfigLoader(nil)
+		s := &serviceInternal{
+			EnvFile: &stringOrStringSlice{
+				Values: []string{"missing.env"},
+			},
+		}
+		dcFile := &dockerComposeFile{
+			resolvedFile: "/project/docker-compose.yml",
+		}
+		err := c.parseDockerComposeFileService(dcFile, s)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseExpose parses the expose list of a service (e.g. "3000", "3000-3005" and "53/udp"). Exposed ports are never published, so the
+// resulting port bindings have an ExternalMin of -1. Duplicates are removed.
+func parseExpose(inputs []port) ([]PortBinding, error) {
+	var result []PortBinding
+	for _, input := range inputs {
+		if input.Long != nil || strings.Contains(input.Value, ":") {
+			return nil, fmt.Errorf("invalid exposed port %q, should be port[-port][/protocol]", input.Value)
+		}
+		portBindings, err := parsePortBindings(input.Value, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, portBinding := range portBindings {
+			result = addPortBinding(result, portBinding)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpose_Success(t *testing.T) {
+	portBindings, err := parseExpose([]port{
+		{Value: "3000-3001"},
+		{Value: "53/udp"},
+		{Value: "3000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PortBinding{
+		{Internal: 3000, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+		{Internal: 3001, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+		{Internal: 53, ExternalMin: -1, ExternalMax: -1, Protocol: "udp"},
+	}
+	if !reflect.DeepEqual(portBindings, expected) {
+		t.Error(portBindings)
+	}
+}
+
+func TestParseExpose_Errors(t *testing.T) {
+	for _, input := range []port{
+		{Value: "8080:80"},
+		{Value: "!"},
+		{Long: &portLong{}},
+	} {
+		_, err := parseExpose([]port{input})
+		if err == nil {
+			t.Error(input)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExternalLink is a parsed element of the external_links field of a docker compose service (e.g. "realmysql:mysql"). External links
+// refer to containers that are not part of the docker compose project.
+type ExternalLink struct {
+	// The name of the external container.
+	Target string
+	// The host name under which the service can reach the target. Equal to Target if no alias was specified.
+	Alias string
+}
+
+// parseExternalLinks parses each element of an external_links list, splitting on the first ':'.
+func parseExternalLinks(externalLinks []string) ([]ExternalLink, error) {
+	var result []ExternalLink
+	for _, s := range externalLinks {
+		var externalLink ExternalLink
+		i := strings.IndexByte(s, ':')
+		if i < 0 {
+			externalLink.Target = s
+			externalLink.Alias = s
+		} else {
+			externalLink.Target = s[:i]
+			externalLink.Alias = s[i+1:]
+		}
+		if externalLink.Target == "" || externalLink.Alias == "" {
+			return nil, fmt.Errorf("external_links contains an invalid link: %#v", s)
+		}
+		result = append(result, externalLink)
+	}
+	return result, nil
+}
+
+// mergeExternalLinks merges external links by alias, where links of into win.
+func mergeExternalLinks(into, from []ExternalLink) []ExternalLink {
+	for _, externalLink1 := range from {
+		found := false
+		for _, externalLink2 := range into {
+			if externalLink1.Alias == externalLink2.Alias {
+				found = true
+				break
+			}
+		}
+		if !found {
+			into = append(into, externalLink1)
+		}
+	}
+	return into
+}
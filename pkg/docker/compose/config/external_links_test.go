@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseExternalLinks_Success(t *testing.T) {
+	externalLinks, err := parseExternalLinks([]string{"realmysql:mysql", "redis_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(externalLinks) != 2 {
+		t.Fatal(externalLinks)
+	}
+	if externalLinks[0] != (ExternalLink{Target: "realmysql", Alias: "mysql"}) {
+		t.Error(externalLinks[0])
+	}
+	if externalLinks[1] != (ExternalLink{Target: "redis_1", Alias: "redis_1"}) {
+		t.Error(externalLinks[1])
+	}
+}
+
+func TestParseExternalLinks_EmptyAliasError(t *testing.T) {
+	_, err := parseExternalLinks([]string{"realmysql:"})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestMergeExternalLinks_IntoWins(t *testing.T) {
+	into := []ExternalLink{
+		{Target: "a", Alias: "db"},
+	}
+	from := []ExternalLink{
+		{Target: "b", Alias: "db"},
+		{Target: "c", Alias: "cache"},
+	}
+	merged := mergeExternalLinks(into, from)
+	if len(merged) != 2 || merged[0].Target != "a" || merged[1].Target != "c" {
+		t.Error(merged)
+	}
+}
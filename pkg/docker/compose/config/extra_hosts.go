@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/uber-go/mapdecode"
+)
+
+// ExtraHost is a parsed element of the extra_hosts field of a docker compose service (e.g. "api.internal:10.0.0.5").
+type ExtraHost struct {
+	Hostname string
+	IP       string
+}
+
+// extraHosts is the extra_hosts field of a docker compose service, which is either a list of "host:ip" strings or a mapping from host
+// names to IP addresses. The mapping form is converted to the list form.
+type extraHosts struct {
+	Values []string
+}
+
+func (t *extraHosts) Decode(into mapdecode.Into) error {
+	var hostMap map[string]string
+	err := into(&hostMap)
+	if err != nil {
+		return into(&t.Values)
+	}
+	for hostname, ip := range hostMap {
+		t.Values = append(t.Values, hostname+":"+ip)
+	}
+	sort.Strings(t.Values)
+	return nil
+}
+
+// parseExtraHosts parses each element of extra_hosts into a host name and an IP address, splitting on the first ':' (or '=', which newer
+// versions of docker compose also accept). IPv6 addresses contain colons, so the host name cannot.
+func parseExtraHosts(hosts *extraHosts) ([]ExtraHost, error) {
+	if hosts == nil {
+		return nil, nil
+	}
+	var result []ExtraHost
+	for _, s := range hosts.Values {
+		i := strings.IndexAny(s, ":=")
+		if i < 0 {
+			return nil, fmt.Errorf("extra_hosts contains an entry without an IP address: %#v", s)
+		}
+		extraHost := ExtraHost{
+			Hostname: s[:i],
+			// Docker accepts IPv6 addresses in brackets (e.g. "host:[::1]").
+			IP: strings.TrimSuffix(strings.TrimPrefix(s[i+1:], "["), "]"),
+		}
+		if extraHost.Hostname == "" {
+			return nil, fmt.Errorf("extra_hosts contains an entry without a host name: %#v", s)
+		}
+		if net.ParseIP(extraHost.IP) == nil {
+			return nil, fmt.Errorf("extra_hosts contains an entry with an invalid IP address: %#v", s)
+		}
+		result = append(result, extraHost)
+	}
+	return result, nil
+}
+
+// mergeExtraHosts merges extra hosts by host name, where hosts of into win.
+func mergeExtraHosts(into, from []ExtraHost) []ExtraHost {
+	n := len(into)
+	for _, extraHost1 := range from {
+		found := false
+		for _, extraHost2 := range into[:n] {
+			if extraHost1.Hostname == extraHost2.Hostname {
+				found = true
+				break
+			}
+		}
+		if !found {
+			into = append(into, extraHost1)
+		}
+	}
+	return into
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uber-go/mapdecode"
+)
+
+func TestExtraHostsDecode_Map(t *testing.T) {
+	var dst extraHosts
+	err := mapdecode.Decode(&dst, map[string]string{"b.internal": "10.0.0.6", "a.internal": "10.0.0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst.Values, []string{"a.internal:10.0.0.5", "b.internal:10.0.0.6"}) {
+		t.Error(dst.Values)
+	}
+}
+
+func TestExtraHostsDecode_List(t *testing.T) {
+	src := []string{"api.internal:10.0.0.5"}
+	var dst extraHosts
+	err := mapdecode.Decode(&dst, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst.Values, src) {
+		t.Error(dst.Values)
+	}
+}
+
+func TestParseExtraHosts_Success(t *testing.T) {
+	hosts, err := parseExtraHosts(&extraHosts{
+		Values: []string{"api.internal:10.0.0.5", "db.internal=10.0.0.6", "ipv6.internal:::1", "brackets.internal:[fe80::1]"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ExtraHost{
+		{Hostname: "api.internal", IP: "10.0.0.5"},
+		{Hostname: "db.internal", IP: "10.0.0.6"},
+		{Hostname: "ipv6.internal", IP: "::1"},
+		{Hostname: "brackets.internal", IP: "fe80::1"},
+	}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Error(hosts)
+	}
+}
+
+func TestParseExtraHosts_Errors(t *testing.T) {
+	for _, value := range []string{"api.internal", "api.internal:", ":10.0.0.5", "api.internal:db.internal"} {
+		_, err := parseExtraHosts(&extraHosts{
+			Values: []string{value},
+		})
+		if err == nil {
+			t.Error(value)
+		}
+	}
+}
+
+func TestMergeExtraHosts(t *testing.T) {
+	into := []ExtraHost{{Hostname: "api.internal", IP: "10.0.0.5"}}
+	from := []ExtraHost{{Hostname: "db.internal", IP: "10.0.0.6"}, {Hostname: "api.internal", IP: "10.0.0.7"}}
+	hosts := mergeExtraHosts(into, from)
+	expected := []ExtraHost{{Hostname: "api.internal", IP: "10.0.0.5"}, {Hostname: "db.internal", IP: "10.0.0.6"}}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Error(hosts)
+	}
+}
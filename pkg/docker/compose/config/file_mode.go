@@ -0,0 +1,98 @@
+package config
+
+import "strings"
+
+// rawFileMode is an integer mode of the long syntax of a secret or config of a service, together with the mode as written in the docker
+// compose file. Whether an integer was written with a leading zero is lost when the file is decoded into a generic map, so
+// yamlFileRoot substitutes a rawFileMode for each integer mode (see parseFileMode).
+type rawFileMode struct {
+	// The mode as written in the file.
+	raw string
+	// The mode as resolved by YAML.
+	value int
+}
+
+// isAmbiguous returns true if the mode is a decimal integer that is likely meant to be octal: all its digits are octal, but it does not
+// have a leading zero, so its value differs from its octal reading (e.g. 440 instead of 0440).
+func (m rawFileMode) isAmbiguous() bool {
+	if len(m.raw) < 2 || m.raw[0] == '0' {
+		return false
+	}
+	return strings.Trim(m.raw, "01234567") == ""
+}
+
+// fileModeRef is an element of the secrets or configs of a service, of which only the mode of the long syntax is decoded.
+type fileModeRef struct {
+	// The mode as written in the file.
+	raw string
+}
+
+func (r *fileModeRef) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Decoding a scalar into a string yields the scalar as written. Elements of the short syntax are left empty instead of failing, so that
+	// the indices of the elements match the indices of the generic map.
+	var helper struct {
+		Mode string `yaml:"mode"`
+	}
+	if unmarshal(&helper) == nil {
+		r.raw = helper.Mode
+	}
+	return nil
+}
+
+type fileModeService struct {
+	Configs []fileModeRef `yaml:"configs"`
+	Secrets []fileModeRef `yaml:"secrets"`
+}
+
+// yamlFileRoot is the root of a docker compose file decoded as a generic map, in which the integer modes of secrets and configs of
+// services are rawFileModes. The raw modes are decoded from the same parsed document as the generic map.
+type yamlFileRoot struct {
+	dataMap genericMap
+}
+
+func (f *yamlFileRoot) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	err := unmarshal(&f.dataMap)
+	if err != nil {
+		return err
+	}
+	// Errors are ignored, because invalid elements are reported when the generic map is decoded. Version 1 docker compose files do not
+	// have a version and declare services at the root.
+	var services map[string]fileModeService
+	servicesMap := f.dataMap
+	if _, ok := f.dataMap["version"]; ok {
+		var root struct {
+			Services map[string]fileModeService `yaml:"services"`
+		}
+		_ = unmarshal(&root)
+		services = root.Services
+		servicesMap, _ = asGenericMap(f.dataMap["services"])
+	} else {
+		_ = unmarshal(&services)
+	}
+	for name, service := range services {
+		serviceMap, ok := asGenericMap(servicesMap[name])
+		if !ok {
+			continue
+		}
+		setRawFileModes(serviceMap["configs"], service.Configs)
+		setRawFileModes(serviceMap["secrets"], service.Secrets)
+	}
+	return nil
+}
+
+// setRawFileModes replaces the integer modes of the elements of the secrets or configs of a service by a rawFileMode.
+func setRawFileModes(elements interface{}, refs []fileModeRef) {
+	slice, _ := elements.([]interface{})
+	for i := 0; i < len(slice) && i < len(refs); i++ {
+		element, ok := asGenericMap(slice[i])
+		if !ok {
+			continue
+		}
+		if value, ok := element["mode"].(int); ok {
+			element["mode"] = rawFileMode{
+				raw:   refs[i].raw,
+				value: value,
+			}
+		}
+	}
+}
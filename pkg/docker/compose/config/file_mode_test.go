@@ -0,0 +1,92 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+var mockFileSystemFileModes = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+	"/octal.yml": {
+		Content: []byte(`version: '3.8'
+services:
+  a:
+    secrets:
+    - b
+    - source: c
+      mode: 0440
+    - source: d
+      mode: "440"
+    - source: e
+      mode: 4
+    configs:
+    - source: f
+      mode: 292
+secrets:
+  b:
+    file: ./b
+  c:
+    file: ./c
+  d:
+    file: ./d
+  e:
+    file: ./e
+configs:
+  f:
+    file: ./f
+`),
+	},
+	"/decimal-secret.yml": {
+		Content: []byte(`version: '3.8'
+services:
+  a:
+    secrets:
+    - source: c
+      mode: 440
+`),
+	},
+	"/decimal-config-v1.yml": {
+		Content: []byte(`a:
+  configs:
+  - source: f
+    mode: 644
+`),
+	},
+})
+
+func TestNew_FileModes(t *testing.T) {
+	withMockFS2(mockFileSystemFileModes, func() {
+		c, err := New([]string{"/octal.yml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		secrets := c.Services["a"].Secrets
+		if len(secrets) != 4 || secrets[1].Mode == nil || *secrets[1].Mode != 0440 || *secrets[2].Mode != 0440 || *secrets[3].Mode != 4 {
+			t.Error(secrets)
+		}
+		configs := c.Services["a"].Configs
+		if len(configs) != 1 || configs[0].Mode == nil || *configs[0].Mode != 0444 {
+			t.Error(configs)
+		}
+	})
+}
+
+func TestNew_DecimalSecretModeError(t *testing.T) {
+	withMockFS2(mockFileSystemFileModes, func() {
+		_, err := New([]string{"/decimal-secret.yml"})
+		if err == nil || !strings.Contains(err.Error(), "mode 440 is a decimal integer because it does not have a leading zero (did you "+
+			"mean 0440?)") {
+			t.Error(err)
+		}
+	})
+}
+
+func TestNew_DecimalConfigModeV1Error(t *testing.T) {
+	withMockFS2(mockFileSystemFileModes, func() {
+		_, err := New([]string{"/decimal-config-v1.yml"})
+		if err == nil || !strings.Contains(err.Error(), "did you mean 0644?") {
+			t.Error(err)
+		}
+	})
+}
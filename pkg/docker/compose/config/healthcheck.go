@@ -48,10 +48,28 @@ func ParseHealthcheck(i *healthcheckInternal) (*Healthcheck, bool, error) {
 	if err != nil {
 		return nil, false, err
 	}
+	err = healthcheck.parseStartPeriod(i.StartPeriod)
+	if err != nil {
+		return nil, false, err
+	}
 	healthcheck.parseRetries(i.Retries)
 	return healthcheck, false, nil
 }
 
+// ApplyDefaults sets the fields that docker treats as unset (zero durations) to docker's default values. This is used for healthchecks of
+// images, where docker stores omitted durations as zero.
+func (healthcheck *Healthcheck) ApplyDefaults() {
+	if healthcheck.Interval == 0 {
+		healthcheck.Interval = HealthcheckDefaultInterval
+	}
+	if healthcheck.Timeout == 0 {
+		healthcheck.Timeout = HealthcheckDefaultTimeout
+	}
+	if healthcheck.Retries == 0 {
+		healthcheck.Retries = HealthcheckDefaultRetries
+	}
+}
+
 func (healthcheck *Healthcheck) parseTimeout(value *string) error {
 	if value != nil {
 		var err error
@@ -90,9 +108,22 @@ func (healthcheck *Healthcheck) parseTest(test []string) error {
 	return nil
 }
 
+func (healthcheck *Healthcheck) parseStartPeriod(value *string) error {
+	// start_period is unsupported in docker-compose 2.1, in which case it is absent and treated as 0 (the default of docker).
+	if value != nil {
+		startPeriod, err := time.ParseDuration(*value)
+		if err != nil {
+			return err
+		}
+		if startPeriod < 0 {
+			return fmt.Errorf("field \"start_period\" of Healthcheck must not be negative")
+		}
+		healthcheck.StartPeriod = startPeriod
+	}
+	return nil
+}
+
 func (healthcheck *Healthcheck) parseInterval(value *string) error {
-	// We do not set StartPeriod because it is unsupported in docker-compose 2.1 and
-	// should therefore be treated as 0.
 	// time.ParseDuration supports a superset of durations compared to docker-compose:
 	// https://golang.org/pkg/time/#Duration
 	// https://docs.docker.com/compose/compose-file/compose-file-v2/#specifying-durations
@@ -256,3 +256,50 @@ func TestParseHealthcheck_Success(t *testing.T) {
 		t.Errorf("%+v\n", *healthcheck)
 	}
 }
+
+func TestParseHealthcheck_TestAndStartPeriodOnly(t *testing.T) {
+	healthcheckYAML := &healthcheckInternal{
+		StartPeriod: util.NewString("1m"),
+		Test: HealthcheckTest{
+			Values: []string{HealthcheckCommandCmd, "true"},
+		},
+	}
+	healthcheck, isDisabled, err := ParseHealthcheck(healthcheckYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isDisabled {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(*healthcheck, Healthcheck{
+		Interval:    HealthcheckDefaultInterval,
+		Retries:     HealthcheckDefaultRetries,
+		StartPeriod: time.Minute,
+		Test:        []string{"true"},
+		Timeout:     HealthcheckDefaultTimeout,
+	}) {
+		t.Errorf("%+v\n", *healthcheck)
+	}
+}
+
+func TestParseStartPeriod_NegativeDuration(t *testing.T) {
+	h := &Healthcheck{}
+	err := h.parseStartPeriod(util.NewString("-1s"))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestHealthcheckApplyDefaults(t *testing.T) {
+	h := &Healthcheck{
+		Interval: time.Second,
+	}
+	h.ApplyDefaults()
+	if !reflect.DeepEqual(*h, Healthcheck{
+		Interval: time.Second,
+		Retries:  HealthcheckDefaultRetries,
+		Timeout:  HealthcheckDefaultTimeout,
+	}) {
+		t.Errorf("%+v\n", *h)
+	}
+}
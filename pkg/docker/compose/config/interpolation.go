@@ -11,8 +11,10 @@ import (
 type ValueGetter func(name string) (string, bool)
 
 type configInterpolator struct {
-	config      genericMap
-	errorList   []error
+	config    genericMap
+	errorList []error
+	// Called with the name of each variable that is substituted with the empty string because it is not set. May be nil.
+	unset       func(name string)
 	valueGetter ValueGetter
 	version     *version.Version
 }
@@ -91,8 +93,15 @@ func (c *configInterpolator) addError(err error, _ path) {
 // https://github.com/docker/compose/master/compose/config/config.py.
 // TODO https://github.com/kube-compose/kube-compose/issues/11 support arbitrary map types instead of genericMap.
 func InterpolateConfig(config genericMap, valueGetter ValueGetter, v *version.Version) error {
+	return interpolateConfig(config, valueGetter, v, nil)
+}
+
+// interpolateConfig is InterpolateConfig, but calls unset (if not nil) with the name of each variable that is substituted with the empty
+// string because it is not set, so that the caller can warn like docker compose does.
+func interpolateConfig(config genericMap, valueGetter ValueGetter, v *version.Version, unset func(name string)) error {
 	c := &configInterpolator{
 		config:      config,
+		unset:       unset,
 		valueGetter: valueGetter,
 		version:     v,
 	}
@@ -102,10 +111,20 @@ func InterpolateConfig(config genericMap, valueGetter ValueGetter, v *version.Ve
 type stringInterpolator struct {
 	sb          strings.Builder
 	str         string
+	unset       func(name string)
 	v           bool
 	valueGetter ValueGetter
 }
 
+// getValue returns the value of a variable without a default value, which is the empty string if the variable is not set.
+func (k *stringInterpolator) getValue(name string) string {
+	value, found := k.valueGetter(name)
+	if !found && k.unset != nil {
+		k.unset(name)
+	}
+	return value
+}
+
 func (k *stringInterpolator) advance(n int) {
 	k.str = k.str[n:]
 }
@@ -117,20 +136,12 @@ func (k *stringInterpolator) processAfterDollarSignSimple() {
 	for i < len(k.str) && (k.str[i] == '_' || IsASCIILetter(k.str[i]) || IsASCIIDigit(k.str[i])) {
 		i++
 	}
-	value, found := k.valueGetter(k.str[0:i])
-	if !found {
-		value = ""
-	}
-	k.sb.WriteString(value)
+	k.sb.WriteString(k.getValue(k.str[0:i]))
 	k.advance(i)
 }
 
 func (k *stringInterpolator) processCurlyBraceExpansionSimple(i int) {
-	value, found := k.valueGetter(k.str[1:i])
-	if !found {
-		value = ""
-	}
-	k.sb.WriteString(value)
+	k.sb.WriteString(k.getValue(k.str[1:i]))
 	k.advance(i + 1)
 }
 
@@ -218,8 +229,13 @@ func (k *stringInterpolator) getResult() string {
 // is otherwise identical to the Python implementation:
 // https://github.com/docker/compose/blob/master/compose/config/interpolation.py
 func Interpolate(str string, valueGetter ValueGetter, v bool) (string, error) {
+	return interpolate(str, valueGetter, v, nil)
+}
+
+func interpolate(str string, valueGetter ValueGetter, v bool, unset func(name string)) (string, error) {
 	k := stringInterpolator{
 		str:         str,
+		unset:       unset,
 		v:           v,
 		valueGetter: valueGetter,
 	}
@@ -253,7 +269,7 @@ func IsASCIIDigit(b byte) bool {
 
 func (c *configInterpolator) interpolateRecursive(obj interface{}, p path) interface{} {
 	if str, ok := obj.(string); ok {
-		str2, err := Interpolate(str, c.valueGetter, !c.version.LessThan(v2_1))
+		str2, err := interpolate(str, c.valueGetter, !c.version.LessThan(v2_1), c.unset)
 		if err != nil {
 			c.addError(err, p)
 		}
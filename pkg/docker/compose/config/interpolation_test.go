@@ -249,3 +249,19 @@ func TestInterpolateConfig_V3(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestInterpolate_UnsetVariables(t *testing.T) {
+	m := map[string]string{
+		"VAR1": testValue,
+	}
+	var unset []string
+	str, err := interpolate("$VAR1 ${VAR2} ${VAR3:-default} $$VAR4 $VAR5", mapValueGetter(m), true, func(name string) {
+		unset = append(unset, name)
+	})
+	if err != nil || str != "val1  default $VAR4 " {
+		t.Fatal(str, err)
+	}
+	if !reflect.DeepEqual(unset, []string{"VAR2", "VAR5"}) {
+		t.Error(unset)
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parseLabels parses the labels of a docker compose service, which are a mapping or a list of "name=value" strings. Labels without a value
+// (e.g. "com.example.flag" in list form, or null in mapping form) have the empty string as value, like docker compose.
+func parseLabels(labels []environmentNameValuePair) (map[string]string, error) {
+	if labels == nil {
+		return nil, nil
+	}
+	labelsParsed := make(map[string]string, len(labels))
+	for _, pair := range labels {
+		if pair.Name == "" {
+			return nil, fmt.Errorf("labels contains a label without a name")
+		}
+		var value string
+		switch {
+		case pair.Value == nil:
+		case pair.Value.StringValue != nil:
+			value = *pair.Value.StringValue
+		case pair.Value.Int64Value != nil:
+			value = strconv.FormatInt(*pair.Value.Int64Value, 10)
+		case pair.Value.FloatValue != nil:
+			value = strconv.FormatFloat(*pair.Value.FloatValue, 'g', -1, 64)
+		}
+		labelsParsed[pair.Name] = value
+	}
+	return labelsParsed, nil
+}
@@ -22,30 +22,65 @@ func addVolume(volumes []ServiceVolume, volume1 ServiceVolume) []ServiceVolume {
 
 func merge(into, from *serviceInternal, mergeExtends bool) {
 	// Rules here are based on https://docs.docker.com/compose/extends/#adding-and-overriding-configuration
+	into.capAddParsed = mergeCapabilities(into.capAddParsed, from.capAddParsed)
+	into.capDropParsed = mergeCapabilities(into.capDropParsed, from.capDropParsed)
 	if into.Command == nil {
 		into.Command = from.Command
 	}
+	into.Configs = mergeServiceConfigs(into.Configs, from.Configs)
 	into.DependsOn = mergeDependsOnMaps(into.DependsOn, from.DependsOn)
+	if into.Deploy == nil {
+		into.Deploy = from.Deploy
+	}
+	if into.dnsOptionsParsed == nil {
+		into.dnsOptionsParsed = from.dnsOptionsParsed
+	}
+	into.dnsServersParsed = mergeUniqueStrings(into.dnsServersParsed, from.dnsServersParsed)
+	into.dnsSearchParsed = mergeUniqueStrings(into.dnsSearchParsed, from.dnsSearchParsed)
 	into.environmentParsed = mergeStringMaps(into.environmentParsed, from.environmentParsed)
+	into.exposeParsed = mergePortBindings(into.exposeParsed, from.exposeParsed)
+	into.externalLinksParsed = mergeExternalLinks(into.externalLinksParsed, from.externalLinksParsed)
+	into.extraHostsParsed = mergeExtraHosts(into.extraHostsParsed, from.extraHostsParsed)
 	into.Healthcheck = mergeHealthchecks(into.Healthcheck, from.Healthcheck)
+	into.labelsParsed = mergeStringMaps(into.labelsParsed, from.labelsParsed)
+	into.networksParsed = mergeServiceNetworks(into.networksParsed, from.networksParsed)
 	into.portsParsed = mergePortBindings(into.portsParsed, from.portsParsed)
+	into.Secrets = mergeServiceSecrets(into.Secrets, from.Secrets)
+	into.tmpfsParsed = mergeTmpfsMounts(into.tmpfsParsed, from.tmpfsParsed)
+	into.unsupportedKeys = mergeUnsupportedKeys(into.unsupportedKeys, from.unsupportedKeys)
 	into.Volumes = mergeVolumes(into.Volumes, from.Volumes)
+	into.xProperties = mergeXProperties(into.xProperties, from.xProperties)
 
 	if into.Entrypoint == nil {
 		into.Entrypoint = from.Entrypoint
 	}
+	if into.EnvFile == nil {
+		into.EnvFile = from.EnvFile
+	}
 	if into.Image == nil {
 		into.Image = from.Image
 	}
 	if into.Privileged == nil {
 		into.Privileged = from.Privileged
 	}
+	if into.profilesParsed == nil {
+		into.profilesParsed = from.profilesParsed
+	}
+	if into.ReadOnly == nil {
+		into.ReadOnly = from.ReadOnly
+	}
 	if into.Restart == nil {
 		into.Restart = from.Restart
 	}
+	if into.StopGracePeriod == nil {
+		into.StopGracePeriod = from.StopGracePeriod
+	}
 	if into.User == nil {
 		into.User = from.User
 	}
+	if into.WorkingDir == nil {
+		into.WorkingDir = from.WorkingDir
+	}
 	if mergeExtends && into.Extends == nil {
 		into.Extends = from.Extends
 	}
@@ -59,6 +94,12 @@ func mergeDependsOnMaps(into, from *dependsOn) *dependsOn {
 		for k, v := range from.Values {
 			if _, ok := into.Values[k]; !ok {
 				into.Values[k] = v
+				if from.Restart[k] {
+					if into.Restart == nil {
+						into.Restart = map[string]bool{}
+					}
+					into.Restart[k] = true
+				}
 			}
 		}
 	}
@@ -79,6 +120,9 @@ func mergeHealthchecks(into, from *healthcheckInternal) *healthcheckInternal {
 		if into.Retries == nil {
 			into.Retries = from.Retries
 		}
+		if into.StartPeriod == nil {
+			into.StartPeriod = from.StartPeriod
+		}
 		// Test.Values is nil if and only if the field is not set. We need to know whether the field is set to correctly merge. See also
 		// healthcheckInternal.
 		if into.Test.Values == nil {
@@ -91,9 +135,14 @@ func mergeHealthchecks(into, from *healthcheckInternal) *healthcheckInternal {
 	return into
 }
 
+// mergePortBindings concatenates the ports of into and from. The result never shares its backing array with from, so that appending to
+// the result cannot mutate the services of a cached docker compose file.
 func mergePortBindings(into, from []PortBinding) []PortBinding {
 	if len(into) == 0 {
-		return from
+		if from == nil {
+			return nil
+		}
+		return append([]PortBinding{}, from...)
 	}
 	for _, v := range from {
 		into = addPortBinding(into, v)
@@ -101,7 +150,9 @@ func mergePortBindings(into, from []PortBinding) []PortBinding {
 	return into
 }
 
-// This merge function is used when merging files together.
+// This merge function is used when merging files together. Files are merged from last to first, so that the services of later files take
+// precedence: single values (e.g. image and command) are replaced, maps (e.g. environment) are merged by key, ports are concatenated and
+// volumes are concatenated, except that a volume of a later file replaces a volume of an earlier file with the same container path.
 // from is never mutated, any any non-frozen/mutable value in from is guaranteed to be copied.
 // This is an important correctness property, because from is a pointer to the cache of a docker
 // compose file. If from were to be mutated then an extends would inherit from an intermediate result instead of the original docker compose
@@ -126,9 +177,14 @@ func mergeServices(into, from map[string]*serviceInternal) {
 	}
 }
 
+// mergeStringMaps adds the entries of from to into, where entries of into take precedence. If into is nil a new map is allocated, so that
+// the result is never from itself (see mergePortBindings).
 func mergeStringMaps(into, from map[string]string) map[string]string {
-	if len(into) == 0 {
-		return from
+	if len(from) == 0 {
+		return into
+	}
+	if into == nil {
+		into = make(map[string]string, len(from))
 	}
 	for k, v := range from {
 		if _, ok := into[k]; !ok {
@@ -138,12 +194,35 @@ func mergeStringMaps(into, from map[string]string) map[string]string {
 	return into
 }
 
+// mergeVolumes adds the volumes of from to into, where volumes of into take precedence (see addVolume and mergePortBindings).
 func mergeVolumes(into, from []ServiceVolume) []ServiceVolume {
 	if len(into) == 0 {
-		return from
+		if from == nil {
+			return nil
+		}
+		return append([]ServiceVolume{}, from...)
 	}
 	for _, v := range from {
 		into = addVolume(into, v)
 	}
 	return into
 }
+
+// mergeXProperties always returns a new map when both into and from are non-empty, because into may be the x- properties of a cached
+// docker compose file.
+func mergeXProperties(into, from XProperties) XProperties {
+	if len(from) == 0 {
+		return into
+	}
+	if len(into) == 0 {
+		return from
+	}
+	result := XProperties{}
+	for k, v := range from {
+		result[k] = v
+	}
+	for k, v := range into {
+		result[k] = v
+	}
+	return result
+}
@@ -8,9 +8,9 @@ import (
 )
 
 func Test_MergePortBindings_Basic(t *testing.T) {
-	intoPorts := []PortBinding{{80, 80, 80, "tcp", ""}}
-	fromPorts := []PortBinding{{8000, 8000, 8000, "tcp", ""}}
-	expected := []PortBinding{{80, 80, 80, "tcp", ""}, {8000, 8000, 8000, "tcp", ""}}
+	intoPorts := []PortBinding{{80, 80, 80, "tcp", "", ""}}
+	fromPorts := []PortBinding{{8000, 8000, 8000, "tcp", "", ""}}
+	expected := []PortBinding{{80, 80, 80, "tcp", "", ""}, {8000, 8000, 8000, "tcp", "", ""}}
 
 	intoPorts = mergePortBindings(intoPorts, fromPorts)
 	if !reflect.DeepEqual(intoPorts, expected) {
@@ -19,9 +19,9 @@ func Test_MergePortBindings_Basic(t *testing.T) {
 }
 
 func Test_MergePortBindings_Duplicate(t *testing.T) {
-	intoPorts := []PortBinding{{80, 80, 80, "tcp", ""}, {8000, 8000, 8000, "tcp", ""}}
-	fromPorts := []PortBinding{{8000, 8000, 8000, "tcp", ""}}
-	expected := []PortBinding{{80, 80, 80, "tcp", ""}, {8000, 8000, 8000, "tcp", ""}}
+	intoPorts := []PortBinding{{80, 80, 80, "tcp", "", ""}, {8000, 8000, 8000, "tcp", "", ""}}
+	fromPorts := []PortBinding{{8000, 8000, 8000, "tcp", "", ""}}
+	expected := []PortBinding{{80, 80, 80, "tcp", "", ""}, {8000, 8000, 8000, "tcp", "", ""}}
 
 	intoPorts = mergePortBindings(intoPorts, fromPorts)
 	if !reflect.DeepEqual(intoPorts, expected) {
@@ -30,9 +30,9 @@ func Test_MergePortBindings_Duplicate(t *testing.T) {
 }
 
 func Test_MergePortBindings_DuplicateInternalOnly(t *testing.T) {
-	intoPorts := []PortBinding{{80, 80, 80, "tcp", ""}, {8000, 8001, 8001, "tcp", ""}}
-	fromPorts := []PortBinding{{8000, 8000, 8000, "tcp", ""}}
-	expected := []PortBinding{{80, 80, 80, "tcp", ""}, {8000, 8001, 8001, "tcp", ""}, {8000, 8000, 8000, "tcp", ""}}
+	intoPorts := []PortBinding{{80, 80, 80, "tcp", "", ""}, {8000, 8001, 8001, "tcp", "", ""}}
+	fromPorts := []PortBinding{{8000, 8000, 8000, "tcp", "", ""}}
+	expected := []PortBinding{{80, 80, 80, "tcp", "", ""}, {8000, 8001, 8001, "tcp", "", ""}, {8000, 8000, 8000, "tcp", "", ""}}
 
 	intoPorts = mergePortBindings(intoPorts, fromPorts)
 	if !reflect.DeepEqual(intoPorts, expected) {
@@ -84,17 +84,17 @@ func Test_MergeStringMaps_Empty(t *testing.T) {
 func Test_Merge_Basic(t *testing.T) {
 	serviceA := &serviceInternal{
 		environmentParsed: map[string]string{"a": "b"},
-		portsParsed:       []PortBinding{{80, 80, 80, "tcp", ""}},
+		portsParsed:       []PortBinding{{80, 80, 80, "tcp", "", ""}},
 	}
 
 	serviceB := &serviceInternal{
 		environmentParsed: map[string]string{"b": "c"},
-		portsParsed:       []PortBinding{{8000, 8000, 8000, "tcp", ""}},
+		portsParsed:       []PortBinding{{8000, 8000, 8000, "tcp", "", ""}},
 	}
 
 	expected := &serviceInternal{
 		environmentParsed: map[string]string{"a": "b", "b": "c"},
-		portsParsed:       []PortBinding{{80, 80, 80, "tcp", ""}, {8000, 8000, 8000, "tcp", ""}},
+		portsParsed:       []PortBinding{{80, 80, 80, "tcp", "", ""}, {8000, 8000, 8000, "tcp", "", ""}},
 	}
 
 	merge(serviceA, serviceB, false)
@@ -156,7 +156,7 @@ func Test_MergeDependsOnMaps_Success(t *testing.T) {
 	}
 	actual := mergeDependsOnMaps(into, from)
 	if !reflect.DeepEqual(actual, &dependsOn{
-		map[string]ServiceHealthiness{
+		Values: map[string]ServiceHealthiness{
 			"mergedependsonsuccess": ServiceStarted,
 		},
 	}) {
@@ -180,3 +180,31 @@ func Test_MergeHealthchecks_Success(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_MergeServices_DoesNotMutateFrom(t *testing.T) {
+	from1 := &serviceInternal{
+		environmentParsed: map[string]string{"a": "b"},
+		portsParsed:       []PortBinding{{80, 80, 80, "tcp", "", ""}},
+	}
+	from2 := &serviceInternal{
+		environmentParsed: map[string]string{"c": "d"},
+		portsParsed:       []PortBinding{{8000, 8000, 8000, "tcp", "", ""}},
+	}
+	into := map[string]*serviceInternal{}
+	mergeServices(into, map[string]*serviceInternal{"service1": from1})
+	mergeServices(into, map[string]*serviceInternal{"service1": from2})
+	if !reflect.DeepEqual(from1.environmentParsed, map[string]string{"a": "b"}) || len(from1.portsParsed) != 1 {
+		t.Fail()
+	}
+	if len(into["service1"].environmentParsed) != 2 || len(into["service1"].portsParsed) != 2 {
+		t.Fail()
+	}
+}
+
+func Test_Merge_WorkingDir(t *testing.T) {
+	into := &serviceInternal{}
+	merge(into, &serviceInternal{WorkingDir: util.NewString("/srv")}, false)
+	if into.WorkingDir == nil || *into.WorkingDir != "/srv" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/uber-go/mapdecode"
+)
+
+// ServiceNetwork is an element of the networks field of a docker compose service (e.g. "default" with the aliases "db" and "database").
+// All pods share the network of the cluster, so only the aliases of networks are translated.
+type ServiceNetwork struct {
+	Name string
+	// Alternative host names of the service on the network.
+	Aliases []string
+}
+
+// serviceNetworks is the networks field of a docker compose service, which is either a list of network names or a mapping from network
+// names to (possibly null) network configurations.
+type serviceNetworks struct {
+	Values []ServiceNetwork
+}
+
+func (t *serviceNetworks) Decode(into mapdecode.Into) error {
+	var networkMap map[string]*struct {
+		Aliases []string `mapdecode:"aliases"`
+	}
+	err := into(&networkMap)
+	if err != nil {
+		var names []string
+		err = into(&names)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			t.Values = append(t.Values, ServiceNetwork{
+				Name: name,
+			})
+		}
+		return nil
+	}
+	for name, network := range networkMap {
+		serviceNetwork := ServiceNetwork{
+			Name: name,
+		}
+		if network != nil {
+			serviceNetwork.Aliases = network.Aliases
+		}
+		t.Values = append(t.Values, serviceNetwork)
+	}
+	sortServiceNetworks(t.Values)
+	return nil
+}
+
+func sortServiceNetworks(networks []ServiceNetwork) {
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].Name < networks[j].Name
+	})
+}
+
+// parseNetworks validates the networks field of a docker compose service.
+func parseNetworks(networks *serviceNetworks) ([]ServiceNetwork, error) {
+	if networks == nil {
+		return nil, nil
+	}
+	for _, network := range networks.Values {
+		for _, alias := range network.Aliases {
+			if alias == "" {
+				return nil, fmt.Errorf("network %s has an empty alias", network.Name)
+			}
+		}
+	}
+	return networks.Values, nil
+}
+
+// mergeServiceNetworks merges networks by name, where networks of into win.
+func mergeServiceNetworks(into, from []ServiceNetwork) []ServiceNetwork {
+	n := len(into)
+	for _, network1 := range from {
+		found := false
+		for _, network2 := range into[:n] {
+			if network1.Name == network2.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			into = append(into, network1)
+		}
+	}
+	if len(into) > n {
+		sortServiceNetworks(into)
+	}
+	return into
+}
+
+// NetworkAliases returns the aliases of all networks of the service, without duplicates, in the order of the networks.
+func (s *Service) NetworkAliases() []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, network := range s.Networks {
+		for _, alias := range network.Aliases {
+			if !seen[alias] {
+				seen[alias] = true
+				result = append(result, alias)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNetworks_EmptyAliasError(t *testing.T) {
+	_, err := parseNetworks(&serviceNetworks{
+		Values: []ServiceNetwork{
+			{Name: "default", Aliases: []string{""}},
+		},
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestMergeServiceNetworks(t *testing.T) {
+	into := []ServiceNetwork{{Name: "default", Aliases: []string{"db"}}}
+	from := []ServiceNetwork{{Name: "backend"}, {Name: "default", Aliases: []string{"database"}}}
+	networks := mergeServiceNetworks(into, from)
+	expected := []ServiceNetwork{{Name: "backend"}, {Name: "default", Aliases: []string{"db"}}}
+	if !reflect.DeepEqual(networks, expected) {
+		t.Error(networks)
+	}
+}
@@ -11,7 +11,9 @@ import (
 )
 
 type stringOrStringSlice struct {
-	Values []string
+	// True if the value was a string, in which case Values has a single element.
+	IsString bool
+	Values   []string
 }
 
 func (t *stringOrStringSlice) Decode(into mapdecode.Into) error {
@@ -22,11 +24,26 @@ func (t *stringOrStringSlice) Decode(into mapdecode.Into) error {
 		if err != nil {
 			return err
 		}
+		t.IsString = true
 		t.Values = []string{str}
 	}
 	return nil
 }
 
+// splitIfString splits the value into shell words if it was a string (see splitShellWords).
+func (t *stringOrStringSlice) splitIfString() error {
+	if t == nil || !t.IsString {
+		return nil
+	}
+	words, err := splitShellWords(t.Values[0])
+	if err != nil {
+		return err
+	}
+	t.IsString = false
+	t.Values = words
+	return nil
+}
+
 type HealthcheckTest struct {
 	Values []string
 }
@@ -56,10 +73,11 @@ type healthcheckInternal struct {
 	Test    HealthcheckTest `mapdecode:"test"`
 	Timeout *string         `mapdecode:"timeout"`
 	// start_period is only available in docker-compose 2.3 or higher
+	StartPeriod *string `mapdecode:"start_period"`
 }
 
 func (h *healthcheckInternal) IsEmpty() bool {
-	return h.Disable == nil && h.Interval == nil && h.Retries == nil && h.GetTest() == nil && h.Timeout == nil
+	return h.Disable == nil && h.Interval == nil && h.Retries == nil && h.GetTest() == nil && h.Timeout == nil && h.StartPeriod == nil
 }
 
 func (h *healthcheckInternal) GetTest() []string {
@@ -68,13 +86,20 @@ func (h *healthcheckInternal) GetTest() []string {
 
 type dependsOn struct {
 	Values map[string]ServiceHealthiness
+	// The names of the services whose dependency sets restart: true (long syntax only). kube-compose does not restart services, but the
+	// value is kept so that it is available to callers.
+	Restart map[string]bool
+}
+
+// dependsOnEntry is an entry of the long syntax of depends_on.
+type dependsOnEntry struct {
+	Condition string `mapdecode:"condition"`
+	Restart   bool   `mapdecode:"restart"`
 }
 
 func (t *dependsOn) Decode(into mapdecode.Into) error {
-	var strMap map[string]struct {
-		Condition string `mapdecode:"condition"`
-	}
-	err := into(&strMap)
+	var rawMap map[string]map[string]interface{}
+	err := into(&rawMap)
 	if err != nil {
 		var services []string
 		err = into(&services)
@@ -90,9 +115,21 @@ func (t *dependsOn) Decode(into mapdecode.Into) error {
 			t.Values[service] = ServiceStarted
 		}
 	} else {
-		n := len(strMap)
+		n := len(rawMap)
 		t.Values = make(map[string]ServiceHealthiness, n)
-		for service, obj := range strMap {
+		for service, raw := range rawMap {
+			// Keys other than condition and restart (e.g. required) are ignored, so that newer docker compose files can be used.
+			var obj dependsOnEntry
+			err = mapdecode.Decode(&obj, raw, mapdecode.IgnoreUnused(true))
+			if err != nil {
+				return err
+			}
+			if obj.Restart {
+				if t.Restart == nil {
+					t.Restart = map[string]bool{}
+				}
+				t.Restart[service] = true
+			}
 			switch obj.Condition {
 			case "service_healthy":
 				t.Values[service] = ServiceHealthy
@@ -121,25 +158,45 @@ type environmentValue struct {
 	StringValue *string
 }
 
+// Decode decodes integers before floats, so that integers that cannot be represented exactly by a float64 (e.g. 1234567890123456789)
+// are not rounded. Booleans are decoded as the strings "true" and "false", and null as the absence of a value.
 func (v *environmentValue) Decode(into mapdecode.Into) error {
-	var f float64
-	err := into(&f)
-	if err == nil {
-		if -9223372036854775000.0 <= f && f <= 9223372036854775000.0 && math.Floor(f) == f {
+	var raw interface{}
+	err := into(&raw)
+	if err != nil {
+		return err
+	}
+	switch t := raw.(type) {
+	case nil:
+	case int:
+		v.Int64Value = new(int64)
+		*v.Int64Value = int64(t)
+	case int64:
+		v.Int64Value = new(int64)
+		*v.Int64Value = t
+	case uint64:
+		if t <= math.MaxInt64 {
 			v.Int64Value = new(int64)
-			*v.Int64Value = int64(f)
-			return nil
+			*v.Int64Value = int64(t)
+		} else {
+			v.StringValue = util.NewString(strconv.FormatUint(t, 10))
 		}
-		v.FloatValue = new(float64)
-		*v.FloatValue = f
-		return nil
-	}
-	var s string
-	err = into(&s)
-	if err == nil {
-		v.StringValue = util.NewString(s)
+	case float64:
+		if -9223372036854775000.0 <= t && t <= 9223372036854775000.0 && math.Floor(t) == t {
+			v.Int64Value = new(int64)
+			*v.Int64Value = int64(t)
+		} else {
+			v.FloatValue = new(float64)
+			*v.FloatValue = t
+		}
+	case bool:
+		v.StringValue = util.NewString(strconv.FormatBool(t))
+	case string:
+		v.StringValue = util.NewString(t)
+	default:
+		return fmt.Errorf("environment value must be a string, number, boolean or null, but got %#v", raw)
 	}
-	return err
+	return nil
 }
 
 type environment struct {
@@ -209,10 +266,22 @@ func (e *extends) Decode(into mapdecode.Into) error {
 	return nil
 }
 
+// portLong is the long syntax of a port of a docker compose service.
+type portLong struct {
+	HostIP    *string `mapdecode:"host_ip"`
+	Mode      *string `mapdecode:"mode"`
+	Protocol  *string `mapdecode:"protocol"`
+	Published *port   `mapdecode:"published"`
+	Target    *port   `mapdecode:"target"`
+}
+
 type port struct {
 	Value string
+	// Nil if the short syntax is used.
+	Long *portLong
 }
 
+// Decode parses either the long or short syntax of a port of a docker compose service.
 func (p *port) Decode(into mapdecode.Into) error {
 	var int64Val int64
 	err := into(&int64Val)
@@ -222,8 +291,16 @@ func (p *port) Decode(into mapdecode.Into) error {
 	}
 	strVal := ""
 	err = into(&strVal)
-	p.Value = strVal
-	return err
+	if err == nil {
+		p.Value = strVal
+		return nil
+	}
+	var long portLong
+	if into(&long) != nil {
+		return err
+	}
+	p.Long = &long
+	return nil
 }
 
 // ServiceVolume is the type used to encode each volume of a docker compose service.
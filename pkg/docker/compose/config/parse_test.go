@@ -100,6 +100,42 @@ func TestEnvironmentValueDecode_FractionalFloat64Success(t *testing.T) {
 	}
 }
 
+func TestEnvironmentValueDecode_LargeIntegerSuccess(t *testing.T) {
+	src := 1234567890123456789
+	var dst environmentValue
+	expected := environmentValue{
+		Int64Value: new(int64),
+	}
+	*expected.Int64Value = int64(src)
+	err := mapdecode.Decode(&dst, src)
+	if err != nil {
+		t.Error(err)
+	} else if !areEnvironmentValuesEqual(&dst, &expected) {
+		t.Fail()
+	}
+}
+
+func TestEnvironmentValueDecode_LargeUnsignedIntegerSuccess(t *testing.T) {
+	src := uint64(18446744073709551615)
+	var dst environmentValue
+	err := mapdecode.Decode(&dst, src)
+	if err != nil {
+		t.Error(err)
+	} else if !areEnvironmentValuesEqual(&dst, &environmentValue{StringValue: util.NewString("18446744073709551615")}) {
+		t.Fail()
+	}
+}
+
+func TestEnvironmentValueDecode_BoolSuccess(t *testing.T) {
+	var dst environmentValue
+	err := mapdecode.Decode(&dst, true)
+	if err != nil {
+		t.Error(err)
+	} else if !areEnvironmentValuesEqual(&dst, &environmentValue{StringValue: util.NewString("true")}) {
+		t.Fail()
+	}
+}
+
 func TestEnvironmentValueDecode_StringSuccess(t *testing.T) {
 	src := "environmentValueStringSuccess"
 	var dst environmentValue
@@ -288,6 +324,50 @@ func TestDependsOnDecode_MapSuccess(t *testing.T) {
 	}
 }
 
+func TestDependsOnDecode_MapRestart(t *testing.T) {
+	src := map[string]map[string]interface{}{
+		"service-bla-1": {
+			"condition": "service_healthy",
+			"restart":   true,
+		},
+		"service-bla-2": {
+			"condition": "service_started",
+			"restart":   false,
+			"required":  true,
+		},
+	}
+	var dst dependsOn
+	err := mapdecode.Decode(&dst, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst.Values, map[string]ServiceHealthiness{
+		"service-bla-1": ServiceHealthy,
+		"service-bla-2": ServiceStarted,
+	}) {
+		t.Error(dst)
+	}
+	if !reflect.DeepEqual(dst.Restart, map[string]bool{
+		"service-bla-1": true,
+	}) {
+		t.Error(dst)
+	}
+}
+
+func TestDependsOnDecode_MapInvalidRestart(t *testing.T) {
+	src := map[string]map[string]interface{}{
+		"service-bla-1": {
+			"condition": "service_healthy",
+			"restart":   "sometimes",
+		},
+	}
+	var dst dependsOn
+	err := mapdecode.Decode(&dst, src)
+	if err == nil {
+		t.Fail()
+	}
+}
+
 func TestDependsOnDecode_MapInvalidCondition(t *testing.T) {
 	src := map[string]map[string]string{
 		"service-bla-6": {
@@ -432,3 +512,68 @@ func TestServiceVolumeDecode_Error(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestPortDecode_SuccessLong(t *testing.T) {
+	src := map[interface{}]interface{}{
+		"target":    80,
+		"published": "8080",
+		"protocol":  "udp",
+		"mode":      "host",
+	}
+	var dst port
+	err := mapdecode.Decode(&dst, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.Long == nil || dst.Long.Target.Value != "80" || dst.Long.Published.Value != "8080" || *dst.Long.Protocol != "udp" ||
+		*dst.Long.Mode != "host" {
+		t.Error(dst.Long)
+	}
+}
+
+func TestPortDecode_Error(t *testing.T) {
+	var dst port
+	err := mapdecode.Decode(&dst, []interface{}{80})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestStringOrStringSliceSplitIfString_String(t *testing.T) {
+	dst := &stringOrStringSlice{
+		IsString: true,
+		Values:   []string{"sh -c 'migrate && serve'"},
+	}
+	err := dst.splitIfString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.IsString || !reflect.DeepEqual(dst.Values, []string{"sh", "-c", "migrate && serve"}) {
+		t.Error(dst)
+	}
+}
+
+func TestStringOrStringSliceSplitIfString_StringSlice(t *testing.T) {
+	src := []string{"sh -c", "migrate && serve"}
+	dst := &stringOrStringSlice{
+		Values: src,
+	}
+	err := dst.splitIfString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst.Values, src) {
+		t.Error(dst)
+	}
+}
+
+func TestStringOrStringSliceDecode_EmptyStringSliceSuccess(t *testing.T) {
+	var dst stringOrStringSlice
+	err := mapdecode.Decode(&dst, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.IsString || dst.Values == nil || len(dst.Values) != 0 {
+		t.Error(dst)
+	}
+}
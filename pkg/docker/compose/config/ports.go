@@ -34,6 +34,8 @@ type PortBinding struct {
 	Protocol string
 	// the host (see docker for more details). Can be an empty string if the host was not set in the specification.
 	Host string
+	// one of "ingress" and "host" if the port was specified with the long syntax, otherwise the empty string.
+	Mode string
 }
 
 type portBindingParser struct {
@@ -59,6 +61,9 @@ func (parser *portBindingParser) parseInternal(matchMap map[string]string) error
 		if err != nil {
 			return err
 		}
+		if internalMax < internalMin {
+			return descendingPortRangeError(internalMin, internalMax)
+		}
 		for i := internalMin; i <= internalMax; i++ {
 			parser.internal = append(parser.internal, i)
 		}
@@ -81,6 +86,9 @@ func (parser *portBindingParser) parseExternal(matchMap map[string]string) error
 			if err != nil {
 				return err
 			}
+			if externalMax < externalMin {
+				return descendingPortRangeError(externalMin, externalMax)
+			}
 			if len(parser.internal) == 1 {
 				parser.result = append(parser.result, PortBinding{
 					Internal:    parser.internal[0],
@@ -102,7 +110,8 @@ func (parser *portBindingParser) parseExternal(matchMap map[string]string) error
 
 func (parser *portBindingParser) buildResult() error {
 	if len(parser.externalMinStr) > 0 && len(parser.internal) != len(parser.external) {
-		return fmt.Errorf("port ranges don't match in length")
+		return fmt.Errorf("port ranges don't match in length: %d published port(s) cannot be mapped to %d container port(s)",
+			len(parser.external), len(parser.internal))
 	}
 	for j, i := range parser.internal {
 		portBinding := PortBinding{
@@ -165,6 +174,10 @@ func parsePortBindings(spec string, portBindings []PortBinding) ([]PortBinding,
 	return parser.result, err
 }
 
+func descendingPortRangeError(min, max int32) error {
+	return fmt.Errorf("invalid port range %d-%d, the first port must not be greater than the last port", min, max)
+}
+
 func parsePortUint(portStr string) (int32, error) {
 	port, err := strconv.ParseUint(portStr, 10, 64)
 	if err != nil {
@@ -176,11 +189,84 @@ func parsePortUint(portStr string) (int32, error) {
 	return int32(port), nil
 }
 
+var publishedPortRegexp = regexp.MustCompile(`^(\d+)(?:-(\d+))?$`)
+
+// parsePortLong parses the long syntax of a port:
+// ports:
+//   - target: 80
+//     published: 8080
+//     protocol: udp
+//     mode: host
+func parsePortLong(long *portLong) (PortBinding, error) {
+	portBinding := PortBinding{
+		ExternalMin: -1,
+		ExternalMax: -1,
+		Mode:        "ingress",
+		Protocol:    "tcp",
+	}
+	if long.Target == nil {
+		return portBinding, fmt.Errorf("a port is missing a required value at \"target\"")
+	}
+	var err error
+	portBinding.Internal, err = parsePortUint(long.Target.Value)
+	if err != nil {
+		return portBinding, err
+	}
+	if long.Published != nil {
+		matches := publishedPortRegexp.FindStringSubmatch(long.Published.Value)
+		if matches == nil {
+			return portBinding, fmt.Errorf("invalid published port %q, should be port[-port]", long.Published.Value)
+		}
+		portBinding.ExternalMin, err = parsePortUint(matches[1])
+		if err != nil {
+			return portBinding, err
+		}
+		portBinding.ExternalMax = portBinding.ExternalMin
+		if matches[2] != "" {
+			portBinding.ExternalMax, err = parsePortUint(matches[2])
+			if err != nil {
+				return portBinding, err
+			}
+			if portBinding.ExternalMax < portBinding.ExternalMin {
+				return portBinding, descendingPortRangeError(portBinding.ExternalMin, portBinding.ExternalMax)
+			}
+		}
+	}
+	if long.Protocol != nil {
+		switch *long.Protocol {
+		case "tcp", "udp", "sctp":
+			portBinding.Protocol = *long.Protocol
+		default:
+			return portBinding, fmt.Errorf("invalid protocol %q of port %d, should be one of \"tcp\", \"udp\" and \"sctp\"",
+				*long.Protocol, portBinding.Internal)
+		}
+	}
+	if long.Mode != nil {
+		switch *long.Mode {
+		case "ingress", "host":
+			portBinding.Mode = *long.Mode
+		default:
+			return portBinding, fmt.Errorf("invalid mode %q of port %d, should be one of \"ingress\" and \"host\"", *long.Mode,
+				portBinding.Internal)
+		}
+	}
+	if long.HostIP != nil {
+		portBinding.Host = *long.HostIP
+	}
+	return portBinding, nil
+}
+
 func parsePorts(inputs []port) ([]PortBinding, error) {
 	portBindings := []PortBinding{}
 	for _, input := range inputs {
 		var err error
-		portBindings, err = parsePortBindings(input.Value, portBindings)
+		if input.Long != nil {
+			var portBinding PortBinding
+			portBinding, err = parsePortLong(input.Long)
+			portBindings = append(portBindings, portBinding)
+		} else {
+			portBindings, err = parsePortBindings(input.Value, portBindings)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -182,3 +182,113 @@ func Test_ParsePorts_DuplicatePortsError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_ParsePorts_LongSyntax(t *testing.T) {
+	protocol := "udp"
+	expected := []PortBinding{
+		{
+			Internal:    53,
+			ExternalMin: 5353,
+			ExternalMax: 5353,
+			Protocol:    "udp",
+			Mode:        "ingress",
+		},
+		{
+			Internal:    8000,
+			ExternalMin: 8000,
+			ExternalMax: 8010,
+			Protocol:    "tcp",
+		},
+	}
+	actual, err := parsePorts([]port{
+		{
+			Long: &portLong{
+				Protocol:  &protocol,
+				Published: &port{Value: "5353"},
+				Target:    &port{Value: "53"},
+			},
+		},
+		{
+			Value: "8000-8010:8000",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error(actual)
+	}
+}
+
+func Test_ParsePortLong_Host(t *testing.T) {
+	mode := "host"
+	hostIP := "127.0.0.1"
+	actual, err := parsePortLong(&portLong{
+		HostIP:    &hostIP,
+		Mode:      &mode,
+		Published: &port{Value: "8080"},
+		Target:    &port{Value: "80"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := PortBinding{
+		Internal:    80,
+		ExternalMin: 8080,
+		ExternalMax: 8080,
+		Protocol:    "tcp",
+		Host:        "127.0.0.1",
+		Mode:        "host",
+	}
+	if actual != expected {
+		t.Error(actual)
+	}
+}
+
+func Test_ParsePortLong_NotPublished(t *testing.T) {
+	actual, err := parsePortLong(&portLong{
+		Target: &port{Value: "80"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.ExternalMin != -1 || actual.Mode != "ingress" {
+		t.Error(actual)
+	}
+}
+
+func Test_ParsePortLong_Errors(t *testing.T) {
+	invalid := "invalid"
+	testCases := []*portLong{
+		{},
+		{Target: &port{Value: "65536"}},
+		{Target: &port{Value: "80"}, Published: &port{Value: "80:80"}},
+		{Target: &port{Value: "80"}, Protocol: &invalid},
+		{Target: &port{Value: "80"}, Mode: &invalid},
+	}
+	for _, testCase := range testCases {
+		_, err := parsePortLong(testCase)
+		if err == nil {
+			t.Error(testCase)
+		}
+	}
+}
+
+func Test_ParsePortBindings_DescendingRangeErrors(t *testing.T) {
+	for _, spec := range []string{"3005-3000", "3005-3000:3005-3000", "8001-8000:80"} {
+		_, err := parsePortBindings(spec, nil)
+		if err == nil {
+			t.Error(spec)
+		}
+	}
+}
+
+func Test_ParsePortLong_DescendingPublishedRangeError(t *testing.T) {
+	_, err := parsePortLong(&portLong{
+		Published: &port{Value: "8010-8000"},
+		Target:    &port{Value: "80"},
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// profileRegexp is the grammar of profile names of the compose specification.
+var profileRegexp = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.-]+$")
+
+// parseProfiles validates the names of the profiles of a service. Duplicates are removed.
+func parseProfiles(profiles []string) ([]string, error) {
+	var result []string
+	for _, profile := range profiles {
+		if !profileRegexp.MatchString(profile) {
+			return nil, fmt.Errorf("profiles contains an invalid profile name: %#v", profile)
+		}
+		result = addUniqueString(result, profile)
+	}
+	return result, nil
+}
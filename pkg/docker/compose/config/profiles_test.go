@@ -0,0 +1,25 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProfiles_Success(t *testing.T) {
+	profiles, err := parseProfiles([]string{"debug", "seed.data", "debug", "0_test-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(profiles, []string{"debug", "seed.data", "0_test-1"}) {
+		t.Error(profiles)
+	}
+}
+
+func TestParseProfiles_Invalid(t *testing.T) {
+	for _, profile := range []string{"", "a", "-debug", "de bug", "debug/1"} {
+		_, err := parseProfiles([]string{profile})
+		if err == nil {
+			t.Error(profile)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matches the memory sizes accepted by docker (e.g. "512M", "1.5g" and "64MiB"). The unit suffixes are binary (powers of 1024) and case
+// insensitive, and may be followed by "i" and/or "b".
+var memoryRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)?) ?([kKmMgGtTpP]?)[iI]?[bB]?$`)
+
+var memoryUnits = map[string]float64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+	"p": 1 << 50,
+}
+
+type resourcesInternal struct {
+	Limits       *resourceSpecInternal `mapdecode:"limits"`
+	Reservations *resourceSpecInternal `mapdecode:"reservations"`
+}
+
+type resourceSpecInternal struct {
+	CPUs   interface{} `mapdecode:"cpus"`
+	Memory interface{} `mapdecode:"memory"`
+}
+
+// Resources is the parsed deploy.resources field of a docker compose service.
+type Resources struct {
+	// Nil if deploy.resources.limits is not present.
+	Limits *ResourceSpec
+	// Nil if deploy.resources.reservations is not present.
+	Reservations *ResourceSpec
+}
+
+// ResourceSpec is the parsed deploy.resources.limits or deploy.resources.reservations field of a docker compose service.
+type ResourceSpec struct {
+	// The number of CPUs in units of 1/1000 CPU (e.g. 500 if cpus is "0.5"). Zero if not set.
+	MilliCPUs int64
+	// The amount of memory in bytes. Zero if not set.
+	MemoryBytes int64
+}
+
+// ParseCPUs parses the number of CPUs of deploy.resources (e.g. "0.5" or 2), in units of 1/1000 CPU.
+func ParseCPUs(v interface{}) (int64, error) {
+	var cpus float64
+	switch t := v.(type) {
+	case int:
+		cpus = float64(t)
+	case float64:
+		cpus = t
+	case string:
+		var err error
+		cpus, err = strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cpus %#v is not a number", t)
+		}
+	default:
+		return 0, fmt.Errorf("cpus must be a number or a string")
+	}
+	if cpus <= 0 || math.IsInf(cpus, 0) || math.IsNaN(cpus) {
+		return 0, fmt.Errorf("cpus must be positive, but got %v", v)
+	}
+	return int64(math.Round(cpus * 1000)), nil
+}
+
+// ParseMemory parses an amount of memory of deploy.resources (e.g. "512M" or 1073741824), in bytes.
+func ParseMemory(v interface{}) (int64, error) {
+	switch t := v.(type) {
+	case int:
+		if t <= 0 {
+			return 0, fmt.Errorf("memory must be positive, but got %d", t)
+		}
+		return int64(t), nil
+	case string:
+		matches := memoryRegexp.FindStringSubmatch(strings.TrimSpace(t))
+		if matches == nil {
+			return 0, fmt.Errorf("memory %#v is not a valid amount of memory (e.g. \"512M\" or \"1.5g\")", t)
+		}
+		n, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("memory %#v is not a valid amount of memory (e.g. \"512M\" or \"1.5g\")", t)
+		}
+		bytes := n * memoryUnits[strings.ToLower(matches[2])]
+		if bytes < 1 || bytes > math.MaxInt64 {
+			return 0, fmt.Errorf("memory %#v is out of range", t)
+		}
+		return int64(bytes), nil
+	}
+	return 0, fmt.Errorf("memory must be an integer or a string")
+}
+
+func parseResourceSpec(field string, r *resourceSpecInternal) (*ResourceSpec, error) {
+	if r == nil {
+		return nil, nil
+	}
+	spec := &ResourceSpec{}
+	var err error
+	if r.CPUs != nil {
+		spec.MilliCPUs, err = ParseCPUs(r.CPUs)
+		if err != nil {
+			return nil, fmt.Errorf("deploy.resources.%s: %v", field, err)
+		}
+	}
+	if r.Memory != nil {
+		spec.MemoryBytes, err = ParseMemory(r.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("deploy.resources.%s: %v", field, err)
+		}
+	}
+	return spec, nil
+}
+
+func parseResources(r *resourcesInternal) (*Resources, error) {
+	if r == nil {
+		return nil, nil
+	}
+	resources := &Resources{}
+	var err error
+	resources.Limits, err = parseResourceSpec("limits", r.Limits)
+	if err != nil {
+		return nil, err
+	}
+	resources.Reservations, err = parseResourceSpec("reservations", r.Reservations)
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseMemory_Success(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected int64
+	}{
+		{1024, 1024},
+		{"100", 100},
+		{"100b", 100},
+		{"2k", 2048},
+		{"2K", 2048},
+		{"2kb", 2048},
+		{"512m", 512 << 20},
+		{"512M", 512 << 20},
+		{"512Mi", 512 << 20},
+		{"512MiB", 512 << 20},
+		{"1.5g", 3 << 29},
+		{"1G", 1 << 30},
+		{"1gi", 1 << 30},
+	}
+	for _, testCase := range testCases {
+		bytes, err := ParseMemory(testCase.input)
+		if err != nil {
+			t.Error(err)
+		} else if bytes != testCase.expected {
+			t.Errorf("%#v: %d", testCase.input, bytes)
+		}
+	}
+}
+
+func TestParseMemory_Errors(t *testing.T) {
+	for _, input := range []interface{}{"", "M", "512X", "-1g", "1.g", 0, true, "0"} {
+		_, err := ParseMemory(input)
+		if err == nil {
+			t.Error(input)
+		}
+	}
+}
+
+func TestParseCPUs_Success(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected int64
+	}{
+		{"0.5", 500},
+		{0.25, 250},
+		{2, 2000},
+		{"0.001", 1},
+	}
+	for _, testCase := range testCases {
+		milliCPUs, err := ParseCPUs(testCase.input)
+		if err != nil {
+			t.Error(err)
+		} else if milliCPUs != testCase.expected {
+			t.Errorf("%#v: %d", testCase.input, milliCPUs)
+		}
+	}
+}
+
+func TestParseCPUs_Errors(t *testing.T) {
+	for _, input := range []interface{}{"half", "-1", 0, "NaN", true} {
+		_, err := ParseCPUs(input)
+		if err == nil {
+			t.Error(input)
+		}
+	}
+}
+
+func TestParseDeploy_ResourcesError(t *testing.T) {
+	_, err := parseDeploy(&deployInternal{
+		Resources: &resourcesInternal{
+			Limits: &resourceSpecInternal{
+				Memory: "lots",
+			},
+		},
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/uber-go/mapdecode"
+)
+
+// The directory in which docker mounts the secrets of a service.
+const secretsDir = "/run/secrets/"
+
+type secretInternal struct {
+	Environment *string `mapdecode:"environment"`
+	External    *bool   `mapdecode:"external"`
+	File        *string `mapdecode:"file"`
+	Name        *string `mapdecode:"name"`
+}
+
+// Secret is a parsed element of the top-level secrets section (see https://docs.docker.com/compose/compose-file/09-secrets/).
+type Secret struct {
+	// The name of the environment variable that holds the value of the secret. Empty if the value of the secret is not read from the
+	// environment.
+	Environment string
+	// True if the secret is managed outside of the docker compose project.
+	External bool
+	// The name of the secret that is managed outside of the docker compose project (the name field). Equal to Name if not set.
+	ExternalName string
+	// The absolute path of the file that holds the value of the secret. Empty if the value of the secret is not read from a file.
+	File string
+	Name string
+}
+
+// ServiceSecret is a reference of a docker compose service to a secret.
+type ServiceSecret struct {
+	// The permissions of the file of the secret in the container. Nil if not set.
+	Mode *int32
+	// The name of the secret in the top-level secrets section.
+	Source string
+	// The absolute path at which the secret is mounted in the container.
+	Target string
+}
+
+type serviceSecretHelper struct {
+	Mode   interface{} `mapdecode:"mode"`
+	Source string      `mapdecode:"source"`
+	Target *string     `mapdecode:"target"`
+}
+
+// parseFileMode parses the mode of the long syntax of secrets. YAML 1.1 already decodes integers with a leading zero (e.g. 0440) as
+// octal, so integers are used as is. Strings are always parsed as octal (e.g. "0440" and "440"), because file modes are conventionally
+// written in octal. Integers of a docker compose file are decoded as a rawFileMode, and integers without a leading zero whose digits are
+// all octal (e.g. 440) are rejected because they are likely meant to be octal.
+func parseFileMode(v interface{}) (*int32, error) {
+	var mode int64
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case rawFileMode:
+		if t.isAmbiguous() {
+			return nil, fmt.Errorf("mode %s is a decimal integer because it does not have a leading zero (did you mean 0%s?)", t.raw, t.raw)
+		}
+		mode = int64(t.value)
+	case int:
+		mode = int64(t)
+	case uint64:
+		if t > math.MaxInt32 {
+			return nil, fmt.Errorf("mode %d is not a valid file mode", t)
+		}
+		mode = int64(t)
+	case string:
+		var err error
+		mode, err = strconv.ParseInt(t, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("mode %#v must be an octal number", t)
+		}
+	default:
+		return nil, fmt.Errorf("mode must be an integer or a string")
+	}
+	if mode < 0 || mode > 0777 {
+		return nil, fmt.Errorf("mode %d is not a valid file mode (did you mean 0%d? YAML integers without a leading zero are decimal)",
+			mode, mode)
+	}
+	mode32 := int32(mode)
+	return &mode32, nil
+}
+
+// Decode parses either the short syntax (the name of a secret) or the long syntax of a secret reference of a docker compose service.
+func (s *ServiceSecret) Decode(into mapdecode.Into) error {
+	var source string
+	err := into(&source)
+	if err == nil {
+		s.Source = source
+		s.Target = secretsDir + source
+		return nil
+	}
+	var helper serviceSecretHelper
+	err = into(&helper)
+	if err != nil {
+		return err
+	}
+	s.Source = helper.Source
+	s.Mode, err = parseFileMode(helper.Mode)
+	if err != nil {
+		return err
+	}
+	s.Target = resolveMountTarget(secretsDir, helper.Source, helper.Target)
+	return nil
+}
+
+// resolveMountTarget returns the absolute path at which a secret or config is mounted. Relative targets are relative to dir, and the
+// target defaults to the source.
+func resolveMountTarget(dir, source string, target *string) string {
+	switch {
+	case target == nil:
+		return dir + source
+	case len(*target) > 0 && (*target)[0] == '/':
+		return *target
+	default:
+		return dir + *target
+	}
+}
+
+func parseSecrets(dcFile *dockerComposeFile) error {
+	for name, secret := range dcFile.Secrets {
+		n := 0
+		if secret.Environment != nil {
+			n++
+		}
+		if secret.External != nil && *secret.External {
+			n++
+		}
+		if secret.File != nil {
+			*secret.File = expandPath(dcFile.resolvedFile, *secret.File)
+			n++
+		}
+		if n != 1 {
+			return fmt.Errorf("secret %s must have exactly one of the fields environment, external and file", name)
+		}
+	}
+	return nil
+}
+
+// mergeSecrets merges the top-level secrets sections of docker compose files, where secrets of into win.
+func mergeSecrets(into, from map[string]*secretInternal) {
+	for name, secret := range from {
+		if _, ok := into[name]; !ok {
+			into[name] = secret
+		}
+	}
+}
+
+// mergeServiceSecrets merges the secrets of docker compose services by source, where secrets of into win.
+func mergeServiceSecrets(into, from []ServiceSecret) []ServiceSecret {
+	for _, secret1 := range from {
+		found := false
+		for _, secret2 := range into {
+			if secret1.Source == secret2.Source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			into = append(into, secret1)
+		}
+	}
+	return into
+}
+
+func finalizeSecrets(secrets map[string]*secretInternal, services map[string]*serviceInternal) (map[string]*Secret, error) {
+	result := map[string]*Secret{}
+	for name, secretInternal := range secrets {
+		secret := &Secret{
+			ExternalName: name,
+			Name:         name,
+		}
+		if secretInternal.Environment != nil {
+			secret.Environment = *secretInternal.Environment
+		}
+		if secretInternal.External != nil {
+			secret.External = *secretInternal.External
+		}
+		if secretInternal.File != nil {
+			secret.File = *secretInternal.File
+		}
+		if secretInternal.Name != nil {
+			secret.ExternalName = *secretInternal.Name
+		}
+		result[name] = secret
+	}
+	for _, s := range services {
+		for _, serviceSecret := range s.Secrets {
+			if result[serviceSecret.Source] == nil {
+				return nil, fmt.Errorf("service %s refers to secret %s, but no secret with that name exists", s.name, serviceSecret.Source)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseSecrets_NoSourceError(t *testing.T) {
+	dcFile := &dockerComposeFile{
+		Secrets: map[string]*secretInternal{
+			"secret1": {},
+		},
+	}
+	err := parseSecrets(dcFile)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseSecrets_FileResolved(t *testing.T) {
+	file := "secret.txt"
+	dcFile := &dockerComposeFile{
+		resolvedFile: "/project/docker-compose.yml",
+		Secrets: map[string]*secretInternal{
+			"secret1": {
+				File: &file,
+			},
+		},
+	}
+	err := parseSecrets(dcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != "/project/secret.txt" {
+		t.Error(file)
+	}
+}
+
+func TestMergeServiceSecrets_IntoWins(t *testing.T) {
+	into := []ServiceSecret{
+		{Source: "a", Target: "/a"},
+	}
+	from := []ServiceSecret{
+		{Source: "a", Target: "/b"},
+		{Source: "c", Target: "/c"},
+	}
+	merged := mergeServiceSecrets(into, from)
+	if len(merged) != 2 || merged[0].Target != "/a" || merged[1].Source != "c" {
+		t.Error(merged)
+	}
+}
+
+func TestParseFileMode_Success(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected int32
+	}{
+		// YAML decodes 0440 as an octal integer.
+		{0440, 0440},
+		{"0440", 0440},
+		{"440", 0440},
+	}
+	for _, testCase := range testCases {
+		mode, err := parseFileMode(testCase.input)
+		if err != nil {
+			t.Error(err)
+		} else if *mode != testCase.expected {
+			t.Errorf("%#v: %o", testCase.input, *mode)
+		}
+	}
+}
+
+func TestParseFileMode_Nil(t *testing.T) {
+	mode, err := parseFileMode(nil)
+	if mode != nil || err != nil {
+		t.Fail()
+	}
+}
+
+func TestParseFileMode_Errors(t *testing.T) {
+	for _, input := range []interface{}{644, "0999", true, -1} {
+		_, err := parseFileMode(input)
+		if err == nil {
+			t.Error(input)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// loadYamlFileServiceOrder returns the names of the services of a docker compose file in the order in which they are declared. This order
+// is lost by loadYamlFileAsGenericMap, so the file is decoded again as a yaml.MapSlice. Version 1 docker compose files declare services
+// at the root.
+func loadYamlFileServiceOrder(file string, isV1 bool) ([]string, error) {
+	reader, err := fs.OS.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(reader)
+	decoder := yaml.NewDecoder(reader)
+	var root yaml.MapSlice
+	err = decoder.Decode(&root)
+	if err != nil {
+		return nil, err
+	}
+	services := root
+	if !isV1 {
+		services = nil
+		for _, item := range root {
+			if item.Key == "services" {
+				services, _ = item.Value.(yaml.MapSlice)
+				break
+			}
+		}
+	}
+	var names []string
+	for _, item := range services {
+		if name, ok := item.Key.(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// serviceOrder returns the names of the merged services in the order in which they are first declared, considering the docker compose
+// files in the order in which they were specified.
+func (c *configLoader) serviceOrder(resolvedFiles []string, services map[string]*serviceInternal) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, resolvedFile := range resolvedFiles {
+		for _, name := range c.loadResolvedFileCache[resolvedFile].parsed.serviceOrder {
+			if !seen[name] && services[name] != nil {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitShellWords splits a string command or entrypoint of a docker compose service into words, like docker compose does (see
+// https://docs.python.org/3/library/shlex.html#shlex.split). Words are separated by unquoted whitespace. Single quotes preserve the
+// literal value of each character within the quotes. Double quotes preserve the literal value of each character within the quotes,
+// except that a backslash escapes a double quote or a backslash. Outside of quotes, a backslash preserves the literal value of the next
+// character. Shell operators (e.g. && and |) are not interpreted, so "sh -c 'migrate && serve'" yields the words "sh", "-c" and
+// "migrate && serve".
+func splitShellWords(s string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\\':
+			i++
+			if i >= len(runes) {
+				return nil, fmt.Errorf("no escaped character after backslash in %#v", s)
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != '\'' {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("no closing quotation in %#v", s)
+			}
+			word.WriteString(string(runes[i+1 : j]))
+			i = j
+			inWord = true
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) && (runes[j+1] == '"' || runes[j+1] == '\\') {
+					j++
+				}
+				word.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("no closing quotation in %#v", s)
+			}
+			i = j
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func TestSplitShellWords_Success(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"  serve  --port 80 ", []string{"serve", "--port", "80"}},
+		{"sh -c 'migrate && serve'", []string{"sh", "-c", "migrate && serve"}},
+		{`sh -c "echo \"hi\" | cat"`, []string{"sh", "-c", `echo "hi" | cat`}},
+		{`echo a\ b`, []string{"echo", "a b"}},
+		{`echo ''`, []string{"echo", ""}},
+		{`echo 'it'"'"'s'`, []string{"echo", "it's"}},
+		{`echo "a\nb"`, []string{"echo", `a\nb`}},
+	}
+	for _, testCase := range testCases {
+		words, err := splitShellWords(testCase.input)
+		if err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(words, testCase.expected) {
+			t.Errorf("%#v: %#v", testCase.input, words)
+		}
+	}
+}
+
+func TestSplitShellWords_Errors(t *testing.T) {
+	for _, input := range []string{"echo 'a", `echo "a`, `echo \`} {
+		_, err := splitShellWords(input)
+		if err == nil {
+			t.Error(input)
+		}
+	}
+}
+
+func Test_New_StringCommandSplit(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(`version: '3'
+services:
+  service1:
+    command: "sh -c 'migrate && serve'"
+    entrypoint: /docker-entrypoint.sh --verbose
+  service2:
+    command: ['sh -c "migrate && serve"']
+`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		c, err := New([]string{"/docker-compose.yml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Command, []string{"sh", "-c", "migrate && serve"}) {
+			t.Error(c.Services["service1"].Command)
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Entrypoint, []string{"/docker-entrypoint.sh", "--verbose"}) {
+			t.Error(c.Services["service1"].Entrypoint)
+		}
+		// The array form is not split.
+		if !reflect.DeepEqual(c.Services["service2"].Command, []string{`sh -c "migrate && serve"`}) {
+			t.Error(c.Services["service2"].Command)
+		}
+	})
+}
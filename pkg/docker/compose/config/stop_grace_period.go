@@ -0,0 +1,23 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// parseStopGracePeriod parses the stop_grace_period field of a docker compose service (e.g. "1m30s"). Returns nil if the field is not
+// set.
+func parseStopGracePeriod(value *string) (*time.Duration, error) {
+	if value == nil {
+		return nil, nil
+	}
+	// time.ParseDuration supports a superset of the durations of docker-compose, as with healthchecks.
+	stopGracePeriod, err := time.ParseDuration(*value)
+	if err != nil {
+		return nil, fmt.Errorf("stop_grace_period %#v is invalid: %v", *value, err)
+	}
+	if stopGracePeriod < 0 {
+		return nil, fmt.Errorf("stop_grace_period %#v must not be negative", *value)
+	}
+	return &stopGracePeriod, nil
+}
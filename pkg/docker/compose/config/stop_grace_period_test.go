@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+func TestParseStopGracePeriod_Success(t *testing.T) {
+	stopGracePeriod, err := parseStopGracePeriod(util.NewString("1m30s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopGracePeriod == nil || *stopGracePeriod != 90*time.Second {
+		t.Error(stopGracePeriod)
+	}
+}
+
+func TestParseStopGracePeriod_Nil(t *testing.T) {
+	stopGracePeriod, err := parseStopGracePeriod(nil)
+	if err != nil || stopGracePeriod != nil {
+		t.Error(stopGracePeriod, err)
+	}
+}
+
+func TestParseStopGracePeriod_Errors(t *testing.T) {
+	for _, value := range []string{"", "30", "ten seconds", "-1s"} {
+		_, err := parseStopGracePeriod(util.NewString(value))
+		if err == nil {
+			t.Error(value)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TmpfsMount is a mount of the tmpfs field of a docker compose service (e.g. "/run" or "/tmp:size=64m,mode=1777").
+type TmpfsMount struct {
+	ContainerPath string
+	// The size limit of the mount in bytes. Zero if the size is not limited.
+	SizeBytes int64
+}
+
+// parseTmpfs parses the tmpfs field of a docker compose service, which is a string or a list of strings. Each string is an absolute
+// container path, optionally followed by a colon and comma separated mount options. Only the size option can be translated, other options
+// (e.g. mode and noexec) are ignored.
+func parseTmpfs(tmpfs *stringOrStringSlice) ([]TmpfsMount, error) {
+	if tmpfs == nil {
+		return nil, nil
+	}
+	var result []TmpfsMount
+	for _, value := range tmpfs.Values {
+		var mount TmpfsMount
+		var options string
+		i := strings.IndexByte(value, ':')
+		if i < 0 {
+			mount.ContainerPath = value
+		} else {
+			mount.ContainerPath = value[:i]
+			options = value[i+1:]
+		}
+		if !strings.HasPrefix(mount.ContainerPath, "/") {
+			return nil, fmt.Errorf("tmpfs %#v must be an absolute path", value)
+		}
+		for _, option := range strings.Split(options, ",") {
+			if !strings.HasPrefix(option, "size=") {
+				continue
+			}
+			size, err := ParseMemory(strings.TrimPrefix(option, "size="))
+			if err != nil {
+				return nil, fmt.Errorf("tmpfs %#v has an invalid size: %v", value, err)
+			}
+			mount.SizeBytes = size
+		}
+		result = addTmpfsMount(result, mount)
+	}
+	return result, nil
+}
+
+func addTmpfsMount(mounts []TmpfsMount, mount TmpfsMount) []TmpfsMount {
+	for _, existing := range mounts {
+		if existing.ContainerPath == mount.ContainerPath {
+			return mounts
+		}
+	}
+	return append(mounts, mount)
+}
+
+// mergeTmpfsMounts adds the mounts of from to into, where mounts of into take precedence if both have a mount at the same container path.
+func mergeTmpfsMounts(into, from []TmpfsMount) []TmpfsMount {
+	for _, mount := range from {
+		into = addTmpfsMount(into, mount)
+	}
+	return into
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTmpfs_Success(t *testing.T) {
+	mounts, err := parseTmpfs(&stringOrStringSlice{
+		Values: []string{"/tmp", "/run:mode=1777,size=64m", "/tmp:size=1g"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TmpfsMount{
+		{
+			ContainerPath: "/tmp",
+		},
+		{
+			ContainerPath: "/run",
+			SizeBytes:     64 * 1024 * 1024,
+		},
+	}
+	if !reflect.DeepEqual(mounts, expected) {
+		t.Error(mounts)
+	}
+}
+
+func TestParseTmpfs_Nil(t *testing.T) {
+	mounts, err := parseTmpfs(nil)
+	if err != nil || mounts != nil {
+		t.Error(mounts, err)
+	}
+}
+
+func TestParseTmpfs_Errors(t *testing.T) {
+	for _, value := range []string{"tmp", "/tmp:size=lots"} {
+		_, err := parseTmpfs(&stringOrStringSlice{
+			Values: []string{value},
+		})
+		if err == nil {
+			t.Error(value)
+		}
+	}
+}
+
+func TestMergeTmpfsMounts(t *testing.T) {
+	into := []TmpfsMount{{ContainerPath: "/tmp"}}
+	from := []TmpfsMount{{ContainerPath: "/run"}, {ContainerPath: "/tmp", SizeBytes: 1}}
+	mounts := mergeTmpfsMounts(into, from)
+	if !reflect.DeepEqual(mounts, []TmpfsMount{{ContainerPath: "/tmp"}, {ContainerPath: "/run"}}) {
+		t.Error(mounts)
+	}
+}
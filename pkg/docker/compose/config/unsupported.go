@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// UnsupportedKey is a key of a docker compose service that is recognized, but is not translated to Kubernetes.
+type UnsupportedKey struct {
+	Key string
+	// A short explanation of why the key is ignored, possibly with a link.
+	Reason string
+}
+
+type unsupportedServiceKey struct {
+	reason string
+	// Validates the shape of the value of the key. Nil if any value is accepted.
+	validate func(v interface{}) bool
+}
+
+// unsupportedServiceKeys are the keys of docker compose services that are recognized, but are not translated. These keys are reported in
+// Service.UnsupportedKeys so that users are warned about them consistently. To stop translating a key, add it here instead of dropping it
+// silently. To start translating a key, remove it from here.
+var unsupportedServiceKeys = map[string]*unsupportedServiceKey{
+	"blkio_config": {
+		reason:   "block IO is tuned by the container runtime of the node, Kubernetes has no equivalent",
+		validate: isMapping,
+	},
+	"build": {
+		reason: "images are not built, build them before running kube-compose (https://github.com/kube-compose/kube-compose/issues/188)",
+	},
+	"cgroup_parent": {
+		reason: "the cgroups of pods are managed by the kubelet",
+	},
+	"container_name": {
+		reason: "Kubernetes resources are named after the service (see --env-id)",
+	},
+	"device_cgroup_rules": {
+		reason:   "device cgroup rules have no Kubernetes equivalent",
+		validate: isSequence,
+	},
+	"devices": {
+		reason: "host devices are exposed to pods by device plugins",
+	},
+	"hostname": {
+		reason: "pods are named after the service (see --env-id)",
+	},
+	"logging": {
+		reason: "logging is configured by the container runtime of the node",
+	},
+	"network_mode": {
+		reason: "all pods share the network of the cluster",
+	},
+	"stop_signal": {
+		reason: "Kubernetes always stops containers with the stop signal of the image",
+	},
+	"sysctls": {
+		reason: "sysctls are not supported yet",
+	},
+	"ulimits": {
+		reason: "ulimits are configured by the container runtime of the node",
+	},
+}
+
+func isMapping(v interface{}) bool {
+	_, ok := asGenericMap(v)
+	return ok
+}
+
+func isSequence(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+// getUnsupportedKeys returns the keys of the docker compose service gm that are not translated (see unsupportedServiceKeys), sorted by
+// key. Returns an error if the value of such a key does not have the shape required by the docker compose file format.
+func getUnsupportedKeys(name string, gm interface{}) ([]UnsupportedKey, error) {
+	gmMap, ok := asGenericMap(gm)
+	if !ok {
+		return nil, nil
+	}
+	var result []UnsupportedKey
+	for key, value := range gmMap {
+		keyString, ok := key.(string)
+		if !ok {
+			continue
+		}
+		unsupportedKey := unsupportedServiceKeys[keyString]
+		if unsupportedKey == nil {
+			continue
+		}
+		if unsupportedKey.validate != nil && !unsupportedKey.validate(value) {
+			return nil, fmt.Errorf("service %s has an invalid value for %#v", name, keyString)
+		}
+		result = append(result, UnsupportedKey{
+			Key:    keyString,
+			Reason: unsupportedKey.reason,
+		})
+	}
+	sortUnsupportedKeys(result)
+	return result, nil
+}
+
+func sortUnsupportedKeys(keys []UnsupportedKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+}
+
+// addUnsupportedKey adds key to keys if keys does not contain an equal key, keeping keys sorted.
+func addUnsupportedKey(keys []UnsupportedKey, key UnsupportedKey) []UnsupportedKey {
+	for _, existing := range keys {
+		if existing == key {
+			return keys
+		}
+	}
+	keys = append(keys, key)
+	sortUnsupportedKeys(keys)
+	return keys
+}
+
+// mergeUnsupportedKeys returns the union of into and from, sorted by key.
+func mergeUnsupportedKeys(into, from []UnsupportedKey) []UnsupportedKey {
+	for _, key := range from {
+		into = addUnsupportedKey(into, key)
+	}
+	return into
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+var mockFileSystemUnsupported = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+	"/project/docker-compose.yml": {
+		Content: []byte(`version: '2.4'
+services:
+  service1:
+    blkio_config:
+      weight: 300
+    device_cgroup_rules:
+    - 'c 1:3 mr'
+  service2:
+    build: .
+`),
+	},
+	"/project/docker-compose.override.yml": {
+		Content: []byte(`version: '2.4'
+services:
+  service2:
+    device_cgroup_rules:
+    - 'a 7:* rmw'
+`),
+	},
+	"/project/docker-compose.invalid.yml": {
+		Content: []byte(`version: '2.4'
+services:
+  service1:
+    blkio_config: 300
+`),
+	},
+})
+
+func unsupportedKeyNames(service *Service) []string {
+	var keys []string
+	for _, key := range service.UnsupportedKeys {
+		keys = append(keys, key.Key)
+	}
+	return keys
+}
+
+func Test_New_UnsupportedKeys(t *testing.T) {
+	withMockFS2(mockFileSystemUnsupported, func() {
+		c, err := New([]string{"/project/docker-compose.yml", "/project/docker-compose.override.yml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keys := unsupportedKeyNames(c.Services["service1"]); !reflect.DeepEqual(keys, []string{"blkio_config", "device_cgroup_rules"}) {
+			t.Error(keys)
+		}
+		if keys := unsupportedKeyNames(c.Services["service2"]); !reflect.DeepEqual(keys, []string{"build", "device_cgroup_rules"}) {
+			t.Error(keys)
+		}
+		if c.Services["service2"].UnsupportedKeys[0].Reason == "" {
+			t.Error(c.Services["service2"].UnsupportedKeys)
+		}
+	})
+}
+
+func Test_New_UnsupportedKeysInvalidValue(t *testing.T) {
+	withMockFS2(mockFileSystemUnsupported, func() {
+		_, err := New([]string{"/project/docker-compose.invalid.yml"})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_MergeUnsupportedKeys(t *testing.T) {
+	a := UnsupportedKey{Key: "a", Reason: "reason a"}
+	b := UnsupportedKey{Key: "b", Reason: "reason b"}
+	merged := mergeUnsupportedKeys([]UnsupportedKey{b}, []UnsupportedKey{a, b})
+	if !reflect.DeepEqual(merged, []UnsupportedKey{a, b}) {
+		t.Error(merged)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"regexp"
 	"strings"
 
 	fsPackage "github.com/kube-compose/kube-compose/internal/pkg/fs"
@@ -19,6 +20,15 @@ type PathMapping struct {
 	ContainerPath string
 }
 
+// Same pattern as docker uses to validate volume names.
+var namedVolumeRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// IsNamedVolume returns true if and only if the host path of the path mapping is the name of a volume (e.g. "data" in
+// "data:/var/lib/data"), instead of a file on the host.
+func (pm *PathMapping) IsNamedVolume() bool {
+	return pm.HasHostPath && namedVolumeRegexp.MatchString(pm.HostPath)
+}
+
 // parsePathMapping has the same logic as split_path_mapping:
 // https://github.com/docker/compose/blob/99e67d0c061fa3d9b9793391f3b7c8bdf8e841fc/compose/config/config.py#L1440
 func parsePathMapping(shortSyntax string) PathMapping {
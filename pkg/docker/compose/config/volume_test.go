@@ -111,3 +111,21 @@ func TestResolveBindMountVolumeHostPath_TildeNotSupported(t *testing.T) {
 	}
 	resolveBindMountVolumeHostPath("/Users/henk/.bash_profile", &sv)
 }
+func TestPathMappingIsNamedVolume_Named(t *testing.T) {
+	pm := parsePathMapping("data:/var/lib/data")
+	if !pm.IsNamedVolume() {
+		t.Fail()
+	}
+}
+func TestPathMappingIsNamedVolume_HostPath(t *testing.T) {
+	pm := parsePathMapping("/srv/data:/var/lib/data")
+	if pm.IsNamedVolume() {
+		t.Fail()
+	}
+}
+func TestPathMappingIsNamedVolume_Anonymous(t *testing.T) {
+	pm := parsePathMapping("/var/lib/data")
+	if pm.IsNamedVolume() {
+		t.Fail()
+	}
+}